@@ -34,8 +34,8 @@ func printPin(fn string, p pin.Pin) {
 	}
 }
 
-func printEnv(e *physic.Env) {
-	if e.Humidity == 0 {
+func printEnv(e *physic.Env, f physic.EnvField) {
+	if !f.Has(physic.EnvHumidity) {
 		fmt.Printf("%8s %10s\n", e.Temperature, e.Pressure)
 	} else {
 		fmt.Printf("%8s %10s %9s\n", e.Temperature, e.Pressure, e.Humidity)
@@ -43,12 +43,13 @@ func printEnv(e *physic.Env) {
 }
 
 func run(dev physic.SenseEnv, interval time.Duration) error {
+	f := physic.SensedFields(dev)
 	if interval == 0 {
 		e := physic.Env{}
 		if err := dev.Sense(&e); err != nil {
 			return err
 		}
-		printEnv(&e)
+		printEnv(&e, f)
 		return nil
 	}
 
@@ -63,7 +64,7 @@ func run(dev physic.SenseEnv, interval time.Duration) error {
 		case <-chanSignal:
 			return nil
 		case e := <-c:
-			printEnv(&e)
+			printEnv(&e, f)
 		}
 	}
 }
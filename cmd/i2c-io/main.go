@@ -19,14 +19,34 @@ import (
 	"periph.io/x/periph/conn/physic"
 )
 
+// recoverBus runs the bus recovery sequence on the bus.
+func recoverBus(name string) error {
+	bus, err := i2creg.Open(name)
+	if err != nil {
+		return err
+	}
+	defer bus.Close()
+	r, ok := bus.(i2c.Recoverer)
+	if !ok {
+		return fmt.Errorf("%s doesn't support bus recovery", bus)
+	}
+	if err := r.Recover(); err != nil {
+		return err
+	}
+	log.Printf("%s: bus recovered", bus)
+	return nil
+}
+
 func mainImpl() error {
 	addr := flag.Int("a", -1, "I²C device address to query")
 	busName := flag.String("b", "", "I²C bus to use")
-	verbose := flag.Bool("v", false, "verbose mode")
+	recover := flag.Bool("recover", false, "run the bus recovery sequence first, to free a bus stuck by a device; -a is then optional")
+	verbose := flag.Bool("v", false, "verbose mode; logs the transactions to stderr")
 	// TODO(maruel): This is not generic enough.
 	write := flag.Bool("w", false, "write instead of reading")
 	reg := flag.Int("r", -1, "register to address")
-	hz := flag.Int("hz", 0, "I²C bus speed (may require root)")
+	var hz physic.Frequency
+	flag.Var(&hz, "hz", "I²C bus speed, e.g. 400kHz (may require root)")
 	l := flag.Int("l", 1, "length of data to read; ignored if -w is specified")
 	flag.Parse()
 	if !*verbose {
@@ -37,6 +57,12 @@ func mainImpl() error {
 		return errors.New("unexpected argument, try -help")
 	}
 
+	if *recover && *addr == -1 {
+		if _, err := hostInit(); err != nil {
+			return err
+		}
+		return recoverBus(*busName)
+	}
 	if *addr < 0 || *addr >= 1<<9 {
 		return fmt.Errorf("-a is required and must be between 0 and %d", 1<<9-1)
 	}
@@ -72,14 +98,19 @@ func mainImpl() error {
 		buf = make([]byte, *l)
 	}
 
+	if *recover {
+		if err := recoverBus(*busName); err != nil {
+			return err
+		}
+	}
 	bus, err := i2creg.Open(*busName)
 	if err != nil {
 		return err
 	}
 	defer bus.Close()
 
-	if *hz != 0 {
-		if err := bus.SetSpeed(physic.Frequency(*hz) * physic.Hertz); err != nil {
+	if hz != 0 {
+		if err := bus.SetSpeed(hz); err != nil {
 			return err
 		}
 	}
@@ -88,7 +119,11 @@ func mainImpl() error {
 			log.Printf("Using pins SCL: %s  SDA: %s", p.SCL(), p.SDA())
 		}
 	}
-	d := i2c.Dev{Bus: bus, Addr: uint16(*addr)}
+	var b i2c.Bus = bus
+	if *verbose {
+		b = &i2c.Log{Bus: bus, Output: os.Stderr}
+	}
+	d := i2c.Dev{Bus: b, Addr: uint16(*addr)}
 	if *write {
 		_, err = d.Write(buf)
 	} else {
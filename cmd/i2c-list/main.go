@@ -6,6 +6,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -28,7 +29,24 @@ func printPin(fn string, p pin.Pin) {
 	}
 }
 
+func printDevices(b i2c.Bus) error {
+	addrs, err := i2c.Scan(context.Background(), b, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Devices:")
+	if len(addrs) == 0 {
+		fmt.Printf(" none")
+	}
+	for _, a := range addrs {
+		fmt.Printf(" 0x%02x", a)
+	}
+	fmt.Print("\n")
+	return nil
+}
+
 func mainImpl() error {
+	scan := flag.Bool("scan", false, "probe each bus for devices, like i2cdetect")
 	verbose := flag.Bool("v", false, "verbose mode")
 	flag.Parse()
 	if !*verbose {
@@ -63,6 +81,11 @@ func mainImpl() error {
 			printPin("SCL", p.SCL())
 			printPin("SDA", p.SDA())
 		}
+		if *scan {
+			if err := printDevices(bus); err != nil {
+				fmt.Printf("  Failed to scan: %v\n", err)
+			}
+		}
 		if err := bus.Close(); err != nil {
 			return err
 		}
@@ -96,7 +96,7 @@ func mainImpl() error {
 	mode := flag.Int("mode", 0, "CLK and data polarity, between 0 and 3")
 	bits := flag.Int("bits", 8, "bits per word")
 
-	verbose := flag.Bool("v", false, "verbose mode")
+	verbose := flag.Bool("v", false, "verbose mode; logs the transactions to stderr")
 	flag.Parse()
 	if !*verbose {
 		log.SetOutput(ioutil.Discard)
@@ -127,7 +127,11 @@ func mainImpl() error {
 		return err
 	}
 	defer s.Close()
-	c, err := s.Connect(physic.Frequency(*hz)*physic.Hertz, m, *bits)
+	var port spi.Port = s
+	if *verbose {
+		port = &spi.Log{Port: s, Output: os.Stderr}
+	}
+	c, err := port.Connect(physic.Frequency(*hz)*physic.Hertz, m, *bits)
 	if err != nil {
 		return err
 	}
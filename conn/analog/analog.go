@@ -0,0 +1,126 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package analog defines analog pins, both digital to analog converter (DAC)
+// and analog to digital converter (ADC).
+package analog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+// Reading is one analog reading.
+type Reading struct {
+	// V is the interpreted electrical level.
+	V physic.ElectricPotential
+	// Raw is the raw measurement as returned by the converter.
+	Raw int32
+}
+
+// String returns the reading formatted as "1.234V (raw 20345)".
+func (r Reading) String() string {
+	return fmt.Sprintf("%s (raw %d)", r.V, r.Raw)
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// The reading is encoded as {"v":"1.2345V","raw":20345}. Unlike String(), V
+// is formatted by physic.ElectricPotential.MarshalJSON() with all its
+// significant digits so it round-trips exactly.
+func (r Reading) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonReading{V: &r.V, Raw: r.Raw})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// V is accepted as parsed by physic.ElectricPotential.UnmarshalJSON(), either
+// as a string with an optional S.I. prefix, like "1.2345V" or "12.5mV", or as
+// a number of volts.
+func (r *Reading) UnmarshalJSON(b []byte) error {
+	var v jsonReading
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("analog: invalid reading: %v", err)
+	}
+	if v.V == nil {
+		return errors.New("analog: invalid reading: missing \"v\"")
+	}
+	r.V = *v.V
+	r.Raw = v.Raw
+	return nil
+}
+
+// PinADC is an analog-to-digital-conversion input.
+type PinADC interface {
+	pin.Pin
+	// Range returns the maximum supported range [min, max] of the values.
+	Range() (Reading, Reading)
+	// Read returns the current pin level.
+	Read() (Reading, error)
+}
+
+// SampleRater is an optional interface implemented by PinADC whose sampling
+// rate is known.
+//
+// Generic code can discover it with a type assertion.
+type SampleRater interface {
+	// SampleRate returns the effective sampling rate of the converter, which
+	// may be higher than the one requested.
+	SampleRate() physic.Frequency
+	// ConversionTime returns the nominal duration of one conversion.
+	ConversionTime() time.Duration
+}
+
+// INVALID implements PinADC and fails on all access.
+var INVALID PinADC = invalidPin{}
+
+//
+
+// errInvalidPin is returned when trying to use INVALID.
+var errInvalidPin = errors.New("analog: invalid pin")
+
+// invalidPin implements PinADC for compatibility but fails on all access.
+type invalidPin struct {
+}
+
+func (invalidPin) String() string {
+	return "INVALID"
+}
+
+func (invalidPin) Halt() error {
+	return nil
+}
+
+func (invalidPin) Number() int {
+	return -1
+}
+
+func (invalidPin) Name() string {
+	return "INVALID"
+}
+
+func (invalidPin) Function() string {
+	return ""
+}
+
+func (invalidPin) Range() (Reading, Reading) {
+	return Reading{}, Reading{}
+}
+
+func (invalidPin) Read() (Reading, error) {
+	return Reading{}, errInvalidPin
+}
+
+// jsonReading is the JSON encoding of Reading.
+type jsonReading struct {
+	V   *physic.ElectricPotential `json:"v"`
+	Raw int32                     `json:"raw"`
+}
+
+var _ PinADC = INVALID
@@ -0,0 +1,90 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package analog
+
+import (
+	"encoding/json"
+	"testing"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestReading_String(t *testing.T) {
+	r := Reading{V: 1234500 * physic.MicroVolt, Raw: 20345}
+	if s := r.String(); s != "1.234V (raw 20345)" && s != "1.235V (raw 20345)" {
+		t.Fatal(s)
+	}
+}
+
+func TestReading_JSON(t *testing.T) {
+	data := []struct {
+		r Reading
+		s string
+	}{
+		{Reading{V: 1234500 * physic.MicroVolt, Raw: 20345}, `{"v":"1.2345V","raw":20345}`},
+		{Reading{V: -125 * physic.MicroVolt, Raw: -1}, `{"v":"-125µV","raw":-1}`},
+		{Reading{V: 3 * physic.Volt, Raw: 0x7fff}, `{"v":"3V","raw":32767}`},
+		{Reading{}, `{"v":"0V","raw":0}`},
+	}
+	for i, line := range data {
+		b, err := json.Marshal(line.r)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if string(b) != line.s {
+			t.Fatalf("#%d: %s != %s", i, b, line.s)
+		}
+		var r Reading
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Fatal(i, err)
+		}
+		if r != line.r {
+			t.Fatalf("#%d: %#v != %#v", i, r, line.r)
+		}
+	}
+}
+
+func TestReading_UnmarshalJSON(t *testing.T) {
+	data := []struct {
+		s string
+		r Reading
+	}{
+		{`{"v":1.2345,"raw":20345}`, Reading{V: 1234500 * physic.MicroVolt, Raw: 20345}},
+		{`{"v":-2e-3}`, Reading{V: -2 * physic.MilliVolt}},
+		{`{"v":"12.5mV","raw":100}`, Reading{V: 12500 * physic.MicroVolt, Raw: 100}},
+		{`{"v":" 7 µV "}`, Reading{V: 7 * physic.MicroVolt}},
+		{`{"v":"1.5kV"}`, Reading{V: 1500 * physic.Volt}},
+		{`{"v":"0.0000000004V"}`, Reading{}},
+	}
+	for i, line := range data {
+		var r Reading
+		if err := json.Unmarshal([]byte(line.s), &r); err != nil {
+			t.Fatal(i, err)
+		}
+		if r != line.r {
+			t.Fatalf("#%d: %#v != %#v", i, r, line.r)
+		}
+	}
+}
+
+func TestReading_UnmarshalJSON_error(t *testing.T) {
+	data := []string{
+		`[]`,
+		`{}`,
+		`{"raw":1}`,
+		`{"v":"1.2"}`,
+		`{"v":"abcV"}`,
+		`{"v":"V"}`,
+		`{"v":true}`,
+		`{"v":"1V","raw":"x"}`,
+		`{"v":"10000000000V"}`,
+	}
+	for i, s := range data {
+		var r Reading
+		if err := json.Unmarshal([]byte(s), &r); err == nil {
+			t.Fatalf("#%d: expected error for %s", i, s)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package analogreg defines a registry for the known analog pins.
+package analogreg
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"periph.io/x/periph/conn/analog"
+)
+
+// ByName returns an analog pin from its name.
+//
+// Returns nil if the analog pin is not present.
+func ByName(name string) analog.PinADC {
+	mu.Lock()
+	defer mu.Unlock()
+	return byName[name]
+}
+
+// All returns all the analog pins registered.
+//
+// The list is guaranteed to be in order of name.
+func All() []analog.PinADC {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]analog.PinADC, 0, len(byName))
+	for _, p := range byName {
+		out = insertPinByName(out, p)
+	}
+	return out
+}
+
+// Register registers an analog pin.
+//
+// Registering the same pin name twice is an error.
+func Register(p analog.PinADC) error {
+	name := p.Name()
+	if len(name) == 0 {
+		return errors.New("analogreg: can't register a pin with no name")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if orig, ok := byName[name]; ok {
+		return errors.New("analogreg: can't register pin " + strconv.Quote(name) + " twice; already registered as " + strconv.Quote(orig.String()))
+	}
+	byName[name] = p
+	return nil
+}
+
+// Unregister removes a previously registered analog pin.
+//
+// This can happen when an ADC is exposed via an USB device and the device is
+// unplugged.
+func Unregister(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := byName[name]; ok {
+		delete(byName, name)
+		return nil
+	}
+	return errors.New("analogreg: can't unregister unknown pin name " + strconv.Quote(name))
+}
+
+//
+
+var (
+	mu     sync.Mutex
+	byName = map[string]analog.PinADC{}
+)
+
+// insertPinByName inserts pin p into list l while keeping l ordered by name.
+func insertPinByName(l []analog.PinADC, p analog.PinADC) []analog.PinADC {
+	n := p.Name()
+	i := 0
+	for ; i < len(l) && l[i].Name() < n; i++ {
+	}
+	l = append(l, nil)
+	copy(l[i+1:], l[i:])
+	l[i] = p
+	return l
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package analogreg
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/analog"
+	"periph.io/x/periph/conn/analog/analogtest"
+)
+
+func TestRegister(t *testing.T) {
+	defer reset()
+	if err := Register(&analogtest.Pin{N: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(&analogtest.Pin{N: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(&analogtest.Pin{N: "a"}); err == nil {
+		t.Fatal("registering the same name twice must fail")
+	}
+	if err := Register(&analogtest.Pin{}); err == nil {
+		t.Fatal("registering a pin with no name must fail")
+	}
+	a := All()
+	if len(a) != 2 || a[0].Name() != "a" || a[1].Name() != "b" {
+		t.Fatalf("unexpected pins %v", a)
+	}
+	if ByName("a") == nil {
+		t.Fatal("failed to get pin 'a'")
+	}
+	if ByName("c") != nil {
+		t.Fatal("pin 'c' is not registered")
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	defer reset()
+	if err := Unregister("a"); err == nil {
+		t.Fatal("unregistering an unknown pin must fail")
+	}
+	if err := Register(analog.INVALID); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unregister("INVALID"); err != nil {
+		t.Fatal(err)
+	}
+	if a := All(); len(a) != 0 {
+		t.Fatalf("expected no pin, got %v", a)
+	}
+}
+
+//
+
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	byName = map[string]analog.PinADC{}
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package analogtest is meant to be used to test drivers using fake analog
+// pins.
+package analogtest
+
+import (
+	"fmt"
+	"sync"
+
+	"periph.io/x/periph/conn/analog"
+)
+
+// Pin implements analog.PinADC.
+//
+// Modify its members to simulate hardware readings.
+type Pin struct {
+	// These should be immutable.
+	N   string
+	Num int
+	Fn  string
+	Min analog.Reading
+	Max analog.Reading
+
+	// Grab the Mutex before accessing the following members.
+	sync.Mutex
+	R   analog.Reading // Value returned by Read()
+	Err error          // Error returned by Read()
+}
+
+// String implements conn.Resource.
+func (p *Pin) String() string {
+	return fmt.Sprintf("%s(%d)", p.N, p.Num)
+}
+
+// Halt implements conn.Resource.
+//
+// It has no effect.
+func (p *Pin) Halt() error {
+	return nil
+}
+
+// Name implements pin.Pin.
+func (p *Pin) Name() string {
+	return p.N
+}
+
+// Number implements pin.Pin.
+func (p *Pin) Number() int {
+	return p.Num
+}
+
+// Function implements pin.Pin.
+func (p *Pin) Function() string {
+	return p.Fn
+}
+
+// Range implements analog.PinADC.
+func (p *Pin) Range() (analog.Reading, analog.Reading) {
+	return p.Min, p.Max
+}
+
+// Read implements analog.PinADC.
+func (p *Pin) Read() (analog.Reading, error) {
+	p.Lock()
+	defer p.Unlock()
+	return p.R, p.Err
+}
+
+var _ analog.PinADC = &Pin{}
@@ -0,0 +1,43 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package analogtest
+
+import (
+	"errors"
+	"testing"
+
+	"periph.io/x/periph/conn/analog"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestPin(t *testing.T) {
+	p := &Pin{N: "ADC1", Num: 1, Fn: "ADC", Max: analog.Reading{V: physic.Volt, Raw: 1023}}
+	if s := p.String(); s != "ADC1(1)" {
+		t.Fatal(s)
+	}
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Name(); n != "ADC1" {
+		t.Fatal(n)
+	}
+	if n := p.Number(); n != 1 {
+		t.Fatal(n)
+	}
+	if f := p.Function(); f != "ADC" {
+		t.Fatal(f)
+	}
+	if min, max := p.Range(); min.Raw != 0 || max.Raw != 1023 {
+		t.Fatal(min, max)
+	}
+	p.R = analog.Reading{V: physic.Volt / 2, Raw: 512}
+	if r, err := p.Read(); err != nil || r.Raw != 512 {
+		t.Fatal(r, err)
+	}
+	p.Err = errors.New("oops")
+	if _, err := p.Read(); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package analog
+
+import "periph.io/x/periph/conn/pin"
+
+const (
+	ADC pin.Func = "ADC" // Analog to digital converter input
+	DAC pin.Func = "DAC" // Digital to analog converter output
+)
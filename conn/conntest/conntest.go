@@ -53,6 +53,50 @@ func (r *RecordRaw) Duplex() conn.Duplex {
 type IO struct {
 	W []byte
 	R []byte
+	// Mask, if set, selects the bits of W that Playback compares; the bits
+	// that are 0 in Mask are wildcards, e.g. don't care status bits. It must
+	// be the same length as W.
+	Mask []byte
+}
+
+// Match returns true if w is the write expected by io, taking Mask into
+// account.
+func (io *IO) Match(w []byte) bool {
+	if len(io.Mask) == 0 {
+		return bytes.Equal(io.W, w)
+	}
+	if len(w) != len(io.W) || len(io.Mask) != len(io.W) {
+		return false
+	}
+	for i := range w {
+		if (w[i]^io.W[i])&io.Mask[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// GoSource returns ops as Go source, to paste the operations recorded on
+// real hardware in a unit test.
+func GoSource(ops []IO) string {
+	s := "[]conntest.IO{\n"
+	for _, io := range ops {
+		s += "\t{"
+		sep := ""
+		if len(io.W) != 0 {
+			s += "W: " + goBytes(io.W)
+			sep = ", "
+		}
+		if len(io.R) != 0 {
+			s += sep + "R: " + goBytes(io.R)
+			sep = ", "
+		}
+		if len(io.Mask) != 0 {
+			s += sep + "Mask: " + goBytes(io.Mask)
+		}
+		s += "},\n"
+	}
+	return s + "}"
 }
 
 // Record implements conn.Conn that records everything written to it.
@@ -138,7 +182,7 @@ func (p *Playback) Tx(w, r []byte) error {
 	if len(p.Ops) <= p.Count {
 		return errorf(p.DontPanic, "conntest: unexpected Tx() (count #%d) expecting []conntest.IO{W:%#v, R:%#v}", p.Count, w, r)
 	}
-	if !bytes.Equal(p.Ops[p.Count].W, w) {
+	if !p.Ops[p.Count].Match(w) {
 		return errorf(p.DontPanic, "conntest: unexpected write (count #%d) %#v != %#v", p.Count, w, p.Ops[p.Count].W)
 	}
 	if len(p.Ops[p.Count].R) != len(r) {
@@ -184,6 +228,17 @@ func (d *Discard) Duplex() conn.Duplex {
 // errorf is the internal implementation that optionally panic.
 //
 // If dontPanic is false, it panics instead.
+func goBytes(b []byte) string {
+	s := "[]byte{"
+	for i, v := range b {
+		if i != 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("0x%02X", v)
+	}
+	return s + "}"
+}
+
 func errorf(dontPanic bool, format string, a ...interface{}) error {
 	err := Errorf(format, a...)
 	if !dontPanic {
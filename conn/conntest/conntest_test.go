@@ -203,6 +203,43 @@ func TestRecord_Playback(t *testing.T) {
 	}
 }
 
+func TestIO_Match(t *testing.T) {
+	io := IO{W: []byte{0x10, 0x80}, Mask: []byte{0xFF, 0xF0}}
+	if !io.Match([]byte{0x10, 0x8F}) {
+		t.Fatal("wildcard bits")
+	}
+	if io.Match([]byte{0x10, 0x90}) || io.Match([]byte{0x10}) {
+		t.Fatal("mismatch")
+	}
+	io.Mask = nil
+	if io.Match([]byte{0x10, 0x8F}) || !io.Match([]byte{0x10, 0x80}) {
+		t.Fatal("exact")
+	}
+}
+
+func TestPlayback_Tx_mask(t *testing.T) {
+	p := Playback{Ops: []IO{{W: []byte{0x01, 0x80}, R: []byte{2}, Mask: []byte{0xFF, 0x80}}}, DontPanic: true}
+	r := make([]byte, 1)
+	if err := p.Tx([]byte{0x01, 0xA5}, r); err != nil {
+		t.Fatal(err)
+	}
+	if r[0] != 2 {
+		t.Fatal(r)
+	}
+}
+
+func TestGoSource(t *testing.T) {
+	ops := []IO{{W: []byte{0x0B, 0xFF}, R: []byte{1}}, {R: []byte{2}}, {W: []byte{3}, Mask: []byte{0xF0}}}
+	expected := "[]conntest.IO{\n" +
+		"\t{W: []byte{0x0B, 0xFF}, R: []byte{0x01}},\n" +
+		"\t{R: []byte{0x02}},\n" +
+		"\t{W: []byte{0x03}, Mask: []byte{0xF0}},\n" +
+		"}"
+	if s := GoSource(ops); s != expected {
+		t.Fatal(s)
+	}
+}
+
 func TestDiscard(t *testing.T) {
 	d := Discard{D: conn.Half}
 	if s := d.String(); s != "discard" {
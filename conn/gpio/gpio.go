@@ -9,7 +9,9 @@
 package gpio
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -182,6 +184,10 @@ type PinIn interface {
 	WaitForEdge(timeout time.Duration) bool
 	// Pull returns the internal pull resistor if the pin is set as input pin.
 	//
+	// It is the pull currently active, which may have been set by the firmware,
+	// the device tree or another process. When the hardware can't report it, a
+	// driver may return the last value set with In() by this process.
+	//
 	// Returns PullNoChange if the value cannot be read.
 	Pull() Pull
 	// DefaultPull returns the pull that is initialized on CPU/device reset. This
@@ -190,6 +196,57 @@ type PinIn interface {
 	DefaultPull() Pull
 }
 
+// PinInCtx is an optional interface implemented by a PinIn that can wait for
+// an edge until a context is done.
+type PinInCtx interface {
+	// WaitForEdgeCtx is like PinIn.WaitForEdge() but waits until ctx is done
+	// instead of a timeout.
+	//
+	// It returns nil if an edge was detected during or before this call, or an
+	// error wrapping ctx.Err() once ctx is done.
+	WaitForEdgeCtx(ctx context.Context) error
+}
+
+// PinInTime is an optional interface implemented by a PinIn that timestamps
+// the edges when they happen, in the kernel or in hardware, like the GPIO
+// character device does.
+type PinInTime interface {
+	// WaitForEdgeTime is like PinInCtx.WaitForEdgeCtx() but also returns when
+	// the edge happened and the level of the pin right after it.
+	//
+	// The edges are queued, so each call returns the next edge even if
+	// several happened since the previous call.
+	WaitForEdgeTime(ctx context.Context) (time.Time, Level, error)
+}
+
+// WaitForEdgeCtx waits for an edge on p until ctx is done.
+//
+// It calls p.WaitForEdgeCtx() if p implements PinInCtx. Otherwise it calls
+// p.WaitForEdge() in a loop with a short timeout to check ctx, so it may
+// return up to 100ms after ctx is done.
+func WaitForEdgeCtx(ctx context.Context, p PinIn) error {
+	if c, ok := p.(PinInCtx); ok {
+		return c.WaitForEdgeCtx(ctx)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("gpio: %s: %w", p, err)
+		}
+		timeout := pollCtx
+		if d, ok := ctx.Deadline(); ok {
+			if left := time.Until(d); left < timeout {
+				timeout = left
+			}
+		}
+		if timeout < time.Millisecond {
+			timeout = time.Millisecond
+		}
+		if p.WaitForEdge(timeout) {
+			return nil
+		}
+	}
+}
+
 // PinOut is an output GPIO pin.
 //
 // A LED, a buzzer, a servo, are semantically a PinOut. So if you are looking
@@ -252,6 +309,10 @@ type RealPin interface {
 
 //
 
+// pollCtx is the maximum timeout used by WaitForEdgeCtx() between checks of
+// the context.
+const pollCtx = 100 * time.Millisecond
+
 // errInvalidPin is returned when trying to use INVALID.
 var errInvalidPin = errors.New("gpio: invalid pin")
 
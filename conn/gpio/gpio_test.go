@@ -5,6 +5,8 @@
 package gpio
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -134,3 +136,123 @@ func TestInvalid(t *testing.T) {
 		t.Fatal("can't set func")
 	}
 }
+
+func TestWaitForEdgeCtx(t *testing.T) {
+	p := &edgePin{edges: make(chan struct{}, 1)}
+	p.edges <- struct{}{}
+	if err := WaitForEdgeCtx(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := WaitForEdgeCtx(ctx, p); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	if p.timeout > 10*time.Millisecond {
+		t.Fatal(p.timeout)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := WaitForEdgeCtx(ctx, p); err == nil || err.Error() != "gpio: INVALID: context canceled" {
+		t.Fatal(err)
+	}
+	// PinInCtx is used when implemented.
+	c := &ctxPin{}
+	if err := WaitForEdgeCtx(ctx, c); err != nil || !c.called {
+		t.Fatal(err, c.called)
+	}
+}
+
+func TestNewGroup(t *testing.T) {
+	if _, err := NewGroup(); err == nil {
+		t.Fatal("empty group")
+	}
+	if _, err := NewGroup(&levelPin{}, nil); err == nil {
+		t.Fatal("nil pin")
+	}
+	pins := []PinIO{&levelPin{name: "A"}, &levelPin{name: "B"}, &levelPin{name: "C"}}
+	g, err := NewGroup(pins...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); s != "Group(A,B,C)" {
+		t.Fatal(s)
+	}
+	if p := g.Pins(); len(p) != 3 || p[2] != pins[2] {
+		t.Fatal(p)
+	}
+	if err := g.Out(5); err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range []Level{High, Low, High} {
+		if pins[i].Read() != l {
+			t.Fatalf("#%d: %s", i, pins[i].Read())
+		}
+	}
+	pins[1].(*levelPin).l = High
+	if v := g.Read(); v != 7 {
+		t.Fatal(v)
+	}
+	if err := g.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	pins[0].(*levelPin).err = errors.New("oops")
+	if err := g.Out(0); err == nil {
+		t.Fatal("Out error not returned")
+	}
+	if err := g.Halt(); err == nil {
+		t.Fatal("Halt error not returned")
+	}
+}
+
+//
+
+type edgePin struct {
+	invalidPin
+	edges   chan struct{}
+	timeout time.Duration
+}
+
+func (e *edgePin) WaitForEdge(timeout time.Duration) bool {
+	e.timeout = timeout
+	select {
+	case <-e.edges:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+type ctxPin struct {
+	invalidPin
+	called bool
+}
+
+func (c *ctxPin) WaitForEdgeCtx(ctx context.Context) error {
+	c.called = true
+	return nil
+}
+
+type levelPin struct {
+	invalidPin
+	name string
+	l    Level
+	err  error
+}
+
+func (l *levelPin) String() string {
+	return l.name
+}
+
+func (l *levelPin) Halt() error {
+	return l.err
+}
+
+func (l *levelPin) Read() Level {
+	return l.l
+}
+
+func (l *levelPin) Out(v Level) error {
+	l.l = v
+	return l.err
+}
@@ -59,7 +59,7 @@ func (b *BitStream) Duration() time.Duration {
 	if b.Freq == 0 {
 		return 0
 	}
-	return b.Freq.Duration() * time.Duration(len(b.Bits)*8)
+	return b.Freq.Duration(int64(len(b.Bits) * 8))
 }
 
 // GoString implements fmt.GoStringer.
@@ -101,7 +101,7 @@ func (e *EdgeStream) Duration() time.Duration {
 	for _, edge := range e.Edges {
 		t += int(edge)
 	}
-	return e.Freq.Duration() * time.Duration(t)
+	return e.Freq.Duration(int64(t))
 }
 
 // Program is a loop of streams.
@@ -109,7 +109,7 @@ func (e *EdgeStream) Duration() time.Duration {
 // This is itself a stream, it can be used to reduce memory usage when repeated
 // patterns are used.
 type Program struct {
-	Parts []Stream // Each part must be a BitStream, EdgeStream or Program
+	Parts []Stream // Each part must be a BitStream, EdgeStream, TimedEdgeStream or Program
 	Loops int      // Set to -1 to create an infinite loop
 }
 
@@ -192,6 +192,10 @@ func deepCopy(s gpiostream.Stream) (gpiostream.Stream, error) {
 		o := &gpiostream.EdgeStream{Edges: make([]uint16, len(t.Edges)), Freq: t.Freq}
 		copy(o.Edges, t.Edges)
 		return o, nil
+	case *gpiostream.TimedEdgeStream:
+		o := &gpiostream.TimedEdgeStream{Start: t.Start, Edges: make([]gpiostream.TimedEdge, len(t.Edges))}
+		copy(o.Edges, t.Edges)
+		return o, nil
 	case *gpiostream.Program:
 		o := &gpiostream.Program{Loops: t.Loops}
 		for _, p := range t.Parts {
@@ -7,6 +7,7 @@ package gpiostreamtest
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"periph.io/x/periph/conn/conntest"
 	"periph.io/x/periph/conn/gpio"
@@ -170,6 +171,7 @@ func TestPinOutRecord(t *testing.T) {
 		&gpiostream.BitStream{Freq: physic.Hertz, Bits: []byte{0xCC}, LSBF: true},
 		&gpiostream.BitStream{Freq: physic.Hertz, Bits: []byte{0xCC}, LSBF: false},
 		&gpiostream.EdgeStream{Freq: physic.Hertz, Edges: []uint16{60, 120}},
+		&gpiostream.TimedEdgeStream{Edges: []gpiostream.TimedEdge{{Delta: time.Second, Level: gpio.High}}},
 		&gpiostream.Program{Parts: []gpiostream.Stream{&gpiostream.BitStream{Freq: physic.Hertz, Bits: []byte{0xCC}, LSBF: true}}, Loops: 2},
 	}
 	for _, line := range data {
@@ -0,0 +1,165 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpiostream
+
+import (
+	"errors"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+)
+
+// TimedEdge is a level change in a TimedEdgeStream.
+type TimedEdge struct {
+	// Delta is the time since the previous edge, or since Start for the first
+	// edge.
+	Delta time.Duration
+	// Level is the level after the edge.
+	Level gpio.Level
+}
+
+// TimedEdgeStream is a stream of timestamped edges, as captured by edge
+// detection or a logic analyzer.
+//
+// Unlike EdgeStream, the edges are not bound to a resolution.
+//
+// The level before the first edge is the opposite of the level of the first
+// edge. A stream without edges is Low.
+type TimedEdgeStream struct {
+	// Start is when the capture started. It should be read with time.Now() so
+	// it holds a monotonic clock reading.
+	Start time.Time
+	// Edges is the list of level changes, in order.
+	Edges []TimedEdge
+}
+
+// NewTimedEdgeStream returns the edges of a BitStream, where the first bit is
+// sampled at start.
+func NewTimedEdgeStream(b *BitStream, start time.Time) (*TimedEdgeStream, error) {
+	if b.Freq <= 0 {
+		return nil, errors.New("gpiostream: invalid frequency")
+	}
+	t := &TimedEdgeStream{Start: start}
+	last := gpio.Low
+	var lastT time.Duration
+	for i := 0; i < len(b.Bits)*8; i++ {
+		var l gpio.Level
+		if b.LSBF {
+			l = b.Bits[i/8]&(1<<uint(i%8)) != 0
+		} else {
+			l = b.Bits[i/8]&(0x80>>uint(i%8)) != 0
+		}
+		if l == last {
+			continue
+		}
+		at := b.Freq.Duration(int64(i))
+		t.Edges = append(t.Edges, TimedEdge{Delta: at - lastT, Level: l})
+		last = l
+		lastT = at
+	}
+	return t, nil
+}
+
+// Frequency implements Stream.
+//
+// It is the rate needed to represent the shortest pulse.
+func (t *TimedEdgeStream) Frequency() physic.Frequency {
+	var min time.Duration
+	for i := 1; i < len(t.Edges); i++ {
+		if d := t.Edges[i].Delta; d > 0 && (min == 0 || d < min) {
+			min = d
+		}
+	}
+	if min == 0 {
+		return 0
+	}
+	return physic.PeriodToFrequency(min)
+}
+
+// Duration implements Stream.
+//
+// It is the time from Start to the last edge.
+func (t *TimedEdgeStream) Duration() time.Duration {
+	var d time.Duration
+	for _, e := range t.Edges {
+		d += e.Delta
+	}
+	return d
+}
+
+// BitStream rasterizes the stream at the frequency f, MSB-first.
+//
+// The first bit is sampled at Start and the last bit after the last edge. The
+// stream is padded to a multiple of 8 bits with the level after the last
+// edge. The pulses shorter than the period of f may be lost.
+func (t *TimedEdgeStream) BitStream(f physic.Frequency) (*BitStream, error) {
+	if f <= 0 {
+		return nil, errors.New("gpiostream: invalid frequency")
+	}
+	// Number of samples to reach the last edge, plus one.
+	n := 1
+	for d := t.Duration(); f.Duration(int64(n-1)) < d; n++ {
+	}
+	b := &BitStream{Bits: make([]byte, (n+7)/8), Freq: f}
+	l := gpio.Low
+	if len(t.Edges) != 0 {
+		l = !t.Edges[0].Level
+	}
+	i := 0
+	var next time.Duration
+	if len(t.Edges) != 0 {
+		next = t.Edges[0].Delta
+	}
+	for s := 0; s < len(b.Bits)*8; s++ {
+		at := f.Duration(int64(s))
+		for i < len(t.Edges) && next <= at {
+			l = t.Edges[i].Level
+			if i++; i < len(t.Edges) {
+				next += t.Edges[i].Delta
+			}
+		}
+		if l == gpio.High {
+			b.Bits[s/8] |= 0x80 >> uint(s%8)
+		}
+	}
+	return b, nil
+}
+
+// Pulses returns the duration of each complete pulse at level l, in order.
+//
+// A pulse is complete when both the edge that starts it and the edge that
+// ends it are in the stream.
+func (t *TimedEdgeStream) Pulses(l gpio.Level) []time.Duration {
+	var out []time.Duration
+	for i := 1; i < len(t.Edges); i++ {
+		if t.Edges[i-1].Level == l {
+			out = append(out, t.Edges[i].Delta)
+		}
+	}
+	return out
+}
+
+// DecodePulseWidth decodes a pulse width encoded signal, where each pulse at
+// level l is a bit: 1 if it is longer than threshold, 0 otherwise.
+//
+// For example the DHT11 and DHT22 sensors send a 0 as a ~27µs high pulse and
+// a 1 as a ~70µs high pulse, so DecodePulseWidth(gpio.High, 50*time.Microsecond)
+// decodes their data, once the pulses of the start sequence are removed.
+//
+// The bits are packed MSB-first. The last byte is padded with 0 bits; the
+// number of bits is len(Pulses(l)).
+func (t *TimedEdgeStream) DecodePulseWidth(l gpio.Level, threshold time.Duration) []byte {
+	p := t.Pulses(l)
+	out := make([]byte, (len(p)+7)/8)
+	for i, d := range p {
+		if d > threshold {
+			out[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	return out
+}
+
+var _ Stream = &TimedEdgeStream{}
@@ -0,0 +1,140 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpiostream
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestTimedEdgeStream(t *testing.T) {
+	s := TimedEdgeStream{
+		Edges: []TimedEdge{
+			{2 * time.Millisecond, gpio.High},
+			{3 * time.Millisecond, gpio.Low},
+			{time.Millisecond, gpio.High},
+			{4 * time.Millisecond, gpio.Low},
+		},
+	}
+	if f := s.Frequency(); f != physic.KiloHertz {
+		t.Fatal(f)
+	}
+	if d := s.Duration(); d != 10*time.Millisecond {
+		t.Fatal(d)
+	}
+	if p := s.Pulses(gpio.High); !reflect.DeepEqual(p, []time.Duration{3 * time.Millisecond, 4 * time.Millisecond}) {
+		t.Fatal(p)
+	}
+	if p := s.Pulses(gpio.Low); !reflect.DeepEqual(p, []time.Duration{time.Millisecond}) {
+		t.Fatal(p)
+	}
+	var empty TimedEdgeStream
+	if f := empty.Frequency(); f != 0 {
+		t.Fatal(f)
+	}
+	if d := empty.Duration(); d != 0 {
+		t.Fatal(d)
+	}
+}
+
+func TestTimedEdgeStream_BitStream(t *testing.T) {
+	start := time.Now()
+	s := &TimedEdgeStream{
+		Start: start,
+		Edges: []TimedEdge{
+			{2 * time.Millisecond, gpio.High},
+			{3 * time.Millisecond, gpio.Low},
+			{time.Millisecond, gpio.High},
+			{4 * time.Millisecond, gpio.Low},
+		},
+	}
+	b, err := s.BitStream(physic.KiloHertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 11 samples padded to 16.
+	expected := &BitStream{Bits: []byte{0x3B, 0xC0}, Freq: physic.KiloHertz}
+	if !reflect.DeepEqual(b, expected) {
+		t.Fatalf("%#v", b)
+	}
+	// Round trip.
+	r, err := NewTimedEdgeStream(b, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, s) {
+		t.Fatalf("%#v", r)
+	}
+	// At a lower resolution, the short pulse is lost.
+	if b, err = s.BitStream(500 * physic.Hertz); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bits, []byte{0x78}) {
+		t.Fatalf("%#v", b)
+	}
+	if _, err := s.BitStream(0); err == nil {
+		t.Fatal("invalid frequency")
+	}
+}
+
+func TestTimedEdgeStream_BitStream_startHigh(t *testing.T) {
+	s := &TimedEdgeStream{Edges: []TimedEdge{{time.Millisecond, gpio.Low}}}
+	b, err := s.BitStream(physic.KiloHertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bits, []byte{0x80}) {
+		t.Fatalf("%#v", b)
+	}
+	r, err := NewTimedEdgeStream(b, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []TimedEdge{{0, gpio.High}, {time.Millisecond, gpio.Low}}
+	if !reflect.DeepEqual(r.Edges, expected) {
+		t.Fatal(r.Edges)
+	}
+	var empty TimedEdgeStream
+	if b, err = empty.BitStream(physic.KiloHertz); err != nil || !bytes.Equal(b.Bits, []byte{0}) {
+		t.Fatal(b, err)
+	}
+}
+
+func TestNewTimedEdgeStream(t *testing.T) {
+	b := &BitStream{Bits: []byte{0x06}, Freq: physic.KiloHertz, LSBF: true}
+	s, err := NewTimedEdgeStream(b, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []TimedEdge{{time.Millisecond, gpio.High}, {2 * time.Millisecond, gpio.Low}}
+	if !reflect.DeepEqual(s.Edges, expected) {
+		t.Fatal(s.Edges)
+	}
+	if _, err := NewTimedEdgeStream(&BitStream{}, time.Time{}); err == nil {
+		t.Fatal("invalid frequency")
+	}
+}
+
+func TestTimedEdgeStream_DecodePulseWidth(t *testing.T) {
+	// 0x5 as 0, 1, 0, 1 followed by a 1, each high pulse preceded by a 50µs low
+	// pulse.
+	s := &TimedEdgeStream{}
+	for _, d := range []time.Duration{27, 70, 27, 70, 70} {
+		s.Edges = append(s.Edges,
+			TimedEdge{50 * time.Microsecond, gpio.High},
+			TimedEdge{d * time.Microsecond, gpio.Low})
+	}
+	if b := s.DecodePulseWidth(gpio.High, 50*time.Microsecond); !bytes.Equal(b, []byte{0x58}) {
+		t.Fatalf("%#x", b)
+	}
+	if b := (&TimedEdgeStream{}).DecodePulseWidth(gpio.High, 0); len(b) != 0 {
+		t.Fatal(b)
+	}
+}
@@ -6,6 +6,7 @@
 package gpiotest
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -33,6 +34,7 @@ type Pin struct {
 	EdgesChan chan gpio.Level  // Use it to fake edges
 	D         gpio.Duty        // PWM duty
 	F         physic.Frequency // PWM period
+	Now       func() time.Time // Timestamps the edges in WaitForEdgeTime(); defaults to time.Now
 }
 
 // String implements conn.Resource.
@@ -81,7 +83,9 @@ func (p *Pin) SetFunc(f pin.Func) error {
 func (p *Pin) In(pull gpio.Pull, edge gpio.Edge) error {
 	p.Lock()
 	defer p.Unlock()
-	p.P = pull
+	if pull != gpio.PullNoChange {
+		p.P = pull
+	}
 	if pull == gpio.PullDown {
 		p.L = gpio.Low
 	} else if pull == gpio.PullUp {
@@ -122,6 +126,35 @@ func (p *Pin) WaitForEdge(timeout time.Duration) bool {
 	}
 }
 
+// WaitForEdgeCtx implements gpio.PinInCtx.
+func (p *Pin) WaitForEdgeCtx(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("gpiotest: %s: %w", p, ctx.Err())
+	case l := <-p.EdgesChan:
+		_ = p.Out(l)
+		return nil
+	}
+}
+
+// WaitForEdgeTime implements gpio.PinInTime.
+//
+// The edge is timestamped with Now when it is received from EdgesChan.
+func (p *Pin) WaitForEdgeTime(ctx context.Context) (time.Time, gpio.Level, error) {
+	select {
+	case <-ctx.Done():
+		return time.Time{}, p.Read(), fmt.Errorf("gpiotest: %s: %w", p, ctx.Err())
+	case l := <-p.EdgesChan:
+		p.Lock()
+		defer p.Unlock()
+		p.L = l
+		if p.Now == nil {
+			return time.Now(), l, nil
+		}
+		return p.Now(), l, nil
+	}
+}
+
 // Pull implements gpio.PinIn.
 func (p *Pin) Pull() gpio.Pull {
 	return p.P
@@ -193,4 +226,6 @@ func (p *LogPinIO) PWM(duty gpio.Duty, f physic.Frequency) error {
 }
 
 var _ gpio.PinIO = &Pin{}
+var _ gpio.PinInCtx = &Pin{}
+var _ gpio.PinInTime = &Pin{}
 var _ pin.PinFunc = &Pin{}
@@ -5,6 +5,8 @@
 package gpiotest
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"log"
 	"os"
@@ -64,6 +66,13 @@ func TestPin(t *testing.T) {
 	if pull := p.Pull(); pull != gpio.PullUp {
 		t.Fatal(pull)
 	}
+	// The pull is kept.
+	if err := p.In(gpio.PullNoChange, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	if pull := p.Pull(); pull != gpio.PullUp {
+		t.Fatal(pull)
+	}
 	if pull := p.DefaultPull(); pull != gpio.PullUp {
 		t.Fatal(pull)
 	}
@@ -97,6 +106,44 @@ func TestPin_edge(t *testing.T) {
 	}
 }
 
+func TestPin_WaitForEdgeCtx(t *testing.T) {
+	p := &Pin{N: "GPIO1", Num: 1, EdgesChan: make(chan gpio.Level, 1)}
+	p.EdgesChan <- gpio.High
+	if err := p.WaitForEdgeCtx(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if p.Read() != gpio.High {
+		t.Fatal("edge not applied")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.WaitForEdgeCtx(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+}
+
+func TestPin_WaitForEdgeTime(t *testing.T) {
+	now := time.Unix(10, 0)
+	p := &Pin{N: "GPIO1", Num: 1, EdgesChan: make(chan gpio.Level, 1), Now: func() time.Time { return now }}
+	p.EdgesChan <- gpio.High
+	if ts, l, err := p.WaitForEdgeTime(context.Background()); !ts.Equal(now) || l != gpio.High || err != nil {
+		t.Fatal(ts, l, err)
+	}
+	if p.Read() != gpio.High {
+		t.Fatal("edge not applied")
+	}
+	p.Now = nil
+	p.EdgesChan <- gpio.Low
+	if ts, l, err := p.WaitForEdgeTime(context.Background()); ts.Before(now) || l != gpio.Low || err != nil {
+		t.Fatal(ts, l, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := p.WaitForEdgeTime(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+}
+
 func TestPin_fail(t *testing.T) {
 	p := &Pin{N: "GPIO1", Num: 1, Fn: "I2C1_SDA"}
 	if err := p.In(gpio.Float, gpio.BothEdges); err == nil {
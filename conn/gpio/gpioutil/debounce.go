@@ -0,0 +1,183 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package gpioutil includes utilities to filter or augment GPIOs.
+package gpioutil
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// Debounce returns a debounced gpio.PinIO from a gpio.PinIO source. Only the
+// PinIn behavior is mutated.
+//
+// denoise is a noise filter: a new level is only reported once the pin has
+// been steady for this amount of time, so glitches shorter than denoise are
+// ignored.
+//
+// debounce locks on a level for this amount of time after it was reported,
+// ignoring the bounces that follow.
+//
+// edge selects the edges reported by WaitForEdge(). The pin is configured as
+// input with its current pull resistor setting; use In() on the returned pin
+// to change it.
+//
+// No goroutine is used; the filtering is done while the caller is blocked in
+// WaitForEdge() or Read().
+func Debounce(p gpio.PinIO, denoise, debounce time.Duration, edge gpio.Edge) (gpio.PinIO, error) {
+	if denoise < 0 || debounce < 0 {
+		return nil, errors.New("gpioutil: invalid debounce duration")
+	}
+	d := &debounced{
+		PinIO:    p,
+		denoise:  denoise,
+		debounce: debounce,
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+	if err := d.In(gpio.PullNoChange, edge); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+//
+
+// debounced is a gpio.PinIO where reading and edge detection pass through a
+// debouncing algorithm.
+type debounced struct {
+	// Immutable.
+	gpio.PinIO
+	denoise  time.Duration
+	debounce time.Duration
+	// now and sleep are overridden in unit tests.
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	mu        sync.Mutex
+	edge      gpio.Edge
+	level     gpio.Level // Last reported level.
+	lockUntil time.Time  // End of the debounce window.
+}
+
+// In implements gpio.PinIn.
+//
+// The underlying pin is configured to detect both edges when edge is not
+// NoEdge, as the debouncing needs to track all the changes.
+func (d *debounced) In(pull gpio.Pull, edge gpio.Edge) error {
+	e := edge
+	if e != gpio.NoEdge {
+		e = gpio.BothEdges
+	}
+	if err := d.PinIO.In(pull, e); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.edge = edge
+	d.level = d.PinIO.Read()
+	d.lockUntil = time.Time{}
+	return nil
+}
+
+// Read implements gpio.PinIn.
+//
+// It returns the last reported level, unless the pin changed to another level
+// outside of the debounce window and is still at this level after denoise.
+func (d *debounced) Read() gpio.Level {
+	d.mu.Lock()
+	level, locked := d.level, d.now().Before(d.lockUntil)
+	d.mu.Unlock()
+	if locked {
+		return level
+	}
+	l := d.PinIO.Read()
+	if l == level {
+		return level
+	}
+	if d.denoise != 0 {
+		// Sample again once the noise filter elapsed.
+		d.sleep(d.denoise)
+		if d.PinIO.Read() != l {
+			return level
+		}
+	}
+	d.report(l)
+	return l
+}
+
+// WaitForEdge implements gpio.PinIn.
+//
+// It returns true once the debounced level changed in the direction requested
+// by the edge. The time spent to verify that the level is steady for denoise
+// can extend past timeout.
+func (d *debounced) WaitForEdge(timeout time.Duration) bool {
+	d.mu.Lock()
+	edge := d.edge
+	d.mu.Unlock()
+	if edge == gpio.NoEdge {
+		return d.PinIO.WaitForEdge(timeout)
+	}
+	var deadline time.Time
+	if timeout >= 0 {
+		deadline = d.now().Add(timeout)
+	}
+	for {
+		left := time.Duration(-1)
+		if !deadline.IsZero() {
+			if left = deadline.Sub(d.now()); left < 0 {
+				left = 0
+			}
+		}
+		d.mu.Lock()
+		lock := d.lockUntil.Sub(d.now())
+		level := d.level
+		d.mu.Unlock()
+		if lock > 0 {
+			// Ignore the bounces until the end of the debounce window, then look
+			// at the level.
+			if left >= 0 && lock > left {
+				d.sleep(left)
+				return false
+			}
+			d.sleep(lock)
+		} else if !d.PinIO.WaitForEdge(left) {
+			return false
+		}
+		// Wait for the pin to be steady.
+		for d.denoise != 0 && d.PinIO.WaitForEdge(d.denoise) {
+		}
+		l := d.PinIO.Read()
+		if l == level {
+			continue
+		}
+		d.report(l)
+		if edge == gpio.BothEdges || (edge == gpio.RisingEdge) == (l == gpio.High) {
+			return true
+		}
+	}
+}
+
+// Real implements gpio.RealPin.
+func (d *debounced) Real() gpio.PinIO {
+	if r, ok := d.PinIO.(gpio.RealPin); ok {
+		return r.Real()
+	}
+	return d.PinIO
+}
+
+// report sets the new debounced level and starts the debounce window.
+func (d *debounced) report(l gpio.Level) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.level = l
+	d.lockUntil = d.now().Add(d.debounce)
+}
+
+var _ gpio.PinIO = &debounced{}
+var _ gpio.RealPin = &debounced{}
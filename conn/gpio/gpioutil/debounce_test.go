@@ -0,0 +1,227 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func TestDebounce_WaitForEdge(t *testing.T) {
+	p, d := newDebounced(t, gpio.BothEdges, bounces)
+	if p.pull != gpio.PullNoChange || p.edge != gpio.BothEdges {
+		t.Fatal(p.pull, p.edge)
+	}
+	expected := []edgeAt{
+		// The glitch at 5ms is ignored, the press is reported once steady for
+		// 1ms and the bounces in the 10ms window are ignored.
+		{21400 * time.Microsecond, gpio.High},
+		{51 * time.Millisecond, gpio.Low},
+	}
+	if got := collect(p, d); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("%v", got)
+	}
+}
+
+func TestDebounce_WaitForEdge_rising(t *testing.T) {
+	p, d := newDebounced(t, gpio.RisingEdge, bounces)
+	// Edge detection is still needed on both edges to track the level.
+	if p.edge != gpio.BothEdges {
+		t.Fatal(p.edge)
+	}
+	expected := []edgeAt{{21400 * time.Microsecond, gpio.High}}
+	if got := collect(p, d); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("%v", got)
+	}
+	// The falling edge was still tracked.
+	if l := d.Read(); l != gpio.Low {
+		t.Fatal(l)
+	}
+}
+
+func TestDebounce_WaitForEdge_timeout(t *testing.T) {
+	p, d := newDebounced(t, gpio.BothEdges, bounces)
+	if d.WaitForEdge(10 * time.Millisecond) {
+		t.Fatal("glitch reported")
+	}
+	if p.t != 10*time.Millisecond {
+		t.Fatal(p.t)
+	}
+	if !d.WaitForEdge(20 * time.Millisecond) {
+		t.Fatal("edge not reported")
+	}
+	// Times out in the debounce window.
+	if d.WaitForEdge(5 * time.Millisecond) {
+		t.Fatal("bounce reported")
+	}
+	if p.t != 26400*time.Microsecond {
+		t.Fatal(p.t)
+	}
+}
+
+func TestDebounce_Read(t *testing.T) {
+	p, d := newDebounced(t, gpio.NoEdge, bounces)
+	if p.edge != gpio.NoEdge {
+		t.Fatal(p.edge)
+	}
+	data := []struct {
+		at time.Duration
+		l  gpio.Level
+	}{
+		// Glitch; the level is sampled again after 1ms.
+		{5200 * time.Microsecond, gpio.Low},
+		// Still high after 1ms, despite the bounces in between.
+		{20100 * time.Microsecond, gpio.High},
+		// The pin is low in the debounce window.
+		{22100 * time.Microsecond, gpio.High},
+		{60 * time.Millisecond, gpio.Low},
+	}
+	for i, line := range data {
+		p.advance(line.at)
+		if l := d.Read(); l != line.l {
+			t.Fatalf("#%d: %s", i, l)
+		}
+	}
+}
+
+func TestDebounce_passthrough(t *testing.T) {
+	p := &bouncePin{Pin: gpiotest.Pin{N: "GPIO1"}}
+	d, err := Debounce(p, 0, 0, gpio.BothEdges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != "GPIO1(0)" {
+		t.Fatal(s)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if r := d.(gpio.RealPin).Real(); r != p {
+		t.Fatal(r)
+	}
+	if err := d.In(gpio.PullUp, gpio.FallingEdge); err != nil {
+		t.Fatal(err)
+	}
+	if p.pull != gpio.PullUp || p.edge != gpio.BothEdges {
+		t.Fatal(p.pull, p.edge)
+	}
+	if err := d.Out(gpio.High); err != nil || p.L != gpio.High {
+		t.Fatal(err, p.L)
+	}
+}
+
+func TestDebounce_err(t *testing.T) {
+	p := &bouncePin{}
+	if _, err := Debounce(p, -1, 0, gpio.BothEdges); err == nil {
+		t.Fatal("invalid denoise")
+	}
+	if _, err := Debounce(p, 0, -1, gpio.BothEdges); err == nil {
+		t.Fatal("invalid debounce")
+	}
+	p.err = errors.New("oops")
+	if _, err := Debounce(p, 0, 0, gpio.BothEdges); err != p.err {
+		t.Fatal(err)
+	}
+}
+
+//
+
+// bounces is a button press with a glitch before and bounces around the
+// press.
+var bounces = []edgeAt{
+	{5 * time.Millisecond, gpio.High},
+	{5500 * time.Microsecond, gpio.Low},
+	{20 * time.Millisecond, gpio.High},
+	{20200 * time.Microsecond, gpio.Low},
+	{20400 * time.Microsecond, gpio.High},
+	{22 * time.Millisecond, gpio.Low},
+	{22300 * time.Microsecond, gpio.High},
+	{50 * time.Millisecond, gpio.Low},
+}
+
+// edgeAt is a level change at a time on the fake clock.
+type edgeAt struct {
+	at time.Duration
+	l  gpio.Level
+}
+
+func newDebounced(t *testing.T, edge gpio.Edge, events []edgeAt) (*bouncePin, *debounced) {
+	p := &bouncePin{Pin: gpiotest.Pin{N: "GPIO1"}, events: events}
+	d, err := Debounce(p, time.Millisecond, 10*time.Millisecond, edge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dd := d.(*debounced)
+	dd.now = p.now
+	dd.sleep = p.sleep
+	return p, dd
+}
+
+// collect returns the edges reported until the fake pin runs out of events.
+func collect(p *bouncePin, d gpio.PinIn) []edgeAt {
+	var out []edgeAt
+	for d.WaitForEdge(-1) {
+		out = append(out, edgeAt{p.t, d.Read()})
+	}
+	return out
+}
+
+// bouncePin is a gpiotest.Pin that follows a timeline of level changes on a
+// fake clock.
+//
+// Like the edge detection of the OS, the edges that happened since the last
+// WaitForEdge() call are coalesced.
+type bouncePin struct {
+	gpiotest.Pin
+	t       time.Duration // Fake clock.
+	events  []edgeAt      // Changes still to happen.
+	pending bool          // An edge happened since the last WaitForEdge().
+	pull    gpio.Pull
+	edge    gpio.Edge
+	err     error
+}
+
+func (p *bouncePin) In(pull gpio.Pull, edge gpio.Edge) error {
+	p.pull = pull
+	p.edge = edge
+	return p.err
+}
+
+func (p *bouncePin) WaitForEdge(timeout time.Duration) bool {
+	if !p.pending {
+		if len(p.events) == 0 || (timeout >= 0 && p.events[0].at > p.t+timeout) {
+			if timeout >= 0 {
+				p.advance(p.t + timeout)
+			}
+			return false
+		}
+		p.advance(p.events[0].at)
+	}
+	p.pending = false
+	return true
+}
+
+func (p *bouncePin) now() time.Time {
+	return time.Unix(0, 0).Add(p.t)
+}
+
+func (p *bouncePin) sleep(d time.Duration) {
+	p.advance(p.t + d)
+}
+
+// advance moves the fake clock to t, applying the changes up to then.
+func (p *bouncePin) advance(t time.Duration) {
+	for len(p.events) != 0 && p.events[0].at <= t {
+		p.L = p.events[0].l
+		p.pending = true
+		p.events = p.events[1:]
+	}
+	p.t = t
+}
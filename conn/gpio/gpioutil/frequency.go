@@ -0,0 +1,222 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiostream"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Frequency measures the frequency of the signal on p by timing its rising
+// edges for window, or until ctx is done.
+//
+// The frequency is the number of periods between the first and the last
+// rising edge divided by the time between them, so it doesn't depend on the
+// phase of the signal at the start of the window. 0 is returned when there is
+// less than two rising edges in the window.
+//
+// The accuracy depends on p. When p implements gpio.PinInTime, the edges are
+// timestamped by the kernel or the hardware and queued, and the accuracy is
+// the one of the timestamps.
+//
+// Otherwise each edge is timestamped when the goroutine wakes up, which is
+// late by 50µs to 1ms depending on the host and its load. The error on the
+// measurement is this latency divided by the window, e.g. below 0.1% over a
+// one second window. The edges that happen while the goroutine is not yet
+// waiting are coalesced by the OS, so the signal must be below ~1kHz to not be
+// under measured.
+//
+// p is configured as input with its current pull resistor setting.
+func Frequency(ctx context.Context, p gpio.PinIn, window time.Duration) (physic.Frequency, error) {
+	edges, err := record(ctx, p, gpio.RisingEdge, window)
+	if err != nil || len(edges) < 2 {
+		return 0, err
+	}
+	return frequency(len(edges)-1, edges[len(edges)-1].t.Sub(edges[0].t)), nil
+}
+
+// DutyCycle measures the duty cycle and the frequency of the signal on p by
+// timing its edges for window, or until ctx is done.
+//
+// The measurement is done over the complete periods between the first and
+// the last rising edge. When there is less than two rising edges in the
+// window, the frequency is 0 and the duty is 0 or gpio.DutyMax depending on
+// the current level of p.
+//
+// It has the same accuracy as Frequency(). Without gpio.PinInTime, the
+// shortest pulse must be longer than the wake up latency to be measured.
+//
+// p is configured as input with its current pull resistor setting.
+func DutyCycle(ctx context.Context, p gpio.PinIn, window time.Duration) (gpio.Duty, physic.Frequency, error) {
+	edges, err := record(ctx, p, gpio.BothEdges, window)
+	if err != nil {
+		return 0, 0, err
+	}
+	// Trim to complete periods: from the first to the last rising edge.
+	for len(edges) != 0 && edges[0].l != gpio.High {
+		edges = edges[1:]
+	}
+	for len(edges) != 0 && edges[len(edges)-1].l != gpio.High {
+		edges = edges[:len(edges)-1]
+	}
+	periods := 0
+	for i := 1; i < len(edges); i++ {
+		if edges[i].l == gpio.High {
+			periods++
+		}
+	}
+	if periods == 0 {
+		if p.Read() == gpio.High {
+			return gpio.DutyMax, 0, nil
+		}
+		return 0, 0, nil
+	}
+	var high time.Duration
+	for i := 1; i < len(edges); i++ {
+		if edges[i-1].l == gpio.High {
+			high += edges[i].t.Sub(edges[i-1].t)
+		}
+	}
+	total := edges[len(edges)-1].t.Sub(edges[0].t)
+	duty := gpio.Duty((int64(high)*int64(gpio.DutyMax) + int64(total)/2) / int64(total))
+	return duty, frequency(periods, total), nil
+}
+
+// Capture records the edges on p for window, or until ctx is done.
+//
+// The stream starts when Capture is called. It has the same accuracy as
+// Frequency(); the edges that are coalesced by the OS are skipped.
+//
+// p is configured as input with its current pull resistor setting.
+func Capture(ctx context.Context, p gpio.PinIn, window time.Duration) (*gpiostream.TimedEdgeStream, error) {
+	s := &gpiostream.TimedEdgeStream{Start: time.Now()}
+	edges, err := record(ctx, p, gpio.BothEdges, window)
+	if err != nil {
+		return nil, err
+	}
+	last := s.Start
+	for _, e := range edges {
+		d := e.t.Sub(last)
+		if d < 0 {
+			// The timestamps of gpio.PinInTime may be slightly off from the clock
+			// of time.Now().
+			d = 0
+		}
+		s.Edges = append(s.Edges, gpiostream.TimedEdge{Delta: d, Level: e.l})
+		last = last.Add(d)
+	}
+	return s, nil
+}
+
+// Count counts the edges on p until ctx is done.
+//
+// The running count is sent on the returned channel every interval. The
+// channel only holds the latest count, so a slow reader doesn't stall the
+// counting. It is closed when ctx is done or if waiting for an edge fails.
+//
+// p is configured as input with its current pull resistor setting and edge.
+// The edges that happen while the goroutine is not yet waiting are coalesced
+// by the OS, unless p implements gpio.PinInTime; see Frequency().
+func Count(ctx context.Context, p gpio.PinIn, edge gpio.Edge, interval time.Duration) (<-chan int64, error) {
+	if edge == gpio.NoEdge {
+		return nil, errors.New("gpioutil: an edge is required to count")
+	}
+	if interval <= 0 {
+		return nil, errors.New("gpioutil: invalid count interval")
+	}
+	if err := p.In(gpio.PullNoChange, edge); err != nil {
+		return nil, err
+	}
+	c := make(chan int64, 1)
+	go func() {
+		defer close(c)
+		var n int64
+		next := time.Now()
+		for {
+			next = next.Add(interval)
+			ictx, cancel := context.WithDeadline(ctx, next)
+			for {
+				if _, _, err := waitForEdge(ictx, p); err != nil {
+					break
+				}
+				n++
+			}
+			cancel()
+			if ctx.Err() != nil || ictx.Err() == nil {
+				return
+			}
+			// Replace the previous count if it wasn't read.
+			select {
+			case <-c:
+			default:
+			}
+			c <- n
+		}
+	}()
+	return c, nil
+}
+
+//
+
+// timedEdge is an edge and when it happened.
+type timedEdge struct {
+	t time.Time
+	l gpio.Level
+}
+
+// record returns the edges on p during window.
+func record(ctx context.Context, p gpio.PinIn, edge gpio.Edge, window time.Duration) ([]timedEdge, error) {
+	if window <= 0 {
+		return nil, errors.New("gpioutil: invalid measurement window")
+	}
+	if err := p.In(gpio.PullNoChange, edge); err != nil {
+		return nil, err
+	}
+	wctx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+	var edges []timedEdge
+	last := p.Read()
+	for {
+		t, l, err := waitForEdge(wctx, p)
+		if err != nil {
+			if ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+			return edges, nil
+		}
+		// Skip the edges that were coalesced in a no-op.
+		if edge == gpio.BothEdges && l == last {
+			continue
+		}
+		last = l
+		edges = append(edges, timedEdge{t, l})
+	}
+}
+
+// waitForEdge waits for an edge on p and returns when it happened and the
+// level after it.
+func waitForEdge(ctx context.Context, p gpio.PinIn) (time.Time, gpio.Level, error) {
+	if t, ok := p.(gpio.PinInTime); ok {
+		return t.WaitForEdgeTime(ctx)
+	}
+	if err := gpio.WaitForEdgeCtx(ctx, p); err != nil {
+		return time.Time{}, gpio.Low, err
+	}
+	return time.Now(), p.Read(), nil
+}
+
+// frequency returns the frequency of n periods over d.
+func frequency(n int, d time.Duration) physic.Frequency {
+	if d <= 0 {
+		return 0
+	}
+	return physic.Frequency(math.Round(float64(n) * float64(time.Second) * float64(physic.Hertz) / float64(d)))
+}
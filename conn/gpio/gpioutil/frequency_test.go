@@ -0,0 +1,227 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiostream"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestFrequency(t *testing.T) {
+	// A 25% duty cycle at 50Hz; starts in the middle of a high pulse.
+	p := newScriptPin(gpio.High, 5*time.Millisecond,
+		20*time.Millisecond, 5*time.Millisecond,
+		15*time.Millisecond, 5*time.Millisecond,
+		15*time.Millisecond, 5*time.Millisecond,
+		15*time.Millisecond)
+	f, err := Frequency(context.Background(), p, 10*time.Millisecond)
+	if err != nil || f != 50*physic.Hertz {
+		t.Fatal(f, err)
+	}
+	if p.edge != gpio.RisingEdge {
+		t.Fatal(p.edge)
+	}
+}
+
+func TestFrequency_steady(t *testing.T) {
+	p := newScriptPin(gpio.Low, 0, 10*time.Millisecond)
+	if f, err := Frequency(context.Background(), p, 10*time.Millisecond); err != nil || f != 0 {
+		t.Fatal(f, err)
+	}
+}
+
+func TestFrequency_err(t *testing.T) {
+	p := newScriptPin(gpio.Low, 0)
+	if _, err := Frequency(context.Background(), p, 0); err == nil {
+		t.Fatal("invalid window")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Frequency(ctx, p, time.Second); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	q := &gpiotest.Pin{N: "GPIO1"}
+	if _, err := Frequency(context.Background(), q, time.Second); err == nil {
+		t.Fatal("edge detection not supported")
+	}
+}
+
+func TestDutyCycle(t *testing.T) {
+	data := []struct {
+		start  gpio.Level
+		deltas []time.Duration
+		duty   gpio.Duty
+		f      physic.Frequency
+	}{
+		// 25% at 50Hz, starting high.
+		{
+			gpio.High,
+			[]time.Duration{
+				5 * time.Millisecond, 15 * time.Millisecond, 5 * time.Millisecond,
+				15 * time.Millisecond, 5 * time.Millisecond, 15 * time.Millisecond,
+				5 * time.Millisecond,
+			},
+			gpio.DutyMax / 4,
+			50 * physic.Hertz,
+		},
+		// 90% at 1kHz, starting low.
+		{
+			gpio.Low,
+			[]time.Duration{
+				100 * time.Microsecond, 900 * time.Microsecond, 100 * time.Microsecond,
+				900 * time.Microsecond, 100 * time.Microsecond,
+			},
+			gpio.DutyMax * 9 / 10,
+			physic.KiloHertz,
+		},
+		// Steady.
+		{gpio.High, nil, gpio.DutyMax, 0},
+		{gpio.Low, nil, 0, 0},
+		// A single pulse.
+		{gpio.Low, []time.Duration{time.Millisecond, time.Millisecond}, 0, 0},
+	}
+	for i, line := range data {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			p := newScriptPin(line.start, 0, line.deltas...)
+			d, f, err := DutyCycle(context.Background(), p, 10*time.Millisecond)
+			if err != nil || d != line.duty || f != line.f {
+				t.Fatal(d, f, err)
+			}
+			if p.edge != gpio.BothEdges {
+				t.Fatal(p.edge)
+			}
+		})
+	}
+}
+
+func TestDutyCycle_err(t *testing.T) {
+	p := newScriptPin(gpio.Low, 0)
+	if _, _, err := DutyCycle(context.Background(), p, -1); err == nil {
+		t.Fatal("invalid window")
+	}
+}
+
+func TestCapture(t *testing.T) {
+	p := newScriptPin(gpio.Low, 0, time.Millisecond, 2*time.Millisecond)
+	start := time.Now()
+	p.times[0] = start.Add(time.Millisecond)
+	p.times[1] = start.Add(3 * time.Millisecond)
+	first := p.times[0]
+	s, err := Capture(context.Background(), p, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Start.Before(start) || s.Start.After(first) {
+		t.Fatal(s.Start)
+	}
+	if len(s.Edges) != 2 || s.Edges[0].Level != gpio.High || s.Edges[1] != (gpiostream.TimedEdge{Delta: 2 * time.Millisecond, Level: gpio.Low}) {
+		t.Fatal(s.Edges)
+	}
+	if d := s.Start.Add(s.Edges[0].Delta); !d.Equal(first) {
+		t.Fatal(d)
+	}
+	if _, err := Capture(context.Background(), p, 0); err == nil {
+		t.Fatal("invalid window")
+	}
+}
+
+func TestCount(t *testing.T) {
+	p := &gpiotest.Pin{N: "GPIO1", EdgesChan: make(chan gpio.Level)}
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := Count(ctx, p, gpio.RisingEdge, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		p.EdgesChan <- gpio.High
+	}
+	for n := range c {
+		if n == 3 {
+			break
+		}
+	}
+	cancel()
+	// The channel is closed once ctx is done.
+	for range c {
+	}
+}
+
+func TestCount_err(t *testing.T) {
+	p := &gpiotest.Pin{N: "GPIO1"}
+	if _, err := Count(context.Background(), p, gpio.NoEdge, time.Millisecond); err == nil {
+		t.Fatal("no edge")
+	}
+	if _, err := Count(context.Background(), p, gpio.RisingEdge, 0); err == nil {
+		t.Fatal("invalid interval")
+	}
+	if _, err := Count(context.Background(), p, gpio.RisingEdge, time.Millisecond); err == nil {
+		t.Fatal("edge detection not supported")
+	}
+}
+
+//
+
+// scriptPin is a gpiotest.Pin that replays scripted timestamped edges once
+// it is configured as input.
+type scriptPin struct {
+	gpiotest.Pin
+	edge   gpio.Edge
+	levels []gpio.Level
+	times  []time.Time
+}
+
+// newScriptPin returns a pin starting at level l that changes level after
+// each delta, starting at offset.
+func newScriptPin(l gpio.Level, offset time.Duration, deltas ...time.Duration) *scriptPin {
+	p := &scriptPin{Pin: gpiotest.Pin{N: "GPIO1", L: l, EdgesChan: make(chan gpio.Level)}}
+	t := time.Unix(0, 0).Add(offset)
+	for _, d := range deltas {
+		t = t.Add(d)
+		l = !l
+		p.levels = append(p.levels, l)
+		p.times = append(p.times, t)
+	}
+	return p
+}
+
+// In implements gpio.PinIn.
+//
+// It starts sending the edges matching edge, after gpiotest.Pin.In() flushed
+// EdgesChan.
+func (p *scriptPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	p.edge = edge
+	if err := p.Pin.In(pull, edge); err != nil {
+		return err
+	}
+	var levels []gpio.Level
+	var times []time.Time
+	for i, l := range p.levels {
+		if edge == gpio.BothEdges || (edge == gpio.RisingEdge) == (l == gpio.High) {
+			levels = append(levels, l)
+			times = append(times, p.times[i])
+		}
+	}
+	p.Lock()
+	p.Now = func() time.Time {
+		t := times[0]
+		times = times[1:]
+		return t
+	}
+	p.Unlock()
+	go func() {
+		for _, l := range levels {
+			p.EdgesChan <- l
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+)
+
+// KeypadOpts are the options for NewKeypad.
+type KeypadOpts struct {
+	// Rows are the row pins. They are left floating and only driven low, one
+	// at a time, during a scan, like open drain outputs. This way two keys
+	// pressed in the same column never short two driven pins.
+	Rows []gpio.PinIO
+	// Cols are the column pins. They are configured as input with a pull up.
+	// At most 64 columns are supported.
+	Cols []gpio.PinIn
+	// Keys, if set, is the key map: one string per row, with one rune per
+	// column, e.g. {"123A", "456B", "789C", "*0#D"}.
+	Keys []string
+	// Interval is the time between two scans. It defaults to 10ms.
+	Interval time.Duration
+	// Debounce is the time a new state of the keypad must be steady before it
+	// is reported. It defaults to 20ms.
+	Debounce time.Duration
+}
+
+// KeyEvent is a key press or release.
+type KeyEvent struct {
+	Row, Col int
+	// Key is the rune for this key in KeypadOpts.Keys, or 0 if not set.
+	Key     rune
+	Pressed bool
+}
+
+func (k KeyEvent) String() string {
+	s := "released"
+	if k.Pressed {
+		s = "pressed"
+	}
+	if k.Key != 0 {
+		return fmt.Sprintf("%q %s", k.Key, s)
+	}
+	return fmt.Sprintf("(%d, %d) %s", k.Row, k.Col, s)
+}
+
+// Keypad scans a matrix keypad.
+//
+// When three keys at the corners of a rectangle are pressed on a keypad
+// without diodes, the fourth corner reads as pressed too. Keypad rejects
+// these ambiguous states, so only the combinations that can be read reliably
+// are reported.
+type Keypad struct {
+	// Immutable.
+	rows     []gpio.PinIO
+	cols     []gpio.PinIn
+	keys     [][]rune
+	interval time.Duration
+	steady   int // Number of identical scans to accept a new state.
+	events   chan KeyEvent
+	stop     chan struct{}
+	done     chan error
+	after    func(time.Duration) <-chan time.Time
+
+	mu     sync.Mutex
+	err    error
+	halted bool
+}
+
+// NewKeypad returns a Keypad that scans the keypad from a goroutine until
+// Halt() is called.
+func NewKeypad(opts *KeypadOpts) (*Keypad, error) {
+	return newKeypad(opts, time.After)
+}
+
+func (k *Keypad) String() string {
+	return fmt.Sprintf("Keypad(%dx%d)", len(k.rows), len(k.cols))
+}
+
+// Halt implements conn.Resource.
+//
+// It stops the scan, leaves the rows floating and closes the channel returned
+// by Events(). It returns the error that stopped the scan early, if any.
+func (k *Keypad) Halt() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.halted {
+		k.halted = true
+		close(k.stop)
+		err := <-k.done
+		close(k.events)
+		for _, r := range k.rows {
+			if err2 := r.In(gpio.Float, gpio.NoEdge); err == nil {
+				err = err2
+			}
+		}
+		k.err = err
+	}
+	return k.err
+}
+
+// Events returns the channel where the key presses and releases are sent.
+//
+// The scan is paused while the channel is full.
+func (k *Keypad) Events() <-chan KeyEvent {
+	return k.events
+}
+
+//
+
+// newKeypad is NewKeypad with after overridable for unit tests.
+func newKeypad(opts *KeypadOpts, after func(time.Duration) <-chan time.Time) (*Keypad, error) {
+	if opts == nil || len(opts.Rows) == 0 || len(opts.Cols) == 0 || len(opts.Cols) > 64 {
+		return nil, errors.New("gpioutil: a keypad needs 1 or more rows and 1 to 64 columns")
+	}
+	if opts.Interval < 0 || opts.Debounce < 0 {
+		return nil, errors.New("gpioutil: invalid keypad timing")
+	}
+	k := &Keypad{
+		rows:     opts.Rows,
+		cols:     opts.Cols,
+		interval: opts.Interval,
+		events:   make(chan KeyEvent, 16),
+		stop:     make(chan struct{}),
+		done:     make(chan error, 1),
+		after:    after,
+	}
+	if opts.Keys != nil {
+		if len(opts.Keys) != len(opts.Rows) {
+			return nil, fmt.Errorf("gpioutil: expected %d rows in the key map, got %d", len(opts.Rows), len(opts.Keys))
+		}
+		for i, s := range opts.Keys {
+			r := []rune(s)
+			if len(r) != len(opts.Cols) {
+				return nil, fmt.Errorf("gpioutil: expected %d keys on row %d, got %d", len(opts.Cols), i, len(r))
+			}
+			k.keys = append(k.keys, r)
+		}
+	}
+	if k.interval == 0 {
+		k.interval = 10 * time.Millisecond
+	}
+	debounce := opts.Debounce
+	if debounce == 0 {
+		debounce = 20 * time.Millisecond
+	}
+	k.steady = int((debounce + k.interval - 1) / k.interval)
+	for _, r := range k.rows {
+		if err := r.In(gpio.Float, gpio.NoEdge); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range k.cols {
+		if err := c.In(gpio.PullUp, gpio.NoEdge); err != nil {
+			return nil, err
+		}
+	}
+	go k.run()
+	return k, nil
+}
+
+// run scans the keypad until stop is closed.
+func (k *Keypad) run() {
+	state := make([]uint64, len(k.rows))
+	candidate := make([]uint64, len(k.rows))
+	n := 0
+	for {
+		select {
+		case <-k.stop:
+			k.done <- nil
+			return
+		case <-k.after(k.interval):
+		}
+		s, err := k.scan()
+		if err != nil {
+			k.done <- err
+			return
+		}
+		if ghosting(s) {
+			// Restart the debounce from the last reported state.
+			copy(candidate, state)
+			n = 0
+			continue
+		}
+		if !equal(s, candidate) {
+			copy(candidate, s)
+			n = 1
+		} else if n < k.steady {
+			n++
+		}
+		if n < k.steady || equal(candidate, state) {
+			continue
+		}
+		for r := range state {
+			for c := range k.cols {
+				m := uint64(1) << uint(c)
+				if (state[r]^candidate[r])&m == 0 {
+					continue
+				}
+				e := KeyEvent{Row: r, Col: c, Pressed: candidate[r]&m != 0}
+				if k.keys != nil {
+					e.Key = k.keys[r][c]
+				}
+				select {
+				case k.events <- e:
+				case <-k.stop:
+					k.done <- nil
+					return
+				}
+			}
+		}
+		copy(state, candidate)
+	}
+}
+
+// scan returns the columns read low for each row.
+func (k *Keypad) scan() ([]uint64, error) {
+	s := make([]uint64, len(k.rows))
+	for i, r := range k.rows {
+		if err := r.Out(gpio.Low); err != nil {
+			return nil, err
+		}
+		for j, c := range k.cols {
+			if c.Read() == gpio.Low {
+				s[i] |= 1 << uint(j)
+			}
+		}
+		if err := r.In(gpio.Float, gpio.NoEdge); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// ghosting returns true if two rows have two or more columns in common, in
+// which case a key may be read as pressed while it is not.
+func ghosting(s []uint64) bool {
+	for i := range s {
+		for j := i + 1; j < len(s); j++ {
+			if bits.OnesCount64(s[i]&s[j]) >= 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equal(a, b []uint64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var _ conn.Resource = &Keypad{}
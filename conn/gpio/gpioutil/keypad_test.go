@@ -0,0 +1,243 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func TestKeypad(t *testing.T) {
+	m, k := newMatrix(t, []string{"12", "34"})
+	if s := k.String(); s != "Keypad(2x2)" {
+		t.Fatal(s)
+	}
+	for _, r := range m.rows {
+		if r.out {
+			t.Fatal("row driven")
+		}
+	}
+	for _, c := range m.cols {
+		if c.P != gpio.PullUp {
+			t.Fatal(c.P)
+		}
+	}
+	m.press(0, 1, true)
+	// Bounce: the key must be read for 2 scans.
+	m.scan(1)
+	m.press(0, 1, false)
+	m.scan(1)
+	m.press(0, 1, true)
+	m.scan(2)
+	m.press(1, 0, true)
+	m.scan(2)
+	m.press(0, 1, false)
+	m.press(1, 0, false)
+	m.scan(2)
+	expected := []KeyEvent{
+		{Row: 0, Col: 1, Key: '2', Pressed: true},
+		{Row: 1, Col: 0, Key: '3', Pressed: true},
+		{Row: 0, Col: 1, Key: '2'},
+		{Row: 1, Col: 0, Key: '3'},
+	}
+	if got := m.halt(t, k); !reflect.DeepEqual(got, expected) {
+		t.Fatal(got)
+	}
+	for _, r := range m.rows {
+		if r.out {
+			t.Fatal("row driven")
+		}
+	}
+}
+
+func TestKeypad_ghosting(t *testing.T) {
+	m, k := newMatrix(t, nil)
+	// Two keys in the same column: the rows are never driven at the same time
+	// so they are not shorted and both keys are read.
+	m.press(0, 0, true)
+	m.press(1, 0, true)
+	m.scan(2)
+	// A third key makes the fourth corner read as pressed.
+	m.press(1, 1, true)
+	m.scan(4)
+	m.press(1, 1, false)
+	m.scan(2)
+	expected := []KeyEvent{
+		{Row: 0, Col: 0, Pressed: true},
+		{Row: 1, Col: 0, Pressed: true},
+	}
+	if got := m.halt(t, k); !reflect.DeepEqual(got, expected) {
+		t.Fatal(got)
+	}
+}
+
+func TestKeypad_err(t *testing.T) {
+	row := &gpiotest.Pin{N: "R"}
+	col := &gpiotest.Pin{N: "C"}
+	data := []*KeypadOpts{
+		nil,
+		{Cols: []gpio.PinIn{col}},
+		{Rows: []gpio.PinIO{row}},
+		{Rows: []gpio.PinIO{row}, Cols: []gpio.PinIn{col}, Interval: -1},
+		{Rows: []gpio.PinIO{row}, Cols: []gpio.PinIn{col}, Keys: []string{"1", "2"}},
+		{Rows: []gpio.PinIO{row}, Cols: []gpio.PinIn{col}, Keys: []string{"12"}},
+	}
+	for i, opts := range data {
+		if _, err := NewKeypad(opts); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+	m := &matrix{ready: make(chan struct{}), ticks: make(chan time.Time)}
+	r := m.row("R0")
+	r.err = errors.New("oops")
+	if _, err := NewKeypad(&KeypadOpts{Rows: []gpio.PinIO{r}, Cols: []gpio.PinIn{col}}); err != r.err {
+		t.Fatal(err)
+	}
+}
+
+func TestKeypad_scanErr(t *testing.T) {
+	m, k := newMatrix(t, nil)
+	m.rows[1].outErr = errors.New("oops")
+	m.ticks <- time.Time{}
+	if err := k.Halt(); err != m.rows[1].outErr {
+		t.Fatal(err)
+	}
+}
+
+//
+
+// matrix is a fake keypad without diodes.
+type matrix struct {
+	mu      sync.Mutex
+	rows    []*rowPin
+	cols    []*colPin
+	pressed map[[2]int]bool
+	ready   chan struct{}
+	ticks   chan time.Time
+}
+
+// newMatrix returns a 2x2 keypad.
+func newMatrix(t *testing.T, keys []string) (*matrix, *Keypad) {
+	m := &matrix{pressed: map[[2]int]bool{}, ready: make(chan struct{}), ticks: make(chan time.Time)}
+	opts := &KeypadOpts{
+		Rows:     []gpio.PinIO{m.row("R0"), m.row("R1")},
+		Cols:     []gpio.PinIn{m.col("C0"), m.col("C1")},
+		Keys:     keys,
+		Interval: 10 * time.Millisecond,
+		Debounce: 20 * time.Millisecond,
+	}
+	k, err := newKeypad(opts, func(d time.Duration) <-chan time.Time {
+		if d != 10*time.Millisecond {
+			t.Error(d)
+		}
+		m.ready <- struct{}{}
+		return m.ticks
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-m.ready
+	return m, k
+}
+
+func (m *matrix) row(name string) *rowPin {
+	r := &rowPin{Pin: gpiotest.Pin{N: name}, m: m}
+	m.rows = append(m.rows, r)
+	return r
+}
+
+func (m *matrix) col(name string) *colPin {
+	c := &colPin{Pin: gpiotest.Pin{N: name}, m: m, i: len(m.cols)}
+	m.cols = append(m.cols, c)
+	return c
+}
+
+func (m *matrix) press(r, c int, pressed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pressed[[2]int{r, c}] = pressed
+}
+
+// scan runs n scans and waits for them to complete.
+func (m *matrix) scan(n int) {
+	for i := 0; i < n; i++ {
+		m.ticks <- time.Time{}
+		<-m.ready
+	}
+}
+
+func (m *matrix) halt(t *testing.T, k *Keypad) []KeyEvent {
+	if err := k.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	var out []KeyEvent
+	for e := range k.Events() {
+		out = append(out, e)
+	}
+	return out
+}
+
+// low returns true if column c is connected to a driven row through the
+// pressed keys.
+func (m *matrix) low(c int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rows := make([]bool, len(m.rows))
+	cols := make([]bool, len(m.cols))
+	for i, r := range m.rows {
+		rows[i] = r.out
+	}
+	// Propagate through the keys until nothing changes.
+	for changed := true; changed; {
+		changed = false
+		for k, p := range m.pressed {
+			if p && rows[k[0]] != cols[k[1]] {
+				rows[k[0]], cols[k[1]] = true, true
+				changed = true
+			}
+		}
+	}
+	return cols[c]
+}
+
+// rowPin is a row of a matrix. It is either driven low or floating.
+type rowPin struct {
+	gpiotest.Pin
+	m      *matrix
+	out    bool
+	err    error
+	outErr error
+}
+
+func (r *rowPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.out = false
+	return r.err
+}
+
+func (r *rowPin) Out(l gpio.Level) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.out = l == gpio.Low
+	return r.outErr
+}
+
+// colPin is a column of a matrix with a pull up.
+type colPin struct {
+	gpiotest.Pin
+	m *matrix
+	i int
+}
+
+func (c *colPin) Read() gpio.Level {
+	return gpio.Level(!c.m.low(c.i))
+}
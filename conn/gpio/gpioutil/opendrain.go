@@ -0,0 +1,154 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"sync"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+// OpenDrain returns a gpio.PinIO that emulates an open drain output on p.
+//
+// Out(gpio.High) releases the line by setting p as input with a pull up, so
+// an external pull up or the internal one brings the line high unless another
+// device drives it low. Out(gpio.Low) drives p low. p is never driven high,
+// so the line can be shared, like a 1-wire bus or an interrupt line.
+//
+// Read() returns the level of the line in both states. In() sets p as a
+// normal input until the next Out() call.
+//
+// p is not modified until Out() or In() is called.
+func OpenDrain(p gpio.PinIO) gpio.PinIO {
+	return &openDrain{PinIO: p}
+}
+
+//
+
+// drainState is the state of an openDrain.
+type drainState int
+
+const (
+	drainInput    drainState = iota // In() was called or Out() wasn't.
+	drainReleased                   // Out(High).
+	drainLow                        // Out(Low).
+)
+
+// openDrain is a gpio.PinIO with an emulated open drain output.
+type openDrain struct {
+	// Immutable.
+	gpio.PinIO
+
+	mu    sync.Mutex
+	state drainState
+}
+
+// Halt implements conn.Resource.
+//
+// It releases the line, then halts the pin.
+func (o *openDrain) Halt() error {
+	if err := o.Out(gpio.High); err != nil {
+		return err
+	}
+	return o.PinIO.Halt()
+}
+
+// Function implements pin.Pin.
+func (o *openDrain) Function() string {
+	return string(o.Func())
+}
+
+// Func implements pin.PinFunc.
+//
+// It returns gpio.FLOAT when the line is released and gpio.OUT_LOW when it is
+// driven low.
+func (o *openDrain) Func() pin.Func {
+	o.mu.Lock()
+	state := o.state
+	o.mu.Unlock()
+	switch state {
+	case drainReleased:
+		return gpio.FLOAT
+	case drainLow:
+		return gpio.OUT_LOW
+	}
+	if pf, ok := o.PinIO.(pin.PinFunc); ok {
+		return pf.Func()
+	}
+	return pin.Func(o.PinIO.Function())
+}
+
+// SupportedFuncs implements pin.PinFunc.
+func (o *openDrain) SupportedFuncs() []pin.Func {
+	return []pin.Func{gpio.IN, gpio.OUT_OC}
+}
+
+// SetFunc implements pin.PinFunc.
+func (o *openDrain) SetFunc(f pin.Func) error {
+	switch f {
+	case gpio.IN:
+		return o.In(gpio.PullNoChange, gpio.NoEdge)
+	case gpio.OUT_OC, gpio.FLOAT, gpio.OUT_HIGH:
+		return o.Out(gpio.High)
+	case gpio.OUT_LOW:
+		return o.Out(gpio.Low)
+	default:
+		return errors.New("gpioutil: unsupported function for an open drain pin")
+	}
+}
+
+// In implements gpio.PinIn.
+func (o *openDrain) In(pull gpio.Pull, edge gpio.Edge) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.state = drainInput
+	return o.PinIO.In(pull, edge)
+}
+
+// Out implements gpio.PinOut.
+func (o *openDrain) Out(l gpio.Level) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if l == gpio.High {
+		if o.state == drainReleased {
+			return nil
+		}
+		if err := o.PinIO.In(gpio.PullUp, gpio.NoEdge); err != nil {
+			return err
+		}
+		o.state = drainReleased
+		return nil
+	}
+	if o.state == drainLow {
+		return nil
+	}
+	if err := o.PinIO.Out(gpio.Low); err != nil {
+		return err
+	}
+	o.state = drainLow
+	return nil
+}
+
+// PWM implements gpio.PinOut.
+//
+// It is not supported.
+func (o *openDrain) PWM(gpio.Duty, physic.Frequency) error {
+	return errors.New("gpioutil: PWM is not supported on an open drain pin")
+}
+
+// Real implements gpio.RealPin.
+func (o *openDrain) Real() gpio.PinIO {
+	if r, ok := o.PinIO.(gpio.RealPin); ok {
+		return r.Real()
+	}
+	return o.PinIO
+}
+
+var _ gpio.PinIO = &openDrain{}
+var _ gpio.RealPin = &openDrain{}
+var _ pin.PinFunc = &openDrain{}
@@ -0,0 +1,181 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/pin"
+)
+
+func TestOpenDrain(t *testing.T) {
+	p := &drainPin{Pin: gpiotest.Pin{N: "GPIO1", Fn: "In/High"}}
+	o := OpenDrain(p)
+	if p.ins != 0 || p.outs != 0 {
+		t.Fatal("pin modified")
+	}
+	if f := o.(pin.PinFunc).Func(); f != "In/High" {
+		t.Fatal(f)
+	}
+	if err := o.Out(gpio.Low); err != nil {
+		t.Fatal(err)
+	}
+	if f := o.Function(); f != string(gpio.OUT_LOW) {
+		t.Fatal(f)
+	}
+	if l := o.Read(); l != gpio.Low {
+		t.Fatal(l)
+	}
+	if err := o.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	if f := o.(pin.PinFunc).Func(); f != gpio.FLOAT {
+		t.Fatal(f)
+	}
+	if p.P != gpio.PullUp {
+		t.Fatal(p.P)
+	}
+	// Another device pulls the line low.
+	p.L = gpio.Low
+	if l := o.Read(); l != gpio.Low {
+		t.Fatal(l)
+	}
+	if err := o.In(gpio.PullDown, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	if f := o.Function(); f != "In/High" {
+		t.Fatal(f)
+	}
+	if o.PWM(gpio.DutyHalf, 0) == nil {
+		t.Fatal("PWM is not supported")
+	}
+	if r := o.(gpio.RealPin).Real(); r != p {
+		t.Fatal(r)
+	}
+	if err := o.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if p.driven {
+		t.Fatal("line not released")
+	}
+}
+
+func TestOpenDrain_toggle(t *testing.T) {
+	p := &drainPin{Pin: gpiotest.Pin{N: "GPIO1"}}
+	o := OpenDrain(p)
+	for i := 0; i < 1000; i++ {
+		l := gpio.Level(i&1 == 0)
+		if err := o.Out(l); err != nil {
+			t.Fatal(err)
+		}
+		if p.driven == bool(l) {
+			t.Fatalf("#%d: driven=%t", i, p.driven)
+		}
+		if r := o.Read(); r != l {
+			t.Fatalf("#%d: %s", i, r)
+		}
+	}
+	if p.high != 0 {
+		t.Fatal("driven high")
+	}
+	if p.ins != 500 || p.outs != 500 {
+		t.Fatal(p.ins, p.outs)
+	}
+	// Repeated levels don't reconfigure the pin.
+	o.Out(gpio.Low)
+	o.Out(gpio.Low)
+	o.Out(gpio.High)
+	o.Out(gpio.High)
+	if p.ins != 501 || p.outs != 500 {
+		t.Fatal(p.ins, p.outs)
+	}
+}
+
+func TestOpenDrain_SetFunc(t *testing.T) {
+	p := &drainPin{Pin: gpiotest.Pin{N: "GPIO1"}}
+	o := OpenDrain(p).(pin.PinFunc)
+	if f := o.SupportedFuncs(); len(f) != 2 {
+		t.Fatal(f)
+	}
+	data := []struct {
+		f      pin.Func
+		driven bool
+		want   pin.Func
+	}{
+		{gpio.OUT_LOW, true, gpio.OUT_LOW},
+		{gpio.OUT_OC, false, gpio.FLOAT},
+		{gpio.OUT_LOW, true, gpio.OUT_LOW},
+		{gpio.IN, false, ""},
+	}
+	for i, line := range data {
+		if err := o.SetFunc(line.f); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if p.driven != line.driven || o.Func() != line.want {
+			t.Fatalf("#%d: %t %s", i, p.driven, o.Func())
+		}
+	}
+	if o.SetFunc(gpio.PWM) == nil {
+		t.Fatal("PWM is not supported")
+	}
+}
+
+func TestOpenDrain_err(t *testing.T) {
+	p := &drainPin{Pin: gpiotest.Pin{N: "GPIO1"}, err: errors.New("oops")}
+	o := OpenDrain(p)
+	if err := o.Out(gpio.Low); err != p.err {
+		t.Fatal(err)
+	}
+	if err := o.Out(gpio.High); err != p.err {
+		t.Fatal(err)
+	}
+	if err := o.Halt(); err != p.err {
+		t.Fatal(err)
+	}
+	if f := o.(pin.PinFunc).Func(); f != "" {
+		t.Fatal(f)
+	}
+}
+
+//
+
+// drainPin is a gpiotest.Pin that tracks whether it is driven.
+type drainPin struct {
+	gpiotest.Pin
+	driven bool
+	ins    int
+	outs   int
+	high   int
+	err    error
+}
+
+func (p *drainPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.ins++
+	p.driven = false
+	p.P = pull
+	if pull == gpio.PullUp {
+		p.L = gpio.High
+	}
+	return nil
+}
+
+func (p *drainPin) Out(l gpio.Level) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.outs++
+	if l == gpio.High {
+		p.high++
+	}
+	p.driven = true
+	p.L = l
+	return nil
+}
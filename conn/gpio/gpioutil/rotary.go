@@ -0,0 +1,259 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+)
+
+// RotaryMode is the number of steps reported per quadrature cycle.
+type RotaryMode int
+
+const (
+	// FullStep reports one step per quadrature cycle, i.e. 4 transitions. It
+	// is the right mode for most encoders, which have one detent per cycle.
+	FullStep RotaryMode = iota
+	// HalfStep reports one step every 2 transitions, when both pins are at
+	// the same level. It is for encoders with two detents per cycle.
+	HalfStep
+)
+
+// RotaryEncoderOpts are the options for NewRotaryEncoder.
+type RotaryEncoderOpts struct {
+	// Mode is the stepping mode. It defaults to FullStep.
+	Mode RotaryMode
+	// Button is the push button of the encoder, if any. Its level changes are
+	// sent to the channel returned by Button(). Wrap it with Debounce() as
+	// needed.
+	Button gpio.PinIn
+}
+
+// RotaryEncoder decodes the quadrature signal of a rotary encoder.
+//
+// A step is +1 when A changes before B and -1 otherwise.
+type RotaryEncoder struct {
+	// Immutable.
+	a, b   gpio.PinIn
+	button gpio.PinIn
+	mode   RotaryMode
+	rest   uint8 // State where a full step ends.
+	steps  chan int
+	levels chan gpio.Level
+	cancel func()
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	state  uint8 // A as bit 1, B as bit 0.
+	acc    int   // Transitions since the last step.
+	pos    int64
+	err    error
+	halted bool
+}
+
+// NewRotaryEncoder returns a RotaryEncoder that decodes the signal on pins a
+// and b. opts can be nil.
+//
+// The pins are configured as input with edge detection and their current pull
+// resistor setting. A goroutine per pin waits for edges until Halt() is
+// called.
+//
+// The decoder is a state machine that counts the transitions in the quadrature
+// sequence and only reports a step once the pins are back to a rest state, so
+// contact bounce and direction reversals between detents cancel out.
+func NewRotaryEncoder(a, b gpio.PinIn, opts *RotaryEncoderOpts) (*RotaryEncoder, error) {
+	r := &RotaryEncoder{
+		a:      a,
+		b:      b,
+		steps:  make(chan int, 16),
+		levels: make(chan gpio.Level, 16),
+	}
+	if opts != nil {
+		if opts.Mode != FullStep && opts.Mode != HalfStep {
+			return nil, fmt.Errorf("gpioutil: invalid rotary mode %d", opts.Mode)
+		}
+		r.mode = opts.Mode
+		r.button = opts.Button
+	}
+	pins := []gpio.PinIn{a, b}
+	if r.button != nil {
+		pins = append(pins, r.button)
+	}
+	for _, p := range pins {
+		if err := p.In(gpio.PullNoChange, gpio.BothEdges); err != nil {
+			return nil, err
+		}
+	}
+	if a.Read() == gpio.High {
+		r.state |= 2
+	}
+	if b.Read() == gpio.High {
+		r.state |= 1
+	}
+	// Most encoders rest with both pins high, some with both pins low.
+	r.rest = 3
+	if r.state == 0 {
+		r.rest = 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.wg.Add(len(pins))
+	go r.watch(ctx, a, 2)
+	go r.watch(ctx, b, 1)
+	if r.button != nil {
+		go r.watchButton(ctx)
+	}
+	return r, nil
+}
+
+func (r *RotaryEncoder) String() string {
+	return fmt.Sprintf("RotaryEncoder(%s, %s)", r.a, r.b)
+}
+
+// Halt implements conn.Resource.
+//
+// It stops the goroutines and closes the channels returned by Steps() and
+// Button(). It returns the error that stopped a goroutine early, if any.
+func (r *RotaryEncoder) Halt() error {
+	r.mu.Lock()
+	halted := r.halted
+	r.halted = true
+	r.mu.Unlock()
+	if !halted {
+		r.cancel()
+		r.wg.Wait()
+		close(r.steps)
+		close(r.levels)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Position returns the sum of the steps reported so far.
+func (r *RotaryEncoder) Position() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pos
+}
+
+// Steps returns the channel where the steps, +1 or -1, are sent.
+//
+// The channel is buffered; steps are dropped when it is full but they are
+// still accounted for in Position().
+func (r *RotaryEncoder) Steps() <-chan int {
+	return r.steps
+}
+
+// Button returns the channel where the levels of the push button are sent
+// when it changes.
+//
+// The channel is buffered; changes are dropped when it is full. The channel
+// is never written to if no button was specified.
+func (r *RotaryEncoder) Button() <-chan gpio.Level {
+	return r.levels
+}
+
+//
+
+// grayIndex is the position of each state in the quadrature sequence.
+var grayIndex = [4]uint8{0, 3, 1, 2}
+
+// watch updates the bit of the state for p on each edge.
+func (r *RotaryEncoder) watch(ctx context.Context, p gpio.PinIn, bit uint8) {
+	defer r.wg.Done()
+	for {
+		if err := gpio.WaitForEdgeCtx(ctx, p); err != nil {
+			r.stopped(err)
+			return
+		}
+		r.update(bit, p.Read())
+	}
+}
+
+// watchButton sends the changes of the button level.
+func (r *RotaryEncoder) watchButton(ctx context.Context) {
+	defer r.wg.Done()
+	last := r.button.Read()
+	for {
+		if err := gpio.WaitForEdgeCtx(ctx, r.button); err != nil {
+			r.stopped(err)
+			return
+		}
+		if l := r.button.Read(); l != last {
+			last = l
+			select {
+			case r.levels <- l:
+			default:
+			}
+		}
+	}
+}
+
+// update runs the state machine for a new level of one pin.
+func (r *RotaryEncoder) update(bit uint8, l gpio.Level) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.state &^ bit
+	if l == gpio.High {
+		s |= bit
+	}
+	if s == r.state {
+		// The pin bounced back before the edge was processed.
+		return
+	}
+	// Only one bit changes at a time, so the transition is to one of the
+	// neighbors in the sequence.
+	if (grayIndex[s]-grayIndex[r.state])&3 == 1 {
+		r.acc++
+	} else {
+		r.acc--
+	}
+	r.state = s
+	n := 4
+	if r.mode == HalfStep {
+		n = 2
+		if s != 0 && s != 3 {
+			return
+		}
+	} else if s != r.rest {
+		return
+	}
+	step := 0
+	if r.acc >= n {
+		step = 1
+	} else if r.acc <= -n {
+		step = -1
+	}
+	// Resynchronize on the rest state, dropping partial steps.
+	r.acc = 0
+	if step != 0 {
+		r.pos += int64(step)
+		select {
+		case r.steps <- step:
+		default:
+		}
+	}
+}
+
+// stopped records the error that stopped a goroutine, unless it is because of
+// Halt().
+func (r *RotaryEncoder) stopped(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+var _ conn.Resource = &RotaryEncoder{}
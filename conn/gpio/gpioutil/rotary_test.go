@@ -0,0 +1,218 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func TestRotaryEncoder(t *testing.T) {
+	data := []struct {
+		name  string
+		mode  RotaryMode
+		edges []pinEdge
+		steps []int
+	}{
+		{"cw", FullStep, cw, []int{1}},
+		{"ccw", FullStep, ccw, []int{-1}},
+		{"cw_ccw", FullStep, append(append([]pinEdge{}, cw...), ccw...), []int{1, -1}},
+		{
+			"bounce",
+			FullStep,
+			[]pinEdge{
+				{0, gpio.Low}, {0, gpio.High}, {0, gpio.Low},
+				{1, gpio.Low}, {1, gpio.High}, {1, gpio.Low},
+				{0, gpio.High}, {1, gpio.High}, {1, gpio.Low}, {1, gpio.High},
+			},
+			[]int{1},
+		},
+		{
+			// Turned half way, then back to the same detent.
+			"reversal",
+			FullStep,
+			[]pinEdge{{0, gpio.Low}, {1, gpio.Low}, {1, gpio.High}, {0, gpio.High}},
+			nil,
+		},
+		{"half_cw", HalfStep, cw, []int{1, 1}},
+		{"half_ccw", HalfStep, ccw, []int{-1, -1}},
+		{
+			"half_reversal",
+			HalfStep,
+			[]pinEdge{{0, gpio.Low}, {1, gpio.Low}, {1, gpio.High}, {0, gpio.High}},
+			[]int{1, -1},
+		},
+	}
+	for _, line := range data {
+		t.Run(line.name, func(t *testing.T) {
+			r, f := newReplay(t, &RotaryEncoderOpts{Mode: line.mode}, false)
+			f.replay(line.edges)
+			var pos int64
+			for _, s := range line.steps {
+				pos += int64(s)
+			}
+			if p := r.Position(); p != pos {
+				t.Fatal(p)
+			}
+			if err := r.Halt(); err != nil {
+				t.Fatal(err)
+			}
+			var steps []int
+			for s := range r.Steps() {
+				steps = append(steps, s)
+			}
+			if !reflect.DeepEqual(steps, line.steps) {
+				t.Fatal(steps)
+			}
+		})
+	}
+}
+
+func TestRotaryEncoder_restLow(t *testing.T) {
+	f := &replay{ready: make(chan struct{})}
+	a := f.pin("A", gpio.Low)
+	b := f.pin("B", gpio.Low)
+	r, err := NewRotaryEncoder(a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.start(2)
+	f.replay([]pinEdge{{0, gpio.High}, {1, gpio.High}, {0, gpio.Low}, {1, gpio.Low}})
+	if err := r.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if p := r.Position(); p != 1 {
+		t.Fatal(p)
+	}
+}
+
+func TestRotaryEncoder_button(t *testing.T) {
+	r, f := newReplay(t, nil, true)
+	if s := r.String(); s != "RotaryEncoder(A(0), B(0))" {
+		t.Fatal(s)
+	}
+	f.replay([]pinEdge{{2, gpio.Low}, {2, gpio.Low}, {2, gpio.High}})
+	if err := r.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	// Halt() can be called multiple times.
+	if err := r.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	var levels []gpio.Level
+	for l := range r.Button() {
+		levels = append(levels, l)
+	}
+	if !reflect.DeepEqual(levels, []gpio.Level{gpio.Low, gpio.High}) {
+		t.Fatal(levels)
+	}
+}
+
+func TestRotaryEncoder_err(t *testing.T) {
+	a := &gpiotest.Pin{N: "A", EdgesChan: make(chan gpio.Level)}
+	if _, err := NewRotaryEncoder(a, &gpiotest.Pin{N: "B"}, nil); err == nil {
+		t.Fatal("edge detection not supported")
+	}
+	if _, err := NewRotaryEncoder(a, a, &RotaryEncoderOpts{Mode: 2}); err == nil {
+		t.Fatal("invalid mode")
+	}
+	// An edge detection failure stops the decoding.
+	e := &failPin{Pin: gpiotest.Pin{N: "B", EdgesChan: make(chan gpio.Level)}}
+	r, err := NewRotaryEncoder(a, e, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Halt(); err != errFail {
+		t.Fatal(err)
+	}
+}
+
+//
+
+// cw and ccw are one detent clockwise and counter clockwise, starting with
+// both pins high.
+var (
+	cw  = []pinEdge{{0, gpio.Low}, {1, gpio.Low}, {0, gpio.High}, {1, gpio.High}}
+	ccw = []pinEdge{{1, gpio.Low}, {0, gpio.Low}, {1, gpio.High}, {0, gpio.High}}
+)
+
+// pinEdge is a recorded level change on one of the pins of a replay.
+type pinEdge struct {
+	pin int
+	l   gpio.Level
+}
+
+// replay feeds recorded edges to the pins, one at a time.
+type replay struct {
+	pins  []*replayPin
+	ready chan struct{}
+}
+
+func newReplay(t *testing.T, opts *RotaryEncoderOpts, button bool) (*RotaryEncoder, *replay) {
+	f := &replay{ready: make(chan struct{})}
+	a := f.pin("A", gpio.High)
+	b := f.pin("B", gpio.High)
+	if button {
+		if opts == nil {
+			opts = &RotaryEncoderOpts{}
+		}
+		opts.Button = f.pin("SW", gpio.High)
+	}
+	r, err := NewRotaryEncoder(a, b, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.start(len(f.pins))
+	return r, f
+}
+
+func (f *replay) pin(name string, l gpio.Level) *replayPin {
+	p := &replayPin{Pin: gpiotest.Pin{N: name, L: l, EdgesChan: make(chan gpio.Level)}, ready: f.ready}
+	f.pins = append(f.pins, p)
+	return p
+}
+
+// start waits for the n goroutines to wait for an edge.
+func (f *replay) start(n int) {
+	for i := 0; i < n; i++ {
+		<-f.ready
+	}
+}
+
+// replay sends each edge once the previous one was processed.
+func (f *replay) replay(edges []pinEdge) {
+	for _, e := range edges {
+		f.pins[e.pin].EdgesChan <- e.l
+		<-f.ready
+	}
+}
+
+// replayPin is a gpiotest.Pin that signals when WaitForEdgeCtx() is called,
+// meaning that the previous edge was processed.
+type replayPin struct {
+	gpiotest.Pin
+	ready chan<- struct{}
+}
+
+func (p *replayPin) WaitForEdgeCtx(ctx context.Context) error {
+	p.ready <- struct{}{}
+	return p.Pin.WaitForEdgeCtx(ctx)
+}
+
+var errFail = errors.New("fail")
+
+// failPin is a gpiotest.Pin where edge detection fails.
+type failPin struct {
+	gpiotest.Pin
+}
+
+func (p *failPin) WaitForEdgeCtx(ctx context.Context) error {
+	return errFail
+}
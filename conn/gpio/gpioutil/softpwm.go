@@ -0,0 +1,152 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+)
+
+// NewSoftPWM returns a gpio.PinOut that implements PWM() in software by
+// toggling p from a goroutine.
+//
+// f is the frequency used when PWM() is called with 0 as frequency.
+//
+// The edges are scheduled with timers against the start of the signal, so the
+// jitter doesn't accumulate, but each edge can be late by the wake up latency
+// of the goroutine, typically 50µs to 1ms depending on the host and its load.
+// This is fine to dim LEDs; it is not accurate enough to drive servos, and
+// the duty cycle is increasingly off above ~1kHz. The frequency is limited to
+// 10kHz.
+//
+// A duty of 0 or gpio.DutyMax sets the pin to a steady level without any
+// goroutine. Out() and Halt() stop the goroutine.
+func NewSoftPWM(p gpio.PinOut, f physic.Frequency) (gpio.PinOut, error) {
+	if f <= 0 || f > maxSoftPWM {
+		return nil, fmt.Errorf("gpioutil: invalid software PWM frequency %s", f)
+	}
+	return &softPWM{PinOut: p, f: f, now: time.Now, after: time.After}, nil
+}
+
+//
+
+// maxSoftPWM is the maximum frequency of a software PWM.
+const maxSoftPWM = 10 * physic.KiloHertz
+
+// softPWM is a gpio.PinOut with a software PWM.
+type softPWM struct {
+	// Immutable.
+	gpio.PinOut
+	f physic.Frequency
+	// now and after are overridden in unit tests.
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+
+	mu   sync.Mutex
+	stop chan struct{} // Closed to stop the goroutine.
+	done chan error    // Returns the error that stopped the goroutine.
+}
+
+// Halt implements conn.Resource.
+//
+// It stops the PWM, then halts the pin.
+func (s *softPWM) Halt() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.stopLocked(); err != nil {
+		return err
+	}
+	return s.PinOut.Halt()
+}
+
+// Out implements gpio.PinOut.
+//
+// It stops the PWM.
+func (s *softPWM) Out(l gpio.Level) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.stopLocked(); err != nil {
+		return err
+	}
+	return s.PinOut.Out(l)
+}
+
+// PWM implements gpio.PinOut.
+func (s *softPWM) PWM(duty gpio.Duty, f physic.Frequency) error {
+	if !duty.Valid() {
+		return fmt.Errorf("gpioutil: invalid duty %d", duty)
+	}
+	if f == 0 {
+		f = s.f
+	} else if f < 0 || f > maxSoftPWM {
+		return fmt.Errorf("gpioutil: invalid software PWM frequency %s", f)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.stopLocked(); err != nil {
+		return err
+	}
+	switch duty {
+	case 0:
+		return s.PinOut.Out(gpio.Low)
+	case gpio.DutyMax:
+		return s.PinOut.Out(gpio.High)
+	}
+	period := f.Period()
+	high := time.Duration(int64(period) * int64(duty) / int64(gpio.DutyMax))
+	s.stop = make(chan struct{})
+	s.done = make(chan error, 1)
+	go s.run(high, period-high, s.stop, s.done)
+	return nil
+}
+
+// run toggles the pin until stop is closed.
+func (s *softPWM) run(high, low time.Duration, stop <-chan struct{}, done chan<- error) {
+	steps := [2]struct {
+		l gpio.Level
+		d time.Duration
+	}{{gpio.High, high}, {gpio.Low, low}}
+	next := s.now()
+	for {
+		for _, step := range steps {
+			if err := s.PinOut.Out(step.l); err != nil {
+				done <- err
+				return
+			}
+			// Schedule against the start of the signal, so the jitter doesn't
+			// accumulate.
+			next = next.Add(step.d)
+			select {
+			case <-stop:
+				done <- nil
+				return
+			case <-s.after(next.Sub(s.now())):
+			}
+		}
+	}
+}
+
+// stopLocked stops the goroutine, if any. s.mu must be held.
+//
+// It returns the error that stopped the goroutine early, if any.
+func (s *softPWM) stopLocked() error {
+	if s.stop == nil {
+		return nil
+	}
+	close(s.stop)
+	err := <-s.done
+	s.stop = nil
+	s.done = nil
+	if err != nil {
+		return fmt.Errorf("gpioutil: software PWM failed: %w", err)
+	}
+	return nil
+}
+
+var _ gpio.PinOut = &softPWM{}
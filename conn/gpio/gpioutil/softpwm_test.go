@@ -0,0 +1,163 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestSoftPWM(t *testing.T) {
+	data := []struct {
+		duty gpio.Duty
+		f    physic.Frequency
+		high time.Duration
+		low  time.Duration
+	}{
+		{gpio.DutyHalf, 0, 5 * time.Millisecond, 5 * time.Millisecond},
+		{gpio.DutyMax / 4, physic.KiloHertz, 250 * time.Microsecond, 750 * time.Microsecond},
+		{gpio.DutyMax / 100, 10 * physic.KiloHertz, 999 * time.Nanosecond, 99001 * time.Nanosecond},
+	}
+	for i, line := range data {
+		p, s := newSoftPWM(t, 100*physic.Hertz)
+		p.limit = 41
+		if err := s.PWM(line.duty, line.f); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		<-p.full
+		if err := s.Halt(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		// The levels alternate at the exact times on the fake clock.
+		for j := 1; j < 41; j++ {
+			e := p.edges[j]
+			expected := line.low
+			if e.l == gpio.Low {
+				expected = line.high
+			}
+			if e.l == p.edges[j-1].l || e.at-p.edges[j-1].at != expected {
+				t.Fatalf("#%d: edge #%d: %v", i, j, p.edges)
+			}
+		}
+	}
+}
+
+func TestSoftPWM_steady(t *testing.T) {
+	p, s := newSoftPWM(t, physic.KiloHertz)
+	p.limit = 3
+	if err := s.PWM(gpio.DutyHalf, 0); err != nil {
+		t.Fatal(err)
+	}
+	<-p.full
+	// Switching to a steady level stops the goroutine.
+	if err := s.PWM(gpio.DutyMax, 0); err != nil {
+		t.Fatal(err)
+	}
+	if s.(*softPWM).stop != nil || p.Read() != gpio.High {
+		t.Fatal("not steady")
+	}
+	if err := s.PWM(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if s.(*softPWM).stop != nil || p.Read() != gpio.Low {
+		t.Fatal("not steady")
+	}
+	if err := s.PWM(gpio.DutyHalf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	if s.(*softPWM).stop != nil || p.Read() != gpio.High {
+		t.Fatal("not steady")
+	}
+}
+
+func TestSoftPWM_err(t *testing.T) {
+	p := &gpiotest.Pin{N: "GPIO1"}
+	if _, err := NewSoftPWM(p, 0); err == nil {
+		t.Fatal("invalid frequency")
+	}
+	if _, err := NewSoftPWM(p, 20*physic.KiloHertz); err == nil {
+		t.Fatal("frequency too high")
+	}
+	fp, s := newSoftPWM(t, physic.KiloHertz)
+	if err := s.PWM(-1, 0); err == nil {
+		t.Fatal("invalid duty")
+	}
+	if err := s.PWM(gpio.DutyHalf, -1); err == nil {
+		t.Fatal("invalid frequency")
+	}
+	// An error stops the goroutine and is returned on the next call.
+	fp.err = errors.New("oops")
+	if err := s.PWM(gpio.DutyHalf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Halt(); !errors.Is(err, fp.err) {
+		t.Fatal(err)
+	}
+}
+
+//
+
+func newSoftPWM(t *testing.T, f physic.Frequency) (*pwmPin, gpio.PinOut) {
+	p := &pwmPin{Pin: gpiotest.Pin{N: "GPIO1"}, full: make(chan struct{})}
+	s, err := NewSoftPWM(p, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.(*softPWM).now = p.now
+	s.(*softPWM).after = p.after
+	return p, s
+}
+
+// pwmPin records the levels set on a fake clock.
+//
+// The clock advances as soon as a timer is started.
+type pwmPin struct {
+	gpiotest.Pin
+	mu    sync.Mutex
+	t     time.Duration
+	edges []edgeAt
+	limit int           // Number of edges to record.
+	full  chan struct{} // Closed once limit is reached.
+	err   error
+}
+
+func (p *pwmPin) Out(l gpio.Level) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	if len(p.edges) < p.limit {
+		p.edges = append(p.edges, edgeAt{p.t, l})
+		if len(p.edges) == p.limit {
+			close(p.full)
+		}
+	}
+	return p.Pin.Out(l)
+}
+
+func (p *pwmPin) now() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Unix(0, 0).Add(p.t)
+}
+
+func (p *pwmPin) after(d time.Duration) <-chan time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.t += d
+	c := make(chan time.Time, 1)
+	c <- time.Unix(0, 0).Add(p.t)
+	return c
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpio
+
+import (
+	"errors"
+	"strings"
+
+	"periph.io/x/periph/conn"
+)
+
+// GPIOValue is the value of the pins of a Group. Bit N is the level of the
+// pin at index N in Group.Pins().
+type GPIOValue uint64
+
+// Group is a set of pins used together, like a parallel data bus.
+type Group interface {
+	conn.Resource
+	// Pins returns the pins in the group, in bit order.
+	Pins() []PinIO
+	// Out sets the pins as output if they weren't already and sets their
+	// level, bit N of bits for the pin at index N.
+	//
+	// The pins are not guaranteed to change at the same time; some
+	// implementations, like the one for the Raspberry Pi, do it in as few
+	// register writes as possible.
+	Out(bits GPIOValue) error
+	// Read returns the current level of the pins.
+	Read() GPIOValue
+}
+
+// NewGroup returns a Group of pins that are set one at a time.
+//
+// It works with any PinIO. Host drivers may provide a faster implementation
+// for their own pins.
+func NewGroup(pins ...PinIO) (Group, error) {
+	if len(pins) == 0 || len(pins) > 64 {
+		return nil, errors.New("gpio: a group must have between 1 and 64 pins")
+	}
+	for _, p := range pins {
+		if p == nil {
+			return nil, errors.New("gpio: nil pin in group")
+		}
+	}
+	return &group{pins: append([]PinIO(nil), pins...)}, nil
+}
+
+//
+
+// group is the generic Group implementation.
+type group struct {
+	pins []PinIO
+}
+
+func (g *group) String() string {
+	names := make([]string, len(g.pins))
+	for i, p := range g.pins {
+		names[i] = p.String()
+	}
+	return "Group(" + strings.Join(names, ",") + ")"
+}
+
+// Halt implements conn.Resource.
+//
+// It halts all the pins and returns the first error.
+func (g *group) Halt() error {
+	var err error
+	for _, p := range g.pins {
+		if err2 := p.Halt(); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+func (g *group) Pins() []PinIO {
+	return append([]PinIO(nil), g.pins...)
+}
+
+func (g *group) Out(bits GPIOValue) error {
+	for i, p := range g.pins {
+		if err := p.Out(Level(bits&(1<<uint(i)) != 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *group) Read() GPIOValue {
+	var v GPIOValue
+	for i, p := range g.pins {
+		if p.Read() == High {
+			v |= 1 << uint(i)
+		}
+	}
+	return v
+}
+
+var _ Group = &group{}
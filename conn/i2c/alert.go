@@ -0,0 +1,134 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// AlertAddr is the SMBus Alert Response Address.
+//
+// A device asserting the shared SMBALERT# line replies to a one byte read at
+// this address with its own address, then releases the line.
+const AlertAddr uint16 = 0x0C
+
+// AlertMonitor monitors a SMBALERT# line shared by devices on a bus, to find
+// out which devices asserted it.
+type AlertMonitor struct {
+	b    Bus
+	p    gpio.PinIn
+	f    func(addr uint16)
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewAlertMonitor starts monitoring the SMBALERT# line connected to p.
+//
+// Each time the line is low, it reads the Alert Response Address until the
+// line is released and calls f with the address of each device that
+// responded. When multiple devices assert the line simultaneously, the one
+// with the lowest address responds first, then the next one on the following
+// read, and so on.
+//
+// f is called from a separate goroutine. p is set as input with a pull up and
+// falling edge detection. Call Close() to stop the monitoring.
+func NewAlertMonitor(b Bus, p gpio.PinIn, f func(addr uint16)) (*AlertMonitor, error) {
+	if err := p.In(gpio.PullUp, gpio.FallingEdge); err != nil {
+		return nil, fmt.Errorf("i2c: %v", err)
+	}
+	a := &AlertMonitor{b: b, p: p, f: f, stop: make(chan struct{}), done: make(chan struct{})}
+	go a.run()
+	return a, nil
+}
+
+func (a *AlertMonitor) String() string {
+	return fmt.Sprintf("AlertMonitor{%s, %s}", a.b, a.p)
+}
+
+// Close stops the monitoring and waits for f to return.
+//
+// It returns the last error that occurred while reading the Alert Response
+// Address, if any; for example when a device asserting the line doesn't
+// support the SMBus Alert protocol, or when a device keeps the line low after
+// it responded.
+func (a *AlertMonitor) Close() error {
+	a.once.Do(func() { close(a.stop) })
+	<-a.done
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+//
+
+// alertPoll is how often the line is read when there is no edge. It bounds
+// the time Close() takes and catches a line that stays low.
+const alertPoll = 100 * time.Millisecond
+
+func (a *AlertMonitor) run() {
+	defer close(a.done)
+	// stuck is set when respond() gave up, so the Alert Response Address isn't
+	// read again until the line is released.
+	stuck := false
+	for {
+		select {
+		case <-a.stop:
+			return
+		default:
+		}
+		if a.p.Read() == gpio.Low {
+			if !stuck {
+				stuck = !a.respond()
+			}
+		} else {
+			stuck = false
+		}
+		a.p.WaitForEdge(alertPoll)
+	}
+}
+
+// respond reads the Alert Response Address until the line is released.
+//
+// It returns false if it gave up with the line still low: when no device
+// responds, or when a device responds again, since it should have released
+// the line the first time.
+func (a *AlertMonitor) respond() bool {
+	var b [1]byte
+	var seen [0x80]bool
+	for a.p.Read() == gpio.Low {
+		select {
+		case <-a.stop:
+			return true
+		default:
+		}
+		if err := a.b.Tx(AlertAddr, nil, b[:]); err != nil {
+			a.setErr(err)
+			return false
+		}
+		// The least significant bit is unused.
+		addr := uint16(b[0] >> 1)
+		if seen[addr] {
+			a.setErr(fmt.Errorf("i2c: SMBALERT# is stuck low, device 0x%02x responded but didn't release it", addr))
+			return false
+		}
+		seen[addr] = true
+		a.f(addr)
+	}
+	return true
+}
+
+func (a *AlertMonitor) setErr(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.err = err
+}
@@ -7,6 +7,7 @@ package i2c_test
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/i2c/i2creg"
@@ -57,3 +58,28 @@ func ExamplePins() {
 		fmt.Printf("SCL: %s", p.SCL())
 	}
 }
+
+func ExampleRetry() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Use i2creg I²C bus registry to find the first available I²C bus.
+	b, err := i2creg.Open("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer b.Close()
+
+	// Try each transaction up to 3 times, waiting about 1ms then 2ms between
+	// the attempts. r can be passed to any device driver.
+	r := &i2c.Retry{Bus: b, Attempts: 3, Backoff: time.Millisecond}
+	d := &i2c.Dev{Addr: 23, Bus: r}
+	read := make([]byte, 5)
+	if err := d.Tx([]byte{0x10}, read); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%v\n", read)
+	fmt.Printf("%d retries\n", r.Retries())
+}
@@ -19,6 +19,9 @@
 package i2c
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"strconv"
 
@@ -40,6 +43,10 @@ type Bus interface {
 	//
 	// Write is done first, then read. One of 'w' or 'r' can be omitted for a
 	// unidirectional operation.
+	//
+	// addr is a 7 bits address, or a 10 bits address when TenBit is set. A
+	// bus that doesn't support 10 bits addressing returns an error wrapping
+	// ErrNotSupported.
 	Tx(addr uint16, w, r []byte) error
 	// SetSpeed changes the bus speed, if supported.
 	//
@@ -48,6 +55,26 @@ type Bus interface {
 	SetSpeed(f physic.Frequency) error
 }
 
+// BusCtx is implemented by a Bus that can abort a transaction.
+type BusCtx interface {
+	// TxCtx is like Bus.Tx() but returns an error wrapping ctx.Err() once ctx
+	// is done, for example when a device stretches the clock forever.
+	//
+	// The content of r is undefined when the transaction is aborted.
+	TxCtx(ctx context.Context, addr uint16, w, r []byte) error
+}
+
+// SpeedTxer is implemented by a Bus that can limit the clock speed of a
+// transaction, so devices supporting different speeds can share the bus.
+type SpeedTxer interface {
+	// TxSpeed is like Bus.Tx() but the clock frequency is at most maxSpeed.
+	//
+	// A bus that can switch speed around each transaction does so, and restores
+	// the previous speed afterward. Otherwise, the bus lowers its speed to the
+	// minimum of all the maxSpeed it got.
+	TxSpeed(addr uint16, maxSpeed physic.Frequency, w, r []byte) error
+}
+
 // BusCloser is an I²C bus that can be closed.
 //
 // This interface is meant to be handled by the application and not the device
@@ -69,14 +96,47 @@ type Pins interface {
 	SDA() gpio.PinIO
 }
 
+// TenBit is set in an address to select 10 bits addressing.
+//
+// For example, the device at the 10 bits address 0x1A3 is accessed with
+// Dev{Bus: b, Addr: 0x1A3 | TenBit}.
+const TenBit uint16 = 0x8000
+
+// ErrNotSupported is returned by a Bus that doesn't support the requested
+// addressing mode.
+var ErrNotSupported = errors.New("i2c: not supported")
+
+// CheckAddr returns an error if addr is not a valid device address.
+//
+// A 7 bits address must be at most 0x77; 0x78 to 0x7F are reserved, notably
+// as the prefix of 10 bits addresses. A 10 bits address, with TenBit set,
+// must be at most 0x3FF. Other values are ambiguous and are rejected.
+func CheckAddr(addr uint16) error {
+	if addr&TenBit != 0 {
+		if a := addr &^ TenBit; a > 0x3FF {
+			return fmt.Errorf("i2c: invalid 10 bits address %#x", a)
+		}
+		return nil
+	}
+	if addr > 0x77 {
+		return fmt.Errorf("i2c: invalid 7 bits address %#x; use TenBit for a 10 bits address", addr)
+	}
+	return nil
+}
+
 // Dev is a device on a I²C bus.
 //
 // It implements conn.Conn.
 //
-// It saves from repeatedly specifying the device address.
+// It saves from repeatedly specifying the device address. Set TenBit in Addr
+// for a device using 10 bits addressing.
+//
+// Set MaxSpeed when the device is slower than other devices on the bus; it is
+// honored when Bus implements SpeedTxer. 0 means no limit.
 type Dev struct {
-	Bus  Bus
-	Addr uint16
+	Bus      Bus
+	Addr     uint16
+	MaxSpeed physic.Frequency
 }
 
 func (d *Dev) String() string {
@@ -84,16 +144,49 @@ func (d *Dev) String() string {
 	if d.Bus != nil {
 		s = d.Bus.String()
 	}
+	if d.Addr&TenBit != 0 {
+		return s + "(" + strconv.Itoa(int(d.Addr&^TenBit)) + ", 10 bits)"
+	}
 	return s + "(" + strconv.Itoa(int(d.Addr)) + ")"
 }
 
 // Tx does a transaction by adding the device's address to each command.
 //
-// It's a wrapper for Bus.Tx().
+// It's a wrapper for Bus.Tx(), or SpeedTxer.TxSpeed() when MaxSpeed is set. It
+// returns an error without accessing the bus if Addr is invalid, as reported
+// by CheckAddr().
 func (d *Dev) Tx(w, r []byte) error {
+	if err := CheckAddr(d.Addr); err != nil {
+		return err
+	}
+	if d.MaxSpeed != 0 {
+		if s, ok := d.Bus.(SpeedTxer); ok {
+			return s.TxSpeed(d.Addr, d.MaxSpeed, w, r)
+		}
+	}
 	return d.Bus.Tx(d.Addr, w, r)
 }
 
+// TxCtx is like Tx() but the transaction is aborted once ctx is done.
+//
+// It's a wrapper for BusCtx.TxCtx(). If Bus doesn't implement BusCtx, or if
+// MaxSpeed is set and Bus implements SpeedTxer, ctx is only checked before
+// calling Tx(), which can't be aborted.
+func (d *Dev) TxCtx(ctx context.Context, w, r []byte) error {
+	if err := CheckAddr(d.Addr); err != nil {
+		return err
+	}
+	if b, ok := d.Bus.(BusCtx); ok {
+		if _, ok := d.Bus.(SpeedTxer); d.MaxSpeed == 0 || !ok {
+			return b.TxCtx(ctx, d.Addr, w, r)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("i2c: %w", err)
+	}
+	return d.Tx(w, r)
+}
+
 // Write writes to the I²C bus without reading, implementing io.Writer.
 //
 // It's a wrapper for Tx()
@@ -104,6 +197,16 @@ func (d *Dev) Write(b []byte) (int, error) {
 	return len(b), nil
 }
 
+// MaxTxSize implements conn.Limits.
+//
+// It returns the limit of Bus if it implements conn.Limits, 0 otherwise.
+func (d *Dev) MaxTxSize() int {
+	if l, ok := d.Bus.(conn.Limits); ok {
+		return l.MaxTxSize()
+	}
+	return 0
+}
+
 // Duplex always return conn.Half for I²C.
 func (d *Dev) Duplex() conn.Duplex {
 	return conn.Half
@@ -112,3 +215,4 @@ func (d *Dev) Duplex() conn.Duplex {
 //
 
 var _ conn.Conn = &Dev{}
+var _ conn.Limits = &Dev{}
@@ -6,24 +6,84 @@ package i2c
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
 	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
 )
 
 func TestDevString(t *testing.T) {
-	d := Dev{&fakeBus{}, 12}
+	d := Dev{Bus: &fakeBus{}, Addr: 12}
 	if s := d.String(); s != "fake(12)" {
 		t.Fatalf("got %s", s)
 	}
 }
 
+func TestDevString_tenBit(t *testing.T) {
+	d := Dev{Bus: &fakeBus{}, Addr: 0x1A3 | TenBit}
+	if s := d.String(); s != "fake(419, 10 bits)" {
+		t.Fatalf("got %s", s)
+	}
+}
+
+func TestDevTx_addr(t *testing.T) {
+	b := &fakeBus{}
+	d := Dev{Bus: b, Addr: 0x78}
+	if d.Tx([]byte{1}, nil) == nil {
+		t.Fatal("0x78 requires TenBit")
+	}
+	if len(b.w) != 0 {
+		t.Fatal("the bus must not be accessed")
+	}
+	d.Addr = 0x78 | TenBit
+	if err := d.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.addr != 0x78|TenBit {
+		t.Fatalf("%#x", b.addr)
+	}
+}
+
+func TestCheckAddr(t *testing.T) {
+	data := []struct {
+		addr  uint16
+		valid bool
+	}{
+		{0, true},
+		{0x77, true},
+		{0x78, false},
+		{0x7F, false},
+		{0x80, false},
+		{0x3FF, false},
+		{TenBit, true},
+		{0x78 | TenBit, true},
+		{0x3FF | TenBit, true},
+		{0x400 | TenBit, false},
+		{0xFFFF, false},
+	}
+	for i, line := range data {
+		if err := CheckAddr(line.addr); (err == nil) != line.valid {
+			t.Fatalf("#%d: %#x: %v", i, line.addr, err)
+		}
+	}
+}
+
 func TestDevTx(t *testing.T) {
 	exErr := errors.New("yes")
 	b := &fakeBus{err: exErr, r: []byte{1, 2, 3}}
-	d := Dev{b, 12}
+	d := Dev{Bus: b, Addr: 12}
 	r := make([]byte, 3)
 	w := []byte{3, 4, 5}
 	if err := d.Tx(w, r); exErr != err {
@@ -44,9 +104,71 @@ func TestDevTx(t *testing.T) {
 	}
 }
 
+func TestDevTxCtx(t *testing.T) {
+	// Bus doesn't implement BusCtx.
+	b := &fakeBus{r: []byte{1}}
+	d := Dev{Bus: b, Addr: 12}
+	r := make([]byte, 1)
+	if err := d.TxCtx(context.Background(), []byte{2}, r); err != nil || r[0] != 1 {
+		t.Fatal(r, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := d.TxCtx(ctx, []byte{3}, nil); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.w, []byte{2}) {
+		t.Fatal(b.w)
+	}
+	d.Addr = 0x80
+	if d.TxCtx(context.Background(), nil, nil) == nil {
+		t.Fatal("invalid address")
+	}
+
+	// Bus implements BusCtx.
+	c := &ctxBus{}
+	d = Dev{Bus: c, Addr: 12}
+	if err := d.TxCtx(ctx, []byte{3}, nil); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if c.ctx != ctx || c.addr != 12 {
+		t.Fatal(c.ctx, c.addr)
+	}
+}
+
+func TestDevTx_maxSpeed(t *testing.T) {
+	b := &speedBus{}
+	d := Dev{Bus: b, Addr: 12}
+	if err := d.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.maxSpeed != 0 || !bytes.Equal(b.w, []byte{1}) {
+		t.Fatal(b.maxSpeed, b.w)
+	}
+	d.MaxSpeed = 100 * physic.KiloHertz
+	if err := d.Tx([]byte{2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.TxCtx(context.Background(), []byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.maxSpeed != 100*physic.KiloHertz || !bytes.Equal(b.w, []byte{1, 2, 3}) {
+		t.Fatal(b.maxSpeed, b.w)
+	}
+	// The bus doesn't implement SpeedTxer.
+	f := &fakeBus{}
+	d.Bus = f
+	if err := d.Tx([]byte{4}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(f.w, []byte{4}) {
+		t.Fatal(f.w)
+	}
+}
+
 func TestDevWrite(t *testing.T) {
 	b := &fakeBus{}
-	d := Dev{b, 12}
+	d := Dev{Bus: b, Addr: 12}
 	w := []byte{3, 4, 5}
 	if n, err := d.Write(w); err != nil || n != 3 {
 		t.Fatalf("got %s", err)
@@ -62,7 +184,7 @@ func TestDevWrite(t *testing.T) {
 func TestDevWriteErr(t *testing.T) {
 	exErr := errors.New("yes")
 	b := &fakeBus{err: exErr}
-	d := Dev{b, 12}
+	d := Dev{Bus: b, Addr: 12}
 	w := []byte{3, 4, 5}
 	if n, err := d.Write(w); err != exErr || n != 0 {
 		t.Fatal(err)
@@ -75,8 +197,511 @@ func TestDevWriteErr(t *testing.T) {
 	}
 }
 
+func TestScan(t *testing.T) {
+	b := &scanBus{devices: map[uint16]bool{0x03: true, 0x20: true, 0x50: true, 0x76: true, 0x78: true}}
+	addrs, err := Scan(context.Background(), b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []uint16{0x20, 0x50, 0x76}; !reflect.DeepEqual(addrs, expected) {
+		t.Fatalf("%#x != %#x", addrs, expected)
+	}
+	if len(b.reads) != 8+16 || b.reads[0] != 0x30 || b.reads[8] != 0x50 {
+		t.Fatalf("%#x", b.reads)
+	}
+	if len(b.quick) != 0x70-len(b.reads) || b.quick[0] != 0x08 || b.quick[len(b.quick)-1] != 0x77 {
+		t.Fatalf("%#x", b.quick)
+	}
+}
+
+func TestScan_range(t *testing.T) {
+	b := &scanBus{devices: map[uint16]bool{0x03: true, 0x20: true, 0x50: true, 0x76: true}}
+	addrs, err := Scan(context.Background(), b, &ScanOpts{Start: 0, End: 0x50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []uint16{0x20, 0x50}; !reflect.DeepEqual(addrs, expected) {
+		t.Fatalf("%#x != %#x", addrs, expected)
+	}
+	if b.quick[0] != 0x08 || b.reads[len(b.reads)-1] != 0x50 {
+		t.Fatalf("%#x %#x", b.quick, b.reads)
+	}
+	if _, err := Scan(context.Background(), b, &ScanOpts{Start: 0x60, End: 0x50}); err == nil {
+		t.Fatal("invalid range")
+	}
+	if _, err := Scan(context.Background(), b, &ScanOpts{Start: 0x78, End: 0x7F}); err == nil {
+		t.Fatal("reserved range")
+	}
+}
+
+func TestScan_read(t *testing.T) {
+	// A bus without quick write support is probed with reads.
+	devices := map[uint16]bool{0x10: true, 0x12: true}
+	for i, b := range []*scanBus{{devices: devices}, {devices: devices, unsupported: true}} {
+		// Embedding hides the QuickWrite method.
+		bus := Bus(struct{ Bus }{b})
+		if b.unsupported {
+			bus = b
+		}
+		addrs, err := Scan(context.Background(), bus, &ScanOpts{Start: 0x10, End: 0x12})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []uint16{0x10, 0x12}; !reflect.DeepEqual(addrs, expected) {
+			t.Fatalf("#%d: %#x != %#x", i, addrs, expected)
+		}
+		if expected := []uint16{0x10, 0x11, 0x12}; !reflect.DeepEqual(b.reads, expected) {
+			t.Fatalf("#%d: %#x != %#x", i, b.reads, expected)
+		}
+	}
+}
+
+func TestScan_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &scanBus{devices: map[uint16]bool{0x08: true, 0x09: true, 0x10: true}, cancel: cancel, cancelAt: 0x09}
+	addrs, err := Scan(ctx, b, nil)
+	if err != context.Canceled {
+		t.Fatal(err)
+	}
+	if expected := []uint16{0x08, 0x09}; !reflect.DeepEqual(addrs, expected) {
+		t.Fatalf("%#x != %#x", addrs, expected)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	f := &flakyBus{fails: 2}
+	r := &Retry{Bus: f, Attempts: 3, Backoff: time.Microsecond}
+	if s := r.String(); s != "retry(fake)" {
+		t.Fatal(s)
+	}
+	if err := r.Tx(12, []byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if f.calls != 3 || r.Retries() != 2 || r.Failures() != 0 {
+		t.Fatal(f.calls, r.Retries(), r.Failures())
+	}
+	f.calls, f.fails = 0, 3
+	if err := r.Tx(12, []byte{1}, nil); err != errFlaky {
+		t.Fatal(err)
+	}
+	if f.calls != 3 || r.Retries() != 4 || r.Failures() != 1 {
+		t.Fatal(f.calls, r.Retries(), r.Failures())
+	}
+	if err := r.SetSpeed(physic.KiloHertz); err != nil || f.freq != physic.KiloHertz {
+		t.Fatal(f.freq, err)
+	}
+}
+
+func TestRetry_permanent(t *testing.T) {
+	f := &flakyBus{fails: 1}
+	r := &Retry{Bus: f, Attempts: 3}
+	if r.Tx(0x80, nil, nil) == nil {
+		t.Fatal("invalid address")
+	}
+	f.err = fmt.Errorf("10 bits: %w", ErrNotSupported)
+	if err := r.Tx(12, nil, nil); !errors.Is(err, ErrNotSupported) {
+		t.Fatal(err)
+	}
+	if f.calls != 1 || r.Retries() != 0 || r.Failures() != 1 {
+		t.Fatal(f.calls, r.Retries(), r.Failures())
+	}
+	// No retry by default.
+	f.calls, f.fails, f.err = 0, 1, nil
+	r = &Retry{Bus: f}
+	if err := r.Tx(12, nil, nil); err != errFlaky {
+		t.Fatal(err)
+	}
+	if f.calls != 1 {
+		t.Fatal(f.calls)
+	}
+}
+
+func TestRetry_TxCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := &flakyBus{}
+	r := &Retry{Bus: f, Attempts: 3, Backoff: time.Hour}
+	if err := r.TxCtx(ctx, 12, nil, nil); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if f.calls != 0 {
+		t.Fatal(f.calls)
+	}
+	c := &ctxBus{}
+	r = &Retry{Bus: c, Attempts: 3, Backoff: time.Hour}
+	if err := r.TxCtx(ctx, 12, nil, nil); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if c.ctx != ctx || r.Failures() != 1 {
+		t.Fatal(c.ctx, r.Failures())
+	}
+	// ctx is done during the backoff.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	f = &flakyBus{fails: 3}
+	r = &Retry{Bus: f, Attempts: 3, Backoff: time.Hour}
+	if err := r.TxCtx(ctx, 12, nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	if f.calls != 1 || r.Retries() != 0 || r.Failures() != 1 {
+		t.Fatal(f.calls, r.Retries(), r.Failures())
+	}
+}
+
+func TestRetry_TxSpeed(t *testing.T) {
+	s := &speedBus{}
+	r := &Retry{Bus: s, Attempts: 3}
+	if err := r.TxSpeed(12, 100*physic.KiloHertz, []byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if s.maxSpeed != 100*physic.KiloHertz || s.addr != 12 {
+		t.Fatal(s.maxSpeed, s.addr)
+	}
+	if r.TxSpeed(0x80, physic.KiloHertz, nil, nil) == nil {
+		t.Fatal("invalid address")
+	}
+	// Bus.Tx() is used when Bus doesn't implement SpeedTxer.
+	f := &flakyBus{fails: 1}
+	r = &Retry{Bus: f, Attempts: 3}
+	if err := r.TxSpeed(12, physic.KiloHertz, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if f.calls != 2 || r.Retries() != 1 {
+		t.Fatal(f.calls, r.Retries())
+	}
+}
+
+func TestRetry_MaxTxSize(t *testing.T) {
+	r := &Retry{Bus: &fakeBus{}}
+	if l := r.MaxTxSize(); l != 0 {
+		t.Fatal(l)
+	}
+	r.Bus = &limitBus{max: 8}
+	if l := r.MaxTxSize(); l != 8 {
+		t.Fatal(l)
+	}
+}
+
+func TestDev_MaxTxSize(t *testing.T) {
+	d := Dev{Bus: &fakeBus{}, Addr: 12}
+	if l := d.MaxTxSize(); l != 0 {
+		t.Fatal(l)
+	}
+	d.Bus = &limitBus{max: 8}
+	if l := d.MaxTxSize(); l != 8 {
+		t.Fatal(l)
+	}
+}
+
+func TestSplitter(t *testing.T) {
+	b := &limitBus{max: 4}
+	s := &Splitter{Conn: &Dev{Bus: b, Addr: 12}, PrefixLen: 1}
+	if str := s.String(); str != "fake(12)" {
+		t.Fatal(str)
+	}
+	if d := s.Duplex(); d != conn.Half {
+		t.Fatal(d)
+	}
+	if n, err := s.Write([]byte{0x40, 1, 2, 3, 4, 5, 6, 7}); n != 8 || err != nil {
+		t.Fatal(n, err)
+	}
+	expected := [][]byte{{0x40, 1, 2, 3}, {0x40, 4, 5, 6}, {0x40, 7}}
+	if !reflect.DeepEqual(b.ws, expected) {
+		t.Fatal(b.ws)
+	}
+	// Not split.
+	b.ws = nil
+	r := make([]byte, 2)
+	if err := s.Tx([]byte{0x40, 1}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b.ws, [][]byte{{0x40, 1}}) {
+		t.Fatal(b.ws)
+	}
+	if s.Tx([]byte{0x40, 1, 2, 3, 4}, r) == nil {
+		t.Fatal("can't split with a read")
+	}
+	s.PrefixLen = 4
+	if s.Tx([]byte{0x40, 1, 2, 3, 4}, nil) == nil {
+		t.Fatal("prefix is too long")
+	}
+	b.err = errors.New("nack")
+	s.PrefixLen = 1
+	if n, err := s.Write([]byte{0x40, 1, 2, 3, 4}); n != 0 || err != b.err {
+		t.Fatal(n, err)
+	}
+}
+
+func TestSplitter_increment(t *testing.T) {
+	b := &limitBus{}
+	s := &Splitter{Conn: &Dev{Bus: b, Addr: 0x50}, PrefixLen: 2, Mode: SplitIncrement, MaxTxSize: 5}
+	if err := s.Tx([]byte{0x01, 0xFE, 1, 2, 3, 4, 5, 6, 7}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]byte{{0x01, 0xFE, 1, 2, 3}, {0x02, 0x01, 4, 5, 6}, {0x02, 0x04, 7}}
+	if !reflect.DeepEqual(b.ws, expected) {
+		t.Fatal(b.ws)
+	}
+	if s.Tx([]byte{0xFF, 0xFE, 1, 2, 3, 4}, nil) == nil {
+		t.Fatal("address overflow")
+	}
+	// No limit.
+	b.ws = nil
+	s.MaxTxSize = 0
+	if err := s.Tx([]byte{0x01, 0xFE, 1, 2, 3, 4, 5, 6, 7}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.ws) != 1 {
+		t.Fatal(b.ws)
+	}
+}
+
+func TestAlertMonitor(t *testing.T) {
+	p := &gpiotest.Pin{N: "ALERT", EdgesChan: make(chan gpio.Level, 1)}
+	b := &alertBus{p: p}
+	got := make(chan uint16, 10)
+	a, err := NewAlertMonitor(b, p, func(addr uint16) { got <- addr })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := a.String(); s != "AlertMonitor{fake, ALERT(0)}" {
+		t.Fatal(s)
+	}
+	// Two devices assert the line simultaneously.
+	b.assert(0x49, 0x48)
+	p.EdgesChan <- gpio.Low
+	for _, expected := range []uint16{0x48, 0x49} {
+		if addr := <-got; addr != expected {
+			t.Fatalf("%#x != %#x", addr, expected)
+		}
+	}
+	b.assert(0x18)
+	p.EdgesChan <- gpio.Low
+	if addr := <-got; addr != 0x18 {
+		t.Fatalf("%#x", addr)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatal(<-got)
+	}
+}
+
+func TestAlertMonitor_noResponse(t *testing.T) {
+	p := &gpiotest.Pin{N: "ALERT", EdgesChan: make(chan gpio.Level, 1)}
+	b := &alertBus{p: p}
+	a, err := NewAlertMonitor(b, p, func(addr uint16) { t.Error("unexpected call") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A device that doesn't support the SMBus Alert protocol holds the line.
+	p.EdgesChan <- gpio.Low
+	for b.count() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := a.Close(); err == nil || err.Error() != "nack" {
+		t.Fatal(err)
+	}
+	if _, err := NewAlertMonitor(b, &gpiotest.Pin{}, nil); err == nil {
+		t.Fatal("edge detection is not supported")
+	}
+}
+
+func TestAlertMonitor_stuck(t *testing.T) {
+	p := &gpiotest.Pin{N: "ALERT", EdgesChan: make(chan gpio.Level, 1)}
+	b := &alertBus{p: p, hold: true}
+	got := make(chan uint16, 0x80)
+	a, err := NewAlertMonitor(b, p, func(addr uint16) { got <- addr })
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The device responds but never releases the line.
+	b.assert(0x20)
+	p.EdgesChan <- gpio.Low
+	if addr := <-got; addr != 0x20 {
+		t.Fatalf("%#x", addr)
+	}
+	for b.count() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	// The Alert Response Address is not read again until the line is released.
+	time.Sleep(2 * alertPoll)
+	if n := b.count(); n != 2 {
+		t.Fatal(n)
+	}
+	if err := a.Close(); err == nil || err.Error() != "i2c: SMBALERT# is stuck low, device 0x20 responded but didn't release it" {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatal(<-got)
+	}
+}
+
+func TestRecoverPins(t *testing.T) {
+	data := []struct {
+		hold    int
+		holdSCL bool
+		clocks  int
+		err     string
+	}{
+		{0, false, 0, ""},
+		{3, false, 3, ""},
+		{9, false, 9, ""},
+		{-1, false, 9, "i2c: bus is stuck: SDA is held low"},
+		{0, true, 0, "i2c: bus is stuck: SCL is held low"},
+	}
+	for i, line := range data {
+		l := newLines(line.hold)
+		l.holdSCL = line.holdSCL
+		err := RecoverPins(l.scl, l.sda)
+		if line.err == "" {
+			if err != nil {
+				t.Fatalf("#%d: %v", i, err)
+			}
+		} else if !errors.Is(err, ErrBusStuck) || err.Error() != line.err {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if l.clocks != line.clocks {
+			t.Fatalf("#%d: %d clocks", i, l.clocks)
+		}
+		// A STOP condition is only visible on a freed bus.
+		if line.err == "" && l.stops != 1 {
+			t.Fatalf("#%d: %d stops", i, l.stops)
+		}
+		// The pins function is restored.
+		if l.scl.Fn != "I2C1_SCL" || l.sda.Fn != "I2C1_SDA" {
+			t.Fatalf("#%d: %s %s", i, l.scl.Fn, l.sda.Fn)
+		}
+	}
+}
+
+func TestRecoverPins_restoreError(t *testing.T) {
+	l := newLines(0)
+	l.sda.failSetFunc = true
+	if err := RecoverPins(l.scl, l.sda); err == nil || err.Error() != "i2c: failed to restore SDA(2) to I2C1_SDA: oops" {
+		t.Fatal(err)
+	}
+}
+
+func TestLog(t *testing.T) {
+	b := &fakeBus{r: []byte{0x0a, 0x0b}}
+	var out bytes.Buffer
+	l := &Log{Bus: b, Output: &out}
+	if s := l.String(); s != "log(fake)" {
+		t.Fatal(s)
+	}
+	r := make([]byte, 2)
+	if err := l.Tx(0x48, []byte{1, 2}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{0x0a, 0x0b}) {
+		t.Fatal(r)
+	}
+	b.err = errors.New("nack")
+	if err := l.Tx(0x49, []byte{3}, nil); err != b.err {
+		t.Fatal(err)
+	}
+	if err := l.SetSpeed(100 * physic.KiloHertz); err != b.err {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"0x48 W:[01 02] R:[0a 0b]",
+		"0x49 W:[03]: nack",
+		"SetSpeed(100kHz): nack",
+	}
+	if got := stripLog(t, out.String()); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("%q", got)
+	}
+}
+
+func TestLog_TxSpeed(t *testing.T) {
+	s := &speedBus{}
+	var out bytes.Buffer
+	l := &Log{Bus: s, Output: &out}
+	if err := l.TxSpeed(0x48, 100*physic.KiloHertz, []byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if s.maxSpeed != 100*physic.KiloHertz {
+		t.Fatal(s.maxSpeed)
+	}
+	// Bus.Tx() is used when Bus doesn't implement SpeedTxer.
+	l.Bus = &fakeBus{}
+	if err := l.TxSpeed(0x49, 100*physic.KiloHertz, []byte{2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"0x48@100kHz W:[01]",
+		"0x49 W:[02]",
+	}
+	if got := stripLog(t, out.String()); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("%q", got)
+	}
+	if m := l.MaxTxSize(); m != 0 {
+		t.Fatal(m)
+	}
+	l.Bus = &limitBus{max: 8}
+	if m := l.MaxTxSize(); m != 8 {
+		t.Fatal(m)
+	}
+}
+
+func TestLog_filter(t *testing.T) {
+	b := &fakeBus{r: []byte{0x55, 0x55}}
+	var out bytes.Buffer
+	l := &Log{Bus: b, Output: &out, Addrs: []uint16{0x50}}
+	l.Redact = func(addr uint16, w, r []byte) {
+		for i := range r {
+			r[i] = 0
+		}
+	}
+	r := make([]byte, 1)
+	if err := l.Tx(0x48, []byte{1}, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Tx(0x50, []byte{2}, r); err != nil {
+		t.Fatal(err)
+	}
+	// The redaction doesn't affect the caller.
+	if r[0] != 0x55 {
+		t.Fatal(r)
+	}
+	if got := stripLog(t, out.String()); !reflect.DeepEqual(got, []string{"0x50 W:[02] R:[00]"}) {
+		t.Fatalf("%q", got)
+	}
+	// No output.
+	l = &Log{Bus: b}
+	if err := l.Tx(0x50, []byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetSpeed(physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.w, []byte{1, 2, 3}) {
+		t.Fatal(b.w)
+	}
+}
+
 //
 
+// stripLog returns the lines logged by Log without the timestamp and the
+// duration.
+func stripLog(t *testing.T, s string) []string {
+	re := regexp.MustCompile(`^[0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{6} (.+?)(?: [0-9.]+[nµm]?s)?(: .+)?$`)
+	var out []string
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("unexpected line %q", line)
+		}
+		out = append(out, m[1]+m[2])
+	}
+	return out
+}
+
 type fakeBus struct {
 	freq physic.Frequency
 	err  error
@@ -104,3 +729,236 @@ func (f *fakeBus) SetSpeed(freq physic.Frequency) error {
 	f.freq = freq
 	return f.err
 }
+
+// ctxBus is a Bus implementing BusCtx.
+type ctxBus struct {
+	fakeBus
+	ctx context.Context
+}
+
+func (c *ctxBus) TxCtx(ctx context.Context, addr uint16, w, r []byte) error {
+	c.ctx = ctx
+	c.addr = addr
+	return ctx.Err()
+}
+
+// speedBus is a Bus implementing SpeedTxer and BusCtx.
+type speedBus struct {
+	ctxBus
+	maxSpeed physic.Frequency
+}
+
+func (s *speedBus) TxSpeed(addr uint16, maxSpeed physic.Frequency, w, r []byte) error {
+	s.maxSpeed = maxSpeed
+	return s.Tx(addr, w, r)
+}
+
+// flakyBus is a Bus that fails the first transactions.
+type flakyBus struct {
+	fakeBus
+	fails int
+	calls int
+}
+
+var errFlaky = errors.New("nack")
+
+func (f *flakyBus) Tx(addr uint16, w, r []byte) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	if f.calls <= f.fails {
+		return errFlaky
+	}
+	return nil
+}
+
+// limitBus is a Bus implementing conn.Limits that records the writes.
+type limitBus struct {
+	fakeBus
+	max int
+	ws  [][]byte
+}
+
+func (l *limitBus) Tx(addr uint16, w, r []byte) error {
+	if l.err != nil {
+		return l.err
+	}
+	if l.max != 0 && (len(w) > l.max || len(r) > l.max) {
+		return errors.New("too large")
+	}
+	l.ws = append(l.ws, append([]byte(nil), w...))
+	return nil
+}
+
+func (l *limitBus) MaxTxSize() int {
+	return l.max
+}
+
+// alertBus is a Bus with devices asserting the SMBALERT# line p.
+type alertBus struct {
+	fakeBus
+	p *gpiotest.Pin
+
+	hold  bool // The devices don't release the line once they responded.
+	mu    sync.Mutex
+	addrs []uint16 // Devices asserting the line.
+	reads int
+}
+
+func (a *alertBus) assert(addrs ...uint16) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.addrs = append(a.addrs, addrs...)
+	sort.Slice(a.addrs, func(i, j int) bool { return a.addrs[i] < a.addrs[j] })
+}
+
+func (a *alertBus) count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.reads
+}
+
+func (a *alertBus) Tx(addr uint16, w, r []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if addr != AlertAddr || len(w) != 0 || len(r) != 1 {
+		return errors.New("unexpected Tx")
+	}
+	a.reads++
+	if len(a.addrs) == 0 {
+		return errors.New("nack")
+	}
+	// The device with the lowest address wins the arbitration.
+	r[0] = byte(a.addrs[0]<<1) | 1
+	if a.hold {
+		return nil
+	}
+	a.addrs = a.addrs[1:]
+	if len(a.addrs) == 0 {
+		_ = a.p.Out(gpio.High)
+	}
+	return nil
+}
+
+// scanBus is a Bus implementing QuickWriter with devices at fixed addresses.
+type scanBus struct {
+	devices     map[uint16]bool
+	unsupported bool
+	reads       []uint16
+	quick       []uint16
+	cancel      func()
+	cancelAt    uint16
+}
+
+func (s *scanBus) String() string {
+	return "scan"
+}
+
+func (s *scanBus) Tx(addr uint16, w, r []byte) error {
+	s.reads = append(s.reads, addr)
+	return s.probe(addr)
+}
+
+func (s *scanBus) SetSpeed(f physic.Frequency) error {
+	return nil
+}
+
+func (s *scanBus) QuickWrite(addr uint16) error {
+	if s.unsupported {
+		return ErrQuickWriteUnsupported
+	}
+	s.quick = append(s.quick, addr)
+	return s.probe(addr)
+}
+
+func (s *scanBus) probe(addr uint16) error {
+	if s.cancel != nil && addr == s.cancelAt {
+		s.cancel()
+	}
+	if !s.devices[addr] {
+		return errors.New("nack")
+	}
+	return nil
+}
+
+// lines simulates the open drain SCL and SDA lines with a device holding SDA
+// low for hold clocks, or forever if hold is -1.
+type lines struct {
+	scl, sda *linePin
+	hold     int
+	holdSCL  bool
+	clocks   int
+	stops    int
+}
+
+func newLines(hold int) *lines {
+	l := &lines{hold: hold}
+	l.scl = &linePin{Pin: gpiotest.Pin{N: "SCL", Num: 1, Fn: "I2C1_SCL"}, l: l}
+	l.sda = &linePin{Pin: gpiotest.Pin{N: "SDA", Num: 2, Fn: "I2C1_SDA"}, l: l}
+	l.scl.released = true
+	l.sda.released = true
+	return l
+}
+
+func (l *lines) level(p *linePin) gpio.Level {
+	if p == l.scl {
+		return gpio.Level(p.released && !l.holdSCL)
+	}
+	return gpio.Level(p.released && l.hold == 0)
+}
+
+func (l *lines) set(p *linePin, released bool) {
+	if p.released == released {
+		return
+	}
+	if p == l.sda && released && l.level(l.scl) == gpio.High && l.level(l.sda) == gpio.Low {
+		p.released = released
+		if l.level(l.sda) == gpio.High {
+			l.stops++
+		}
+		return
+	}
+	p.released = released
+	if p == l.scl {
+		if !released && l.hold > 0 {
+			// The device shifts out one bit per clock.
+			l.hold--
+		} else if released && l.sda.released {
+			// A recovery clock, not part of the STOP condition.
+			l.clocks++
+		}
+	}
+}
+
+// linePin is one line of lines.
+type linePin struct {
+	gpiotest.Pin
+	l           *lines
+	released    bool
+	failSetFunc bool
+}
+
+func (p *linePin) In(pull gpio.Pull, edge gpio.Edge) error {
+	p.Fn = "In"
+	p.l.set(p, true)
+	return nil
+}
+
+func (p *linePin) Out(l gpio.Level) error {
+	p.Fn = "Out"
+	p.l.set(p, bool(l))
+	return nil
+}
+
+func (p *linePin) Read() gpio.Level {
+	return p.l.level(p)
+}
+
+func (p *linePin) SetFunc(f pin.Func) error {
+	if p.failSetFunc {
+		return errors.New("oops")
+	}
+	p.Fn = string(f)
+	return nil
+}
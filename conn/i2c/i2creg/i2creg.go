@@ -174,6 +174,48 @@ func Register(name string, aliases []string, number int, o Opener) error {
 	return nil
 }
 
+// Alias adds alias as an alternative name for a registered bus.
+//
+// canonical is resolved like in Open(): a bus name, an existing alias or a bus
+// number. It is meant to let an application or a host driver give a stable
+// name to a bus whose number may change, for example across kernel versions.
+//
+// The alias is removed when the bus is unregistered.
+func Alias(alias, canonical string) error {
+	if len(alias) == 0 {
+		return errors.New("i2creg: can't add an empty alias")
+	}
+	if _, err := strconv.Atoi(alias); err == nil {
+		return errors.New("i2creg: can't add alias " + strconv.Quote(alias) + " that is a number")
+	}
+	if strings.Contains(alias, ":") {
+		return errors.New("i2creg: can't add alias " + strconv.Quote(alias) + " containing ':'")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := byName[alias]; ok {
+		return errors.New("i2creg: can't add alias " + strconv.Quote(alias) + "; it is already a bus")
+	}
+	if _, ok := byAlias[alias]; ok {
+		return errors.New("i2creg: can't add alias " + strconv.Quote(alias) + " twice")
+	}
+	r := byName[canonical]
+	if r == nil {
+		if r = byAlias[canonical]; r == nil {
+			if i, err := strconv.Atoi(canonical); err == nil {
+				r = byNumber[i]
+			}
+		}
+	}
+	if r == nil {
+		return errors.New("i2creg: can't add alias " + strconv.Quote(alias) + " to unknown bus " + strconv.Quote(canonical))
+	}
+	// Ref are copied by All(), so it's safe to modify it in place.
+	r.Aliases = append(r.Aliases, alias)
+	byAlias[alias] = r
+	return nil
+}
+
 // Unregister removes a previously registered I²C bus.
 //
 // This can happen when an I²C bus is exposed via an USB device and the device
@@ -5,6 +5,7 @@
 package i2creg
 
 import (
+	"reflect"
 	"testing"
 
 	"periph.io/x/periph/conn/i2c"
@@ -127,6 +128,55 @@ func TestRegister_fail(t *testing.T) {
 	}
 }
 
+func TestAlias(t *testing.T) {
+	defer reset()
+	if Alias("y", "a") == nil {
+		t.Fatal("unknown bus")
+	}
+	if err := Register("a", []string{"x"}, 1, fakeBuser); err != nil {
+		t.Fatal(err)
+	}
+	if err := Alias("y", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Alias("z", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Alias("w", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if o, err := Open("z"); o == nil || err != nil {
+		t.Fatal(o, err)
+	}
+	if a := All(); len(a) != 1 || !reflect.DeepEqual(a[0].Aliases, []string{"x", "y", "z", "w"}) {
+		t.Fatal(a)
+	}
+	if Alias("", "a") == nil {
+		t.Fatal("empty alias")
+	}
+	if Alias("2", "a") == nil {
+		t.Fatal("numeric alias")
+	}
+	if Alias("a:b", "a") == nil {
+		t.Fatal("':' in alias")
+	}
+	if Alias("a", "a") == nil {
+		t.Fatal("alias is a bus name")
+	}
+	if Alias("y", "a") == nil {
+		t.Fatal("alias already registered")
+	}
+	if Register("c", []string{"y"}, -1, fakeBuser) == nil {
+		t.Fatal("alias already registered")
+	}
+	if err := Unregister("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open("y"); err == nil {
+		t.Fatal("alias was removed")
+	}
+}
+
 func TestUnregister(t *testing.T) {
 	defer reset()
 	if Unregister("") == nil {
@@ -7,7 +7,10 @@ package i2ctest
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	"periph.io/x/periph/conn/conntest"
 	"periph.io/x/periph/conn/gpio"
@@ -27,6 +30,10 @@ type IO struct {
 // This can then be used to feed to Playback to do "replay" based unit tests.
 //
 // Record doesn't implement i2c.BusCloser on purpose.
+//
+// It is safe for concurrent use. The transactions are serialized, so Ops is in
+// the order they happened on Bus. Lock it to read Ops while transactions may
+// still happen.
 type Record struct {
 	sync.Mutex
 	Bus i2c.Bus // Bus can be nil if only writes are being recorded.
@@ -94,6 +101,14 @@ func (r *Record) SDA() gpio.PinIO {
 //
 // Set DontPanic to true to return an error instead of panicking, which is the
 // default.
+//
+// Set Delay to simulate a slow device, for example one stretching the clock;
+// each transaction then lasts Delay.
+//
+// By default, the transactions must happen exactly in the order of Ops. Set
+// ByAddr to only enforce the order between the Ops of the same device
+// address, so the transactions of multiple device drivers sharing the bus can
+// interleave, for example when they run in separate goroutines.
 type Playback struct {
 	sync.Mutex
 	Ops       []IO
@@ -101,6 +116,10 @@ type Playback struct {
 	DontPanic bool
 	SDAPin    gpio.PinIO
 	SCLPin    gpio.PinIO
+	Delay     time.Duration
+	ByAddr    bool
+
+	done []bool // Ops consumed when ByAddr is set.
 }
 
 func (p *Playback) String() string {
@@ -114,28 +133,71 @@ func (p *Playback) Close() error {
 	p.Lock()
 	defer p.Unlock()
 	if len(p.Ops) != p.Count {
-		return errorf(p.DontPanic, "i2ctest: expected playback to be empty: I/O count %d; expected %d", p.Count, len(p.Ops))
+		i := p.Count
+		if p.ByAddr {
+			for i = range p.Ops {
+				if !p.isDone(i) {
+					break
+				}
+			}
+		}
+		op := &p.Ops[i]
+		return errorf(p.DontPanic, "i2ctest: expected playback to be empty: I/O count %d; expected %d; next is #%d at addr %#x W:[% x] R:%d bytes", p.Count, len(p.Ops), i, op.Addr, op.W, len(op.R))
 	}
 	return nil
 }
 
 // Tx implements i2c.Bus.
 func (p *Playback) Tx(addr uint16, w, r []byte) error {
+	return p.TxCtx(context.Background(), addr, w, r)
+}
+
+// TxCtx implements i2c.BusCtx.
+//
+// It returns an error wrapping ctx.Err() if ctx is done before Delay elapsed,
+// without consuming an IO.
+func (p *Playback) TxCtx(ctx context.Context, addr uint16, w, r []byte) error {
 	p.Lock()
 	defer p.Unlock()
-	if len(p.Ops) <= p.Count {
-		return errorf(p.DontPanic, "i2ctest: unexpected Tx() (count #%d) expecting i2ctest.IO{Addr:%d, W:%#v, R:%#v}", p.Count, addr, w, r)
+	if p.Delay != 0 {
+		t := time.NewTimer(p.Delay)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+		case <-t.C:
+		}
 	}
-	if addr != p.Ops[p.Count].Addr {
-		return errorf(p.DontPanic, "i2ctest: unexpected addr (count #%d) %d != %d", p.Count, addr, p.Ops[p.Count].Addr)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("i2ctest: %w", err)
 	}
-	if !bytes.Equal(p.Ops[p.Count].W, w) {
-		return errorf(p.DontPanic, "i2ctest: unexpected write (count #%d) %#v != %#v", p.Count, w, p.Ops[p.Count].W)
+	i := p.Count
+	if p.ByAddr {
+		for i = 0; i < len(p.Ops); i++ {
+			if !p.isDone(i) && p.Ops[i].Addr == addr {
+				break
+			}
+		}
+	}
+	if i >= len(p.Ops) {
+		return errorf(p.DontPanic, "i2ctest: unexpected Tx() (count #%d) at addr %#x W:[% x] R:%d bytes", p.Count, addr, w, len(r))
+	}
+	op := &p.Ops[i]
+	if addr != op.Addr {
+		return errorf(p.DontPanic, "i2ctest: unexpected addr (count #%d) %#x != %#x", i, addr, op.Addr)
+	}
+	if !bytes.Equal(op.W, w) {
+		return errorf(p.DontPanic, "i2ctest: unexpected write (count #%d) at addr %#x: got [% x]; expected [% x]", i, addr, w, op.W)
 	}
-	if len(p.Ops[p.Count].R) != len(r) {
-		return errorf(p.DontPanic, "i2ctest: unexpected read buffer length (count #%d) %d != %d", p.Count, len(r), len(p.Ops[p.Count].R))
+	if len(op.R) != len(r) {
+		return errorf(p.DontPanic, "i2ctest: unexpected read buffer length (count #%d) at addr %#x: got %d; expected %d", i, addr, len(r), len(op.R))
+	}
+	copy(r, op.R)
+	if p.ByAddr {
+		if len(p.done) < len(p.Ops) {
+			p.done = append(p.done, make([]bool, len(p.Ops)-len(p.done))...)
+		}
+		p.done[i] = true
 	}
-	copy(r, p.Ops[p.Count].R)
 	p.Count++
 	return nil
 }
@@ -157,6 +219,11 @@ func (p *Playback) SDA() gpio.PinIO {
 
 //
 
+// isDone returns true if the Op i was consumed while ByAddr is set.
+func (p *Playback) isDone(i int) bool {
+	return i < len(p.done) && p.done[i]
+}
+
 // errorf is the internal implementation that optionally panic.
 //
 // If dontPanic is false, it panics instead.
@@ -171,4 +238,5 @@ func errorf(dontPanic bool, format string, a ...interface{}) error {
 var _ i2c.Bus = &Record{}
 var _ i2c.Pins = &Record{}
 var _ i2c.Bus = &Playback{}
+var _ i2c.BusCtx = &Playback{}
 var _ i2c.Pins = &Playback{}
@@ -5,7 +5,11 @@
 package i2ctest
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"periph.io/x/periph/conn/conntest"
 	"periph.io/x/periph/conn/gpio"
@@ -129,6 +133,106 @@ func TestPlayback_Tx(t *testing.T) {
 	}
 }
 
+func TestPlayback_TxCtx(t *testing.T) {
+	p := Playback{
+		Ops:   []IO{{Addr: 23, W: []byte{10}, R: []byte{12}}},
+		Delay: time.Hour,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	r := make([]byte, 1)
+	if err := p.TxCtx(ctx, 23, []byte{10}, r); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	p.Delay = time.Millisecond
+	if err := p.TxCtx(context.Background(), 23, []byte{10}, r); err != nil || r[0] != 12 {
+		t.Fatal(r, err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlayback_ByAddr(t *testing.T) {
+	p := Playback{
+		Ops: []IO{
+			{Addr: 0x48, W: []byte{1}, R: []byte{2}},
+			{Addr: 0x48, W: []byte{3}},
+			{Addr: 0x76, W: []byte{4}},
+			{Addr: 0x76, W: []byte{5}, R: []byte{6}},
+		},
+		DontPanic: true,
+		ByAddr:    true,
+	}
+	r := make([]byte, 1)
+	if err := p.Tx(0x76, []byte{4}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Tx(0x48, []byte{1}, r); err != nil || r[0] != 2 {
+		t.Fatal(r, err)
+	}
+	// The order is enforced within an address.
+	err := p.Tx(0x48, []byte{4}, nil)
+	if s := err.Error(); s != "i2ctest: unexpected write (count #1) at addr 0x48: got [04]; expected [03]" {
+		t.Fatal(s)
+	}
+	err = p.Close()
+	if s := err.Error(); s != "i2ctest: expected playback to be empty: I/O count 2; expected 4; next is #1 at addr 0x48 W:[03] R:0 bytes" {
+		t.Fatal(s)
+	}
+	if err := p.Tx(0x76, []byte{5}, r); err != nil || r[0] != 6 {
+		t.Fatal(r, err)
+	}
+	if err := p.Tx(0x48, []byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	err = p.Tx(0x48, []byte{3}, nil)
+	if s := err.Error(); s != "i2ctest: unexpected Tx() (count #4) at addr 0x48 W:[03] R:0 bytes" {
+		t.Fatal(s)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecord_concurrent(t *testing.T) {
+	// Two device drivers sharing the bus in separate goroutines.
+	var ops []IO
+	for i := byte(0); i < 100; i++ {
+		ops = append(ops, IO{Addr: 0x48, W: []byte{i}}, IO{Addr: 0x76, W: []byte{i}, R: []byte{i}})
+	}
+	r := Record{Bus: &Playback{Ops: ops, ByAddr: true}}
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for _, addr := range []uint16{0x48, 0x76} {
+		wg.Add(1)
+		go func(addr uint16) {
+			defer wg.Done()
+			for i := byte(0); i < 100; i++ {
+				var rd []byte
+				if addr == 0x76 {
+					rd = make([]byte, 1)
+				}
+				if err := r.Tx(addr, []byte{i}, rd); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(addr)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if len(r.Ops) != 200 {
+		t.Fatal(len(r.Ops))
+	}
+	if err := r.Bus.(*Playback).Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestRecord_Playback(t *testing.T) {
 	r := Record{
 		Bus: &Playback{
@@ -0,0 +1,143 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Log is a Bus that logs the transactions on the bus it wraps.
+//
+// It is meant to debug device drivers. Pass it to a driver in place of the
+// bus. Each transaction is written as a single line to Output, e.g.:
+// "15:04:05.000000 0x48 W:[01 02] R:[0a 0b] 152µs".
+//
+// It is safe for concurrent use; the lines of concurrent transactions are not
+// interleaved.
+//
+// It implements SpeedTxer and conn.Limits by forwarding to Bus.
+type Log struct {
+	// Bus is the bus to use.
+	Bus Bus
+	// Output is where the transactions are logged. When nil, nothing is logged
+	// and the transactions are passed through as is.
+	Output io.Writer
+	// Addrs restricts the logging to these device addresses. When empty, all
+	// transactions are logged.
+	Addrs []uint16
+	// Redact, if set, is called before a transaction is logged. It can
+	// overwrite the bytes of w and r, which are copies of the transaction
+	// buffers, to hide sensitive data like keys.
+	Redact func(addr uint16, w, r []byte)
+
+	mu sync.Mutex
+}
+
+func (l *Log) String() string {
+	return "log(" + l.Bus.String() + ")"
+}
+
+// Tx implements Bus.
+func (l *Log) Tx(addr uint16, w, r []byte) error {
+	return l.tx(addr, "", w, r, func() error {
+		return l.Bus.Tx(addr, w, r)
+	})
+}
+
+// TxSpeed implements SpeedTxer.
+//
+// It uses Bus.TxSpeed() if Bus implements SpeedTxer, Bus.Tx() otherwise. The
+// speed limit is logged after the address, e.g. "0x48@100kHz".
+func (l *Log) TxSpeed(addr uint16, maxSpeed physic.Frequency, w, r []byte) error {
+	s, ok := l.Bus.(SpeedTxer)
+	if !ok {
+		return l.Tx(addr, w, r)
+	}
+	return l.tx(addr, "@"+maxSpeed.String(), w, r, func() error {
+		return s.TxSpeed(addr, maxSpeed, w, r)
+	})
+}
+
+// MaxTxSize implements conn.Limits.
+//
+// It returns the limit of Bus if it implements conn.Limits, 0 otherwise.
+func (l *Log) MaxTxSize() int {
+	if c, ok := l.Bus.(conn.Limits); ok {
+		return c.MaxTxSize()
+	}
+	return 0
+}
+
+// SetSpeed implements Bus.
+func (l *Log) SetSpeed(f physic.Frequency) error {
+	err := l.Bus.SetSpeed(f)
+	if l.Output != nil {
+		line := time.Now().Format("15:04:05.000000") + " SetSpeed(" + f.String() + ")"
+		if err != nil {
+			line += ": " + err.Error()
+		}
+		l.write(line)
+	}
+	return err
+}
+
+//
+
+// tx runs the transaction and logs it. speed is appended to the address.
+func (l *Log) tx(addr uint16, speed string, w, r []byte, tx func() error) error {
+	if l.Output == nil || !l.match(addr) {
+		return tx()
+	}
+	start := time.Now()
+	err := tx()
+	d := time.Since(start)
+	if l.Redact != nil {
+		w = append([]byte(nil), w...)
+		r = append([]byte(nil), r...)
+		l.Redact(addr, w, r)
+	}
+	line := start.Format("15:04:05.000000") + fmt.Sprintf(" 0x%02x", addr) + speed
+	if len(w) != 0 {
+		line += fmt.Sprintf(" W:[% x]", w)
+	}
+	if len(r) != 0 {
+		line += fmt.Sprintf(" R:[% x]", r)
+	}
+	line += " " + d.Round(time.Microsecond).String()
+	if err != nil {
+		line += ": " + err.Error()
+	}
+	l.write(line)
+	return err
+}
+
+// match returns true if the transactions to addr are logged.
+func (l *Log) match(addr uint16) bool {
+	if len(l.Addrs) == 0 {
+		return true
+	}
+	for _, a := range l.Addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Log) write(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.Output, line+"\n")
+}
+
+var _ Bus = &Log{}
+var _ SpeedTxer = &Log{}
+var _ conn.Limits = &Log{}
@@ -0,0 +1,112 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/pin"
+)
+
+// Recoverer is implemented by a Bus that can run the bus recovery sequence.
+type Recoverer interface {
+	// Recover frees the bus when a device holds SDA low, e.g. after a
+	// transaction was interrupted.
+	//
+	// It returns an error wrapping ErrBusStuck if the bus is still not free
+	// afterward.
+	Recover() error
+}
+
+// ErrBusStuck is returned by Recoverer.Recover() when a line is still held low
+// after the bus recovery sequence.
+var ErrBusStuck = errors.New("i2c: bus is stuck")
+
+// RecoverPins runs the bus recovery sequence on the SCL and SDA pins of a bus.
+//
+// Page 20, section 3.1.16 Bus clear of the I²C specification: when SDA is
+// held low, SCL is clocked up to 9 times until the device releases SDA, then
+// a STOP condition is sent. The clock is 100kHz or slower.
+//
+// The pins are used as open drain GPIOs. If they implement pin.PinFunc,
+// their function is restored afterward, so a Bus implementation can call it
+// on the pins it normally drives in hardware. The caller must ensure the bus
+// isn't used meanwhile.
+//
+// It returns an error wrapping ErrBusStuck if SCL or SDA is still held low
+// afterward.
+func RecoverPins(scl, sda gpio.PinIO) (err error) {
+	for _, p := range []gpio.PinIO{scl, sda} {
+		if pf, ok := p.(pin.PinFunc); ok {
+			defer func(p gpio.PinIO, pf pin.PinFunc, f pin.Func) {
+				if err2 := pf.SetFunc(f); err == nil && err2 != nil {
+					err = fmt.Errorf("i2c: failed to restore %s to %s: %v", p, f, err2)
+				}
+			}(p, pf, pf.Func())
+		}
+	}
+	if err := release(scl, gpio.PullUp); err != nil {
+		return err
+	}
+	if err := release(sda, gpio.PullUp); err != nil {
+		return err
+	}
+	sleepHalfCycle()
+	if scl.Read() == gpio.Low {
+		return fmt.Errorf("%w: SCL is held low", ErrBusStuck)
+	}
+	for i := 0; i < 9 && sda.Read() == gpio.Low; i++ {
+		if err := scl.Out(gpio.Low); err != nil {
+			return fmt.Errorf("i2c: %v", err)
+		}
+		sleepHalfCycle()
+		if err := release(scl, gpio.PullNoChange); err != nil {
+			return err
+		}
+		sleepHalfCycle()
+	}
+	// STOP: SDA rises while SCL is high.
+	if err := scl.Out(gpio.Low); err != nil {
+		return fmt.Errorf("i2c: %v", err)
+	}
+	sleepHalfCycle()
+	if err := sda.Out(gpio.Low); err != nil {
+		return fmt.Errorf("i2c: %v", err)
+	}
+	sleepHalfCycle()
+	if err := release(scl, gpio.PullNoChange); err != nil {
+		return err
+	}
+	sleepHalfCycle()
+	if err := release(sda, gpio.PullNoChange); err != nil {
+		return err
+	}
+	sleepHalfCycle()
+	if scl.Read() == gpio.Low {
+		return fmt.Errorf("%w: SCL is held low", ErrBusStuck)
+	}
+	if sda.Read() == gpio.Low {
+		return fmt.Errorf("%w: SDA is held low", ErrBusStuck)
+	}
+	return nil
+}
+
+//
+
+// release releases an open drain line so it's pulled high.
+func release(p gpio.PinIO, pull gpio.Pull) error {
+	if err := p.In(pull, gpio.NoEdge); err != nil {
+		return fmt.Errorf("i2c: %v", err)
+	}
+	return nil
+}
+
+// sleepHalfCycle waits for half a cycle at 100kHz, or longer.
+func sleepHalfCycle() {
+	time.Sleep(5 * time.Microsecond)
+}
@@ -0,0 +1,174 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Retry is a Bus that retries the failed transactions on the bus it wraps.
+//
+// It is meant for buses with occasional transient errors, like a NACK caused
+// by noise on a long cable. Pass it to a device driver in place of the bus.
+//
+// An error is permanent and is not retried when the address is invalid, as
+// reported by CheckAddr(), or when it wraps ErrNotSupported,
+// context.Canceled or context.DeadlineExceeded.
+//
+// Keep in mind that a transaction that failed may still have had an effect
+// on the device, e.g. a write that succeeded but whose last ACK was lost.
+//
+// It implements SpeedTxer and conn.Limits by forwarding to Bus.
+type Retry struct {
+	// Bus is the bus to use.
+	Bus Bus
+	// Attempts is the maximum number of times a transaction is tried. Values
+	// below 1 are treated as 1, i.e. no retry.
+	Attempts int
+	// Backoff is the delay before the first retry. It is doubled before each
+	// following retry, and a random jitter of up to half of it is added.
+	Backoff time.Duration
+
+	mu       sync.Mutex
+	retries  int
+	failures int
+}
+
+func (r *Retry) String() string {
+	return "retry(" + r.Bus.String() + ")"
+}
+
+// Tx implements Bus.
+//
+// It returns the error of the last attempt.
+func (r *Retry) Tx(addr uint16, w, rd []byte) error {
+	return r.TxCtx(context.Background(), addr, w, rd)
+}
+
+// TxCtx implements BusCtx.
+//
+// It doesn't wait for the backoff once ctx is done, and returns ctx.Err()
+// instead of retrying. Each attempt uses Bus.TxCtx() if Bus implements
+// BusCtx.
+func (r *Retry) TxCtx(ctx context.Context, addr uint16, w, rd []byte) error {
+	if err := CheckAddr(addr); err != nil {
+		return err
+	}
+	if b, ok := r.Bus.(BusCtx); ok {
+		return r.do(ctx, func() error {
+			return b.TxCtx(ctx, addr, w, rd)
+		})
+	}
+	return r.do(ctx, func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return r.Bus.Tx(addr, w, rd)
+	})
+}
+
+// TxSpeed implements SpeedTxer.
+//
+// Each attempt uses Bus.TxSpeed() if Bus implements SpeedTxer, Bus.Tx()
+// otherwise.
+func (r *Retry) TxSpeed(addr uint16, maxSpeed physic.Frequency, w, rd []byte) error {
+	if err := CheckAddr(addr); err != nil {
+		return err
+	}
+	s, ok := r.Bus.(SpeedTxer)
+	if !ok {
+		return r.Tx(addr, w, rd)
+	}
+	return r.do(context.Background(), func() error {
+		return s.TxSpeed(addr, maxSpeed, w, rd)
+	})
+}
+
+// SetSpeed implements Bus.
+func (r *Retry) SetSpeed(f physic.Frequency) error {
+	return r.Bus.SetSpeed(f)
+}
+
+// MaxTxSize implements conn.Limits.
+//
+// It returns the limit of Bus if it implements conn.Limits, 0 otherwise.
+func (r *Retry) MaxTxSize() int {
+	if l, ok := r.Bus.(conn.Limits); ok {
+		return l.MaxTxSize()
+	}
+	return 0
+}
+
+// Retries returns the number of retries done so far.
+func (r *Retry) Retries() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retries
+}
+
+// Failures returns the number of transactions that failed after all their
+// attempts.
+func (r *Retry) Failures() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failures
+}
+
+//
+
+// do calls tx until it succeeds, up to r.Attempts times.
+func (r *Retry) do(ctx context.Context, tx func() error) error {
+	backoff := r.Backoff
+	for i := 1; ; i++ {
+		err := tx()
+		if err == nil {
+			return nil
+		}
+		if i >= r.Attempts || isPermanent(err) {
+			r.fail()
+			return err
+		}
+		if backoff > 0 {
+			d := backoff + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+			case <-t.C:
+			}
+			t.Stop()
+			backoff *= 2
+		}
+		if err := ctx.Err(); err != nil {
+			r.fail()
+			return err
+		}
+		r.mu.Lock()
+		r.retries++
+		r.mu.Unlock()
+	}
+}
+
+func (r *Retry) fail() {
+	r.mu.Lock()
+	r.failures++
+	r.mu.Unlock()
+}
+
+// isPermanent returns true if err won't go away by retrying.
+func isPermanent(err error) bool {
+	return errors.Is(err, ErrNotSupported) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+var _ Bus = &Retry{}
+var _ BusCtx = &Retry{}
+var _ SpeedTxer = &Retry{}
+var _ conn.Limits = &Retry{}
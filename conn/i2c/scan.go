@@ -0,0 +1,99 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// QuickWriter is implemented by a Bus that supports the SMBus quick write
+// command, which sends the device address with the write bit and no data.
+//
+// It is the least intrusive way to detect a device, since the device doesn't
+// receive any byte.
+type QuickWriter interface {
+	// QuickWrite sends a quick write command at the specified device address.
+	//
+	// It returns nil if a device acknowledged its address. It returns an error
+	// wrapping ErrQuickWriteUnsupported if the bus can't send the command.
+	QuickWrite(addr uint16) error
+}
+
+// ErrQuickWriteUnsupported is returned by QuickWriter.QuickWrite() when the
+// bus can't send a quick write command.
+var ErrQuickWriteUnsupported = errors.New("i2c: quick write is not supported")
+
+// ScanOpts are the options for Scan.
+type ScanOpts struct {
+	// Start and End are the first and last addresses to probe, inclusive.
+	//
+	// The addresses reserved by the I²C specification, 0x00 to 0x07 and 0x78
+	// to 0x7F, are never probed. When End is 0, it defaults to 0x77.
+	Start, End uint16
+}
+
+// Scan probes the addresses of a bus and returns the ones acknowledged by a
+// device, in increasing order.
+//
+// Scan uses the same strategy as i2cdetect. It probes with a quick write when
+// the bus implements QuickWriter, except in the ranges 0x30 to 0x37 and 0x50
+// to 0x5F, where it reads one byte instead. A quick write can be interpreted
+// as a command by some devices in these ranges, e.g. it can lock the write
+// protection of some EEPROMs. When the bus doesn't support quick write, all
+// addresses are probed with a one byte read, which can upset some write-only
+// devices.
+//
+// Each probe is a separate transaction, so other users of the bus are
+// serialized between probes by the bus itself. Scan returns ctx.Err() with
+// the addresses found so far if ctx is done before all the addresses are
+// probed.
+func Scan(ctx context.Context, b Bus, opts *ScanOpts) ([]uint16, error) {
+	start, end := uint16(0x08), uint16(0x77)
+	if opts != nil {
+		if opts.Start > start {
+			start = opts.Start
+		}
+		if opts.End != 0 && opts.End < end {
+			end = opts.End
+		}
+		if start > end {
+			return nil, fmt.Errorf("i2c: invalid scan range %#x to %#x", opts.Start, opts.End)
+		}
+	}
+	q, _ := b.(QuickWriter)
+	var out []uint16
+	var buf [1]byte
+	for addr := start; addr <= end; addr++ {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		var err error
+		if q != nil && !readProbe(addr) {
+			if err = q.QuickWrite(addr); errors.Is(err, ErrQuickWriteUnsupported) {
+				q = nil
+			}
+		}
+		if q == nil || readProbe(addr) {
+			err = b.Tx(addr, nil, buf[:])
+		}
+		if err == nil {
+			out = append(out, addr)
+		}
+	}
+	return out, nil
+}
+
+//
+
+// readProbe returns true if addr must be probed with a read instead of a
+// quick write.
+//
+// These ranges are commonly used by EEPROMs and their write protection, which
+// a quick write can trigger.
+func readProbe(addr uint16) bool {
+	return (addr >= 0x30 && addr <= 0x37) || (addr >= 0x50 && addr <= 0x5F)
+}
@@ -0,0 +1,252 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package smbus implements the SMBus protocol over an I²C bus.
+//
+// SMBus is a subset of I²C with well defined transactions, where each
+// transaction starts with a command byte, and optional packet error checking
+// (PEC), a CRC-8 over the whole transaction including the address bytes.
+//
+// The transactions are done natively when the bus implements Bus, like the
+// Linux kernel I²C driver, and are otherwise encoded as I²C transactions via
+// i2c.Bus.Tx().
+//
+// See http://smbus.org/specs/ for more information.
+package smbus
+
+import (
+	"errors"
+	"fmt"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+// BlockMax is the maximum number of bytes in a block transaction.
+const BlockMax = 32
+
+// Protocol is a SMBus transaction type.
+type Protocol uint8
+
+// Transactions supported by Dev.
+const (
+	// ByteData writes or reads one byte after a command byte.
+	ByteData Protocol = iota + 1
+	// WordData writes or reads a 16 bits little endian word after a command
+	// byte.
+	WordData
+	// ProcessCall writes a word after a command byte then reads a word.
+	ProcessCall
+	// BlockData writes or reads a byte count followed by up to BlockMax
+	// bytes after a command byte.
+	BlockData
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case ByteData:
+		return "ByteData"
+	case WordData:
+		return "WordData"
+	case ProcessCall:
+		return "ProcessCall"
+	case BlockData:
+		return "BlockData"
+	default:
+		return fmt.Sprintf("Protocol(%d)", uint8(p))
+	}
+}
+
+// Bus is implemented by an i2c.Bus that can do SMBus transactions natively.
+//
+// It permits to use adapters that only support SMBus and to leave the PEC
+// handling to the hardware.
+type Bus interface {
+	i2c.Bus
+	// SMBusTx does the transaction p with the command byte cmd at the specified
+	// device address and returns the number of bytes read.
+	//
+	// w is the data written after the command byte and r receives the data
+	// read; one of them is empty, except for ProcessCall. A word is 2 bytes
+	// in little endian. For BlockData, w is the block without the byte count
+	// and r must be BlockMax bytes long.
+	//
+	// It returns an error wrapping i2c.ErrNotSupported when the bus can't do
+	// this transaction, in which case Dev falls back to i2c.Bus.Tx().
+	SMBusTx(addr uint16, p Protocol, cmd byte, pec bool, w, r []byte) (int, error)
+}
+
+// Dev is a SMBus device on an I²C bus.
+//
+// The embedded i2c.Dev can still be used for raw I²C transactions.
+type Dev struct {
+	i2c.Dev
+	// PEC enables packet error checking. A CRC-8 is appended to the written
+	// bytes and the one sent by the device is verified.
+	PEC bool
+}
+
+// ErrPEC is returned when the PEC sent by the device doesn't match the data
+// read.
+var ErrPEC = errors.New("smbus: packet error check mismatch")
+
+// ReadByteData reads one byte from the register cmd.
+func (d *Dev) ReadByteData(cmd byte) (byte, error) {
+	var r [1]byte
+	if _, err := d.tx(ByteData, cmd, nil, r[:]); err != nil {
+		return 0, err
+	}
+	return r[0], nil
+}
+
+// WriteByteData writes one byte to the register cmd.
+func (d *Dev) WriteByteData(cmd, v byte) error {
+	_, err := d.tx(ByteData, cmd, []byte{v}, nil)
+	return err
+}
+
+// ReadWordData reads a word from the register cmd.
+func (d *Dev) ReadWordData(cmd byte) (uint16, error) {
+	var r [2]byte
+	if _, err := d.tx(WordData, cmd, nil, r[:]); err != nil {
+		return 0, err
+	}
+	return uint16(r[0]) | uint16(r[1])<<8, nil
+}
+
+// WriteWordData writes a word to the register cmd.
+func (d *Dev) WriteWordData(cmd byte, v uint16) error {
+	_, err := d.tx(WordData, cmd, []byte{byte(v), byte(v >> 8)}, nil)
+	return err
+}
+
+// ProcessCall writes a word to the register cmd and returns the word the
+// device replies with.
+func (d *Dev) ProcessCall(cmd byte, v uint16) (uint16, error) {
+	var r [2]byte
+	if _, err := d.tx(ProcessCall, cmd, []byte{byte(v), byte(v >> 8)}, r[:]); err != nil {
+		return 0, err
+	}
+	return uint16(r[0]) | uint16(r[1])<<8, nil
+}
+
+// ReadBlockData reads a block of up to BlockMax bytes from the register cmd.
+//
+// Without native support from the bus, BlockMax bytes are always read since
+// the length isn't known in advance; the device is expected to send 0xFF past
+// the end of the block.
+func (d *Dev) ReadBlockData(cmd byte) ([]byte, error) {
+	var r [BlockMax]byte
+	n, err := d.tx(BlockData, cmd, nil, r[:])
+	if err != nil {
+		return nil, err
+	}
+	return r[:n], nil
+}
+
+// WriteBlockData writes a block of up to BlockMax bytes to the register cmd.
+func (d *Dev) WriteBlockData(cmd byte, b []byte) error {
+	if len(b) == 0 || len(b) > BlockMax {
+		return fmt.Errorf("smbus: invalid block length %d; must be between 1 and %d", len(b), BlockMax)
+	}
+	_, err := d.tx(BlockData, cmd, b, nil)
+	return err
+}
+
+//
+
+// tx does the transaction natively if supported, otherwise over Tx().
+func (d *Dev) tx(p Protocol, cmd byte, w, r []byte) (int, error) {
+	if err := i2c.CheckAddr(d.Addr); err != nil {
+		return 0, err
+	}
+	if b, ok := d.Bus.(Bus); ok {
+		n, err := b.SMBusTx(d.Addr, p, cmd, d.PEC, w, r)
+		if !errors.Is(err, i2c.ErrNotSupported) {
+			return n, err
+		}
+	}
+	// The wire format is: the command byte, the byte count for a block write,
+	// the data and the PEC.
+	buf := make([]byte, 0, 2+len(w)+1)
+	buf = append(buf, cmd)
+	if p == BlockData && len(w) != 0 {
+		buf = append(buf, byte(len(w)))
+	}
+	buf = append(buf, w...)
+	if len(r) == 0 {
+		if d.PEC {
+			buf = append(buf, pec(pec(0, d.addrBytes(false)), buf))
+		}
+		return 0, d.Bus.Tx(d.Addr, buf, nil)
+	}
+	// The device sends the byte count for a block read, the data and the PEC.
+	l := len(r)
+	if p == BlockData {
+		l++
+	}
+	if d.PEC {
+		l++
+	}
+	in := make([]byte, l)
+	if err := d.Bus.Tx(d.Addr, buf, in); err != nil {
+		return 0, err
+	}
+	data := in
+	if p == BlockData {
+		n := int(in[0])
+		if n > BlockMax {
+			return 0, fmt.Errorf("smbus: invalid block length %d", n)
+		}
+		// Ignore the bytes read past the block and its PEC.
+		if d.PEC {
+			n++
+		}
+		in = in[:1+n]
+		data = in[1:]
+	}
+	if d.PEC {
+		c := pec(pec(pec(0, d.addrBytes(false)), buf), d.addrBytes(true))
+		if c = pec(c, in[:len(in)-1]); c != in[len(in)-1] {
+			return 0, ErrPEC
+		}
+		data = data[:len(data)-1]
+	}
+	return copy(r, data), nil
+}
+
+// addrBytes returns the address bytes as sent on the wire, including the
+// read bit.
+//
+// A 10 bits address is two bytes in write mode; in read mode after a repeated
+// start, only the first byte is sent.
+func (d *Dev) addrBytes(read bool) []byte {
+	var rd byte
+	if read {
+		rd = 1
+	}
+	if d.Addr&i2c.TenBit == 0 {
+		return []byte{byte(d.Addr)<<1 | rd}
+	}
+	a := d.Addr &^ i2c.TenBit
+	b := 0xF0 | byte(a>>7)&6 | rd
+	if read {
+		return []byte{b}
+	}
+	return []byte{b, byte(a)}
+}
+
+// pec updates the CRC-8 c, polynomial x⁸+x²+x+1, with b.
+func pec(c byte, b []byte) byte {
+	for _, v := range b {
+		c ^= v
+		for i := 0; i < 8; i++ {
+			if c&0x80 != 0 {
+				c = c<<1 ^ 0x07
+			} else {
+				c <<= 1
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,209 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package smbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+)
+
+func TestPEC(t *testing.T) {
+	// CRC-8/SMBUS check value.
+	if c := pec(0, []byte("123456789")); c != 0xF4 {
+		t.Fatalf("%#x", c)
+	}
+	// Example from the MLX90614 datasheet: read of the RAM address 0x07.
+	if c := pec(0, []byte{0xB4, 0x07, 0xB5, 0xD2, 0x3A}); c != 0x30 {
+		t.Fatalf("%#x", c)
+	}
+}
+
+func TestDev_ReadWordData_PEC(t *testing.T) {
+	b := i2ctest.Playback{
+		Ops: []i2ctest.IO{{Addr: 0x5A, W: []byte{0x07}, R: []byte{0xD2, 0x3A, 0x30}}},
+	}
+	d := Dev{Dev: i2c.Dev{Bus: &b, Addr: 0x5A}, PEC: true}
+	v, err := d.ReadWordData(0x07)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 0x3AD2 {
+		t.Fatalf("%#x", v)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev(t *testing.T) {
+	b := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x0B, W: []byte{0x10}, R: []byte{0x42}},
+			{Addr: 0x0B, W: []byte{0x10, 0x42}},
+			{Addr: 0x0B, W: []byte{0x11}, R: []byte{0x34, 0x12}},
+			{Addr: 0x0B, W: []byte{0x11, 0x34, 0x12}},
+			{Addr: 0x0B, W: []byte{0x12, 0x34, 0x12}, R: []byte{0x78, 0x56}},
+			{Addr: 0x0B, W: []byte{0x13}, R: append([]byte{3, 1, 2, 3}, bytes.Repeat([]byte{0xFF}, BlockMax-3)...)},
+			{Addr: 0x0B, W: []byte{0x13, 3, 1, 2, 3}},
+		},
+	}
+	d := Dev{Dev: i2c.Dev{Bus: &b, Addr: 0x0B}}
+	if v, err := d.ReadByteData(0x10); err != nil || v != 0x42 {
+		t.Fatal(v, err)
+	}
+	if err := d.WriteByteData(0x10, 0x42); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d.ReadWordData(0x11); err != nil || v != 0x1234 {
+		t.Fatal(v, err)
+	}
+	if err := d.WriteWordData(0x11, 0x1234); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d.ProcessCall(0x12, 0x1234); err != nil || v != 0x5678 {
+		t.Fatal(v, err)
+	}
+	if v, err := d.ReadBlockData(0x13); err != nil || !bytes.Equal(v, []byte{1, 2, 3}) {
+		t.Fatal(v, err)
+	}
+	if err := d.WriteBlockData(0x13, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_PEC(t *testing.T) {
+	// The address byte is 0x16 for a write and 0x17 for a read.
+	b := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x0B, W: []byte{0x10, 0x42, 0x41}},
+			{Addr: 0x0B, W: []byte{0x10}, R: []byte{0x42, 0x44}},
+			{Addr: 0x0B, W: []byte{0x20}, R: append([]byte{2, 0x0A, 0x0B, 0x6C}, bytes.Repeat([]byte{0xFF}, BlockMax-2)...)},
+			{Addr: 0x0B, W: []byte{0x10}, R: []byte{0x42, 0x45}},
+		},
+	}
+	d := Dev{Dev: i2c.Dev{Bus: &b, Addr: 0x0B}, PEC: true}
+	if err := d.WriteByteData(0x10, 0x42); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d.ReadByteData(0x10); err != nil || v != 0x42 {
+		t.Fatal(v, err)
+	}
+	if v, err := d.ReadBlockData(0x20); err != nil || !bytes.Equal(v, []byte{0x0A, 0x0B}) {
+		t.Fatal(v, err)
+	}
+	if _, err := d.ReadByteData(0x10); err != ErrPEC {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_PEC_tenBit(t *testing.T) {
+	// The address bytes are 0xF2 0xA3 for a write.
+	b := i2ctest.Playback{
+		Ops: []i2ctest.IO{{Addr: 0x1A3 | i2c.TenBit, W: []byte{0x10, 0x42, 0x44}}},
+	}
+	d := Dev{Dev: i2c.Dev{Bus: &b, Addr: 0x1A3 | i2c.TenBit}, PEC: true}
+	if err := d.WriteByteData(0x10, 0x42); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_error(t *testing.T) {
+	b := i2ctest.Playback{
+		Ops:       []i2ctest.IO{{Addr: 0x0B, W: []byte{0x13}, R: append([]byte{BlockMax + 1}, make([]byte, BlockMax)...)}},
+		DontPanic: true,
+	}
+	d := Dev{Dev: i2c.Dev{Bus: &b, Addr: 0x0B}}
+	if _, err := d.ReadBlockData(0x13); err == nil {
+		t.Fatal("invalid block length")
+	}
+	if d.WriteBlockData(0x13, nil) == nil {
+		t.Fatal("empty block")
+	}
+	if d.WriteBlockData(0x13, make([]byte, BlockMax+1)) == nil {
+		t.Fatal("block too long")
+	}
+	if _, err := d.ReadByteData(0x10); err == nil {
+		t.Fatal("unexpected Tx")
+	}
+	d.Addr = 0x78
+	if _, err := d.ReadByteData(0x10); err == nil {
+		t.Fatal("invalid address")
+	}
+}
+
+func TestDev_native(t *testing.T) {
+	b := &nativeBus{r: []byte{1, 2}}
+	d := Dev{Dev: i2c.Dev{Bus: b, Addr: 0x0B}, PEC: true}
+	if v, err := d.ReadBlockData(0x20); err != nil || !bytes.Equal(v, []byte{1, 2}) {
+		t.Fatal(v, err)
+	}
+	if b.p != BlockData || b.cmd != 0x20 || !b.pec || len(b.tx) != 0 {
+		t.Fatalf("%#v", b)
+	}
+
+	// Falls back to Tx().
+	b.err = i2c.ErrNotSupported
+	if err := d.WriteByteData(0x10, 0x42); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.tx, []byte{0x10, 0x42, 0x41}) {
+		t.Fatalf("%#v", b.tx)
+	}
+
+	b.err = errors.New("oops")
+	if err := d.WriteByteData(0x10, 0x42); err != b.err {
+		t.Fatal(err)
+	}
+}
+
+func TestProtocol_String(t *testing.T) {
+	if s := BlockData.String(); s != "BlockData" {
+		t.Fatal(s)
+	}
+	if s := Protocol(0).String(); s != "Protocol(0)" {
+		t.Fatal(s)
+	}
+}
+
+//
+
+// nativeBus implements Bus.
+type nativeBus struct {
+	i2ctest.Record
+	p   Protocol
+	cmd byte
+	pec bool
+	r   []byte
+	tx  []byte
+	err error
+}
+
+func (n *nativeBus) Tx(addr uint16, w, r []byte) error {
+	n.tx = append(n.tx, w...)
+	return nil
+}
+
+func (n *nativeBus) SMBusTx(addr uint16, p Protocol, cmd byte, pec bool, w, r []byte) (int, error) {
+	n.p, n.cmd, n.pec = p, cmd, pec
+	if n.err != nil {
+		return 0, n.err
+	}
+	return copy(r, n.r), nil
+}
+
+var _ Bus = &nativeBus{}
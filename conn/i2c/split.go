@@ -0,0 +1,131 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"errors"
+	"fmt"
+
+	"periph.io/x/periph/conn"
+)
+
+// SplitMode defines how the prefix of a write is adapted in each chunk by
+// Splitter.
+type SplitMode int
+
+const (
+	// SplitRepeat sends the same prefix at the start of each chunk.
+	//
+	// It is meant for a device that streams the data after a control byte, like
+	// the ssd1306, or that writes to a FIFO register.
+	SplitRepeat SplitMode = iota
+	// SplitIncrement adds the offset of the chunk to the prefix, read as a big
+	// endian register address.
+	//
+	// It is meant for a device with a byte addressed memory that increments the
+	// address after each byte, like an EEPROM, so each chunk continues where the
+	// previous one stopped.
+	SplitIncrement
+)
+
+// Splitter is a conn.Conn that splits the writes that are too large for the
+// bus in multiple transactions.
+//
+// The first PrefixLen bytes of each write, like a register address or a
+// control byte, are sent at the start of each chunk as defined by Mode.
+//
+// A transaction that reads is never split; it returns an error if the write
+// is too large.
+type Splitter struct {
+	// Conn is the connection to the device, usually a *Dev.
+	Conn conn.Conn
+	// PrefixLen is the number of bytes at the start of each write that address
+	// the data.
+	PrefixLen int
+	// Mode defines the prefix of each chunk.
+	Mode SplitMode
+	// MaxTxSize is the maximum size of a write, including the prefix. When 0,
+	// the limit of Conn is used if it implements conn.Limits.
+	MaxTxSize int
+}
+
+func (s *Splitter) String() string {
+	return s.Conn.String()
+}
+
+// Duplex implements conn.Conn.
+func (s *Splitter) Duplex() conn.Duplex {
+	return s.Conn.Duplex()
+}
+
+// Tx implements conn.Conn.
+func (s *Splitter) Tx(w, r []byte) error {
+	max := s.limit()
+	if max == 0 || len(w) <= max {
+		return s.Conn.Tx(w, r)
+	}
+	if len(r) != 0 {
+		return fmt.Errorf("i2c: can't split a write of %d bytes followed by a read; maximum is %d bytes", len(w), max)
+	}
+	if s.PrefixLen < 0 || s.PrefixLen >= max {
+		return fmt.Errorf("i2c: invalid prefix length %d for a maximum of %d bytes", s.PrefixLen, max)
+	}
+	prefix, data := w[:s.PrefixLen], w[s.PrefixLen:]
+	n := max - s.PrefixLen
+	buf := make([]byte, 0, max)
+	for offset := 0; offset < len(data); offset += n {
+		end := offset + n
+		if end > len(data) {
+			end = len(data)
+		}
+		buf = append(buf[:0], prefix...)
+		if s.Mode == SplitIncrement {
+			if err := addOffset(buf, offset); err != nil {
+				return err
+			}
+		}
+		buf = append(buf, data[offset:end]...)
+		if err := s.Conn.Tx(buf, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write implements io.Writer.
+func (s *Splitter) Write(b []byte) (int, error) {
+	if err := s.Tx(b, nil); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+//
+
+// limit returns the maximum size of a write.
+func (s *Splitter) limit() int {
+	if s.MaxTxSize != 0 {
+		return s.MaxTxSize
+	}
+	if l, ok := s.Conn.(conn.Limits); ok {
+		return l.MaxTxSize()
+	}
+	return 0
+}
+
+// addOffset adds offset to the big endian number in b.
+func addOffset(b []byte, offset int) error {
+	for i := len(b) - 1; i >= 0 && offset != 0; i-- {
+		v := int(b[i]) + offset
+		b[i] = byte(v)
+		offset = v >> 8
+	}
+	if offset != 0 {
+		return errors.New("i2c: register address overflow")
+	}
+	return nil
+}
+
+var _ conn.Conn = &Splitter{}
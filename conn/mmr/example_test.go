@@ -92,3 +92,12 @@ func ExampleDev8_WriteStruct() {
 		log.Fatal(err)
 	}
 }
+
+func ExampleFormatDump() {
+	// The registers 0x0E to 0x11 as returned by Dev8.Dump(0x0E, 4).
+	fmt.Print(mmr.FormatDump(0x0E, []byte{0x60, 0x00, 0x1F, 0x80}))
+	// Output:
+	//        0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f
+	// 0x00:                                           60 00
+	// 0x10: 1f 80
+}
@@ -16,6 +16,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"periph.io/x/periph/conn"
 )
@@ -86,6 +87,32 @@ func (d *Dev8) ReadUint64(reg uint8) (uint64, error) {
 	return d.Order.Uint64(v[:]), err
 }
 
+// ReadBlock reads consecutive registers starting at reg into b, in a single
+// transaction.
+//
+// It relies on the device incrementing the register address after each byte,
+// which is common.
+func (d *Dev8) ReadBlock(reg uint8, b []byte) error {
+	if err := d.check(); err != nil {
+		return err
+	}
+	return d.Conn.Tx([]byte{reg}, b)
+}
+
+// Dump reads count registers starting at start, to help debugging.
+//
+// It is a wrapper for ReadBlock(). Use FormatDump() to print the registers.
+func (d *Dev8) Dump(start, count uint8) ([]byte, error) {
+	if int(start)+int(count) > 0x100 {
+		return nil, fmt.Errorf("mmr: registers %#x to %#x are out of range", start, int(start)+int(count)-1)
+	}
+	b := make([]byte, count)
+	if err := d.ReadBlock(start, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
 // ReadStruct writes the register number to the connection, then reads the data
 // into `b` and marshall it via `.Order` as appropriate.
 //
@@ -139,6 +166,21 @@ func (d *Dev8) WriteUint64(reg uint8, v uint64) error {
 	return d.Conn.Tx(a[:], nil)
 }
 
+// WriteBlock writes b to consecutive registers starting at reg, in a single
+// transaction.
+//
+// It relies on the device incrementing the register address after each byte,
+// which is common.
+func (d *Dev8) WriteBlock(reg uint8, b []byte) error {
+	if err := d.check(); err != nil {
+		return err
+	}
+	w := make([]byte, 1+len(b))
+	w[0] = reg
+	copy(w[1:], b)
+	return d.Conn.Tx(w, nil)
+}
+
 // WriteStruct writes the register number to the connection, then the data
 // `b` marshalled via `.Order` as appropriate.
 //
@@ -234,6 +276,34 @@ func (d *Dev16) ReadUint64(reg uint16) (uint64, error) {
 	return d.Order.Uint64(v[:]), err
 }
 
+// ReadBlock reads consecutive registers starting at reg into b, in a single
+// transaction.
+//
+// It relies on the device incrementing the register address after each byte,
+// which is common.
+func (d *Dev16) ReadBlock(reg uint16, b []byte) error {
+	if err := d.check(); err != nil {
+		return err
+	}
+	var r [2]byte
+	d.Order.PutUint16(r[:], reg)
+	return d.Conn.Tx(r[:], b)
+}
+
+// Dump reads count registers starting at start, to help debugging.
+//
+// It is a wrapper for ReadBlock(). Use FormatDump() to print the registers.
+func (d *Dev16) Dump(start, count uint16) ([]byte, error) {
+	if int(start)+int(count) > 0x10000 {
+		return nil, fmt.Errorf("mmr: registers %#x to %#x are out of range", start, int(start)+int(count)-1)
+	}
+	b := make([]byte, count)
+	if err := d.ReadBlock(start, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
 // ReadStruct writes the register number to the connection, then reads the data
 // into `b` and marshall it via `.Order` as appropriate.
 //
@@ -292,6 +362,21 @@ func (d *Dev16) WriteUint64(reg uint16, v uint64) error {
 	return d.Conn.Tx(r[:], nil)
 }
 
+// WriteBlock writes b to consecutive registers starting at reg, in a single
+// transaction.
+//
+// It relies on the device incrementing the register address after each byte,
+// which is common.
+func (d *Dev16) WriteBlock(reg uint16, b []byte) error {
+	if err := d.check(); err != nil {
+		return err
+	}
+	w := make([]byte, 2+len(b))
+	d.Order.PutUint16(w, reg)
+	copy(w[2:], b)
+	return d.Conn.Tx(w, nil)
+}
+
 // WriteStruct writes the register number to the connection, then the data
 // `b` marshalled via `.Order` as appropriate.
 //
@@ -313,6 +398,36 @@ func (d *Dev16) check() error {
 	return nil
 }
 
+// FormatDump formats registers read with Dump() as an hexadecimal table.
+//
+// Each line holds up to 16 registers and starts with the address of the
+// first column, aligned on 16 registers.
+func FormatDump(start uint16, b []byte) string {
+	prefix := "0x%02x:"
+	if int(start)+len(b) > 0x100 {
+		prefix = "0x%04x:"
+	}
+	var buf bytes.Buffer
+	pad := len(fmt.Sprintf(prefix, 0))
+	buf.WriteString(strings.Repeat(" ", pad))
+	for i := 0; i < 16; i++ {
+		fmt.Fprintf(&buf, "  %x", i)
+	}
+	buf.WriteByte('\n')
+	for i, v := range b {
+		a := int(start) + i
+		if i == 0 || a%16 == 0 {
+			fmt.Fprintf(&buf, prefix, a&^0xF)
+			buf.WriteString(strings.Repeat("   ", a%16))
+		}
+		fmt.Fprintf(&buf, " %02x", v)
+		if a%16 == 15 || i == len(b)-1 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String()
+}
+
 //
 
 func readReg(c conn.Conn, order binary.ByteOrder, reg []byte, b interface{}) error {
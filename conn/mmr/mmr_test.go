@@ -527,6 +527,115 @@ func TestDev16_WriteStruct_uint16(t *testing.T) {
 
 //
 
+func TestDev8_Block(t *testing.T) {
+	c := &conntest.Playback{
+		Ops: []conntest.IO{
+			{W: []byte{0x10}, R: []byte{1, 2, 3}},
+			{W: []byte{0x20, 4, 5}},
+			{W: []byte{0xF0}, R: []byte{6, 7}},
+		},
+		D:         conn.Half,
+		DontPanic: true,
+	}
+	d := Dev8{Conn: c, Order: binary.BigEndian}
+	r := make([]byte, 3)
+	if err := d.ReadBlock(0x10, r); err != nil || !bytes.Equal(r, []byte{1, 2, 3}) {
+		t.Fatal(r, err)
+	}
+	if err := d.WriteBlock(0x20, []byte{4, 5}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := d.Dump(0xF0, 2); err != nil || !bytes.Equal(b, []byte{6, 7}) {
+		t.Fatal(b, err)
+	}
+	if _, err := d.Dump(0xF0, 0x11); err == nil {
+		t.Fatal("out of range")
+	}
+	if _, err := d.Dump(0, 1); err == nil {
+		t.Fatal("playback is empty")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	d = Dev8{Conn: &conntest.Discard{D: conn.Full}, Order: binary.BigEndian}
+	if d.ReadBlock(0x10, r) == nil {
+		t.Fatal("full duplex")
+	}
+	if d.WriteBlock(0x10, r) == nil {
+		t.Fatal("full duplex")
+	}
+}
+
+func TestDev16_Block(t *testing.T) {
+	c := &conntest.Playback{
+		Ops: []conntest.IO{
+			{W: []byte{0x01, 0x10}, R: []byte{1, 2, 3}},
+			{W: []byte{0x20, 0x01, 4, 5}},
+			{W: []byte{0xFF, 0xF0}, R: []byte{6, 7}},
+		},
+		D:         conn.Half,
+		DontPanic: true,
+	}
+	d := Dev16{Conn: c, Order: binary.BigEndian}
+	r := make([]byte, 3)
+	if err := d.ReadBlock(0x0110, r); err != nil || !bytes.Equal(r, []byte{1, 2, 3}) {
+		t.Fatal(r, err)
+	}
+	d.Order = binary.LittleEndian
+	if err := d.WriteBlock(0x0120, []byte{4, 5}); err != nil {
+		t.Fatal(err)
+	}
+	d.Order = binary.BigEndian
+	if b, err := d.Dump(0xFFF0, 2); err != nil || !bytes.Equal(b, []byte{6, 7}) {
+		t.Fatal(b, err)
+	}
+	if _, err := d.Dump(0xFFF0, 0x11); err == nil {
+		t.Fatal("out of range")
+	}
+	if _, err := d.Dump(0, 1); err == nil {
+		t.Fatal("playback is empty")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	d = Dev16{Conn: &conntest.Discard{D: conn.Full}, Order: binary.BigEndian}
+	if d.ReadBlock(0x10, r) == nil {
+		t.Fatal("full duplex")
+	}
+	if d.WriteBlock(0x10, r) == nil {
+		t.Fatal("full duplex")
+	}
+}
+
+func TestFormatDump(t *testing.T) {
+	data := []struct {
+		start    uint16
+		b        []byte
+		expected string
+	}{
+		{0, nil, "       0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f\n"},
+		{
+			0x0E,
+			[]byte{1, 2, 3},
+			"       0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f\n" +
+				"0x00:                                           01 02\n" +
+				"0x10: 03\n",
+		},
+		{
+			0xFF,
+			[]byte{0xAB, 0xCD},
+			"         0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f\n" +
+				"0x00f0:                                              ab\n" +
+				"0x0100: cd\n",
+		},
+	}
+	for i, line := range data {
+		if s := FormatDump(line.start, line.b); s != line.expected {
+			t.Fatalf("#%d:\n%s", i, s)
+		}
+	}
+}
+
 func TestEdgeCases(t *testing.T) {
 	if getSize(reflect.ValueOf(nil)) != 0 {
 		t.FailNow()
@@ -6,4 +6,11 @@
 // units.
 //
 // This includes temperature, humidity, pressure, tension, current, etc.
+//
+// The most common quantities can be parsed with their Set() method, which
+// makes them usable as command line flags, and are encoded in JSON as exact
+// strings like "23.45°C". A JSON number in the base unit, e.g. volts or
+// kelvins, is also accepted when decoding; it is rounded to the resolution
+// of the type, but encoders using float64 only keep 15 to 17 significant
+// digits.
 package physic
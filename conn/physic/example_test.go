@@ -6,11 +6,20 @@ package physic_test
 
 import (
 	"fmt"
+	"log"
 	"time"
 
 	"periph.io/x/periph/conn/physic"
 )
 
+func ExampleAcceleration() {
+	fmt.Println(physic.StandardGravity)
+	fmt.Println(-3 * physic.MilliMetrePerSecondSquared)
+	// Output:
+	// 9.807m/s²
+	// -3mm/s²
+}
+
 func ExampleAngle() {
 	fmt.Println(physic.Degree)
 	fmt.Println(physic.Pi)
@@ -21,6 +30,23 @@ func ExampleAngle() {
 	// 360.0°
 }
 
+func ExampleAngle_Diff() {
+	// The rotation from a heading of 359° to 1° is 2°, not -358°.
+	fmt.Println((1 * physic.Degree).Diff(359 * physic.Degree))
+	fmt.Println((359 * physic.Degree).Diff(1 * physic.Degree))
+	// Output:
+	// 2.000°
+	// -2.000°
+}
+
+func ExampleAngularVelocity() {
+	fmt.Println(250 * physic.DegreePerSecond)
+	fmt.Println(physic.RevolutionPerMinute)
+	// Output:
+	// 4.363rad/s
+	// 104.720mrad/s
+}
+
 func ExampleDistance() {
 	fmt.Println(physic.Inch)
 	fmt.Println(physic.Foot)
@@ -31,6 +57,14 @@ func ExampleDistance() {
 	// 1.609km
 }
 
+func ExampleDistance_Imperial() {
+	fmt.Println((1800 * physic.MilliMetre).Imperial())
+	fmt.Println((42195 * physic.Metre).Imperial())
+	// Output:
+	// 5.906ft
+	// 26.219mi
+}
+
 func ExampleElectricCurrent() {
 	fmt.Println(10010 * physic.MilliAmpere)
 	fmt.Println(10 * physic.Ampere)
@@ -41,6 +75,16 @@ func ExampleElectricCurrent() {
 	// -10mA
 }
 
+func ExampleElectricCharge() {
+	fmt.Println(2500 * physic.MilliAmpereHour)
+	fmt.Println(physic.ChargeFromCurrent(20*physic.MilliAmpere, 30*time.Minute))
+	fmt.Println(physic.Coulomb)
+	// Output:
+	// 2.500Ah
+	// 10mAh
+	// 1C
+}
+
 func ExampleElectricPotential() {
 	fmt.Println(10010 * physic.MilliVolt)
 	fmt.Println(10 * physic.Volt)
@@ -51,6 +95,21 @@ func ExampleElectricPotential() {
 	// -10mV
 }
 
+func ExampleElectricPotential_Set() {
+	var v physic.ElectricPotential
+	if err := v.Set("4.096V"); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(v)
+	if err := v.Set("-12 mV"); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(v)
+	// Output:
+	// 4.096V
+	// -12mV
+}
+
 func ExampleElectricResistance() {
 	fmt.Println(10010 * physic.MilliOhm)
 	fmt.Println(10 * physic.Ohm)
@@ -81,12 +140,21 @@ func ExampleFrequency() {
 	// 10MHz
 }
 
-func ExampleFrequency_Duration() {
-	fmt.Println(physic.MilliHertz.Duration())
-	fmt.Println(physic.MegaHertz.Duration())
+func ExampleFrequency_Period() {
+	fmt.Println(physic.MilliHertz.Period())
+	fmt.Println(physic.MegaHertz.Period())
+	fmt.Println((3 * physic.Hertz).Period())
 	// Output:
 	// 16m40s
 	// 1µs
+	// 333.333333ms
+}
+
+func ExampleFrequency_Duration() {
+	// Duration of a 800kHz NRZ bit stream of 24 bits.
+	fmt.Println((800 * physic.KiloHertz).Duration(24))
+	// Output:
+	// 30µs
 }
 
 func ExamplePeriodToFrequency() {
@@ -94,7 +162,7 @@ func ExamplePeriodToFrequency() {
 	fmt.Println(physic.PeriodToFrequency(time.Minute))
 	// Output:
 	// 1MHz
-	// 16.666mHz
+	// 16.667mHz
 }
 
 func ExampleMass() {
@@ -117,6 +185,23 @@ func ExamplePressure() {
 	// 101kPa
 }
 
+func ExampleBarometricAltitude() {
+	// The QNH is the pressure at sea level reported by the local weather
+	// station.
+	qnh := 1021 * physic.HectoPascal
+	fmt.Println(physic.BarometricAltitude(95650*physic.Pascal, qnh))
+	fmt.Printf("%.2finHg\n", float64(qnh)/float64(physic.InchOfMercury))
+	// Output:
+	// 547.018m
+	// 30.15inHg
+}
+
+func ExamplePressure_PSI() {
+	fmt.Println((101325 * physic.Pascal).PSI())
+	// Output:
+	// 14.696psi
+}
+
 func ExampleRelativeHumidity() {
 	fmt.Println(506 * physic.MilliRH)
 	fmt.Println(20 * physic.PercentRH)
@@ -149,6 +234,42 @@ func ExampleTemperature() {
 	// 26.666°C
 }
 
+func ExampleTemperature_Fahrenheit() {
+	fmt.Println((23010*physic.MilliCelsius + physic.ZeroCelsius).Fahrenheit())
+	fmt.Println(physic.ZeroCelsius.Fahrenheit())
+	// Output:
+	// 73.418°F
+	// 32°F
+}
+
+func ExampleEnv_DewPoint() {
+	e := physic.Env{
+		Temperature: 25*physic.Celsius + physic.ZeroCelsius,
+		Humidity:    60 * physic.PercentRH,
+	}
+	d, err := e.DewPoint()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(d)
+	// Output:
+	// 16.698°C
+}
+
+func ExampleEnv_HeatIndex() {
+	e := physic.Env{
+		Temperature: 32*physic.Celsius + physic.ZeroCelsius,
+		Humidity:    60 * physic.PercentRH,
+	}
+	h, err := e.HeatIndex()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(h)
+	// Output:
+	// 37.074°C
+}
+
 func ExamplePower() {
 	fmt.Println(1 * physic.Watt)
 	fmt.Println(16 * physic.MilliWatt)
@@ -167,12 +288,28 @@ func ExampleElectricalCapacitance() {
 	// 22pF
 }
 
+func ExampleMagneticFluxDensity() {
+	fmt.Println(physic.Gauss)
+	fmt.Println(-48 * physic.MicroTesla)
+	// Output:
+	// 100µT
+	// -48µT
+}
+
 func ExampleLuminousFlux() {
 	fmt.Println(18282 * physic.Lumen)
 	// Output:
 	// 18.282klm
 }
 
+func ExampleIlluminance() {
+	fmt.Println(320 * physic.Lux)
+	fmt.Println(100 * physic.KiloLux)
+	// Output:
+	// 320lx
+	// 100klx
+}
+
 func ExampleLuminousIntensity() {
 	fmt.Println(12 * physic.Candela)
 	// Output:
@@ -5,16 +5,151 @@
 package physic
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"periph.io/x/periph/conn"
 )
 
 // Env represents measurements from an environmental sensor.
+//
+// Its JSON encoding is an object with the "temperature", "pressure" and
+// "humidity" fields, each encoded as a string like "23.45°C". The
+// "gasResistance" field is only present when GasResistance is not zero.
 type Env struct {
-	Temperature Temperature
-	Pressure    Pressure
-	Humidity    RelativeHumidity
+	Temperature Temperature      `json:"temperature"`
+	Pressure    Pressure         `json:"pressure"`
+	Humidity    RelativeHumidity `json:"humidity"`
+	// GasResistance is the resistance of a metal oxide gas sensor, as used by
+	// some sensors to estimate the air quality. It decreases as the
+	// concentration of volatile organic compounds increases.
+	GasResistance ElectricResistance `json:"gasResistance,omitempty"`
+}
+
+// Fields returns the fields of e that are not zero.
+//
+// It is meant to be used on the Env returned by SenseEnv.Precision().
+func (e *Env) Fields() EnvField {
+	var f EnvField
+	if e.Temperature != 0 {
+		f |= EnvTemperature
+	}
+	if e.Pressure != 0 {
+		f |= EnvPressure
+	}
+	if e.Humidity != 0 {
+		f |= EnvHumidity
+	}
+	if e.GasResistance != 0 {
+		f |= EnvGasResistance
+	}
+	return f
+}
+
+// DewPoint returns the temperature at which the air would be saturated with
+// water vapor, computed from Temperature and Humidity with the Magnus
+// formula.
+//
+// It uses the coefficients from Alduchov and Eskridge (1996), which are
+// within 0.1°C of the reference tables between -40°C and 50°C. It returns
+// an error wrapping ErrFieldNotSet when either field is zero.
+func (e *Env) DewPoint() (Temperature, error) {
+	if err := e.require(EnvTemperature | EnvHumidity); err != nil {
+		return 0, err
+	}
+	const a, b = 17.625, 243.04
+	t := float64(e.Temperature-ZeroCelsius) / float64(Celsius)
+	g := math.Log(float64(e.Humidity)/float64(100*PercentRH)) + a*t/(b+t)
+	return celsius(b * g / (a - g)), nil
+}
+
+// HeatIndex returns the apparent temperature felt by a human body,
+// computed from Temperature and Humidity with the algorithm of the US
+// National Weather Service.
+//
+// It is the Rothfusz regression with its adjustments when the heat index is
+// 80°F (26.7°C) or more, and Steadman's simpler formula otherwise. It is
+// meant to be used in the shade with a light wind; it is not meaningful
+// in cold weather. It returns an error wrapping ErrFieldNotSet when either
+// field is zero.
+func (e *Env) HeatIndex() (Temperature, error) {
+	if err := e.require(EnvTemperature | EnvHumidity); err != nil {
+		return 0, err
+	}
+	// The regression is in Fahrenheit and percents.
+	t := float64(e.Temperature-ZeroCelsius)/float64(Celsius)*9/5 + 32
+	rh := float64(e.Humidity) / float64(PercentRH)
+	hi := 0.5 * (t + 61 + (t-68)*1.2 + rh*0.094)
+	if (hi+t)/2 >= 80 {
+		hi = -42.379 + 2.04901523*t + 10.14333127*rh - .22475541*t*rh -
+			.00683783*t*t - .05481717*rh*rh + .00122874*t*t*rh +
+			.00085282*t*rh*rh - .00000199*t*t*rh*rh
+		if rh < 13 && t >= 80 && t <= 112 {
+			hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+		} else if rh > 85 && t >= 80 && t <= 87 {
+			hi += (rh - 85) / 10 * (87 - t) / 5
+		}
+	}
+	return celsius((hi - 32) * 5 / 9), nil
+}
+
+// require returns an error wrapping ErrFieldNotSet if any of the fields in
+// m is zero.
+func (e *Env) require(m EnvField) error {
+	if missing := m &^ e.Fields(); missing != 0 {
+		return fmt.Errorf("physic: %w: %s", ErrFieldNotSet, missing)
+	}
+	return nil
+}
+
+// celsius returns the temperature c in Celsius rounded to the millikelvin,
+// the precision of a derived temperature being far lower.
+func celsius(c float64) Temperature {
+	m, _ := round(c * 1000)
+	return ZeroCelsius + Temperature(m)*MilliKelvin
+}
+
+// ErrFieldNotSet is returned when a computation requires a field of Env that
+// is zero.
+var ErrFieldNotSet = errors.New("field not set")
+
+// EnvField is a bitmask of the fields of Env.
+type EnvField uint8
+
+// Fields of Env.
+const (
+	EnvTemperature EnvField = 1 << iota
+	EnvPressure
+	EnvHumidity
+	EnvGasResistance
+)
+
+// Has returns true if all the fields in m are set.
+func (f EnvField) Has(m EnvField) bool {
+	return f&m == m
+}
+
+func (f EnvField) String() string {
+	if f == 0 {
+		return "0"
+	}
+	var out []string
+	for i, n := range envFieldNames {
+		if f&(1<<uint(i)) != 0 {
+			out = append(out, n)
+		}
+	}
+	if r := f &^ (1<<uint(len(envFieldNames)) - 1); r != 0 {
+		out = append(out, "EnvField(0x"+strconv.FormatUint(uint64(r), 16)+")")
+	}
+	return strings.Join(out, "|")
 }
 
 // SenseEnv represents an environmental sensor.
@@ -22,7 +157,7 @@ type SenseEnv interface {
 	conn.Resource
 
 	// Sense returns the value read from the sensor. Unsupported metrics are not
-	// modified.
+	// modified; use SensedFields() to know which ones are supported.
 	Sense(env *Env) error
 	// SenseContinuous initiates a continuous sensing at the specified interval.
 	//
@@ -32,7 +167,8 @@ type SenseEnv interface {
 	// Precision returns this sensor's precision.
 	//
 	// The env values are set to the number of bits that are significant for each
-	// items that this sensor can measure.
+	// items that this sensor can measure. The items that are not measured are
+	// left to zero.
 	//
 	// Precision is not accuracy. The sensor may have absolute and relative
 	// errors in its measurement, that are likely well above the reported
@@ -40,3 +176,210 @@ type SenseEnv interface {
 	// or doing oversampling in software. Refer to its datasheet if available.
 	Precision(env *Env)
 }
+
+// SenseLight represents an ambient light sensor.
+type SenseLight interface {
+	conn.Resource
+
+	// Sense returns the illuminance measured by the sensor.
+	Sense() (Illuminance, error)
+}
+
+// SenseEnvFields is implemented by a SenseEnv that declares the fields of Env
+// it populates.
+type SenseEnvFields interface {
+	// Fields returns the fields of Env set by Sense() and SenseContinuous().
+	Fields() EnvField
+}
+
+// SensedFields returns the fields of Env populated by s.
+//
+// It uses s.Fields() when s implements SenseEnvFields, otherwise the fields
+// for which s.Precision() returns a non-zero precision. It permits to tell a
+// metric that is not measured apart from a measurement of 0, e.g. the
+// pressure of a humidity sensor.
+func SensedFields(s SenseEnv) EnvField {
+	if f, ok := s.(SenseEnvFields); ok {
+		return f.Fields()
+	}
+	var e Env
+	s.Precision(&e)
+	return e.Fields()
+}
+
+// MulDiv returns v*num/den rounded half away from zero, e.g. to scale a
+// quantity by a ratio without overflowing in the intermediate product.
+//
+// ok is false when the result doesn't fit in an int64, in which case the
+// result is saturated at the limit of its sign, or when den is 0.
+func MulDiv(v, num, den int64) (int64, bool) {
+	if den == 0 {
+		return 0, false
+	}
+	neg := (v < 0) != (num < 0) != (den < 0)
+	hi, lo := bits.Mul64(abs64(v), abs64(num))
+	d := abs64(den)
+	if hi >= d {
+		return saturate(neg), false
+	}
+	q, r := bits.Div64(hi, lo, d)
+	if r >= d-r {
+		q++
+	}
+	if neg {
+		if q > 1<<63 {
+			return saturate(neg), false
+		}
+		return int64(-q), true
+	}
+	if q > math.MaxInt64 {
+		return saturate(neg), false
+	}
+	return int64(q), true
+}
+
+// Scale returns v*f rounded half away from zero, e.g. to apply a calibration
+// factor to a quantity.
+//
+// ok is false when the result doesn't fit in an int64, in which case the
+// result is saturated at the limit of its sign, or when f is NaN.
+func Scale(v int64, f float64) (int64, bool) {
+	return round(float64(v) * f)
+}
+
+// Add returns a+b.
+//
+// ok is false when the result doesn't fit in an int64, in which case the
+// result is saturated at the limit of its sign.
+func Add(a, b int64) (int64, bool) {
+	s := a + b
+	if (s < a) != (b < 0) {
+		return saturate(b < 0), false
+	}
+	return s, true
+}
+
+// Sub returns a-b.
+//
+// ok is false when the result doesn't fit in an int64, in which case the
+// result is saturated at the limit of its sign.
+func Sub(a, b int64) (int64, bool) {
+	s := a - b
+	if (s > a) != (b < 0) {
+		return saturate(b > 0), false
+	}
+	return s, true
+}
+
+// round returns f rounded half away from zero.
+//
+// ok is false when f is NaN or doesn't fit in an int64.
+func round(f float64) (int64, bool) {
+	switch {
+	case f != f:
+		return 0, false
+	// float64(math.MaxInt64) is 2^63, which doesn't fit.
+	case f >= math.MaxInt64:
+		return math.MaxInt64, false
+	case f < math.MinInt64:
+		return math.MinInt64, false
+	}
+	f = math.Trunc(f + math.Copysign(0.5, f))
+	// Rounding up may reach 2^63.
+	if f >= math.MaxInt64 {
+		return math.MaxInt64, false
+	}
+	return int64(f), true
+}
+
+// EnvSample is a measurement sent by SenseStreamer.SenseStream().
+type EnvSample struct {
+	Env
+	// T is the time at which the measurement was retrieved.
+	T time.Time
+	// Err is set when the measurement failed, in which case Env is not
+	// valid.
+	Err error
+}
+
+// SenseStreamer is a SenseEnv that can stream measurements until a context is
+// done.
+type SenseStreamer interface {
+	SenseEnv
+	// SenseStream initiates a continuous sensing at the specified interval.
+	//
+	// The sensing stops and the channel is closed once ctx is done or Halt()
+	// is called. Unlike SenseContinuous(), a failed measurement doesn't stop
+	// the sensing; it is sent with Err set.
+	//
+	// It's the responsibility of the caller to retrieve the values from the
+	// channel as fast as possible, otherwise the interval may not be
+	// respected.
+	SenseStream(ctx context.Context, interval time.Duration) (<-chan EnvSample, error)
+}
+
+// PollStream returns a SenseStreamer for s.
+//
+// s is returned as is when it implements SenseStreamer. Otherwise
+// SenseStream() calls s.Sense() at each interval. Halt() stops the streams
+// started via the returned SenseStreamer, then calls s.Halt().
+func PollStream(s SenseEnv) SenseStreamer {
+	if ss, ok := s.(SenseStreamer); ok {
+		return ss
+	}
+	return &pollStreamer{SenseEnv: s, halt: make(chan struct{})}
+}
+
+// pollStreamer implements SenseStreamer for PollStream.
+type pollStreamer struct {
+	SenseEnv
+
+	mu   sync.Mutex
+	halt chan struct{}
+}
+
+func (p *pollStreamer) Halt() error {
+	p.mu.Lock()
+	close(p.halt)
+	p.halt = make(chan struct{})
+	p.mu.Unlock()
+	return p.SenseEnv.Halt()
+}
+
+func (p *pollStreamer) SenseStream(ctx context.Context, interval time.Duration) (<-chan EnvSample, error) {
+	if interval <= 0 {
+		return nil, errors.New("physic: interval must be positive")
+	}
+	p.mu.Lock()
+	halt := p.halt
+	p.mu.Unlock()
+	c := make(chan EnvSample)
+	go func() {
+		defer close(c)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			// Do one initial sensing right away.
+			var s EnvSample
+			s.Err = p.Sense(&s.Env)
+			s.T = time.Now()
+			select {
+			case c <- s:
+			case <-ctx.Done():
+				return
+			case <-halt:
+				return
+			}
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			case <-halt:
+				return
+			}
+		}
+	}()
+	return c, nil
+}
+
+var envFieldNames = [...]string{"Temperature", "Pressure", "Humidity", "GasResistance"}
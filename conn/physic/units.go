@@ -5,8 +5,14 @@
 package physic
 
 import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // Angle is the measurement of the difference in orientation between two vectors
@@ -54,6 +60,37 @@ func (a Angle) String() string {
 	}
 }
 
+// Normalize returns the equivalent angle in [0, Theta), e.g. 370° becomes
+// 10° and -90° becomes 270°.
+func (a Angle) Normalize() Angle {
+	a %= Theta
+	if a < 0 {
+		a += Theta
+	}
+	return a
+}
+
+// NormalizeSigned returns the equivalent angle in [-Pi, Pi], e.g. 270°
+// becomes -90°.
+//
+// Theta being an odd number of nano radians, both -Pi and Pi are in the
+// range but they are distinct angles.
+func (a Angle) NormalizeSigned() Angle {
+	a = a.Normalize()
+	if a > Pi {
+		a -= Theta
+	}
+	return a
+}
+
+// Diff returns the shortest rotation from b to a, in [-Pi, Pi].
+//
+// It wraps around correctly: 1°.Diff(359°) is 2°, not -358°.
+func (a Angle) Diff(b Angle) Angle {
+	// Normalize first so the subtraction can't overflow.
+	return (a.Normalize() - b.Normalize()).NormalizeSigned()
+}
+
 const (
 	NanoRadian  Angle = 1
 	MicroRadian Angle = 1000 * NanoRadian
@@ -78,6 +115,62 @@ func (d Distance) String() string {
 	return nanoAsString(int64(d)) + "m"
 }
 
+// Imperial returns the distance formatted as a string in inches, feet or
+// miles, e.g. "3.250in", "6ft" or "1.243mi".
+//
+// Miles are used from one mile and feet from one foot. The value is rounded
+// to the nearest thousandth of the unit.
+func (d Distance) Imperial() string {
+	switch a := abs64(int64(d)); {
+	case a >= uint64(Mile):
+		return milliAsString(roundDiv(int64(d), int64(Mile/1000))) + "mi"
+	case a >= uint64(Foot):
+		return milliAsString(roundDiv(int64(d), int64(Foot/1000))) + "ft"
+	default:
+		return milliAsString(roundDiv(int64(d), int64(Thou))) + "in"
+	}
+}
+
+// Set sets the distance to the value represented by s, e.g. "1.5km" or
+// "12 mm".
+//
+// It accepts the output of String() and implements flag.Value.
+func (d *Distance) Set(s string) error {
+	v, err := parseInt64("Distance", s, -9, "m")
+	if err != nil {
+		return err
+	}
+	*d = Distance(v)
+	return nil
+}
+
+// Get implements flag.Getter.
+func (d *Distance) Get() interface{} {
+	return *d
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It is the exact value as a string, e.g. "1.2345678m".
+func (d Distance) MarshalJSON() ([]byte, error) {
+	return marshalExact(int64(d), -9, true, "m"), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts either a string parsed by Set() or a number of metres.
+func (d *Distance) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := unmarshalInt64("Distance", b, -9, "m")
+	if err != nil {
+		return err
+	}
+	*d = Distance(v)
+	return nil
+}
+
 const (
 	NanoMetre  Distance = 1
 	MicroMetre Distance = 1000 * NanoMetre
@@ -107,6 +200,45 @@ func (e ElectricCurrent) String() string {
 	return nanoAsString(int64(e)) + "A"
 }
 
+// Set sets the current to the value represented by s, e.g. "20mA".
+//
+// It accepts the output of String() and implements flag.Value.
+func (e *ElectricCurrent) Set(s string) error {
+	v, err := parseInt64("ElectricCurrent", s, -9, "A")
+	if err != nil {
+		return err
+	}
+	*e = ElectricCurrent(v)
+	return nil
+}
+
+// Get implements flag.Getter.
+func (e *ElectricCurrent) Get() interface{} {
+	return *e
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It is the exact value as a string, e.g. "1.2345678A".
+func (e ElectricCurrent) MarshalJSON() ([]byte, error) {
+	return marshalExact(int64(e), -9, true, "A"), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts either a string parsed by Set() or a number of amperes.
+func (e *ElectricCurrent) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := unmarshalInt64("ElectricCurrent", b, -9, "A")
+	if err != nil {
+		return err
+	}
+	*e = ElectricCurrent(v)
+	return nil
+}
+
 const (
 	NanoAmpere  ElectricCurrent = 1
 	MicroAmpere ElectricCurrent = 1000 * NanoAmpere
@@ -125,6 +257,46 @@ func (e ElectricPotential) String() string {
 	return nanoAsString(int64(e)) + "V"
 }
 
+// Set sets the tension to the value represented by s, e.g. "3.3V" or
+// "-12 mV".
+//
+// It accepts the output of String() and implements flag.Value.
+func (e *ElectricPotential) Set(s string) error {
+	v, err := parseInt64("ElectricPotential", s, -9, "V")
+	if err != nil {
+		return err
+	}
+	*e = ElectricPotential(v)
+	return nil
+}
+
+// Get implements flag.Getter.
+func (e *ElectricPotential) Get() interface{} {
+	return *e
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It is the exact value as a string, e.g. "1.2345678V".
+func (e ElectricPotential) MarshalJSON() ([]byte, error) {
+	return marshalExact(int64(e), -9, true, "V"), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts either a string parsed by Set() or a number of volts.
+func (e *ElectricPotential) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := unmarshalInt64("ElectricPotential", b, -9, "V")
+	if err != nil {
+		return err
+	}
+	*e = ElectricPotential(v)
+	return nil
+}
+
 const (
 	// Volt is W/A, kg⋅m²/s³/A.
 	NanoVolt  ElectricPotential = 1
@@ -145,6 +317,46 @@ func (e ElectricResistance) String() string {
 	return nanoAsString(int64(e)) + "Ω"
 }
 
+// Set sets the resistance to the value represented by s, e.g. "10kΩ" or
+// "4.7 kohm".
+//
+// It accepts the output of String() and implements flag.Value.
+func (e *ElectricResistance) Set(s string) error {
+	v, err := parseInt64("ElectricResistance", s, -9, "Ω", "ohm")
+	if err != nil {
+		return err
+	}
+	*e = ElectricResistance(v)
+	return nil
+}
+
+// Get implements flag.Getter.
+func (e *ElectricResistance) Get() interface{} {
+	return *e
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It is the exact value as a string, e.g. "1.2345678Ω".
+func (e ElectricResistance) MarshalJSON() ([]byte, error) {
+	return marshalExact(int64(e), -9, true, "Ω"), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts either a string parsed by Set() or a number of ohms.
+func (e *ElectricResistance) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := unmarshalInt64("ElectricResistance", b, -9, "Ω", "ohm")
+	if err != nil {
+		return err
+	}
+	*e = ElectricResistance(v)
+	return nil
+}
+
 const (
 	// Ohm is V/A, kg⋅m²/s³/A².
 	NanoOhm  ElectricResistance = 1
@@ -198,16 +410,88 @@ func (f Frequency) String() string {
 	return microAsString(int64(f)) + "Hz"
 }
 
-// Duration returns the duration of one cycle at this frequency.
-func (f Frequency) Duration() time.Duration {
-	// Note: Duration() should have been named Period().
-	// TODO(maruel): Rounding should be fine-tuned.
-	return time.Second * time.Duration(Hertz) / time.Duration(f)
+// Set sets the frequency to the value represented by s, e.g. "860Hz" or
+// "400 kHz".
+//
+// It accepts the output of String() and implements flag.Value.
+func (f *Frequency) Set(s string) error {
+	v, err := parseInt64("Frequency", s, -6, "Hz")
+	if err != nil {
+		return err
+	}
+	*f = Frequency(v)
+	return nil
+}
+
+// Get implements flag.Getter.
+func (f *Frequency) Get() interface{} {
+	return *f
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It is the exact value as a string, e.g. "1.2345678Hz".
+func (f Frequency) MarshalJSON() ([]byte, error) {
+	return marshalExact(int64(f), -6, true, "Hz"), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts either a string parsed by Set() or a number of hertz.
+func (f *Frequency) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := unmarshalInt64("Frequency", b, -6, "Hz")
+	if err != nil {
+		return err
+	}
+	*f = Frequency(v)
+	return nil
+}
+
+// Period returns the duration of one cycle at this frequency, rounded to the
+// nearest nanosecond.
+//
+// A zero frequency has an infinite period; the maximum time.Duration is
+// returned. Frequencies above 2GHz have a period of 0.
+func (f Frequency) Period() time.Duration {
+	return f.Duration(1)
+}
+
+// Duration returns the duration of cycles at this frequency, rounded to the
+// nearest nanosecond.
+//
+// The result saturates at the time.Duration limits on overflow, including
+// for a non-zero number of cycles at a zero frequency.
+func (f Frequency) Duration(cycles int64) time.Duration {
+	if f == 0 {
+		if cycles == 0 {
+			return 0
+		}
+		return time.Duration(saturate(cycles < 0))
+	}
+	// Frequency is in µHz, so a cycle lasts 10^15/f ns.
+	n, d := int64(time.Second)*int64(Hertz), positive(int64(f))
+	if f < 0 {
+		n = -n
+	}
+	return time.Duration(mulDiv(cycles, n, d))
 }
 
-// PeriodToFrequency returns the frequency for a period of this interval.
+// PeriodToFrequency returns the frequency for a period of this interval,
+// rounded to the nearest µHz.
+//
+// A zero period has an infinite frequency; the maximum Frequency is returned.
 func PeriodToFrequency(t time.Duration) Frequency {
-	return Frequency(time.Second) * Hertz / Frequency(t)
+	if t == 0 {
+		return Frequency(math.MaxInt64)
+	}
+	n, d := int64(time.Second)*int64(Hertz), positive(int64(t))
+	if t < 0 {
+		n = -n
+	}
+	return Frequency(roundDiv(n, d))
 }
 
 const (
@@ -263,6 +547,53 @@ func (p Pressure) String() string {
 	return nanoAsString(int64(p)) + "Pa"
 }
 
+// PSI returns the pressure formatted as a string in pounds per square inch,
+// rounded to the nearest thousandth, e.g. "14.696psi".
+func (p Pressure) PSI() string {
+	// 1psi is exactly 4.4482216152605N / 0.00064516m², so 1mpsi is
+	// 444822161526050/64516 nPa.
+	return milliAsString(mulDiv(int64(p), 64516, 444822161526050)) + "psi"
+}
+
+// Set sets the pressure to the value represented by s, e.g. "101.3kPa".
+//
+// It accepts the output of String() and implements flag.Value.
+func (p *Pressure) Set(s string) error {
+	v, err := parseInt64("Pressure", s, -9, "Pa")
+	if err != nil {
+		return err
+	}
+	*p = Pressure(v)
+	return nil
+}
+
+// Get implements flag.Getter.
+func (p *Pressure) Get() interface{} {
+	return *p
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It is the exact value as a string, e.g. "1.2345678Pa".
+func (p Pressure) MarshalJSON() ([]byte, error) {
+	return marshalExact(int64(p), -9, true, "Pa"), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts either a string parsed by Set() or a number of pascals.
+func (p *Pressure) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := unmarshalInt64("Pressure", b, -9, "Pa")
+	if err != nil {
+		return err
+	}
+	*p = Pressure(v)
+	return nil
+}
+
 const (
 	// Pascal is N/m², kg/m/s².
 	NanoPascal  Pressure = 1
@@ -272,8 +603,50 @@ const (
 	KiloPascal  Pressure = 1000 * Pascal
 	MegaPascal  Pressure = 1000 * KiloPascal
 	GigaPascal  Pressure = 1000 * MegaPascal
+
+	// Common units in meteorology.
+	HectoPascal Pressure = 100 * Pascal
+	MilliBar    Pressure = HectoPascal
+	Bar         Pressure = 1000 * MilliBar
+
+	// Atmosphere is the standard atmospheric pressure at sea level.
+	Atmosphere Pressure = 101325 * Pascal
+
+	// Conventional millimetre and inch of mercury, as used by barometers and
+	// aviation.
+	MillimetreOfMercury Pressure = 133322387415 * NanoPascal
+	InchOfMercury       Pressure = 3386388640341 * NanoPascal
 )
 
+// BarometricAltitude returns the altitude at which the pressure is p,
+// relative to where it is seaLevel, e.g. Atmosphere or the QNH reported by a
+// weather station.
+//
+// It uses the International Standard Atmosphere model of the troposphere:
+//
+//	h = T0/L × (1 - (p/seaLevel)^(R×L/(g0×M)))
+//
+// with the temperature at sea level T0 = 288.15K, the temperature lapse rate
+// L = 0.0065K/m, the gas constant R = 8.3144598J/(mol×K), the standard
+// gravity g0 = 9.80665m/s² and the molar mass of dry air M =
+// 0.0289644kg/mol. It is valid up to 11km. The actual temperature profile
+// differs from the standard one, so the absolute error is typically a few
+// percent of the altitude.
+//
+// It returns 0 when p or seaLevel is not positive.
+func BarometricAltitude(p, seaLevel Pressure) Distance {
+	if p <= 0 || seaLevel <= 0 {
+		return 0
+	}
+	const (
+		t0 = 288.15
+		l  = 0.0065
+		e  = 8.3144598 * l / (9.80665 * 0.0289644)
+	)
+	h := t0 / l * (1 - math.Pow(float64(p)/float64(seaLevel), e))
+	return Distance(math.Floor(h*float64(Metre) + 0.5))
+}
+
 // RelativeHumidity is a humidity level measurement stored as an int32 fixed
 // point integer at a precision of 0.00001%rH.
 //
@@ -293,6 +666,57 @@ func (r RelativeHumidity) String() string {
 	return strconv.Itoa(int(r)/10) + "." + strconv.Itoa(frac) + "%rH"
 }
 
+// Set sets the humidity to the value represented by s, e.g. "45.5%rH" or
+// "45.5%". S.I. prefixes are not accepted.
+//
+// It accepts the output of String() and implements flag.Value.
+func (r *RelativeHumidity) Set(s string) error {
+	n, _, err := parseDecimal(s, -5, false, "%rH", "%")
+	return r.set(s, n, err)
+}
+
+// Get implements flag.Getter.
+func (r *RelativeHumidity) Get() interface{} {
+	return *r
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It is the exact value as a string, e.g. "45.12345%rH".
+func (r RelativeHumidity) MarshalJSON() ([]byte, error) {
+	return marshalExact(int64(r), -5, false, "%rH"), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts either a string parsed by Set() or a number of percents.
+func (r *RelativeHumidity) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	s, number, err := jsonValue(b)
+	if err != nil {
+		return &ParseError{Quantity: "RelativeHumidity", Input: string(b), Err: err}
+	}
+	if number {
+		n, _, err := parseDecimal(s, -5, false, "")
+		return r.set(s, n, err)
+	}
+	return r.Set(s)
+}
+
+// set sets r to n, as parsed from s.
+func (r *RelativeHumidity) set(s string, n *big.Int, err error) error {
+	if err == nil && (!n.IsInt64() || n.Int64() < math.MinInt32 || n.Int64() > math.MaxInt32) {
+		err = ErrRange
+	}
+	if err != nil {
+		return &ParseError{Quantity: "RelativeHumidity", Input: s, Err: err}
+	}
+	*r = RelativeHumidity(n.Int64())
+	return nil
+}
+
 const (
 	TenthMicroRH RelativeHumidity = 1                 // 0.00001%rH
 	MicroRH      RelativeHumidity = 10 * TenthMicroRH // 0.0001%rH
@@ -339,6 +763,74 @@ func (t Temperature) String() string {
 	return nanoAsString(int64(t-ZeroCelsius)) + "°C"
 }
 
+// Fahrenheit returns the temperature formatted as a string in °Fahrenheit,
+// rounded to the nearest thousandth, e.g. "73.400°F".
+//
+// Unlike the ZeroFahrenheit and Fahrenheit constants, the conversion is
+// exact.
+func (t Temperature) Fahrenheit() string {
+	// °F = K × 9/5 - 459.67.
+	return milliAsString(mulDiv(int64(t), 9, 5*int64(MilliKelvin))-459670) + "°F"
+}
+
+// Set sets the temperature to the value represented by s, in Celsius, e.g.
+// "25.5°C" or "25.5C", or in Kelvin, e.g. "298.65K".
+//
+// It accepts the output of String() and implements flag.Value. A
+// temperature below absolute zero is a range error.
+func (t *Temperature) Set(s string) error {
+	n, unit, err := parseDecimal(s, -9, true, "°C", "C", "K")
+	return t.set(s, n, unit, err)
+}
+
+// Get implements flag.Getter.
+func (t *Temperature) Get() interface{} {
+	return *t
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It is the exact value as a string in Celsius, e.g. "23.45°C".
+func (t Temperature) MarshalJSON() ([]byte, error) {
+	return marshalExact(int64(t-ZeroCelsius), -9, true, "°C"), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It accepts either a string parsed by Set() or a number of kelvins, the
+// S.I. unit.
+func (t *Temperature) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	s, number, err := jsonValue(b)
+	if err != nil {
+		return &ParseError{Quantity: "Temperature", Input: string(b), Err: err}
+	}
+	if number {
+		n, _, err := parseDecimal(s, -9, false, "")
+		return t.set(s, n, "K", err)
+	}
+	return t.Set(s)
+}
+
+// set sets t to n, as parsed from s in unit.
+func (t *Temperature) set(s string, n *big.Int, unit string, err error) error {
+	if err == nil {
+		if unit != "K" {
+			n.Add(n, big.NewInt(int64(ZeroCelsius)))
+		}
+		if !n.IsInt64() || n.Sign() < 0 {
+			err = ErrRange
+		}
+	}
+	if err != nil {
+		return &ParseError{Quantity: "Temperature", Input: s, Err: err}
+	}
+	*t = Temperature(n.Int64())
+	return nil
+}
+
 const (
 	NanoKelvin  Temperature = 1
 	MicroKelvin Temperature = 1000 * NanoKelvin
@@ -473,6 +965,155 @@ const (
 	GigaLumen  LuminousFlux = 1000 * MegaLumen
 )
 
+// Illuminance is a measurement of the luminous flux incident on a surface per
+// unit area, as measured by ambient light sensors.
+//
+// Illuminance is stored as nano lux.
+//
+// The highest representable value is 9.2Glx.
+type Illuminance int64
+
+// String returns the illuminance formatted as a string in Lux.
+func (i Illuminance) String() string {
+	return nanoAsString(int64(i)) + "lx"
+}
+
+const (
+	// Lux is a unit of illuminance. lm/m²
+	NanoLux  Illuminance = 1
+	MicroLux Illuminance = 1000 * NanoLux
+	MilliLux Illuminance = 1000 * MicroLux
+	Lux      Illuminance = 1000 * MilliLux
+	KiloLux  Illuminance = 1000 * Lux
+	MegaLux  Illuminance = 1000 * KiloLux
+	GigaLux  Illuminance = 1000 * MegaLux
+)
+
+// MagneticFluxDensity is a measurement of magnetic flux density, also known
+// as magnetic field strength, stored as an int64 nano Tesla.
+//
+// The highest representable value is 9.2GT.
+type MagneticFluxDensity int64
+
+// String returns the magnetic flux density formatted as a string in Tesla.
+func (m MagneticFluxDensity) String() string {
+	return nanoAsString(int64(m)) + "T"
+}
+
+const (
+	// Tesla is Wb/m², kg/s²/A.
+	NanoTesla  MagneticFluxDensity = 1
+	MicroTesla MagneticFluxDensity = 1000 * NanoTesla
+	MilliTesla MagneticFluxDensity = 1000 * MicroTesla
+	Tesla      MagneticFluxDensity = 1000 * MilliTesla
+
+	// Conversion between Tesla and Gauss, the CGS unit commonly used in
+	// magnetometer datasheets. The Earth's field is around 0.5G.
+	MilliGauss MagneticFluxDensity = 100 * NanoTesla
+	Gauss      MagneticFluxDensity = 100 * MicroTesla
+)
+
+// ElectricCharge is a measurement of electric charge stored as an int64 nano
+// Coulomb.
+//
+// The highest representable value is 9.2GC, a bit over 2.5MAh.
+type ElectricCharge int64
+
+// String returns the charge formatted as a string in Ampere hour, e.g.
+// "2.500Ah" or "150mAh", or in Coulomb below 1mAh, e.g. "360mC".
+//
+// It is equivalent to FormatAh(MilliAmpereHour).
+func (c ElectricCharge) String() string {
+	return c.FormatAh(MilliAmpereHour)
+}
+
+// FormatAh returns the charge formatted as a string in Ampere hour when its
+// magnitude is at least threshold, in Coulomb otherwise. A threshold of 0
+// always uses Ampere hour.
+func (c ElectricCharge) FormatAh(threshold ElectricCharge) string {
+	if abs64(int64(c)) >= abs64(int64(threshold)) {
+		// Round to the nano Ampere hour.
+		return nanoAsString(roundDiv(int64(c), 3600)) + "Ah"
+	}
+	return nanoAsString(int64(c)) + "C"
+}
+
+// Current returns the average current flowing when the charge is transferred
+// in d, which must be positive. The result saturates at the limits of
+// ElectricCurrent.
+func (c ElectricCharge) Current(d time.Duration) ElectricCurrent {
+	// nC/ns is A, so the nA is c*1e9/d.
+	return ElectricCurrent(mulDiv(int64(c), 1000000000, int64(d)))
+}
+
+const (
+	// Coulomb is A⋅s.
+	NanoCoulomb  ElectricCharge = 1
+	MicroCoulomb ElectricCharge = 1000 * NanoCoulomb
+	MilliCoulomb ElectricCharge = 1000 * MicroCoulomb
+	Coulomb      ElectricCharge = 1000 * MilliCoulomb
+	KiloCoulomb  ElectricCharge = 1000 * Coulomb
+	MegaCoulomb  ElectricCharge = 1000 * KiloCoulomb
+
+	// Conversion between Coulomb and Ampere hour, the unit used by battery
+	// capacities and fuel gauges.
+	MilliAmpereHour ElectricCharge = 3600 * MilliCoulomb
+	AmpereHour      ElectricCharge = 3600 * Coulomb
+)
+
+// ChargeFromCurrent returns the charge transferred by the current i flowing
+// during d. The result saturates at the limits of ElectricCharge.
+func ChargeFromCurrent(i ElectricCurrent, d time.Duration) ElectricCharge {
+	// nA×ns is 1e-9nC.
+	return ElectricCharge(mulDiv(int64(i), int64(d), 1000000000))
+}
+
+// Acceleration is a measurement of the rate of change of velocity stored as
+// an int64 nano metre per second squared.
+//
+// The highest representable value is 9.2Gm/s².
+type Acceleration int64
+
+// String returns the acceleration formatted as a string in m/s².
+func (a Acceleration) String() string {
+	return nanoAsString(int64(a)) + "m/s²"
+}
+
+const (
+	// MetrePerSecondSquared is m/s².
+	NanoMetrePerSecondSquared  Acceleration = 1
+	MicroMetrePerSecondSquared Acceleration = 1000 * NanoMetrePerSecondSquared
+	MilliMetrePerSecondSquared Acceleration = 1000 * MicroMetrePerSecondSquared
+	MetrePerSecondSquared      Acceleration = 1000 * MilliMetrePerSecondSquared
+
+	// StandardGravity is the nominal gravitational acceleration at the surface
+	// of the Earth, also known as 1g.
+	StandardGravity Acceleration = 9806650 * MicroMetrePerSecondSquared
+)
+
+// AngularVelocity is a measurement of the rate of change of an angle stored as
+// an int64 nano radian per second.
+//
+// The highest representable value is 9.2Grad/s.
+type AngularVelocity int64
+
+// String returns the angular velocity formatted as a string in rad/s.
+func (a AngularVelocity) String() string {
+	return nanoAsString(int64(a)) + "rad/s"
+}
+
+const (
+	// RadianPerSecond is rad/s.
+	NanoRadianPerSecond  AngularVelocity = 1
+	MicroRadianPerSecond AngularVelocity = 1000 * NanoRadianPerSecond
+	MilliRadianPerSecond AngularVelocity = 1000 * MicroRadianPerSecond
+	RadianPerSecond      AngularVelocity = 1000 * MilliRadianPerSecond
+
+	// Conversion to the units used in gyroscope datasheets.
+	DegreePerSecond     AngularVelocity = AngularVelocity(Degree)
+	RevolutionPerMinute AngularVelocity = AngularVelocity(Theta) / 60
+)
+
 //
 
 func prefixZeros(digits, v int) string {
@@ -486,6 +1127,21 @@ func prefixZeros(digits, v int) string {
 	return s
 }
 
+// milliAsString converts a value in thousandths of a unit in a string, with
+// three decimals unless it is an integer.
+func milliAsString(v int64) string {
+	sign := ""
+	a := abs64(v)
+	if v < 0 {
+		sign = "-"
+	}
+	base := strconv.FormatUint(a/1000, 10)
+	if frac := int(a % 1000); frac != 0 {
+		return sign + base + "." + prefixZeros(3, frac)
+	}
+	return sign + base
+}
+
 // nanoAsString converts a value in S.I. unit in a string with the predefined
 // prefix.
 func nanoAsString(v int64) string {
@@ -719,3 +1375,302 @@ func picoAsString(v int64) string {
 	}
 	return sign + strconv.Itoa(base) + "." + prefixZeros(3, frac) + unit
 }
+
+var (
+	// ErrSyntax is returned by the Set methods when the string is not a
+	// decimal number followed by a supported unit.
+	ErrSyntax = errors.New("invalid syntax")
+	// ErrRange is returned by the Set methods when the value can't be
+	// represented by the quantity.
+	ErrRange = errors.New("value out of range")
+)
+
+// ParseError is returned by the Set methods when parsing fails, like
+// strconv.NumError.
+type ParseError struct {
+	// Quantity is the type being parsed, e.g. "ElectricPotential".
+	Quantity string
+	// Input is the string being parsed.
+	Input string
+	// Err is either ErrSyntax or ErrRange.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return "physic: parsing " + e.Quantity + " " + strconv.Quote(e.Input) + ": " + e.Err.Error()
+}
+
+// Unwrap returns Err.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// siPrefixes are the S.I. prefixes accepted when parsing, with their power
+// of ten.
+var siPrefixes = []struct {
+	prefix string
+	exp    int
+}{
+	{"p", -12},
+	{"n", -9},
+	{"µ", -6}, // U+00B5 MICRO SIGN, as printed by String().
+	{"μ", -6}, // U+03BC GREEK SMALL LETTER MU.
+	{"u", -6},
+	{"m", -3},
+	{"k", 3},
+	{"M", 6},
+	{"G", 9},
+	{"T", 12},
+}
+
+// parseInt64 parses s with parseDecimal and checks that the value fits in an
+// int64. It returns a *ParseError for quantity on failure.
+func parseInt64(quantity, s string, exp int, units ...string) (int64, error) {
+	n, _, err := parseDecimal(s, exp, true, units...)
+	if err == nil && !n.IsInt64() {
+		err = ErrRange
+	}
+	if err != nil {
+		return 0, &ParseError{Quantity: quantity, Input: s, Err: err}
+	}
+	return n.Int64(), nil
+}
+
+// unmarshalInt64 parses a JSON value: either a string parsed like
+// parseInt64 or a number of units.
+func unmarshalInt64(quantity string, b []byte, exp int, units ...string) (int64, error) {
+	s, number, err := jsonValue(b)
+	if err != nil {
+		return 0, &ParseError{Quantity: quantity, Input: string(b), Err: err}
+	}
+	if number {
+		units = []string{""}
+	}
+	return parseInt64(quantity, s, exp, units...)
+}
+
+// jsonValue returns the content of a JSON string, or the text of a JSON
+// number.
+func jsonValue(b []byte) (string, bool, error) {
+	if len(b) != 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return "", false, ErrSyntax
+		}
+		return s, false, nil
+	}
+	return string(b), true, nil
+}
+
+// marshalExact returns the JSON string of v, a multiple of 10^exp of unit,
+// without rounding.
+//
+// The largest S.I. prefix keeping a non-zero integer part is used when
+// prefixes is set, e.g. "1.2345678V" or "12.5µV".
+func marshalExact(v int64, exp int, prefixes bool, unit string) []byte {
+	b := make([]byte, 0, 32)
+	b = append(b, '"')
+	// Work on the absolute value as an uint64 to handle math.MinInt64.
+	u := uint64(v)
+	if v < 0 {
+		b = append(b, '-')
+		u = -u
+	}
+	p := 0
+	prefix := ""
+	if prefixes && u != 0 {
+		for e := exp; e <= 12 && e-exp <= 19; e += 3 {
+			if u >= pow10(e-exp) {
+				p = e
+			}
+		}
+		for _, c := range siPrefixes {
+			if c.exp == p {
+				prefix = c.prefix
+				break
+			}
+		}
+	}
+	div := pow10(p - exp)
+	b = strconv.AppendUint(b, u/div, 10)
+	if frac := u % div; frac != 0 {
+		digits := strconv.FormatUint(frac, 10)
+		b = append(b, '.')
+		for i := len(digits); i < p-exp; i++ {
+			b = append(b, '0')
+		}
+		b = append(b, strings.TrimRight(digits, "0")...)
+	}
+	b = append(b, prefix...)
+	b = append(b, unit...)
+	return append(b, '"')
+}
+
+// roundDiv returns n/d rounded half away from zero, d must be positive.
+func roundDiv(n, d int64) int64 {
+	q := int64((abs64(n) + uint64(d/2)) / uint64(d))
+	if n < 0 {
+		return -q
+	}
+	return q
+}
+
+// mulDiv returns a*b/c rounded half away from zero, saturating at the int64
+// limits. c must be positive.
+func mulDiv(a, b, c int64) int64 {
+	v, _ := MulDiv(a, b, c)
+	return v
+}
+
+// abs64 returns the absolute value of v, as an uint64 to handle
+// math.MinInt64.
+func abs64(v int64) uint64 {
+	if v < 0 {
+		return -uint64(v)
+	}
+	return uint64(v)
+}
+
+// positive returns the absolute value of v, saturated at math.MaxInt64.
+func positive(v int64) int64 {
+	if v == math.MinInt64 {
+		return math.MaxInt64
+	}
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// saturate returns the int64 limit of the sign.
+func saturate(neg bool) int64 {
+	if neg {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
+
+// pow10 returns 10^n, n must be between 0 and 19.
+func pow10(n int) uint64 {
+	v := uint64(1)
+	for ; n > 0; n-- {
+		v *= 10
+	}
+	return v
+}
+
+// parseDecimal parses a decimal number followed by an optional S.I. prefix,
+// when prefixes is set, and one of units. Whitespace is accepted around the
+// number.
+//
+// It returns the value as a multiple of 10^exp of the unit, rounded half
+// away from zero, along the unit found. The decimal number is converted
+// exactly, so the output of the String() methods is parsed back without
+// loss.
+func parseDecimal(s string, exp int, prefixes bool, units ...string) (*big.Int, string, error) {
+	s = strings.TrimSpace(s)
+	unit := ""
+	found := false
+	for _, u := range units {
+		if strings.HasSuffix(s, u) {
+			unit = u
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, "", ErrSyntax
+	}
+	s = s[:len(s)-len(unit)]
+	if prefixes {
+		for _, p := range siPrefixes {
+			// The prefix must follow the number, not be the number itself.
+			if strings.HasSuffix(s, p.prefix) {
+				if r, _ := utf8.DecodeLastRuneInString(s[:len(s)-len(p.prefix)]); r >= '0' && r <= '9' || r == '.' || r == ' ' || r == '\t' {
+					s = s[:len(s)-len(p.prefix)]
+					exp -= p.exp
+					break
+				}
+			}
+		}
+	}
+	s = strings.TrimSpace(s)
+
+	// Parse the mantissa, the number of fractional digits and the exponent.
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	digits := 0
+	frac := -1
+	n := new(big.Int)
+	ten := big.NewInt(10)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			n.Mul(n, ten)
+			n.Add(n, big.NewInt(int64(c-'0')))
+			digits++
+			if frac >= 0 {
+				frac++
+			}
+		case c == '.' && frac < 0:
+			frac = 0
+		case (c == 'e' || c == 'E') && digits != 0:
+			e, err := strconv.Atoi(s[i+1:])
+			if err != nil {
+				if err.(*strconv.NumError).Err == strconv.ErrRange {
+					return nil, "", ErrRange
+				}
+				return nil, "", ErrSyntax
+			}
+			// Clamp to not overflow exp; the result is the same.
+			if e > 1000 {
+				e = 1000
+			} else if e < -1000 {
+				e = -1000
+			}
+			exp -= e
+			i = len(s)
+		default:
+			return nil, "", ErrSyntax
+		}
+	}
+	if digits == 0 {
+		return nil, "", ErrSyntax
+	}
+	if frac > 0 {
+		exp += frac
+	}
+	if neg {
+		n.Neg(n)
+	}
+
+	// Scale to 10^exp. The bounds avoid computing huge powers of ten: no
+	// quantity is above 10^40 and the result rounds to 0 when dividing by
+	// more than 10^(digits+1).
+	switch {
+	case n.Sign() == 0:
+	case exp < -40:
+		return nil, "", ErrRange
+	case exp > digits+1:
+		n.SetInt64(0)
+	case exp < 0:
+		n.Mul(n, new(big.Int).Exp(ten, big.NewInt(int64(-exp)), nil))
+	case exp > 0:
+		d := new(big.Int).Exp(ten, big.NewInt(int64(exp)), nil)
+		r := new(big.Int)
+		n.QuoRem(n, d, r)
+		// Round half away from zero.
+		if r.Abs(r).Lsh(r, 1).Cmp(d) >= 0 {
+			if neg {
+				n.Sub(n, big.NewInt(1))
+			} else {
+				n.Add(n, big.NewInt(1))
+			}
+		}
+	}
+	return n, unit, nil
+}
@@ -6,7 +6,15 @@ package physic
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -46,12 +54,84 @@ func TestAngle_String(t *testing.T) {
 	}
 }
 
+func TestAngle_Normalize(t *testing.T) {
+	data := []struct {
+		in     Angle
+		normal Angle
+		signed Angle
+	}{
+		{0, 0, 0},
+		{Pi, Pi, Pi},
+		{Pi + 1, Pi + 1, -Pi},
+		{-Pi, Pi + 1, -Pi},
+		{Theta, 0, 0},
+		{-Theta, 0, 0},
+		{Theta + Radian, Radian, Radian},
+		{-Radian, Theta - Radian, -Radian},
+		{3*Theta + 4*Radian, 4 * Radian, 4*Radian - Theta},
+		{-9223372036854775808, 1992036556, 1992036556},
+		{9223372036854775807, 4291148750, 4291148750 - Theta},
+	}
+	for i, line := range data {
+		if a := line.in.Normalize(); a != line.normal {
+			t.Fatalf("%d: %d.Normalize() = %d != %d", i, line.in, a, line.normal)
+		}
+		if a := line.in.NormalizeSigned(); a != line.signed {
+			t.Fatalf("%d: %d.NormalizeSigned() = %d != %d", i, line.in, a, line.signed)
+		}
+	}
+}
+
+func TestAngle_Diff(t *testing.T) {
+	data := []struct {
+		a, b     Angle
+		expected Angle
+	}{
+		{Degree, 359 * Degree, Degree - 359*Degree + Theta},
+		{359 * Degree, Degree, 359*Degree - Degree - Theta},
+		{10 * Degree, 20 * Degree, -10 * Degree},
+		{-170 * Degree, 170 * Degree, -170*Degree - 170*Degree + Theta},
+		{-9223372036854775808, 9223372036854775807, 1992036556 - 4291148750},
+	}
+	for i, line := range data {
+		if d := line.a.Diff(line.b); d != line.expected {
+			t.Fatalf("%d: %s.Diff(%s) = %s != %s", i, line.a, line.b, d, line.expected)
+		}
+	}
+}
+
 func TestDistance_String(t *testing.T) {
 	if s := Mile.String(); s != "1.609km" {
 		t.Fatalf("%#v", s)
 	}
 }
 
+func TestDistance_Imperial(t *testing.T) {
+	data := []struct {
+		in       Distance
+		expected string
+	}{
+		{0, "0in"},
+		{Thou / 2, "0.001in"},
+		{Thou/2 - 1, "0in"},
+		{Inch, "1in"},
+		{3250 * Thou, "3.250in"},
+		{-3250 * Thou, "-3.250in"},
+		{Foot, "1ft"},
+		{6 * Foot, "6ft"},
+		{Mile, "1mi"},
+		{2 * KiloMetre, "1.243mi"},
+		{-5 * KiloMetre, "-3.107mi"},
+		{math.MaxInt64, "5731137.679mi"},
+		{math.MinInt64, "-5731137.679mi"},
+	}
+	for i, line := range data {
+		if s := line.in.Imperial(); s != line.expected {
+			t.Fatalf("#%d: %d: %q != %q", i, int64(line.in), s, line.expected)
+		}
+	}
+}
+
 func TestElectricCurrent_String(t *testing.T) {
 	if s := Ampere.String(); s != "1A" {
 		t.Fatalf("%#v", s)
@@ -82,15 +162,68 @@ func TestFrequency_String(t *testing.T) {
 	}
 }
 
+func TestFrequency_Period(t *testing.T) {
+	data := []struct {
+		in       Frequency
+		expected time.Duration
+	}{
+		{MegaHertz, time.Microsecond},
+		{3 * Hertz, 333333333 * time.Nanosecond},
+		{3 * MegaHertz / 2, 667 * time.Nanosecond},
+		{-MegaHertz, -time.Microsecond},
+		{3 * GigaHertz, 0},
+		{MicroHertz, 1000000 * time.Second},
+		{0, time.Duration(math.MaxInt64)},
+		{Frequency(math.MinInt64), 0},
+	}
+	for i, line := range data {
+		if v := line.in.Period(); v != line.expected {
+			t.Fatalf("#%d: %s.Period() = %s, expected %s", i, line.in, v, line.expected)
+		}
+	}
+}
+
 func TestFrequency_Duration(t *testing.T) {
-	if v := MegaHertz.Duration(); v != time.Microsecond {
-		t.Fatalf("%#v", v)
+	data := []struct {
+		in       Frequency
+		cycles   int64
+		expected time.Duration
+	}{
+		{MegaHertz, 1, time.Microsecond},
+		{3 * Hertz, 3, time.Second},
+		{3 * Hertz, -3, -time.Second},
+		{-3 * Hertz, 3, -time.Second},
+		{3 * MegaHertz / 2, 1000, 666667 * time.Nanosecond},
+		{Hertz, 0, 0},
+		{0, 0, 0},
+		{0, 1, time.Duration(math.MaxInt64)},
+		{0, -1, time.Duration(math.MinInt64)},
+		{Hertz, math.MaxInt64, time.Duration(math.MaxInt64)},
+		{Hertz, math.MinInt64, time.Duration(math.MinInt64)},
+	}
+	for i, line := range data {
+		if v := line.in.Duration(line.cycles); v != line.expected {
+			t.Fatalf("#%d: %s.Duration(%d) = %s, expected %s", i, line.in, line.cycles, v, line.expected)
+		}
 	}
 }
 
 func TestFrequency_PeriodToFrequency(t *testing.T) {
-	if v := PeriodToFrequency(time.Millisecond); v != KiloHertz {
-		t.Fatalf("%#v", v)
+	data := []struct {
+		in       time.Duration
+		expected Frequency
+	}{
+		{time.Millisecond, KiloHertz},
+		{3 * time.Second, 333333 * MicroHertz},
+		{3 * time.Nanosecond, 333333333333333 * MicroHertz},
+		{-time.Millisecond, -KiloHertz},
+		{0, Frequency(math.MaxInt64)},
+		{time.Duration(math.MinInt64), 0},
+	}
+	for i, line := range data {
+		if v := PeriodToFrequency(line.in); v != line.expected {
+			t.Fatalf("#%d: PeriodToFrequency(%s) = %s, expected %s", i, line.in, v, line.expected)
+		}
 	}
 }
 
@@ -157,6 +290,103 @@ func TestSpeed_String(t *testing.T) {
 	}
 }
 
+func TestPressure_PSI(t *testing.T) {
+	data := []struct {
+		in       Pressure
+		expected string
+	}{
+		{0, "0psi"},
+		{Pascal, "0psi"},
+		{101325 * Pascal, "14.696psi"},
+		{6894757293168 * NanoPascal, "1psi"},
+		{-6894757293168 * NanoPascal, "-1psi"},
+		{3447378646584 * NanoPascal, "0.500psi"},
+		{math.MaxInt64, "1337737.014psi"},
+		{math.MinInt64, "-1337737.014psi"},
+	}
+	for i, line := range data {
+		if s := line.in.PSI(); s != line.expected {
+			t.Fatalf("#%d: %d: %q != %q", i, int64(line.in), s, line.expected)
+		}
+	}
+}
+
+func TestPressure_units(t *testing.T) {
+	data := []struct {
+		in       Pressure
+		expected string
+	}{
+		{Atmosphere, "101.325kPa"},
+		{1013 * HectoPascal, "101.300kPa"},
+		{1013 * MilliBar, "101.300kPa"},
+		{Bar, "100kPa"},
+		{760 * MillimetreOfMercury, "101.325kPa"},
+		{InchOfMercury, "3.386kPa"},
+	}
+	for i, line := range data {
+		if s := line.in.String(); s != line.expected {
+			t.Fatalf("#%d: %q != %q", i, s, line.expected)
+		}
+	}
+	// An inch is exactly 25.4mm.
+	if InchOfMercury*10 != MillimetreOfMercury*254 {
+		t.Fatal(InchOfMercury)
+	}
+}
+
+func TestBarometricAltitude(t *testing.T) {
+	// From the International Standard Atmosphere tables.
+	data := []struct {
+		p        Pressure
+		seaLevel Pressure
+		expected Distance
+	}{
+		{Atmosphere, Atmosphere, 0},
+		{898746 * Pascal / 10, Atmosphere, 1000 * Metre},
+		{794952 * Pascal / 10, Atmosphere, 2000 * Metre},
+		{540199 * Pascal / 10, Atmosphere, 5000 * Metre},
+		{264363 * Pascal / 10, Atmosphere, 10000 * Metre},
+		{1074778 * Pascal / 10, Atmosphere, -500 * Metre},
+		// The reference pressure at sea level is the one of the day.
+		{1000 * HectoPascal, 1020 * HectoPascal, 166714 * MilliMetre},
+	}
+	for i, line := range data {
+		h := BarometricAltitude(line.p, line.seaLevel)
+		if d := h - line.expected; d > Metre/2 || d < -Metre/2 {
+			t.Fatalf("#%d: BarometricAltitude(%s, %s) = %s, expected %s", i, line.p, line.seaLevel, h, line.expected)
+		}
+	}
+	if h := BarometricAltitude(0, Atmosphere); h != 0 {
+		t.Fatal(h)
+	}
+	if h := BarometricAltitude(Atmosphere, -Atmosphere); h != 0 {
+		t.Fatal(h)
+	}
+}
+
+func TestTemperature_Fahrenheit(t *testing.T) {
+	data := []struct {
+		in       Temperature
+		expected string
+	}{
+		{0, "-459.670°F"},
+		{1, "-459.670°F"},
+		{ZeroCelsius, "32°F"},
+		{ZeroCelsius + 23450*MilliCelsius, "74.210°F"},
+		{ZeroCelsius - 40*Celsius, "-40°F"},
+		{255372222222 * NanoKelvin, "0°F"},
+		// Negative temperatures are invalid but still formatted.
+		{-ZeroCelsius, "-951.340°F"},
+		{math.MaxInt64, "16602069206.669°F"},
+		{math.MinInt64, "-16602070126.009°F"},
+	}
+	for i, line := range data {
+		if s := line.in.Fahrenheit(); s != line.expected {
+			t.Fatalf("#%d: %d: %q != %q", i, int64(line.in), s, line.expected)
+		}
+	}
+}
+
 func TestTemperature_String(t *testing.T) {
 	if s := ZeroCelsius.String(); s != "0°C" {
 		t.Fatalf("%#v", s)
@@ -285,6 +515,150 @@ func TestFlux_String(t *testing.T) {
 	}
 }
 
+func TestIlluminance_String(t *testing.T) {
+	data := []struct {
+		in       Illuminance
+		expected string
+	}{
+		{NanoLux, "1nlx"},
+		{MilliLux, "1mlx"},
+		{Lux, "1lx"},
+		{KiloLux, "1klx"},
+		{GigaLux, "1Glx"},
+		{-320 * Lux, "-320lx"},
+	}
+	for i, line := range data {
+		if s := line.in.String(); s != line.expected {
+			t.Fatalf("%d: Illuminance(%d).String() = %s != %s", i, int64(line.in), s, line.expected)
+		}
+	}
+}
+
+func TestMagneticFluxDensity_String(t *testing.T) {
+	data := []struct {
+		in       MagneticFluxDensity
+		expected string
+	}{
+		{0, "0T"},
+		{NanoTesla, "1nT"},
+		{-NanoTesla, "-1nT"},
+		{MilliGauss, "100nT"},
+		{50 * MicroTesla, "50µT"},
+		{-50 * MicroTesla, "-50µT"},
+		{Gauss, "100µT"},
+		{-12345 * MicroTesla, "-12.345mT"},
+		{Tesla, "1T"},
+		{9223372036854775807, "9.223GT"},
+		{-9223372036854775807, "-9.223GT"},
+		{-9223372036854775808, "-9.223GT"},
+	}
+	for i, line := range data {
+		if s := line.in.String(); s != line.expected {
+			t.Fatalf("%d: MagneticFluxDensity(%d).String() = %s != %s", i, int64(line.in), s, line.expected)
+		}
+	}
+}
+
+func TestElectricCharge_String(t *testing.T) {
+	data := []struct {
+		in       ElectricCharge
+		expected string
+	}{
+		{0, "0C"},
+		{NanoCoulomb, "1nC"},
+		{360 * MilliCoulomb, "360mC"},
+		{-360 * MilliCoulomb, "-360mC"},
+		{MilliAmpereHour, "1mAh"},
+		{-MilliAmpereHour, "-1mAh"},
+		{150 * MilliAmpereHour, "150mAh"},
+		{2500 * MilliAmpereHour, "2.500Ah"},
+		{Coulomb + MilliAmpereHour, "1.278mAh"},
+		{9223372036854775807, "2.562MAh"},
+		{-9223372036854775808, "-2.562MAh"},
+	}
+	for i, line := range data {
+		if s := line.in.String(); s != line.expected {
+			t.Fatalf("%d: ElectricCharge(%d).String() = %s != %s", i, int64(line.in), s, line.expected)
+		}
+	}
+	if s := (150 * MilliAmpereHour).FormatAh(AmpereHour); s != "540C" {
+		t.Fatal(s)
+	}
+	if s := (3 * Coulomb).FormatAh(0); s != "833.333µAh" {
+		t.Fatal(s)
+	}
+}
+
+func TestElectricCharge_Current(t *testing.T) {
+	data := []struct {
+		i        ElectricCurrent
+		d        time.Duration
+		expected ElectricCharge
+	}{
+		{Ampere, time.Hour, AmpereHour},
+		{-20 * MilliAmpere, 30 * time.Minute, -10 * MilliAmpereHour},
+		{NanoAmpere, time.Nanosecond, 0},
+		{NanoAmpere, time.Second / 2, 1},
+		{-NanoAmpere, time.Second / 2, -1},
+		{9223372036854775807, 9223372036854775807, 9223372036854775807},
+		{-9223372036854775808, 9223372036854775807, -9223372036854775808},
+	}
+	for i, line := range data {
+		if c := ChargeFromCurrent(line.i, line.d); c != line.expected {
+			t.Fatalf("%d: ChargeFromCurrent(%s, %s) = %d != %d", i, line.i, line.d, c, line.expected)
+		}
+	}
+	if i := AmpereHour.Current(2 * time.Hour); i != 500*MilliAmpere {
+		t.Fatal(i)
+	}
+	if i := (-3 * Coulomb).Current(time.Second); i != -3*Ampere {
+		t.Fatal(i)
+	}
+	if i := (10 * Coulomb).Current(time.Nanosecond); i != 9223372036854775807 {
+		t.Fatal(i)
+	}
+}
+
+func TestAcceleration_String(t *testing.T) {
+	data := []struct {
+		in       Acceleration
+		expected string
+	}{
+		{0, "0m/s²"},
+		{MilliMetrePerSecondSquared, "1mm/s²"},
+		{StandardGravity, "9.807m/s²"},
+		{-2 * StandardGravity, "-19.613m/s²"},
+		{16 * StandardGravity, "156.906m/s²"},
+		{9223372036854775807, "9.223Gm/s²"},
+		{-9223372036854775808, "-9.223Gm/s²"},
+	}
+	for i, line := range data {
+		if s := line.in.String(); s != line.expected {
+			t.Fatalf("%d: Acceleration(%d).String() = %s != %s", i, int64(line.in), s, line.expected)
+		}
+	}
+}
+
+func TestAngularVelocity_String(t *testing.T) {
+	data := []struct {
+		in       AngularVelocity
+		expected string
+	}{
+		{0, "0rad/s"},
+		{MicroRadianPerSecond, "1µrad/s"},
+		{DegreePerSecond, "17.453mrad/s"},
+		{-250 * DegreePerSecond, "-4.363rad/s"},
+		{RevolutionPerMinute, "104.720mrad/s"},
+		{9223372036854775807, "9.223Grad/s"},
+		{-9223372036854775808, "-9.223Grad/s"},
+	}
+	for i, line := range data {
+		if s := line.in.String(); s != line.expected {
+			t.Fatalf("%d: AngularVelocity(%d).String() = %s != %s", i, int64(line.in), s, line.expected)
+		}
+	}
+}
+
 func TestPicoAsString(t *testing.T) {
 	data := []struct {
 		in       int64
@@ -510,6 +884,408 @@ func TestMicroAsString(t *testing.T) {
 	}
 }
 
+func TestSet(t *testing.T) {
+	var (
+		d Distance
+		i ElectricCurrent
+		v ElectricPotential
+		r ElectricResistance
+		f Frequency
+		p Pressure
+		h RelativeHumidity
+		c Temperature
+	)
+	data := []struct {
+		v        interface{ Set(string) error }
+		in       string
+		get      func() int64
+		expected int64
+	}{
+		{&d, "1.5km", func() int64 { return int64(d) }, int64(1500 * Metre)},
+		{&d, "12 mm", func() int64 { return int64(d) }, int64(12 * MilliMetre)},
+		{&d, "1m", func() int64 { return int64(d) }, int64(Metre)},
+		{&i, "-20mA", func() int64 { return int64(i) }, int64(-20 * MilliAmpere)},
+		{&v, "3.3V", func() int64 { return int64(v) }, int64(3300 * MilliVolt)},
+		{&v, " +12 µV ", func() int64 { return int64(v) }, int64(12 * MicroVolt)},
+		{&v, "12uV", func() int64 { return int64(v) }, int64(12 * MicroVolt)},
+		{&v, "1.5nV", func() int64 { return int64(v) }, int64(2 * NanoVolt)},
+		{&v, "-1.5nV", func() int64 { return int64(v) }, int64(-2 * NanoVolt)},
+		{&v, ".5GV", func() int64 { return int64(v) }, int64(500000 * KiloVolt)},
+		{&v, "1.5e3mV", func() int64 { return int64(v) }, int64(1500 * MilliVolt)},
+		{&v, "1E-9999999V", func() int64 { return int64(v) }, 0},
+		{&v, "0e99999V", func() int64 { return int64(v) }, 0},
+		{&r, "4.7kΩ", func() int64 { return int64(r) }, int64(4700 * Ohm)},
+		{&r, "10 Mohm", func() int64 { return int64(r) }, int64(10 * MegaOhm)},
+		{&f, "860Hz", func() int64 { return int64(f) }, int64(860 * Hertz)},
+		{&f, "1.5THz", func() int64 { return int64(f) }, int64(1500 * GigaHertz)},
+		{&f, "1mHz", func() int64 { return int64(f) }, int64(MilliHertz)},
+		{&p, "101.325kPa", func() int64 { return int64(p) }, int64(101325 * Pascal)},
+		{&h, "45.5%rH", func() int64 { return int64(h) }, int64(455 * MilliRH)},
+		{&h, "45.5%", func() int64 { return int64(h) }, int64(455 * MilliRH)},
+		{&c, "25.5°C", func() int64 { return int64(c) }, int64(ZeroCelsius + 25500*MilliCelsius)},
+		{&c, "-40C", func() int64 { return int64(c) }, int64(ZeroCelsius - 40*Celsius)},
+		{&c, "1m°C", func() int64 { return int64(c) }, int64(ZeroCelsius + MilliCelsius)},
+		{&c, "300K", func() int64 { return int64(c) }, int64(300 * Kelvin)},
+	}
+	for i, line := range data {
+		if err := line.v.Set(line.in); err != nil {
+			t.Fatalf("%d: Set(%q) = %v", i, line.in, err)
+		}
+		if v := line.get(); v != line.expected {
+			t.Fatalf("%d: Set(%q) = %d != %d", i, line.in, v, line.expected)
+		}
+	}
+}
+
+func TestSet_roundTrip(t *testing.T) {
+	values := []int64{0, 1, -1, 999, 1000, 1234567, -1234567, 999999500, 999999501, 1000000000, 45678901234, 9223372036854775807, -9223372036854775807}
+	for _, n := range values {
+		// The value printed by String() is parsed exactly, so a second round
+		// trip is stable.
+		d, i, v, r, f, p := Distance(n), ElectricCurrent(n), ElectricPotential(n), ElectricResistance(n), Frequency(n), Pressure(n)
+		for _, in := range []interface {
+			String() string
+			Set(string) error
+		}{&d, &i, &v, &r, &f, &p} {
+			s := in.String()
+			if err := in.Set(s); err != nil {
+				t.Fatal(err)
+			}
+			if s2 := in.String(); s2 != s {
+				t.Fatalf("%T: %s != %s", in, s2, s)
+			}
+		}
+	}
+	// Values with 4 significant digits round trip exactly.
+	for _, v := range []ElectricPotential{1234 * MilliVolt, -5 * NanoVolt, 1001 * MicroVolt, 42 * KiloVolt} {
+		var v2 ElectricPotential
+		if err := v2.Set(v.String()); err != nil || v2 != v {
+			t.Fatal(v, v2, err)
+		}
+	}
+	for _, v := range []Temperature{0, ZeroCelsius, ZeroCelsius + 25500*MilliCelsius, ZeroCelsius - 40*Celsius} {
+		var v2 Temperature
+		if err := v2.Set(v.String()); err != nil || v2 != v {
+			t.Fatal(v, v2, err)
+		}
+	}
+	for _, v := range []RelativeHumidity{0, 455 * MilliRH, 100 * PercentRH} {
+		var v2 RelativeHumidity
+		if err := v2.Set(v.String()); err != nil || v2 != v {
+			t.Fatal(v, v2, err)
+		}
+	}
+}
+
+func TestSet_error(t *testing.T) {
+	var (
+		d Distance
+		v ElectricPotential
+		f Frequency
+		h RelativeHumidity
+		c Temperature
+	)
+	data := []struct {
+		v   interface{ Set(string) error }
+		in  string
+		err error
+	}{
+		{&v, "", ErrSyntax},
+		{&v, "V", ErrSyntax},
+		{&v, "mV", ErrSyntax},
+		{&v, "3.3", ErrSyntax},
+		{&v, "3.3A", ErrSyntax},
+		{&v, "3..3V", ErrSyntax},
+		{&v, "3.3 xV", ErrSyntax},
+		{&v, "1eV", ErrSyntax},
+		{&v, "e3V", ErrSyntax},
+		{&v, "1e3.5V", ErrSyntax},
+		{&v, "1e99999999999999999999V", ErrRange},
+		{&v, "--1V", ErrSyntax},
+		{&d, "m", ErrSyntax},
+		{&h, "45m%", ErrSyntax},
+		{&v, "9.3GV", ErrRange},
+		{&v, "-9.3GV", ErrRange},
+		{&f, "9.3THz", ErrRange},
+		{&h, "30000%", ErrRange},
+		{&c, "-273.16°C", ErrRange},
+		{&c, "-1K", ErrRange},
+	}
+	for i, line := range data {
+		err := line.v.Set(line.in)
+		if !errors.Is(err, line.err) {
+			t.Fatalf("%d: Set(%q) = %v; expected %v", i, line.in, err, line.err)
+		}
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Input != line.in {
+			t.Fatalf("%d: Set(%q) = %#v", i, line.in, err)
+		}
+	}
+	const s = `physic: parsing ElectricPotential "3.3": invalid syntax`
+	if err := v.Set("3.3"); err.Error() != s {
+		t.Fatal(err)
+	}
+}
+
+func TestFlag(t *testing.T) {
+	var (
+		d Distance
+		i ElectricCurrent
+		f Frequency
+		p Pressure
+		c Temperature
+	)
+	v := 4096 * MilliVolt
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&d, "d", "distance")
+	fs.Var(&i, "i", "current")
+	fs.Var(&v, "v", "full-scale voltage")
+	fs.Var(&f, "f", "frequency")
+	fs.Var(&p, "p", "pressure")
+	fs.Var(&c, "c", "temperature")
+	if err := fs.Parse([]string{"-d", "1.5m", "-i", "20mA", "-f", "400kHz", "-p", "101.3kPa", "-c", "25°C"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{1500 * MilliMetre, 20 * MilliAmpere, 4096 * MilliVolt, 400 * KiloHertz, 101300 * Pascal, ZeroCelsius + 25*Celsius}
+	for j, name := range []string{"d", "i", "v", "f", "p", "c"} {
+		if g := fs.Lookup(name).Value.(flag.Getter).Get(); g != expected[j] {
+			t.Fatalf("-%s: %v != %v", name, g, expected[j])
+		}
+	}
+	// The zero value is not printed as a default.
+	b := bytes.Buffer{}
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	if s := b.String(); !strings.Contains(s, "full-scale voltage (default 4.096V)") || strings.Count(s, "default") != 1 {
+		t.Fatal(s)
+	}
+	if err := fs.Parse([]string{"-f", "400"}); err == nil {
+		t.Fatal("expected missing unit error")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data := []struct {
+		in       json.Marshaler
+		expected string
+	}{
+		{1234567891 * NanoVolt, `"1.234567891V"`},
+		{-12500 * NanoVolt, `"-12.5µV"`},
+		{ElectricPotential(0), `"0V"`},
+		{ElectricPotential(-9223372036854775808), `"-9.223372036854775808GV"`},
+		{1500 * Metre, `"1.5km"`},
+		{20 * MilliAmpere, `"20mA"`},
+		{4700 * Ohm, `"4.7kΩ"`},
+		{860 * Hertz, `"860Hz"`},
+		{MicroHertz, `"1µHz"`},
+		{Frequency(9223372036854775807), `"9.223372036854775807THz"`},
+		{101325 * Pascal, `"101.325kPa"`},
+		{455*MilliRH + 3*TenthMicroRH, `"45.50003%rH"`},
+		{ZeroCelsius + 23450*MilliCelsius, `"23.45°C"`},
+		{ZeroCelsius - 40*Celsius, `"-40°C"`},
+		{Temperature(0), `"-273.15°C"`},
+	}
+	for i, line := range data {
+		b, err := json.Marshal(line.in)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if string(b) != line.expected {
+			t.Fatalf("%d: %s != %s", i, b, line.expected)
+		}
+		// The string form round trips exactly.
+		v := reflect.New(reflect.TypeOf(line.in))
+		if err := json.Unmarshal(b, v.Interface()); err != nil {
+			t.Fatal(i, err)
+		}
+		if v.Elem().Interface() != line.in {
+			t.Fatalf("%d: %v != %v", i, v.Elem().Interface(), line.in)
+		}
+	}
+}
+
+func TestJSON_number(t *testing.T) {
+	var v struct {
+		V ElectricPotential
+		F Frequency
+		T Temperature
+		H RelativeHumidity
+	}
+	if err := json.Unmarshal([]byte(`{"V":1.2345678912,"F":4e5,"T":298.15,"H":45.5}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.V != 1234567891*NanoVolt || v.F != 400*KiloHertz || v.T != ZeroCelsius+25*Celsius || v.H != 455*MilliRH {
+		t.Fatalf("%+v", v)
+	}
+	// null is ignored.
+	if err := json.Unmarshal([]byte(`{"V":null,"T":null,"H":null}`), &v); err != nil || v.V != 1234567891*NanoVolt {
+		t.Fatal(v, err)
+	}
+	for _, in := range []string{`{"V":true}`, `{"V":"3.3"}`, `{"T":-1}`, `{"H":1e10}`, `{"T":"x"}`} {
+		err := json.Unmarshal([]byte(in), &v)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Fatalf("%s: %v", in, err)
+		}
+	}
+}
+
+func TestEnv_JSON(t *testing.T) {
+	e := Env{Temperature: ZeroCelsius + 23450*MilliCelsius, Pressure: 101325 * Pascal, Humidity: 455 * MilliRH}
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const s = `{"temperature":"23.45°C","pressure":"101.325kPa","humidity":"45.5%rH"}`
+	if string(b) != s {
+		t.Fatal(string(b))
+	}
+	var e2 Env
+	if err := json.Unmarshal(b, &e2); err != nil || e2 != e {
+		t.Fatal(e2, err)
+	}
+	e.GasResistance = 125 * KiloOhm
+	if b, err = json.Marshal(e); err != nil {
+		t.Fatal(err)
+	}
+	const g = `{"temperature":"23.45°C","pressure":"101.325kPa","humidity":"45.5%rH","gasResistance":"125kΩ"}`
+	if string(b) != g {
+		t.Fatal(string(b))
+	}
+	e2 = Env{}
+	if err := json.Unmarshal(b, &e2); err != nil || e2 != e {
+		t.Fatal(e2, err)
+	}
+}
+
+func TestEnv_Fields(t *testing.T) {
+	if f := (&Env{}).Fields(); f != 0 {
+		t.Fatal(f)
+	}
+	e := Env{Temperature: MilliKelvin, Humidity: MicroRH, GasResistance: Ohm}
+	if f := e.Fields(); f != EnvTemperature|EnvHumidity|EnvGasResistance {
+		t.Fatal(f)
+	}
+}
+
+func TestEnv_DewPoint(t *testing.T) {
+	// Reference values in °C, from the saturation vapor pressure over water of
+	// Hyland and Wexler (1983) used by the psychrometric tables.
+	data := []struct {
+		t, rh, expected float64
+	}{
+		{-10, 80, -12.8},
+		{0, 100, 0},
+		{0, 50, -9.2},
+		{10, 70, 4.8},
+		{20, 50, 9.3},
+		{25, 60, 16.7},
+		{30, 80, 26.2},
+		{35, 40, 19.4},
+		{40, 20, 12.8},
+	}
+	for i, line := range data {
+		e := Env{
+			Temperature: ZeroCelsius + Temperature(line.t*float64(Celsius)),
+			Humidity:    RelativeHumidity(line.rh * float64(PercentRH)),
+		}
+		d, err := e.DewPoint()
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if c := float64(d-ZeroCelsius) / float64(Celsius); math.Abs(c-line.expected) > 0.1 {
+			t.Fatalf("#%d: %s: %.2f != %.1f", i, d, c, line.expected)
+		}
+	}
+}
+
+func TestEnv_HeatIndex(t *testing.T) {
+	// Reference values from the heat index chart of the US National Weather
+	// Service, in °F.
+	data := []struct {
+		t, rh, expected float64
+	}{
+		{70, 50, 69},
+		{80, 40, 80},
+		{86, 90, 105},
+		{90, 60, 100},
+		{94, 55, 106},
+		{100, 50, 118},
+		{110, 40, 136},
+		{104, 10, 98},
+	}
+	for i, line := range data {
+		e := Env{
+			Temperature: ZeroCelsius + Temperature((line.t-32)*5/9*float64(Celsius)),
+			Humidity:    RelativeHumidity(line.rh * float64(PercentRH)),
+		}
+		h, err := e.HeatIndex()
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		// The chart is rounded to the degree.
+		if f := float64(h-ZeroCelsius)/float64(Celsius)*9/5 + 32; math.Abs(f-line.expected) > 1 {
+			t.Fatalf("#%d: %s: %.2f != %.0f", i, h.Fahrenheit(), f, line.expected)
+		}
+	}
+}
+
+func TestEnv_derived_error(t *testing.T) {
+	data := []struct {
+		in      Env
+		missing string
+	}{
+		{Env{}, "Temperature|Humidity"},
+		{Env{Temperature: ZeroCelsius, Pressure: Atmosphere}, "Humidity"},
+		{Env{Humidity: 50 * PercentRH}, "Temperature"},
+	}
+	for i, line := range data {
+		for _, f := range []func() (Temperature, error){line.in.DewPoint, line.in.HeatIndex} {
+			v, err := f()
+			if !errors.Is(err, ErrFieldNotSet) {
+				t.Fatalf("#%d: %v", i, err)
+			}
+			if s := "physic: field not set: " + line.missing; err.Error() != s {
+				t.Fatalf("#%d: %q != %q", i, err, s)
+			}
+			if v != 0 {
+				t.Fatalf("#%d: %s", i, v)
+			}
+		}
+	}
+}
+
+func TestEnvField(t *testing.T) {
+	data := []struct {
+		in       EnvField
+		expected string
+	}{
+		{0, "0"},
+		{EnvTemperature, "Temperature"},
+		{EnvTemperature | EnvPressure | EnvHumidity | EnvGasResistance, "Temperature|Pressure|Humidity|GasResistance"},
+		{EnvHumidity | 0x30, "Humidity|EnvField(0x30)"},
+	}
+	for i, line := range data {
+		if s := line.in.String(); s != line.expected {
+			t.Fatalf("#%d: %q != %q", i, s, line.expected)
+		}
+	}
+	f := EnvTemperature | EnvPressure
+	if !f.Has(EnvPressure) || !f.Has(EnvTemperature|EnvPressure) || f.Has(EnvPressure|EnvHumidity) {
+		t.Fatal(f)
+	}
+}
+
+func TestSensedFields(t *testing.T) {
+	if f := SensedFields(&envSensor{}); f != EnvTemperature|EnvHumidity {
+		t.Fatal(f)
+	}
+	if f := SensedFields(&envSensorFields{fields: EnvGasResistance}); f != EnvGasResistance {
+		t.Fatal(f)
+	}
+}
+
 func BenchmarkCelsiusString(b *testing.B) {
 	v := 10*Celsius + ZeroCelsius
 	buf := bytes.Buffer{}
@@ -536,3 +1312,181 @@ func BenchmarkCelsiusFloatg(b *testing.B) {
 		buf.Reset()
 	}
 }
+
+func TestMulDiv(t *testing.T) {
+	data := []struct {
+		v, num, den int64
+		expected    int64
+		ok          bool
+	}{
+		{10, 1, 3, 3, true},
+		{20, 1, 3, 7, true},
+		{-20, 1, 3, -7, true},
+		{20, -1, 3, -7, true},
+		{20, 1, -3, -7, true},
+		{-20, -1, -3, -7, true},
+		{3, 1, 2, 2, true},
+		{-3, 1, 2, -2, true},
+		// The intermediate product doesn't overflow.
+		{math.MaxInt64, 1000, 1000, math.MaxInt64, true},
+		{math.MinInt64, 3, 3, math.MinInt64, true},
+		{math.MaxInt64 / 2, 5, 2, math.MaxInt64, false},
+		{math.MinInt64 / 2, 5, 2, math.MinInt64, false},
+		{math.MinInt64, -1, 1, math.MaxInt64, false},
+		{1, 1, 0, 0, false},
+	}
+	for i, line := range data {
+		if v, ok := MulDiv(line.v, line.num, line.den); v != line.expected || ok != line.ok {
+			t.Fatalf("#%d: MulDiv(%d, %d, %d) = %d, %t; expected %d, %t", i, line.v, line.num, line.den, v, ok, line.expected, line.ok)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	data := []struct {
+		v        int64
+		f        float64
+		expected int64
+		ok       bool
+	}{
+		{10, 1.5, 15, true},
+		{3, 0.5, 2, true},
+		{-3, 0.5, -2, true},
+		{1000000000, -2.5, -2500000000, true},
+		{1 << 52, 1024, 1 << 62, true},
+		{math.MaxInt64, 1, math.MaxInt64, false},
+		{math.MinInt64, 1, math.MinInt64, true},
+		{math.MaxInt64 / 2, 3, math.MaxInt64, false},
+		{math.MinInt64 / 2, 3, math.MinInt64, false},
+		{1, math.Inf(1), math.MaxInt64, false},
+		{-1, math.Inf(1), math.MinInt64, false},
+		{1, math.NaN(), 0, false},
+	}
+	for i, line := range data {
+		if v, ok := Scale(line.v, line.f); v != line.expected || ok != line.ok {
+			t.Fatalf("#%d: Scale(%d, %g) = %d, %t; expected %d, %t", i, line.v, line.f, v, ok, line.expected, line.ok)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	data := []struct {
+		a, b     int64
+		expected int64
+		ok       bool
+	}{
+		{1, 2, 3, true},
+		{-1, -2, -3, true},
+		{math.MaxInt64, math.MinInt64, -1, true},
+		{math.MaxInt64, 1, math.MaxInt64, false},
+		{math.MinInt64, -1, math.MinInt64, false},
+		{math.MaxInt64, math.MaxInt64, math.MaxInt64, false},
+	}
+	for i, line := range data {
+		if v, ok := Add(line.a, line.b); v != line.expected || ok != line.ok {
+			t.Fatalf("#%d: Add(%d, %d) = %d, %t; expected %d, %t", i, line.a, line.b, v, ok, line.expected, line.ok)
+		}
+	}
+}
+
+func TestSub(t *testing.T) {
+	data := []struct {
+		a, b     int64
+		expected int64
+		ok       bool
+	}{
+		{1, 2, -1, true},
+		{-1, -2, 1, true},
+		{-1, math.MaxInt64, math.MinInt64, true},
+		{0, math.MinInt64, math.MaxInt64, false},
+		{math.MinInt64, 1, math.MinInt64, false},
+		{math.MaxInt64, -1, math.MaxInt64, false},
+	}
+	for i, line := range data {
+		if v, ok := Sub(line.a, line.b); v != line.expected || ok != line.ok {
+			t.Fatalf("#%d: Sub(%d, %d) = %d, %t; expected %d, %t", i, line.a, line.b, v, ok, line.expected, line.ok)
+		}
+	}
+}
+
+func TestPollStream(t *testing.T) {
+	s := &envSensor{}
+	p := PollStream(s)
+	if PollStream(p) != p {
+		t.Fatal("expected the SenseStreamer as is")
+	}
+	if _, err := p.SenseStream(context.Background(), 0); err == nil {
+		t.Fatal("expected error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := p.SenseStream(ctx, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := <-c; v.Err != nil || v.Temperature != ZeroCelsius || v.T.IsZero() {
+		t.Fatal(v)
+	}
+	cancel()
+	for range c {
+	}
+
+	s.err = errors.New("oops")
+	c, err = p.SenseStream(context.Background(), time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if v := <-c; v.Err != s.err || v.Temperature != 0 {
+			t.Fatal(v)
+		}
+	}
+	if err := p.Halt(); err != nil || !s.halted {
+		t.Fatal(err)
+	}
+	for range c {
+	}
+}
+
+//
+
+// envSensor is a SenseEnv measuring the temperature and the humidity.
+type envSensor struct {
+	err    error
+	halted bool
+}
+
+func (e *envSensor) String() string {
+	return "envSensor"
+}
+
+func (e *envSensor) Halt() error {
+	e.halted = true
+	return nil
+}
+
+func (e *envSensor) Sense(env *Env) error {
+	if e.err != nil {
+		return e.err
+	}
+	env.Temperature = ZeroCelsius
+	return nil
+}
+
+func (e *envSensor) SenseContinuous(interval time.Duration) (<-chan Env, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *envSensor) Precision(env *Env) {
+	env.Temperature = MilliKelvin
+	env.Humidity = MicroRH
+}
+
+// envSensorFields is an envSensor implementing SenseEnvFields.
+type envSensorFields struct {
+	envSensor
+	fields EnvField
+}
+
+func (e *envSensorFields) Fields() EnvField {
+	return e.fields
+}
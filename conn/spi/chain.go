@@ -0,0 +1,130 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"periph.io/x/periph/conn"
+)
+
+// Chain composes the frames of devices daisy chained on one connection, like
+// MAX7219 LED matrices or TLC5947 LED drivers.
+//
+// The devices shift the data through to the next one, so the whole chain is
+// sent in one transaction; the devices latch their frame when CS is released.
+// Device 0 is the one connected to MOSI, its frame is sent last.
+//
+// Write() and WriteAll() buffer the frames and Flush() sends them. It is safe
+// for concurrent use.
+type Chain struct {
+	c    conn.Conn
+	n    int
+	size int
+	noop []byte
+
+	mu      sync.Mutex
+	frames  []byte // Last frame of each device, in device order.
+	pending []bool
+}
+
+// NewChain returns a Chain of devices of frameSize bytes each on c.
+//
+// noop is the frame sent by Flush() to the devices that were not written to,
+// e.g. {0x00, 0x00} for the MAX7219. When nil, these devices are sent their
+// last frame again, as needed by plain shift registers; it is initially all
+// zeros.
+func NewChain(c conn.Conn, devices, frameSize int, noop []byte) (*Chain, error) {
+	if devices < 1 {
+		return nil, errors.New("spi: chain needs at least one device")
+	}
+	if frameSize < 1 {
+		return nil, errors.New("spi: invalid chain frame size")
+	}
+	if noop != nil && len(noop) != frameSize {
+		return nil, fmt.Errorf("spi: no-op frame must be %d bytes, got %d", frameSize, len(noop))
+	}
+	ch := &Chain{
+		c:       c,
+		n:       devices,
+		size:    frameSize,
+		frames:  make([]byte, devices*frameSize),
+		pending: make([]bool, devices),
+	}
+	if noop != nil {
+		ch.noop = append([]byte(nil), noop...)
+	}
+	return ch, nil
+}
+
+func (c *Chain) String() string {
+	return fmt.Sprintf("Chain{%s, %d}", c.c, c.n)
+}
+
+// Devices returns the number of devices in the chain.
+func (c *Chain) Devices() int {
+	return c.n
+}
+
+// Write buffers frame for the device. It is sent on the next Flush().
+func (c *Chain) Write(device int, frame []byte) error {
+	if device < 0 || device >= c.n {
+		return fmt.Errorf("spi: invalid chain device %d; there are %d devices", device, c.n)
+	}
+	if len(frame) != c.size {
+		return fmt.Errorf("spi: chain frame must be %d bytes, got %d", c.size, len(frame))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	copy(c.frames[device*c.size:], frame)
+	c.pending[device] = true
+	return nil
+}
+
+// WriteAll buffers frame for all the devices. It is sent on the next Flush().
+func (c *Chain) WriteAll(frame []byte) error {
+	if len(frame) != c.size {
+		return fmt.Errorf("spi: chain frame must be %d bytes, got %d", c.size, len(frame))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < c.n; i++ {
+		copy(c.frames[i*c.size:], frame)
+		c.pending[i] = true
+	}
+	return nil
+}
+
+// Flush sends the frames of all the devices in one transaction.
+//
+// Nothing is sent if no frame was written since the last Flush().
+func (c *Chain) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dirty := false
+	for _, p := range c.pending {
+		dirty = dirty || p
+	}
+	if !dirty {
+		return nil
+	}
+	w := make([]byte, 0, len(c.frames))
+	for i := c.n - 1; i >= 0; i-- {
+		if c.pending[i] || c.noop == nil {
+			w = append(w, c.frames[i*c.size:(i+1)*c.size]...)
+		} else {
+			w = append(w, c.noop...)
+		}
+	}
+	if err := c.c.Tx(w, nil); err != nil {
+		return err
+	}
+	for i := range c.pending {
+		c.pending[i] = false
+	}
+	return nil
+}
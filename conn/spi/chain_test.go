@@ -0,0 +1,129 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi_test
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spitest"
+)
+
+func TestChain_noop(t *testing.T) {
+	p := spitest.Playback{
+		Playback: conntest.Playback{
+			Ops: []conntest.IO{
+				// Device 2 is sent first, device 0 last.
+				{W: []byte{0x00, 0x00, 0x01, 0xAA, 0x00, 0x00}},
+				{W: []byte{0x0C, 0x01, 0x0C, 0x01, 0x0C, 0x01}},
+			},
+			DontPanic: true,
+		},
+	}
+	c, err := p.Connect(0, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := spi.NewChain(c, 3, 2, []byte{0x00, 0x00})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := ch.String(); s != "Chain{playback, 3}" {
+		t.Fatal(s)
+	}
+	if n := ch.Devices(); n != 3 {
+		t.Fatal(n)
+	}
+	// Nothing to send.
+	if err := ch.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Write(1, []byte{0x01, 0xAA}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// Broadcast.
+	if err := ch.WriteAll([]byte{0x0C, 0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChain_shiftRegister(t *testing.T) {
+	p := spitest.Playback{
+		Playback: conntest.Playback{
+			Ops: []conntest.IO{
+				{W: []byte{0x00, 0x01}},
+				// Device 0 keeps its last frame.
+				{W: []byte{0x02, 0x01}},
+			},
+			DontPanic: true,
+		},
+	}
+	c, err := p.Connect(0, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := spi.NewChain(c, 2, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Write(0, []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Write(1, []byte{0x02}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The frames stay pending when the transaction fails.
+	if err := ch.Write(1, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if ch.Flush() == nil {
+		t.Fatal("Playback.Ops is empty")
+	}
+}
+
+func TestChain_err(t *testing.T) {
+	c := &conntest.Discard{}
+	if _, err := spi.NewChain(c, 0, 1, nil); err == nil {
+		t.Fatal("no device")
+	}
+	if _, err := spi.NewChain(c, 1, 0, nil); err == nil {
+		t.Fatal("no frame")
+	}
+	if _, err := spi.NewChain(c, 1, 2, []byte{0}); err == nil {
+		t.Fatal("invalid no-op")
+	}
+	ch, err := spi.NewChain(c, 2, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.Write(2, []byte{0, 0}) == nil {
+		t.Fatal("invalid device")
+	}
+	if ch.Write(0, []byte{0}) == nil {
+		t.Fatal("invalid frame")
+	}
+	if ch.WriteAll([]byte{0}) == nil {
+		t.Fatal("invalid frame")
+	}
+}
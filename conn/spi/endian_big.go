@@ -0,0 +1,12 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build armbe arm64be mips mips64 mips64p32 ppc ppc64 s390 s390x sparc sparc64
+
+package spi
+
+import "encoding/binary"
+
+// hostOrder is the byte order of the host, used by spidev for the words.
+var hostOrder binary.ByteOrder = binary.BigEndian
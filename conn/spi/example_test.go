@@ -70,3 +70,37 @@ func ExamplePins() {
 		fmt.Printf("  CS  : %s", p.CS())
 	}
 }
+
+func ExamplePacket() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Use spireg SPI port registry to find the first available SPI bus.
+	p, err := spireg.Open("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer p.Close()
+
+	// Connect at the slowest speed used, so the transaction still works on a
+	// port that can't change the speed in the middle of it.
+	c, err := p.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Read 16 bytes at address 0x001000 of a SPI NOR flash with the "Fast Read"
+	// command: the command and the address are sent slowly, then 8 dummy
+	// cycles give the flash the time to fetch the data, which is read fast.
+	data := make([]byte, 16)
+	pkts := []spi.Packet{
+		{W: []byte{0x0B, 0x00, 0x10, 0x00}, KeepCS: true},
+		{R: data, Speed: 50 * physic.MegaHertz, DummyBits: 8},
+	}
+	if err := c.TxPackets(pkts); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%x\n", data)
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Log is a Port that logs the transactions on the connection returned by the
+// port it wraps.
+//
+// It is meant to debug device drivers. Pass it to a driver in place of the
+// port. Each transaction is written as a single line to Output, e.g.:
+// "15:04:05.000000 W:[01 02] R:[0a 0b] 152µs". The packets of a TxPackets()
+// call are separated with " |".
+//
+// It is safe for concurrent use; the lines of concurrent transactions are not
+// interleaved.
+type Log struct {
+	// Port is the port to use.
+	Port Port
+	// Output is where the transactions are logged. When nil, nothing is logged
+	// and the transactions are passed through as is.
+	Output io.Writer
+	// Redact, if set, is called before a transaction is logged. It can
+	// overwrite the bytes of w and r, which are copies of the transaction
+	// buffers, to hide sensitive data like keys.
+	Redact func(w, r []byte)
+
+	mu sync.Mutex
+}
+
+func (l *Log) String() string {
+	return "log(" + l.Port.String() + ")"
+}
+
+// Connect implements Port.
+func (l *Log) Connect(f physic.Frequency, mode Mode, bits int) (Conn, error) {
+	c, err := l.Port.Connect(f, mode, bits)
+	if l.Output != nil {
+		line := fmt.Sprintf("%s Connect(%s, %s, %d)", time.Now().Format("15:04:05.000000"), f, mode, bits)
+		if err != nil {
+			line += ": " + err.Error()
+		}
+		l.write(line)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &logConn{l: l, c: c}, nil
+}
+
+//
+
+func (l *Log) write(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.Output, line+"\n")
+}
+
+func (l *Log) format(w, r []byte) string {
+	return formatTransfer(l.Redact, w, r)
+}
+
+func (l *Log) log(start time.Time, transfers string, err error) {
+	l.write(logLine(start, transfers, err))
+}
+
+// formatTransfer returns the log representation of a transfer.
+func formatTransfer(redact func(w, r []byte), w, r []byte) string {
+	if redact != nil {
+		w = append([]byte(nil), w...)
+		r = append([]byte(nil), r...)
+		redact(w, r)
+	}
+	s := ""
+	if len(w) != 0 {
+		s += fmt.Sprintf(" W:[% x]", w)
+	}
+	if len(r) != 0 {
+		s += fmt.Sprintf(" R:[% x]", r)
+	}
+	return s
+}
+
+// logLine returns the log line of a transaction started at start.
+func logLine(start time.Time, transfers string, err error) string {
+	line := start.Format("15:04:05.000000") + transfers + " " + time.Since(start).Round(time.Microsecond).String()
+	if err != nil {
+		line += ": " + err.Error()
+	}
+	return line
+}
+
+// logConn is the Conn returned by Log.Connect().
+type logConn struct {
+	l *Log
+	c Conn
+}
+
+func (l *logConn) String() string {
+	return l.c.String()
+}
+
+func (l *logConn) Duplex() conn.Duplex {
+	return l.c.Duplex()
+}
+
+func (l *logConn) Tx(w, r []byte) error {
+	if l.l.Output == nil {
+		return l.c.Tx(w, r)
+	}
+	start := time.Now()
+	err := l.c.Tx(w, r)
+	l.l.log(start, l.l.format(w, r), err)
+	return err
+}
+
+func (l *logConn) TxPackets(p []Packet) error {
+	if l.l.Output == nil {
+		return l.c.TxPackets(p)
+	}
+	start := time.Now()
+	err := l.c.TxPackets(p)
+	s := ""
+	for i := range p {
+		if i != 0 {
+			s += " |"
+		}
+		s += l.l.format(p[i].W, p[i].R)
+	}
+	l.l.log(start, s, err)
+	return err
+}
+
+var _ Port = &Log{}
+var _ Conn = &logConn{}
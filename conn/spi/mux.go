@@ -0,0 +1,280 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+)
+
+// MuxOpts is the options of the chip select lines of MultiplexPort().
+type MuxOpts struct {
+	// ActiveHigh specifies that the chip select lines are asserted high
+	// instead of low.
+	ActiveHigh bool
+	// Setup is the minimum delay between asserting a chip select line and the
+	// start of the transfer.
+	Setup time.Duration
+	// Hold is the minimum delay between the end of the transfer and releasing
+	// the chip select line.
+	Hold time.Duration
+}
+
+// MultiplexPort shares p between multiple devices, each selected with its own
+// GPIO used as chip select line.
+//
+// It returns one Port per entry in cs, with the same key. The lines are
+// released immediately. The transactions of all the devices are serialized
+// on p; the line of the device is asserted around each transaction, and is
+// kept asserted across the packets with KeepCS.
+//
+// p is connected with NoCS when the first device connects, so its own chip
+// select line is unused. All the devices must use the same clock mode, as
+// most ports can only set it once. The speed and bits per word of each
+// device are set on each packet with Packet.Speed and Packet.BitsPerWord;
+// on a port that ignores Packet.Speed, all the devices use the speed of the
+// first one to connect.
+//
+// opts can be nil for active low lines without delays. Use
+// spireg.RegisterMux() to also register the ports.
+func MultiplexPort(p Port, cs map[string]gpio.PinOut, opts *MuxOpts) (map[string]*MuxPort, error) {
+	if len(cs) == 0 {
+		return nil, errors.New("spi: no chip select line to multiplex")
+	}
+	if opts == nil {
+		opts = &MuxOpts{}
+	}
+	names := make([]string, 0, len(cs))
+	for name, pin := range cs {
+		if name == "" {
+			return nil, errors.New("spi: chip select line with no name")
+		}
+		if pin == nil {
+			return nil, fmt.Errorf("spi: chip select line %q is nil", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	m := &mux{p: p}
+	out := make(map[string]*MuxPort, len(cs))
+	for _, name := range names {
+		mp := &MuxPort{name: name, m: m, cs: cs[name], opts: *opts}
+		if err := mp.release(); err != nil {
+			return nil, fmt.Errorf("spi: failed to release chip select line %q: %v", name, err)
+		}
+		out[name] = mp
+	}
+	return out, nil
+}
+
+// MuxPort is a Port for one of the devices sharing a port, as returned by
+// MultiplexPort().
+type MuxPort struct {
+	name string
+	m    *mux
+	cs   gpio.PinOut
+	opts MuxOpts
+
+	mu        sync.Mutex
+	limit     physic.Frequency
+	connected bool
+}
+
+func (p *MuxPort) String() string {
+	return p.m.p.String() + "/" + p.name
+}
+
+// Close implements PortCloser.
+//
+// It doesn't close the shared port.
+func (p *MuxPort) Close() error {
+	return nil
+}
+
+// LimitSpeed implements PortCloser.
+func (p *MuxPort) LimitSpeed(f physic.Frequency) error {
+	if f <= 0 {
+		return errors.New("spi: invalid speed")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limit = f
+	return nil
+}
+
+// Connect implements Port.
+//
+// mode must be the same clock mode, LSBFirst and HalfDuplex as the other
+// devices that connected; NoCS tells to not use the chip select line of this
+// device.
+func (p *MuxPort) Connect(f physic.Frequency, mode Mode, bits int) (Conn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.connected {
+		return nil, errors.New("spi: Connect() can only be called exactly once")
+	}
+	if bits < 1 || bits > 255 {
+		return nil, fmt.Errorf("spi: invalid bits %d", bits)
+	}
+	if p.limit != 0 && (f == 0 || p.limit < f) {
+		f = p.limit
+	}
+	if err := p.m.connect(f, mode&^NoCS, bits); err != nil {
+		return nil, err
+	}
+	p.connected = true
+	return &muxConn{p: p, f: f, bits: uint8(bits), noCS: mode&NoCS != 0}, nil
+}
+
+//
+
+// mux is the port shared by the MuxPort.
+type mux struct {
+	mu   sync.Mutex
+	p    Port
+	c    Conn
+	mode Mode
+	bits int
+}
+
+// connect connects the shared port on first use.
+func (m *mux) connect(f physic.Frequency, mode Mode, bits int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.c == nil {
+		c, err := m.p.Connect(f, mode|NoCS, bits)
+		if err != nil {
+			return err
+		}
+		m.c, m.mode, m.bits = c, mode, bits
+		return nil
+	}
+	if mode != m.mode {
+		return fmt.Errorf("spi: mode %s differs from %s used by the other devices on %s: %w", mode, m.mode, m.p, ErrNotSupported)
+	}
+	return nil
+}
+
+func (p *MuxPort) assert() error {
+	return p.cs.Out(gpio.Level(p.opts.ActiveHigh))
+}
+
+func (p *MuxPort) release() error {
+	return p.cs.Out(gpio.Level(!p.opts.ActiveHigh))
+}
+
+// muxConn is the Conn returned by MuxPort.Connect().
+type muxConn struct {
+	p    *MuxPort
+	f    physic.Frequency
+	bits uint8
+	noCS bool
+}
+
+func (c *muxConn) String() string {
+	return c.p.String()
+}
+
+func (c *muxConn) Duplex() conn.Duplex {
+	return c.p.m.c.Duplex()
+}
+
+func (c *muxConn) Tx(w, r []byte) error {
+	return c.TxPackets([]Packet{{W: w, R: r}})
+}
+
+// TxPackets asserts the chip select line around each run of packets with
+// KeepCS.
+func (c *muxConn) TxPackets(p []Packet) error {
+	if len(p) == 0 {
+		return errors.New("spi: empty packets")
+	}
+	// Copy the packets to set the speed and bits of this device.
+	pkts := make([]Packet, len(p))
+	for i := range p {
+		pkts[i] = p[i]
+		if pkts[i].Speed == 0 {
+			pkts[i].Speed = c.f
+		}
+		if pkts[i].BitsPerWord == 0 && int(c.bits) != c.p.m.bits {
+			pkts[i].BitsPerWord = c.bits
+		}
+	}
+	m := c.p.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for start := 0; start < len(pkts); {
+		end := start + 1
+		for end < len(pkts) && pkts[end-1].KeepCS {
+			end++
+		}
+		if err := c.tx(pkts[start:end]); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+// CLK implements Pins.
+func (c *muxConn) CLK() gpio.PinOut {
+	if p, ok := c.p.m.c.(Pins); ok {
+		return p.CLK()
+	}
+	return gpio.INVALID
+}
+
+// MOSI implements Pins.
+func (c *muxConn) MOSI() gpio.PinOut {
+	if p, ok := c.p.m.c.(Pins); ok {
+		return p.MOSI()
+	}
+	return gpio.INVALID
+}
+
+// MISO implements Pins.
+func (c *muxConn) MISO() gpio.PinIn {
+	if p, ok := c.p.m.c.(Pins); ok {
+		return p.MISO()
+	}
+	return gpio.INVALID
+}
+
+// CS implements Pins.
+func (c *muxConn) CS() gpio.PinOut {
+	return c.p.cs
+}
+
+// tx sends the packets with the chip select line asserted. m.mu must be held.
+func (c *muxConn) tx(p []Packet) error {
+	if c.noCS {
+		return c.p.m.c.TxPackets(p)
+	}
+	if err := c.p.assert(); err != nil {
+		return fmt.Errorf("spi: failed to assert chip select line of %s: %v", c.p, err)
+	}
+	if c.p.opts.Setup != 0 {
+		time.Sleep(c.p.opts.Setup)
+	}
+	err := c.p.m.c.TxPackets(p)
+	if c.p.opts.Hold != 0 {
+		time.Sleep(c.p.opts.Hold)
+	}
+	if err2 := c.p.release(); err2 != nil && err == nil {
+		err = fmt.Errorf("spi: failed to release chip select line of %s: %v", c.p, err2)
+	}
+	return err
+}
+
+var _ PortCloser = &MuxPort{}
+var _ Conn = &muxConn{}
+var _ Pins = &muxConn{}
@@ -22,6 +22,8 @@
 package spi
 
 import (
+	"context"
+	"errors"
 	"io"
 	"strconv"
 
@@ -49,13 +51,22 @@ const (
 	Mode3 Mode = 0x3 // CPOL=1, CPHA=1
 
 	// HalfDuplex specifies that MOSI and MISO use the same wire, and that only
-	// one duplex is used at a time.
+	// one duplex is used at a time. This is also known as 3-wire SPI.
+	//
+	// A port that can't turn the data line around returns an error wrapping
+	// ErrNotSupported from Connect().
 	HalfDuplex Mode = 0x4
 	// NoCS request the driver to not use the CS line.
 	NoCS Mode = 0x8
-	// LSBFirst requests the words to be encoded in little endian instead of the
-	// default big endian.
-	LSBFirst = 0x10
+	// LSBFirst requests the bits of each word to be sent least significant bit
+	// first instead of the default most significant bit first.
+	LSBFirst Mode = 0x10
+	// NoSplit requests a transaction larger than the port buffer to fail
+	// instead of being transparently split in multiple transfers.
+	//
+	// Use it for devices that require a single uninterrupted transaction, as
+	// the clock may pause between the pieces even when CS is kept asserted.
+	NoSplit Mode = 0x20
 )
 
 func (m Mode) String() string {
@@ -83,6 +94,10 @@ func (m Mode) String() string {
 		s += "|LSBFirst"
 	}
 	m &^= LSBFirst
+	if m&NoSplit != 0 {
+		s += "|NoSplit"
+	}
+	m &^= NoSplit
 	if m != 0 {
 		s += "|0x"
 		s += strconv.FormatUint(uint64(m), 16)
@@ -93,10 +108,27 @@ func (m Mode) String() string {
 // Packet represents one packet when sending multiple packets as a transaction.
 type Packet struct {
 	// W and R are the output and input data. When HalfDuplex is specified to
-	// Connect, only one of the two can be set.
+	// Connect, W is written first then the data line is turned around and R is
+	// read, with CS kept asserted in between; they can then be of different
+	// sizes.
 	W, R []byte
 	// BitsPerWord overrides the default bits per word value set in Connect.
 	BitsPerWord uint8
+	// Speed overrides the speed set in Connect for this packet, e.g. to send a
+	// command slowly and then read the data fast. The limit set with
+	// LimitSpeed still applies.
+	//
+	// A port that can't change the speed within a transaction ignores it and
+	// uses the speed set in Connect for all the packets, so Connect should be
+	// called with a speed all the packets can use.
+	Speed physic.Frequency
+	// DummyBits is the number of clock cycles sent before W and R, with MOSI
+	// held low and MISO ignored. Some devices like flash memories need them
+	// between the address and the data.
+	//
+	// A port may only support a multiple of 8, in which case it returns an
+	// error wrapping ErrNotSupported otherwise.
+	DummyBits uint8
 	// KeepCS tells the driver to keep CS asserted after this packet is
 	// completed. This can be leveraged to create long transaction as multiple
 	// packets like to use 9 bits commands then 8 bits data.
@@ -123,7 +155,9 @@ type Conn interface {
 	// TxPackets does multiple operations over the SPI connection.
 	//
 	// The maximum number of bytes can be limited depending on the driver. Query
-	// conn.Limits.MaxTxSize() can be used to determine the limit.
+	// conn.Limits.MaxTxSize() can be used to determine the limit. A driver may
+	// split a larger transaction in multiple transfers unless NoSplit was
+	// specified to Connect.
 	//
 	// If the last packet has KeepCS:true, the behavior is undefined. The CS line
 	// will likely not stay asserted. This is a driver limitation.
@@ -148,7 +182,11 @@ type Port interface {
 	// mode specifies the clock and signal polarities, if the port is using half
 	// duplex (shared MISO and MOSI) or if CS is not needed.
 	//
-	// bits is the number of bits per word. Generally you should use 8.
+	// bits is the number of bits per word. Generally you should use 8. See
+	// BytesPerWord() for the layout of larger words in the buffers.
+	//
+	// It returns an error wrapping ErrNotSupported if the port doesn't support
+	// mode.
 	Connect(f physic.Frequency, mode Mode, bits int) (Conn, error)
 }
 
@@ -170,6 +208,27 @@ type PortCloser interface {
 	LimitSpeed(f physic.Frequency) error
 }
 
+// Slave is a port acting as a SPI slave, also known as peripheral, clocked by
+// a remote master.
+//
+// A host that can't act as a slave returns an error wrapping ErrNotSupported
+// when the port is opened.
+type Slave interface {
+	String() string
+	// Receive waits for the master to clock a transaction and stores the bytes
+	// written by the master in buf. It returns the number of bytes received.
+	//
+	// It returns an error wrapping ctx.Err() once ctx is done.
+	Receive(ctx context.Context, buf []byte) (int, error)
+	// Respond sets the bytes sent to the master during the next transaction.
+	// Zeros are sent past the end of buf, or if Respond was not called.
+	Respond(buf []byte) error
+}
+
+// ErrNotSupported is returned by a Port that doesn't support the requested
+// mode, e.g. HalfDuplex, or by a host that can't act as a Slave.
+var ErrNotSupported = errors.New("spi: not supported")
+
 // Pins defines the pins that a SPI port interconnect is using on the host.
 //
 // It is expected that a implementer of ConnCloser or Conn also implement Pins
@@ -5,11 +5,25 @@
 package spi
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"expvar"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/physic"
 )
 
 func TestMode_String(t *testing.T) {
-	if s := Mode(^int(0)).String(); s != "Mode3|HalfDuplex|NoCS|LSBFirst|0xffffffffffffffe0" {
+	if s := Mode(^int(0)).String(); s != "Mode3|HalfDuplex|NoCS|LSBFirst|NoSplit|0xffffffffffffffc0" {
 		t.Fatal(s)
 	}
 	if s := Mode0.String(); s != "Mode0" {
@@ -22,3 +36,420 @@ func TestMode_String(t *testing.T) {
 		t.Fatal(s)
 	}
 }
+
+func TestBytesPerWord(t *testing.T) {
+	data := []struct{ bits, n int }{{1, 1}, {8, 1}, {9, 2}, {16, 2}, {17, 4}, {32, 4}}
+	for _, line := range data {
+		if n := BytesPerWord(line.bits); n != line.n {
+			t.Fatalf("%d: %d != %d", line.bits, n, line.n)
+		}
+	}
+}
+
+func TestPackWords16(t *testing.T) {
+	b := PackWords16([]uint16{0x123, 0xFFF})
+	expected := []byte{0x23, 0x01, 0xFF, 0x0F}
+	if hostOrder == binary.BigEndian {
+		expected = []byte{0x01, 0x23, 0x0F, 0xFF}
+	}
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("%#v", b)
+	}
+	if w := UnpackWords16(append(b, 0xAA)); !reflect.DeepEqual(w, []uint16{0x123, 0xFFF}) {
+		t.Fatalf("%#v", w)
+	}
+}
+
+func TestLog(t *testing.T) {
+	var out bytes.Buffer
+	l := &Log{Port: &fakePort{}, Output: &out}
+	l.Redact = func(w, r []byte) {
+		if len(w) != 0 && w[0] == 0xFF {
+			for i := range w {
+				w[i] = 0
+			}
+		}
+	}
+	if s := l.String(); s != "log(fake)" {
+		t.Fatal(s)
+	}
+	c, err := l.Connect(physic.MegaHertz, Mode3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := c.String(); s != "fake" {
+		t.Fatal(s)
+	}
+	if d := c.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+	r := make([]byte, 2)
+	if err := c.Tx([]byte{1, 2}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{1, 2}) {
+		t.Fatal(r)
+	}
+	p := []Packet{{W: []byte{0xFF, 0xFE}, KeepCS: true}, {R: make([]byte, 1)}}
+	if err := c.TxPackets(p); err != nil {
+		t.Fatal(err)
+	}
+	// The redaction doesn't affect the caller.
+	if p[0].W[0] != 0xFF {
+		t.Fatal(p[0].W)
+	}
+	expected := []string{
+		"Connect(1MHz, Mode3, 8)",
+		"W:[01 02] R:[01 02]",
+		"W:[00 00] | R:[00]",
+	}
+	re := regexp.MustCompile(`^[0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{6} (.+?)(?: [0-9.]+[nµm]?s)?$`)
+	var got []string
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("unexpected line %q", line)
+		}
+		got = append(got, m[1])
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("%q", got)
+	}
+}
+
+func TestLog_noOutput(t *testing.T) {
+	l := &Log{Port: &fakePort{}}
+	c, err := l.Connect(physic.MegaHertz, Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := make([]byte, 1)
+	if err := c.Tx([]byte{3}, r); err != nil || r[0] != 3 {
+		t.Fatal(r, err)
+	}
+	if err := c.TxPackets([]Packet{{W: []byte{1}}}); err != nil {
+		t.Fatal(err)
+	}
+	l = &Log{Port: &fakePort{err: errors.New("oops")}}
+	if _, err := l.Connect(physic.MegaHertz, Mode0, 8); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestStats(t *testing.T) {
+	f := &fakePort{}
+	c, err := f.Connect(physic.MegaHertz, Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	s := &Stats{Conn: c, Output: &out}
+	if v := s.String(); v != "fake" {
+		t.Fatal(v)
+	}
+	if d := s.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+	var got []Packet
+	f.onTx = func(p []Packet) {
+		got = p
+	}
+	if err := s.Tx([]byte{1, 2}, make([]byte, 2)); err != nil {
+		t.Fatal(err)
+	}
+	p := []Packet{{W: []byte{3}, KeepCS: true}, {R: make([]byte, 4)}}
+	if err := s.TxPackets(p); err != nil {
+		t.Fatal(err)
+	}
+	// The packets are passed through as is.
+	if len(got) != 2 || &got[0] != &p[0] || !got[0].KeepCS {
+		t.Fatal(got)
+	}
+	f.txErr = errors.New("oops")
+	if err := s.Tx([]byte{4}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	v := s.Snapshot()
+	if v.Transactions != 3 || v.Errors != 1 || v.Written != 4 || v.Read != 6 {
+		t.Fatalf("%+v", v)
+	}
+	if v.BusTime < v.MaxLatency || v.MaxLatency < 0 {
+		t.Fatalf("%+v", v)
+	}
+	expected := []string{
+		"W:[01 02] R:[01 02]",
+		"W:[03] | R:[00 00 00 00]",
+		"W:[04]",
+	}
+	re := regexp.MustCompile(`^[0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{6} (.+?)(?: [0-9.]+[nµm]?s)?(?:: .+)?$`)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("unexpected line %q", line)
+		}
+		lines = append(lines, m[1])
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("%q", lines)
+	}
+	s.Reset()
+	if v := s.Snapshot(); v != (StatsSnapshot{}) {
+		t.Fatalf("%+v", v)
+	}
+}
+
+func TestStats_Publish(t *testing.T) {
+	// expvar names can't be reused, so each run uses its own.
+	publishRuns++
+	name := "spi_test_stats" + strconv.Itoa(publishRuns)
+	s := &Stats{Conn: &fakeConn{p: &fakePort{}}}
+	s.Publish(name)
+	if err := s.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	v := expvar.Get(name)
+	if v == nil {
+		t.Fatal("not published")
+	}
+	if !strings.Contains(v.String(), `"Transactions":1,`) {
+		t.Fatal(v.String())
+	}
+}
+
+func TestStats_forward(t *testing.T) {
+	s := &Stats{Conn: &fakeConn{p: &fakePort{}}}
+	if l := s.MaxTxSize(); l != 0 {
+		t.Fatal(l)
+	}
+	if s.CLK() != gpio.INVALID || s.MOSI() != gpio.INVALID || s.MISO() != gpio.INVALID || s.CS() != gpio.INVALID {
+		t.Fatal("expected INVALID")
+	}
+	p := &pinsConn{max: 4096, clk: &gpiotest.Pin{N: "CLK"}, mosi: &gpiotest.Pin{N: "MOSI"}, miso: &gpiotest.Pin{N: "MISO"}, cs: &gpiotest.Pin{N: "CS"}}
+	s.Conn = p
+	if l := s.MaxTxSize(); l != 4096 {
+		t.Fatal(l)
+	}
+	if s.CLK() != p.clk || s.MOSI() != p.mosi || s.MISO() != p.miso || s.CS() != p.cs {
+		t.Fatal("expected the pins of Conn")
+	}
+}
+
+func TestMultiplexPort(t *testing.T) {
+	cs0 := &gpiotest.Pin{N: "CS0"}
+	cs1 := &gpiotest.Pin{N: "CS1"}
+	f := &fakePort{}
+	ports, err := MultiplexPort(f, map[string]gpio.PinOut{"flash": cs0, "adc": cs1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lines are released.
+	if cs0.L != gpio.High || cs1.L != gpio.High {
+		t.Fatal(cs0.L, cs1.L)
+	}
+	flash := ports["flash"]
+	if s := flash.String(); s != "fake/flash" {
+		t.Fatal(s)
+	}
+	if flash.LimitSpeed(0) == nil {
+		t.Fatal("invalid speed")
+	}
+	if err := flash.LimitSpeed(20 * physic.MegaHertz); err != nil {
+		t.Fatal(err)
+	}
+	c0, err := flash.Connect(50*physic.MegaHertz, Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := flash.Connect(physic.MegaHertz, Mode0, 8); err == nil {
+		t.Fatal("Connect twice")
+	}
+	// The shared port doesn't use its CS.
+	if f.mode != Mode0|NoCS || f.f != 20*physic.MegaHertz || f.bits != 8 {
+		t.Fatal(f.mode, f.f, f.bits)
+	}
+	if _, err := ports["adc"].Connect(physic.MegaHertz, Mode3, 8); !errors.Is(err, ErrNotSupported) {
+		t.Fatal(err)
+	}
+	c1, err := ports["adc"].Connect(physic.MegaHertz, Mode0, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := c1.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+	if p := c1.(Pins); p.CS() != cs1 || p.CLK() != gpio.INVALID || p.MOSI() != gpio.INVALID || p.MISO() != gpio.INVALID {
+		t.Fatal("pins")
+	}
+
+	// Record the lines during each transfer.
+	var levels []string
+	var pkts [][]Packet
+	f.onTx = func(p []Packet) {
+		levels = append(levels, cs0.L.String()+cs1.L.String())
+		pkts = append(pkts, append([]Packet(nil), p...))
+	}
+	p := []Packet{
+		{W: []byte{0x0B}, KeepCS: true},
+		{R: make([]byte, 2)},
+		{W: []byte{0x04}},
+	}
+	if err := c0.TxPackets(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := c1.Tx([]byte{1, 2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"LowHigh", "LowHigh", "HighLow"}
+	if !reflect.DeepEqual(levels, expected) {
+		t.Fatal(levels)
+	}
+	// The first two packets are sent together, at the speed of each device.
+	if len(pkts[0]) != 2 || len(pkts[1]) != 1 || pkts[0][0].Speed != 20*physic.MegaHertz {
+		t.Fatal(pkts)
+	}
+	if pkts[2][0].Speed != physic.MegaHertz || pkts[2][0].BitsPerWord != 16 {
+		t.Fatal(pkts[2])
+	}
+	if cs0.L != gpio.High || cs1.L != gpio.High {
+		t.Fatal(cs0.L, cs1.L)
+	}
+	if c0.TxPackets(nil) == nil {
+		t.Fatal("empty packets")
+	}
+	if err := flash.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMultiplexPort_opts(t *testing.T) {
+	cs := &gpiotest.Pin{N: "CS"}
+	f := &fakePort{}
+	ports, err := MultiplexPort(f, map[string]gpio.PinOut{"dev": cs}, &MuxOpts{ActiveHigh: true, Setup: time.Microsecond, Hold: time.Microsecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.L != gpio.Low {
+		t.Fatal(cs.L)
+	}
+	c, err := ports["dev"].Connect(physic.MegaHertz, Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.onTx = func(p []Packet) {
+		if cs.L != gpio.High {
+			t.Error("CS not asserted")
+		}
+	}
+	if err := c.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if cs.L != gpio.Low {
+		t.Fatal(cs.L)
+	}
+}
+
+func TestMultiplexPort_err(t *testing.T) {
+	if _, err := MultiplexPort(&fakePort{}, nil, nil); err == nil {
+		t.Fatal("no line")
+	}
+	if _, err := MultiplexPort(&fakePort{}, map[string]gpio.PinOut{"": &gpiotest.Pin{}}, nil); err == nil {
+		t.Fatal("no name")
+	}
+	if _, err := MultiplexPort(&fakePort{}, map[string]gpio.PinOut{"a": nil}, nil); err == nil {
+		t.Fatal("nil pin")
+	}
+	ports, err := MultiplexPort(&fakePort{err: errors.New("oops")}, map[string]gpio.PinOut{"a": &gpiotest.Pin{}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ports["a"].Connect(physic.MegaHertz, Mode0, 8); err == nil || err.Error() != "oops" {
+		t.Fatal(err)
+	}
+	if _, err := ports["a"].Connect(physic.MegaHertz, Mode0, 0); err == nil {
+		t.Fatal("invalid bits")
+	}
+}
+
+//
+
+// publishRuns is the number of times TestStats_Publish ran.
+var publishRuns int
+
+// fakePort returns a Conn that echoes the bytes written.
+type fakePort struct {
+	err  error
+	f    physic.Frequency
+	mode Mode
+	bits int
+	// onTx is called on each TxPackets().
+	onTx func(p []Packet)
+	// txErr is returned by Tx() and TxPackets().
+	txErr error
+}
+
+func (f *fakePort) String() string {
+	return "fake"
+}
+
+func (f *fakePort) Connect(freq physic.Frequency, mode Mode, bits int) (Conn, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.f, f.mode, f.bits = freq, mode, bits
+	return &fakeConn{p: f}, nil
+}
+
+type fakeConn struct {
+	p *fakePort
+}
+
+func (f *fakeConn) String() string {
+	return "fake"
+}
+
+func (f *fakeConn) Duplex() conn.Duplex {
+	return conn.Full
+}
+
+func (f *fakeConn) Tx(w, r []byte) error {
+	copy(r, w)
+	return f.p.txErr
+}
+
+func (f *fakeConn) TxPackets(p []Packet) error {
+	if f.p.onTx != nil {
+		f.p.onTx(p)
+	}
+	for i := range p {
+		copy(p[i].R, p[i].W)
+	}
+	return f.p.txErr
+}
+
+// pinsConn is a Conn implementing Pins and conn.Limits.
+type pinsConn struct {
+	fakeConn
+	max                 int
+	clk, mosi, miso, cs *gpiotest.Pin
+}
+
+func (p *pinsConn) MaxTxSize() int {
+	return p.max
+}
+
+func (p *pinsConn) CLK() gpio.PinOut {
+	return p.clk
+}
+
+func (p *pinsConn) MOSI() gpio.PinOut {
+	return p.mosi
+}
+
+func (p *pinsConn) MISO() gpio.PinIn {
+	return p.miso
+}
+
+func (p *pinsConn) CS() gpio.PinOut {
+	return p.cs
+}
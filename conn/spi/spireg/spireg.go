@@ -10,10 +10,12 @@ package spireg
 
 import (
 	"errors"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
+	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/spi"
 )
 
@@ -203,6 +205,33 @@ func Unregister(name string) error {
 	return nil
 }
 
+// RegisterMux shares p between the devices selected by the chip select lines
+// cs with spi.MultiplexPort(), and registers each resulting port under its key
+// in cs.
+//
+// Nothing is registered if any registration fails.
+func RegisterMux(p spi.Port, cs map[string]gpio.PinOut, opts *spi.MuxOpts) (map[string]*spi.MuxPort, error) {
+	ports, err := spi.MultiplexPort(p, cs, opts)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		mp := ports[name]
+		if err := Register(name, nil, -1, func() (spi.PortCloser, error) { return mp, nil }); err != nil {
+			for _, n := range names[:i] {
+				_ = Unregister(n)
+			}
+			return nil, err
+		}
+	}
+	return ports, nil
+}
+
 //
 
 var (
@@ -9,6 +9,8 @@ import (
 	"testing"
 
 	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
 	"periph.io/x/periph/conn/physic"
 	"periph.io/x/periph/conn/spi"
 )
@@ -145,6 +147,38 @@ func TestUnregister(t *testing.T) {
 	}
 }
 
+func TestRegisterMux(t *testing.T) {
+	defer reset()
+	cs := map[string]gpio.PinOut{"flash": &gpiotest.Pin{N: "CS0"}, "adc": &gpiotest.Pin{N: "CS1"}}
+	ports, err := RegisterMux(&fakePort{}, cs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Open("adc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != ports["adc"] {
+		t.Fatal("expected the same port")
+	}
+	if s := p.String(); s != "fake/adc" {
+		t.Fatal(s)
+	}
+	// "flash" is already registered, "adc" is unregistered on failure.
+	if err := Unregister("adc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RegisterMux(&fakePort{}, cs, nil); err == nil {
+		t.Fatal("name already registered")
+	}
+	if _, err := Open("adc"); err == nil {
+		t.Fatal("adc must be unregistered")
+	}
+	if _, err := RegisterMux(&fakePort{}, nil, nil); err == nil {
+		t.Fatal("no line")
+	}
+}
+
 //
 
 func getFakePort() (spi.PortCloser, error) {
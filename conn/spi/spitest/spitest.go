@@ -6,6 +6,7 @@
 package spitest
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"sync"
@@ -83,6 +84,10 @@ type Record struct {
 	Port        spi.PortCloser // Port can be nil if only writes are being recorded.
 	Ops         []conntest.IO
 	Initialized bool
+	// Speed, Mode and Bits are the parameters passed to Connect().
+	Speed physic.Frequency
+	Mode  spi.Mode
+	Bits  int
 }
 
 func (r *Record) String() string {
@@ -113,6 +118,7 @@ func (r *Record) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn,
 		return nil, conntest.Errorf("spitest: Connect cannot be called twice")
 	}
 	r.Initialized = true
+	r.Speed, r.Mode, r.Bits = f, mode, bits
 	if r.Port != nil {
 		c, err := r.Port.Connect(f, mode, bits)
 		if err != nil {
@@ -180,6 +186,41 @@ func (r *Record) txInternal(c spi.Conn, w, read []byte) error {
 	return nil
 }
 
+func (r *Record) txPacketsInternal(c spi.Conn, p []spi.Packet) error {
+	if len(p) == 0 {
+		return conntest.Errorf("spitest: empty packets")
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.Port == nil {
+		for i := range p {
+			if len(p[i].R) != 0 {
+				return conntest.Errorf("spitest: read unsupported when no port is connected")
+			}
+		}
+	} else {
+		if err := c.TxPackets(p); err != nil {
+			return err
+		}
+	}
+	for i := range p {
+		if d := p[i].DummyBits / 8; d != 0 {
+			r.Ops = append(r.Ops, conntest.IO{W: make([]byte, d)})
+		}
+		io := conntest.IO{}
+		if len(p[i].W) != 0 {
+			io.W = make([]byte, len(p[i].W))
+			copy(io.W, p[i].W)
+		}
+		if len(p[i].R) != 0 {
+			io.R = make([]byte, len(p[i].R))
+			copy(io.R, p[i].R)
+		}
+		r.Ops = append(r.Ops, io)
+	}
+	return nil
+}
+
 //
 
 type recordConn struct {
@@ -202,9 +243,10 @@ func (r *recordConn) Tx(w, read []byte) error {
 	return r.r.txInternal(r.c, w, read)
 }
 
-// TxPackets is not yet implemented.
+// TxPackets records each packet as one IO, preceded by an IO writing zeros
+// for Packet.DummyBits.
 func (r *recordConn) TxPackets(p []spi.Packet) error {
-	return conntest.Errorf("spitest: TxPackets is not implemented")
+	return r.r.txPacketsInternal(r.c, p)
 }
 
 // CLK implements spi.Pins.
@@ -233,6 +275,14 @@ func (r *recordConn) CS() gpio.PinOut {
 //
 // While "replay" type of unit tests are of limited value, they still present
 // an easy way to do basic code coverage.
+//
+// Each packet of a TxPackets() call is played back as one IO. Packet.DummyBits
+// is played back as a preceding IO writing as many zero bytes, the way Record
+// records it; it must be a multiple of 8. Packet.Speed is ignored.
+//
+// The written bytes are compared with IO.W, honoring IO.Mask, and the read
+// buffers must be the length of IO.R. It is safe for concurrent use; the
+// packets of a TxPackets() call are played back without interleaving.
 type Playback struct {
 	conntest.Playback
 	CLKPin      gpio.PinIO
@@ -240,6 +290,18 @@ type Playback struct {
 	MISOPin     gpio.PinIO
 	CSPin       gpio.PinIO
 	Initialized bool
+	// Unsupported is the mode bits that Connect() rejects with an error
+	// wrapping spi.ErrNotSupported, e.g. spi.HalfDuplex to model a controller
+	// that can't do 3-wire.
+	Unsupported spi.Mode
+	// MaxBits, if not 0, is the maximum bits per word Connect() accepts; more
+	// returns an error wrapping spi.ErrNotSupported.
+	MaxBits int
+	// Speed, Mode and Bits are the parameters passed to Connect(), so a test
+	// can assert what the driver requested.
+	Speed physic.Frequency
+	Mode  spi.Mode
+	Bits  int
 }
 
 // Close implements spi.PortCloser.
@@ -261,8 +323,18 @@ func (p *Playback) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Con
 	if p.Initialized {
 		return nil, conntest.Errorf("spitest: Connect cannot be called twice")
 	}
+	if m := mode & p.Unsupported; m != 0 {
+		return nil, fmt.Errorf("spitest: mode %s: %w", m, spi.ErrNotSupported)
+	}
+	if p.MaxBits != 0 && bits > p.MaxBits {
+		return nil, fmt.Errorf("spitest: %d bits per word: %w", bits, spi.ErrNotSupported)
+	}
 	p.Initialized = true
-	return &playbackConn{p}, nil
+	p.Speed, p.Mode, p.Bits = f, mode, bits
+	if mode&spi.HalfDuplex != 0 {
+		p.D = conn.Half
+	}
+	return &playbackConn{p, mode&spi.HalfDuplex != 0}, nil
 }
 
 // CLK implements spi.Pins.
@@ -285,8 +357,39 @@ func (p *Playback) CS() gpio.PinOut {
 	return p.CSPin
 }
 
+// play plays back the next IO for the packet #i. p.Mutex must be held.
+func (p *Playback) play(i int, w, r []byte) error {
+	if len(p.Ops) <= p.Count {
+		return p.errorf("spitest: unexpected Tx() (count #%d, packet #%d): got W:[% x] and %d bytes to read; no more IO expected", p.Count, i, w, len(r))
+	}
+	io := &p.Ops[p.Count]
+	if !io.Match(w) {
+		if len(io.Mask) != 0 {
+			return p.errorf("spitest: unexpected write (count #%d, packet #%d): got [% x]; expected [% x] with mask [% x]", p.Count, i, w, io.W, io.Mask)
+		}
+		return p.errorf("spitest: unexpected write (count #%d, packet #%d): got [% x]; expected [% x]", p.Count, i, w, io.W)
+	}
+	if len(io.R) != len(r) {
+		return p.errorf("spitest: unexpected read length (count #%d, packet #%d): got %d bytes; expected %d", p.Count, i, len(r), len(io.R))
+	}
+	copy(r, io.R)
+	p.Count++
+	return nil
+}
+
+// errorf returns an error, or panics unless DontPanic is set, like
+// conntest.Playback.
+func (p *Playback) errorf(format string, a ...interface{}) error {
+	err := conntest.Errorf(format, a...)
+	if !p.DontPanic {
+		panic(err)
+	}
+	return err
+}
+
 type playbackConn struct {
-	p *Playback
+	p          *Playback
+	halfDuplex bool
 }
 
 func (p *playbackConn) String() string {
@@ -298,11 +401,35 @@ func (p *playbackConn) Duplex() conn.Duplex {
 }
 
 func (p *playbackConn) Tx(w, r []byte) error {
-	return p.p.Tx(w, r)
+	return p.TxPackets([]spi.Packet{{W: w, R: r}})
 }
 
 func (p *playbackConn) TxPackets(packets []spi.Packet) error {
-	return conntest.Errorf("spitest: TxPackets is not implemented")
+	if len(packets) == 0 {
+		return conntest.Errorf("spitest: empty packets")
+	}
+	for i := range packets {
+		w, r := packets[i].W, packets[i].R
+		if !p.halfDuplex && len(w) != 0 && len(r) != 0 && len(w) != len(r) {
+			return conntest.Errorf("spitest: packet #%d: when both w and r are used, they must be the same size; got %d and %d bytes", i, len(w), len(r))
+		}
+		if d := packets[i].DummyBits; d%8 != 0 {
+			return fmt.Errorf("spitest: %d dummy bits is not a multiple of 8: %w", d, spi.ErrNotSupported)
+		}
+	}
+	p.p.Lock()
+	defer p.p.Unlock()
+	for i := range packets {
+		if d := packets[i].DummyBits; d != 0 {
+			if err := p.p.play(i, make([]byte, d/8), nil); err != nil {
+				return err
+			}
+		}
+		if err := p.p.play(i, packets[i].W, packets[i].R); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (p *playbackConn) CLK() gpio.PinOut {
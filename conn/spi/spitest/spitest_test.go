@@ -9,6 +9,8 @@ import (
 	"errors"
 	"io/ioutil"
 	"log"
+	"reflect"
+	"sync"
 	"testing"
 
 	"periph.io/x/periph/conn"
@@ -77,7 +79,7 @@ func TestRecord_empty(t *testing.T) {
 		t.Fatal("Port is nil")
 	}
 	if err := c.TxPackets(nil); err == nil {
-		t.Fatal("not yet implemented")
+		t.Fatal("empty packets")
 	}
 	if d := c.Duplex(); d != conn.DuplexUnknown {
 		t.Fatal(d)
@@ -147,7 +149,7 @@ func TestPlayback(t *testing.T) {
 		t.Fatal("Can't call Connect twice")
 	}
 	if err := c.TxPackets(nil); err == nil {
-		t.Fatal("not yet implemented")
+		t.Fatal("empty packets")
 	}
 	if n := c.(spi.Pins).CLK().Name(); n != "CLK" {
 		t.Fatal(n)
@@ -274,6 +276,193 @@ func TestRecord_Playback(t *testing.T) {
 	}
 }
 
+func TestRecord_Playback_halfDuplex(t *testing.T) {
+	r := Record{
+		Port: &Playback{
+			Playback: conntest.Playback{
+				Ops:       []conntest.IO{{W: []byte{0x80}, R: []byte{1, 2}}, {W: []byte{3}}},
+				DontPanic: true,
+			},
+		},
+	}
+	c, err := r.Connect(0, spi.Mode0|spi.HalfDuplex, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := c.Duplex(); d != conn.Half {
+		t.Fatal(d)
+	}
+	// A write then a read turnaround of a different size.
+	p := []spi.Packet{{W: []byte{0x80}, R: make([]byte, 2)}, {W: []byte{3}}}
+	if err := c.TxPackets(p); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(p[0].R, []byte{1, 2}) {
+		t.Fatal(p[0].R)
+	}
+	expected := []conntest.IO{{W: []byte{0x80}, R: []byte{1, 2}}, {W: []byte{3}}}
+	if !reflect.DeepEqual(r.Ops, expected) {
+		t.Fatal(r.Ops)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecord_Playback_dummy(t *testing.T) {
+	r := Record{
+		Port: &Playback{
+			Playback: conntest.Playback{
+				Ops:       []conntest.IO{{W: []byte{0x0B, 0, 0, 0}}, {W: []byte{0}}, {R: []byte{1, 2}}},
+				D:         conn.Full,
+				DontPanic: true,
+			},
+		},
+	}
+	c, err := r.Connect(0, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := []spi.Packet{
+		{W: []byte{0x0B, 0, 0, 0}, KeepCS: true},
+		{R: make([]byte, 2), DummyBits: 8, Speed: 50 * physic.MegaHertz},
+	}
+	if err := c.TxPackets(p); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(p[1].R, []byte{1, 2}) {
+		t.Fatal(p[1].R)
+	}
+	expected := []conntest.IO{{W: []byte{0x0B, 0, 0, 0}}, {W: []byte{0}}, {R: []byte{1, 2}}}
+	if !reflect.DeepEqual(r.Ops, expected) {
+		t.Fatal(r.Ops)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.TxPackets([]spi.Packet{{DummyBits: 3}}); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+}
+
+func TestPlayback_fullDuplex(t *testing.T) {
+	p := Playback{Playback: conntest.Playback{DontPanic: true}}
+	c, err := p.Connect(0, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Tx([]byte{1}, make([]byte, 2)) == nil {
+		t.Fatal("different sizes in full duplex")
+	}
+}
+
+func TestPlayback_Unsupported(t *testing.T) {
+	p := Playback{Unsupported: spi.HalfDuplex}
+	if _, err := p.Connect(0, spi.Mode0|spi.HalfDuplex, 8); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	if _, err := p.Connect(0, spi.Mode0, 8); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlayback_params(t *testing.T) {
+	p := Playback{MaxBits: 16}
+	if _, err := p.Connect(physic.MegaHertz, spi.Mode1, 24); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	r := Record{Port: &p}
+	if _, err := r.Connect(physic.MegaHertz, spi.Mode1|spi.LSBFirst, 12); err != nil {
+		t.Fatal(err)
+	}
+	if r.Speed != physic.MegaHertz || r.Mode != spi.Mode1|spi.LSBFirst || r.Bits != 12 {
+		t.Fatal(r.Speed, r.Mode, r.Bits)
+	}
+	if p.Speed != physic.MegaHertz || p.Mode != spi.Mode1|spi.LSBFirst || p.Bits != 12 {
+		t.Fatal(p.Speed, p.Mode, p.Bits)
+	}
+}
+
+func TestPlayback_mismatch(t *testing.T) {
+	p := Playback{
+		Playback: conntest.Playback{
+			Ops: []conntest.IO{
+				{W: []byte{0x01}},
+				{W: []byte{0x02, 0x40}, Mask: []byte{0xFF, 0xF0}},
+				{R: []byte{1, 2}},
+			},
+			DontPanic: true,
+		},
+	}
+	c, err := p.Connect(0, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkts := []spi.Packet{{W: []byte{0x01}}, {W: []byte{0x02, 0x4F}}, {R: make([]byte, 1)}}
+	err = c.TxPackets(pkts)
+	if err == nil || err.Error() != "spitest: unexpected read length (count #2, packet #2): got 1 bytes; expected 2" {
+		t.Fatal(err)
+	}
+	p.Count = 1
+	err = c.Tx([]byte{0x02, 0x8F}, nil)
+	if err == nil || err.Error() != "spitest: unexpected write (count #1, packet #0): got [02 8f]; expected [02 40] with mask [ff f0]" {
+		t.Fatal(err)
+	}
+	p.Count = 3
+	err = c.Tx([]byte{0x01}, nil)
+	if err == nil || err.Error() != "spitest: unexpected Tx() (count #3, packet #0): got W:[01] and 0 bytes to read; no more IO expected" {
+		t.Fatal(err)
+	}
+}
+
+func TestPlayback_panic(t *testing.T) {
+	p := Playback{Playback: conntest.Playback{Ops: []conntest.IO{{W: []byte{1}}}}}
+	c, err := p.Connect(0, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if v := recover(); v == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	_ = c.Tx([]byte{2}, nil)
+}
+
+func TestPlayback_concurrent(t *testing.T) {
+	// Each goroutine sends a command then reads the reply with two packets; the
+	// packets must not interleave.
+	const n = 10
+	var ops []conntest.IO
+	for i := 0; i < n; i++ {
+		ops = append(ops, conntest.IO{W: []byte{0x80}}, conntest.IO{R: []byte{0x55}})
+	}
+	p := Playback{Playback: conntest.Playback{Ops: ops, DontPanic: true}}
+	c, err := p.Connect(0, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.TxPackets([]spi.Packet{{W: []byte{0x80}, KeepCS: true}, {R: make([]byte, 1)}})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 type connectFail struct {
 	Playback
 }
@@ -0,0 +1,189 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi
+
+import (
+	"expvar"
+	"io"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+)
+
+// Stats is a Conn that counts the transactions on the Conn it wraps.
+//
+// It is meant to measure the throughput of device drivers, e.g. the frame
+// rate a display driver can achieve. Pass it to a driver in place of the
+// connection. The transactions, including the packets of TxPackets() and
+// their KeepCS, are passed through as is.
+//
+// It implements Pins and conn.Limits by forwarding to Conn.
+//
+// It is safe for concurrent use.
+type Stats struct {
+	// Conn is the connection to use.
+	Conn Conn
+	// Output, if set, is where the transactions are logged in the same format
+	// as Log.
+	Output io.Writer
+	// Redact, if set, is called before a transaction is logged. See Log.Redact.
+	Redact func(w, r []byte)
+
+	mu sync.Mutex
+	s  StatsSnapshot
+}
+
+// StatsSnapshot is the state of the counters of a Stats.
+type StatsSnapshot struct {
+	// Transactions is the number of Tx() and TxPackets() calls.
+	Transactions int64
+	// Errors is the number of transactions that returned an error.
+	Errors int64
+	// Written and Read are the number of bytes written and read.
+	Written int64
+	Read    int64
+	// BusTime is the cumulative duration of the transactions.
+	BusTime time.Duration
+	// MaxLatency is the duration of the longest transaction.
+	MaxLatency time.Duration
+}
+
+func (s *Stats) String() string {
+	return s.Conn.String()
+}
+
+// Duplex implements conn.Conn.
+func (s *Stats) Duplex() conn.Duplex {
+	return s.Conn.Duplex()
+}
+
+// Tx implements conn.Conn.
+func (s *Stats) Tx(w, r []byte) error {
+	start := time.Now()
+	err := s.Conn.Tx(w, r)
+	d := time.Since(start)
+	var line string
+	if s.Output != nil {
+		line = logLine(start, formatTransfer(s.Redact, w, r), err)
+	}
+	s.add(len(w), len(r), d, err, line)
+	return err
+}
+
+// TxPackets implements Conn.
+func (s *Stats) TxPackets(p []Packet) error {
+	start := time.Now()
+	err := s.Conn.TxPackets(p)
+	d := time.Since(start)
+	nW, nR := 0, 0
+	for i := range p {
+		nW += len(p[i].W)
+		nR += len(p[i].R)
+	}
+	var line string
+	if s.Output != nil {
+		t := ""
+		for i := range p {
+			if i != 0 {
+				t += " |"
+			}
+			t += formatTransfer(s.Redact, p[i].W, p[i].R)
+		}
+		line = logLine(start, t, err)
+	}
+	s.add(nW, nR, d, err, line)
+	return err
+}
+
+// MaxTxSize implements conn.Limits.
+//
+// It returns the limit of Conn if it implements conn.Limits, 0 otherwise.
+func (s *Stats) MaxTxSize() int {
+	if l, ok := s.Conn.(conn.Limits); ok {
+		return l.MaxTxSize()
+	}
+	return 0
+}
+
+// CLK implements Pins.
+func (s *Stats) CLK() gpio.PinOut {
+	if p, ok := s.Conn.(Pins); ok {
+		return p.CLK()
+	}
+	return gpio.INVALID
+}
+
+// MOSI implements Pins.
+func (s *Stats) MOSI() gpio.PinOut {
+	if p, ok := s.Conn.(Pins); ok {
+		return p.MOSI()
+	}
+	return gpio.INVALID
+}
+
+// MISO implements Pins.
+func (s *Stats) MISO() gpio.PinIn {
+	if p, ok := s.Conn.(Pins); ok {
+		return p.MISO()
+	}
+	return gpio.INVALID
+}
+
+// CS implements Pins.
+func (s *Stats) CS() gpio.PinOut {
+	if p, ok := s.Conn.(Pins); ok {
+		return p.CS()
+	}
+	return gpio.INVALID
+}
+
+// Snapshot returns the current state of the counters.
+func (s *Stats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s
+}
+
+// Reset clears the counters.
+func (s *Stats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.s = StatsSnapshot{}
+}
+
+// Publish exports the counters as an expvar variable.
+//
+// Like expvar.Publish(), it panics if name is already registered.
+func (s *Stats) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return s.Snapshot()
+	}))
+}
+
+//
+
+func (s *Stats) add(w, r int, d time.Duration, err error, line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.s.Transactions++
+	if err != nil {
+		s.s.Errors++
+	}
+	s.s.Written += int64(w)
+	s.s.Read += int64(r)
+	s.s.BusTime += d
+	if d > s.s.MaxLatency {
+		s.s.MaxLatency = d
+	}
+	if line != "" {
+		io.WriteString(s.Output, line+"\n")
+	}
+}
+
+var _ Conn = &Stats{}
+var _ Pins = &Stats{}
+var _ conn.Limits = &Stats{}
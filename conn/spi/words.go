@@ -0,0 +1,46 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi
+
+// BytesPerWord returns the number of bytes used in the buffers of Tx() and
+// Packet to store one word of bits, as set in Connect() or
+// Packet.BitsPerWord.
+//
+// Words of 9 to 16 bits use 2 bytes and words of 17 to 32 bits use 4 bytes,
+// in the host native byte order and right aligned, as expected by the Linux
+// spidev driver. The buffers must be a multiple of this size.
+func BytesPerWord(bits int) int {
+	switch {
+	case bits <= 8:
+		return 1
+	case bits <= 16:
+		return 2
+	default:
+		return 4
+	}
+}
+
+// PackWords16 returns the buffer to use to send words of 9 to 16 bits, in the
+// host native byte order.
+//
+// The bits of each word above the word size are ignored by the port.
+func PackWords16(w []uint16) []byte {
+	b := make([]byte, 2*len(w))
+	for i, v := range w {
+		hostOrder.PutUint16(b[2*i:], v)
+	}
+	return b
+}
+
+// UnpackWords16 returns the words of 9 to 16 bits read in b.
+//
+// A trailing odd byte is ignored.
+func UnpackWords16(b []byte) []uint16 {
+	w := make([]uint16, len(b)/2)
+	for i := range w {
+		w[i] = hostOrder.Uint16(b[2*i:])
+	}
+	return w
+}
@@ -360,6 +360,9 @@ func TestBmp180Precision(t *testing.T) {
 	if e.Humidity != 0 {
 		t.Fatal(e.Humidity)
 	}
+	if f := dev.Fields(); f != physic.EnvTemperature|physic.EnvPressure {
+		t.Fatal(f)
+	}
 	if err := bus.Close(); err != nil {
 		t.Fatal(err)
 	}
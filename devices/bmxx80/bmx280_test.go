@@ -5,6 +5,7 @@
 package bmxx80
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"log"
@@ -652,6 +653,93 @@ func TestI2CSenseContinuous280_sense_fail(t *testing.T) {
 	}
 }
 
+func TestI2CSenseStream280(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			// Chip ID detection.
+			{Addr: 0x76, W: []byte{0xd0}, R: []byte{0x60}},
+			// Calibration data.
+			{
+				Addr: 0x76,
+				W:    []byte{0x88},
+				R:    []byte{0x10, 0x6e, 0x6c, 0x66, 0x32, 0x0, 0x5d, 0x95, 0xb8, 0xd5, 0xd0, 0xb, 0x77, 0x1e, 0x9d, 0xff, 0xf9, 0xff, 0xac, 0x26, 0xa, 0xd8, 0xbd, 0x10, 0x0, 0x4b},
+			},
+			// Calibration data humidity.
+			{Addr: 0x76, W: []byte{0xe1}, R: []byte{0x6e, 0x1, 0x0, 0x13, 0x5, 0x0, 0x1e}},
+			// Configuration.
+			{Addr: 0x76, W: []byte{0xf4, 0x6c, 0xf2, 0x3, 0xf5, 0xa0, 0xf4, 0x6c}, R: nil},
+			// Normal mode.
+			{Addr: 0x76, W: []byte{0xF5, 0xa0, 0xf4, 0x6f}},
+			// Read.
+			{Addr: 0x76, W: []byte{0xf7}, R: []byte{0x4a, 0x52, 0xc0, 0x80, 0x96, 0xc0, 0x7a, 0x76}},
+			// Sleep once the context is canceled.
+			{Addr: 0x76, W: []byte{0xF5, 0xa0, 0xf4, 0x6c}},
+			// Normal mode.
+			{Addr: 0x76, W: []byte{0xF5, 0xa0, 0xf4, 0x6f}},
+			// Read fails, then sleep on Halt().
+			{Addr: 0x76, W: []byte{0xF5, 0xa0, 0xf4, 0x6c}},
+		},
+		DontPanic: true,
+	}
+	dev, err := NewI2C(&bus, 0x76, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := dev.SenseStream(ctx, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s physic.EnvSample
+	select {
+	case s = <-c:
+	case <-time.After(2 * time.Second):
+		t.Fatal("failed")
+	}
+	if s.Err != nil {
+		t.Fatal(s.Err)
+	}
+	if s.T.IsZero() {
+		t.Fatal("missing timestamp")
+	}
+	if expected := 23720*physic.MilliCelsius + physic.ZeroCelsius; s.Temperature != expected {
+		t.Fatalf("temperature %s(%d) != %s(%d)", expected, expected, s.Temperature, s.Temperature)
+	}
+	cancel()
+	if _, ok := <-c; ok {
+		t.Fatal("c should be closed")
+	}
+	// The device was put back to sleep before the channel was closed.
+	bus.Lock()
+	count := bus.Count
+	bus.Unlock()
+	if count != 7 {
+		t.Fatal(count)
+	}
+
+	c, err = dev.SenseStream(context.Background(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case s = <-c:
+	case <-time.After(2 * time.Second):
+		t.Fatal("failed")
+	}
+	if s.Err == nil {
+		t.Fatal("expected error")
+	}
+	if err := dev.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-c; ok {
+		t.Fatal("c should be closed")
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestCalibration280Float(t *testing.T) {
 	// Real data extracted from measurements from this device.
 	tRaw := int32(524112)
@@ -766,6 +854,9 @@ func TestBme280Precision(t *testing.T) {
 	if e.Humidity != 90*physic.TenthMicroRH {
 		t.Fatal(int(e.Humidity))
 	}
+	if f := dev.Fields(); f != physic.EnvTemperature|physic.EnvPressure|physic.EnvHumidity {
+		t.Fatal(f)
+	}
 	if err := bus.Close(); err != nil {
 		t.Fatal(err)
 	}
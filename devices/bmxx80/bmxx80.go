@@ -5,6 +5,7 @@
 package bmxx80
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -265,24 +266,8 @@ func (d *Dev) Sense(e *physic.Env) error {
 func (d *Dev) SenseContinuous(interval time.Duration) (<-chan physic.Env, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	if d.stop != nil {
-		// Don't send the stop command to the device.
-		close(d.stop)
-		d.stop = nil
-		d.wg.Wait()
-	}
-
-	if d.is280 {
-		s := chooseStandby(d.isBME, interval-d.measDelay)
-		err := d.writeCommands([]byte{
-			// config
-			0xF5, byte(s)<<5 | byte(d.opts.Filter)<<2,
-			// ctrl_meas
-			0xF4, byte(d.opts.Temperature)<<5 | byte(d.opts.Pressure)<<2 | byte(normal),
-		})
-		if err != nil {
-			return nil, d.wrap(err)
-		}
+	if err := d.startContinuous(interval); err != nil {
+		return nil, err
 	}
 
 	sensing := make(chan physic.Env)
@@ -296,6 +281,41 @@ func (d *Dev) SenseContinuous(interval time.Duration) (<-chan physic.Env, error)
 	return sensing, nil
 }
 
+// SenseStream implements physic.SenseStreamer.
+//
+// It is like SenseContinuous() except that the sensing stops once ctx is
+// done, in which case the device is put back to sleep as with Halt(). A
+// failed measurement is sent with Err set instead of stopping the sensing.
+func (d *Dev) SenseStream(ctx context.Context, interval time.Duration) (<-chan physic.EnvSample, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.startContinuous(interval); err != nil {
+		return nil, err
+	}
+
+	sensing := make(chan physic.EnvSample)
+	stop := make(chan struct{})
+	d.stop = stop
+	d.wg.Add(1)
+	go func() {
+		defer close(sensing)
+		d.sensingStream(ctx, interval, sensing, stop)
+		d.wg.Done()
+		// When ctx is done, the sensing is still owned by this stream. Halt()
+		// and SenseContinuous() don't wait past wg.Done() above.
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.stop == stop {
+			close(d.stop)
+			d.stop = nil
+			if err := d.sleep(); err != nil {
+				log.Printf("%s: failed to stop sensing: %v", d, err)
+			}
+		}
+	}()
+	return sensing, nil
+}
+
 // Precision implements physic.SenseEnv.
 func (d *Dev) Precision(e *physic.Env) {
 	if d.is280 {
@@ -311,6 +331,16 @@ func (d *Dev) Precision(e *physic.Env) {
 	}
 }
 
+// Fields implements physic.SenseEnvFields.
+//
+// The humidity is only measured by the BME280.
+func (d *Dev) Fields() physic.EnvField {
+	if d.isBME {
+		return physic.EnvTemperature | physic.EnvPressure | physic.EnvHumidity
+	}
+	return physic.EnvTemperature | physic.EnvPressure
+}
+
 // Halt stops the BMxx80 from acquiring measurements as initiated by
 // SenseContinuous().
 //
@@ -325,7 +355,40 @@ func (d *Dev) Halt() error {
 	close(d.stop)
 	d.stop = nil
 	d.wg.Wait()
+	return d.sleep()
+}
+
+//
+
+// startContinuous stops the current continuous sensing, if any, and starts
+// the normal mode on the BMx280.
+//
+// d.mu must be held.
+func (d *Dev) startContinuous(interval time.Duration) error {
+	if d.stop != nil {
+		// Don't send the stop command to the device.
+		close(d.stop)
+		d.stop = nil
+		d.wg.Wait()
+	}
+
+	if d.is280 {
+		s := chooseStandby(d.isBME, interval-d.measDelay)
+		err := d.writeCommands([]byte{
+			// config
+			0xF5, byte(s)<<5 | byte(d.opts.Filter)<<2,
+			// ctrl_meas
+			0xF4, byte(d.opts.Temperature)<<5 | byte(d.opts.Pressure)<<2 | byte(normal),
+		})
+		if err != nil {
+			return d.wrap(err)
+		}
+	}
+	return nil
+}
 
+// sleep puts the BMx280 back to sleep after a continuous sensing.
+func (d *Dev) sleep() error {
 	if d.is280 {
 		// Page 27 (for register) and 12~13 section 3.3.
 		return d.writeCommands([]byte{
@@ -338,8 +401,6 @@ func (d *Dev) Halt() error {
 	return nil
 }
 
-//
-
 func (d *Dev) makeDev(opts *Opts) error {
 	d.opts = *opts
 	d.measDelay = d.opts.delayTypical280()
@@ -437,11 +498,7 @@ func (d *Dev) sensingContinuous(interval time.Duration, sensing chan<- physic.En
 		// Do one initial sensing right away.
 		e := physic.Env{}
 		d.mu.Lock()
-		if d.is280 {
-			err = d.sense280(&e)
-		} else {
-			err = d.sense180(&e)
-		}
+		err = d.senseContinuous(&e)
 		d.mu.Unlock()
 		if err != nil {
 			log.Printf("%s: failed to sense: %v", d, err)
@@ -460,6 +517,42 @@ func (d *Dev) sensingContinuous(interval time.Duration, sensing chan<- physic.En
 	}
 }
 
+func (d *Dev) sensingStream(ctx context.Context, interval time.Duration, sensing chan<- physic.EnvSample, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		// Do one initial sensing right away.
+		s := physic.EnvSample{}
+		d.mu.Lock()
+		s.Err = d.senseContinuous(&s.Env)
+		d.mu.Unlock()
+		s.T = time.Now()
+		select {
+		case sensing <- s:
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// senseContinuous reads a measurement while sensing continuously.
+func (d *Dev) senseContinuous(e *physic.Env) error {
+	if d.is280 {
+		return d.sense280(e)
+	}
+	return d.sense180(e)
+}
+
 func (d *Dev) readReg(reg uint8, b []byte) error {
 	// Page 32-33
 	if d.isSPI {
@@ -504,3 +597,5 @@ var doSleep = time.Sleep
 
 var _ conn.Resource = &Dev{}
 var _ physic.SenseEnv = &Dev{}
+var _ physic.SenseEnvFields = &Dev{}
+var _ physic.SenseStreamer = &Dev{}
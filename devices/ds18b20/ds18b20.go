@@ -115,6 +115,11 @@ func (d *Dev) Precision(e *physic.Env) {
 	e.Temperature = physic.Kelvin / 16
 }
 
+// Fields implements physic.SenseEnvFields.
+func (d *Dev) Fields() physic.EnvField {
+	return physic.EnvTemperature
+}
+
 // LastTemp reads the temperature resulting from the last conversion from the
 // device.
 //
@@ -184,3 +189,4 @@ var sleep = time.Sleep
 
 var _ conn.Resource = &Dev{}
 var _ physic.SenseEnv = &Dev{}
+var _ physic.SenseEnvFields = &Dev{}
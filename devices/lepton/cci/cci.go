@@ -288,6 +288,11 @@ func (d *Dev) Precision(e *physic.Env) {
 	e.Temperature = 10 * physic.MilliKelvin
 }
 
+// Fields implements physic.SenseEnvFields.
+func (d *Dev) Fields() physic.EnvField {
+	return physic.EnvTemperature
+}
+
 // GetFFCModeControl returns the internal state with regards to calibration.
 func (d *Dev) GetFFCModeControl() (*FFCMode, error) {
 	v := internal.FFCMode{}
@@ -571,3 +576,4 @@ var sleep = time.Sleep
 
 var _ conn.Resource = &Dev{}
 var _ physic.SenseEnv = &Dev{}
+var _ physic.SenseEnvFields = &Dev{}
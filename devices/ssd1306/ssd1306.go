@@ -113,9 +113,15 @@ func NewSPI(p spi.Port, dc gpio.PinOut, opts *Opts) (*Dev, error) {
 
 // NewI2C returns a Dev object that communicates over I²C to a SSD1306 display
 // controller.
+//
+// A frame larger than what the bus supports in a single transaction is sent
+// in multiple transactions.
 func NewI2C(i i2c.Bus, opts *Opts) (*Dev, error) {
 	// Maximum clock speed is 1/2.5µs = 400KHz.
-	return newDev(&i2c.Dev{Bus: i, Addr: 0x3C}, opts, false, nil)
+	// Each transaction starts with the control byte, then the GDDRAM address
+	// continues to increment across transactions.
+	c := &i2c.Splitter{Conn: &i2c.Dev{Bus: i, Addr: 0x3C}, PrefixLen: 1, Mode: i2c.SplitRepeat}
+	return newDev(c, opts, false, nil)
 }
 
 // Dev is an open handle to the display controller.
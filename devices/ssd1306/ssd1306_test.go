@@ -236,6 +236,32 @@ func TestI2C_DrawGray(t *testing.T) {
 	}
 }
 
+func TestI2C_DrawGray_split(t *testing.T) {
+	// The bus limits the transactions to 513 bytes, so the 1024 bytes frame is
+	// sent in two transactions, each starting with the control byte.
+	frame := grayCheckboard()
+	bus := limitBus{
+		Playback: i2ctest.Playback{
+			Ops: []i2ctest.IO{
+				{Addr: 0x3c, W: initCmdI2C()},
+				{Addr: 0x3c, W: append([]byte{i2cData}, frame[:512]...)},
+				{Addr: 0x3c, W: append([]byte{i2cData}, frame[512:]...)},
+			},
+		},
+		max: 513,
+	}
+	dev, err := NewI2C(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.Draw(dev.Bounds(), makeGrayCheckboard(dev.Bounds()), image.Point{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestI2C_Scroll(t *testing.T) {
 	bus := i2ctest.Playback{
 		Ops: []i2ctest.IO{
@@ -553,6 +579,16 @@ func makeGrayCheckboard(r image.Rectangle) image.Image {
 	return img
 }
 
+// limitBus is an I²C bus implementing conn.Limits.
+type limitBus struct {
+	i2ctest.Playback
+	max int
+}
+
+func (l *limitBus) MaxTxSize() int {
+	return l.max
+}
+
 type configFail struct {
 	spitest.Record
 }
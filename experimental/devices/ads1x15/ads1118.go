@@ -0,0 +1,156 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"errors"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+)
+
+const (
+	// The ADS1118 config register matches the ADS1x15 one for bits 15 to 5.
+	// The lower bits differ.
+	ads1118ConfigTempSensor = 0x0010
+	ads1118ConfigPullUp     = 0x0008
+	ads1118ConfigNOPValid   = 0x0002 // Data is written to the config register
+	ads1118ConfigReserved   = 0x0001 // Always write 1
+	ads1118ConfigLowMask    = 0x001F
+)
+
+// NewADS1118 creates a new driver for the ADS1118 (16-bit ADC with an SPI
+// interface and an internal temperature sensor).
+//
+// The ADS1118 has no comparator, so SetComparator() fails on its pins. The
+// I2cAddress and DisablePolling fields of opts are ignored, the end of a
+// conversion is always determined by waiting for the conversion time. A nil
+// opts is equivalent to &DefaultOpts.
+func NewADS1118(p spi.Port, opts *Opts) (*Dev, error) {
+	if opts == nil {
+		opts = &DefaultOpts
+	}
+	o := *opts
+	o.I2cAddress = 0
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	c, err := p.Connect(4*physic.MegaHertz, spi.Mode1, 8)
+	if err != nil {
+		return nil, err
+	}
+	l, err := newADS1x15(&spiTransport{c: c}, opts)
+	if err != nil {
+		return nil, err
+	}
+	l.dataRates = ads1115DataRates
+	l.resolution = 16
+	l.name = "ADS1118"
+	if err := l.checkDefaults(); err != nil {
+		return nil, err
+	}
+	// The end of the conversion cannot be read back.
+	l.polling = false
+	return l, nil
+}
+
+// Temperature measures the temperature with the internal sensor of the
+// ADS1118.
+//
+// It returns an error on the other chips.
+func (d *Dev) Temperature() (physic.Temperature, error) {
+	t, ok := d.t.(*spiTransport)
+	if !ok {
+		return 0, errors.New("ads1x15: the temperature sensor is only available on the ADS1118")
+	}
+	d.acquire()
+	defer d.release()
+	if err := d.checkState(); err != nil {
+		return 0, err
+	}
+	// Use 128SPS, the default data rate.
+	config := uint16(ads1x15ConfigOsSingle | ads1x15ConfigModeSingle | 0x0080)
+	d.configValid = false
+	d.lowLatency = nil
+	if err := t.tx(config | ads1118ConfigTempSensor | ads1118ConfigPullUp | ads1118ConfigNOPValid | ads1118ConfigReserved); err != nil {
+		return 0, err
+	}
+	time.Sleep(d.conversionTime(128))
+	raw, err := t.readConversion()
+	if err != nil {
+		return 0, err
+	}
+	// The result is 14 bits left-justified, with a resolution of 0.03125°C.
+	return physic.ZeroCelsius + physic.Temperature(int16(raw)>>2)*31250*physic.MicroKelvin, nil
+}
+
+// spiTransport implements transport for the ADS1118.
+//
+// It uses the 32 bits transaction cycle: the config register is written twice
+// while the conversion register then the config register are read back.
+type spiTransport struct {
+	c spi.Conn
+	// w and r are the scratch buffers for the transactions, to not allocate on
+	// each read. They are protected by the device lock.
+	w, r [4]byte
+}
+
+func (t *spiTransport) id() string {
+	return t.c.String()
+}
+
+func (t *spiTransport) bus() string {
+	return t.c.String()
+}
+
+func (t *spiTransport) addr() uint16 {
+	return 0
+}
+
+func (t *spiTransport) conn() conn.Conn {
+	return t.c
+}
+
+func (t *spiTransport) writeConfig(config uint16) error {
+	return t.tx(config&^ads1118ConfigLowMask | ads1118ConfigPullUp | ads1118ConfigNOPValid | ads1118ConfigReserved)
+}
+
+func (t *spiTransport) readConfig() (uint16, error) {
+	return 0, errors.New("ads1x15: reading the config register is not supported by the ADS1118")
+}
+
+func (t *spiTransport) readConversion() (uint16, error) {
+	// Without the NOP valid bits, the config register is left untouched.
+	t.w = [4]byte{}
+	if err := t.c.Tx(t.w[:], t.r[:]); err != nil {
+		return 0, err
+	}
+	return uint16(t.r[0])<<8 | uint16(t.r[1]), nil
+}
+
+func (t *spiTransport) writeRegister(reg byte, v uint16) error {
+	if reg == ads1x15PointerConfig {
+		return t.writeConfig(v)
+	}
+	return errors.New("ads1x15: the comparator is not supported by the ADS1118")
+}
+
+func (t *spiTransport) readRegister(reg byte) (uint16, error) {
+	switch reg {
+	case ads1x15PointerConversion:
+		return t.readConversion()
+	case ads1x15PointerConfig:
+		return t.readConfig()
+	default:
+		return 0, errors.New("ads1x15: the comparator is not supported by the ADS1118")
+	}
+}
+
+func (t *spiTransport) tx(config uint16) error {
+	t.w = [4]byte{byte(config >> 8), byte(config), byte(config >> 8), byte(config)}
+	return t.c.Tx(t.w[:], t.r[:])
+}
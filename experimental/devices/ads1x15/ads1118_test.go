@@ -0,0 +1,94 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi/spitest"
+)
+
+func TestADS1118_Read(t *testing.T) {
+	port := spitest.Playback{
+		Playback: conntest.Playback{
+			Ops: []conntest.IO{
+				// AIN0, ±6.144V, 860SPS.
+				{W: []byte{0xc1, 0xeb, 0xc1, 0xeb}, R: []byte{0, 0, 0, 0}},
+				{W: []byte{0, 0, 0, 0}, R: []byte{0x40, 0x00, 0x41, 0xeb}},
+			},
+		},
+	}
+	d, err := NewADS1118(&port, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.Type(); s != "ADS1118" {
+		t.Fatal(s)
+	}
+	if d.Addr() != 0 || d.Conn() == nil {
+		t.Fatal(d.Addr(), d.Conn())
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != "ADS1118{playback}" {
+		t.Fatal(s)
+	}
+	if n := p.Name(); n != "ads1118-playback-ain0" {
+		t.Fatal(n)
+	}
+	r, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Raw != 0x4000 || r.V != 3072*physic.MilliVolt {
+		t.Fatalf("unexpected reading %#v", r)
+	}
+	if err := p.SetComparator(0, physic.Volt, ComparatorOpts{Queue: 1}); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := port.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestADS1118_Temperature(t *testing.T) {
+	port := spitest.Playback{
+		Playback: conntest.Playback{
+			Ops: []conntest.IO{
+				{W: []byte{0x81, 0x9b, 0x81, 0x9b}, R: []byte{0, 0, 0, 0}},
+				// 25°C is 0x0320 before being left-justified.
+				{W: []byte{0, 0, 0, 0}, R: []byte{0x0c, 0x80, 0x01, 0x9b}},
+			},
+		},
+	}
+	d, err := NewADS1118(&port, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	temp, err := d.Temperature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := physic.ZeroCelsius + 25*physic.Celsius; temp != expected {
+		t.Fatalf("%s != %s", temp, expected)
+	}
+	if err := port.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTemperature_unsupported(t *testing.T) {
+	d, err := NewADS1115(&busyBus{}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Temperature(); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -5,13 +5,19 @@
 package ads1x15
 
 import (
-	"encoding/binary"
+	"context"
 	"errors"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/analog"
+	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/physic"
 	"periph.io/x/periph/conn/pin"
@@ -37,6 +43,22 @@ const (
 	ads1x15ConfigCompAactiveHigh = 0x0008
 	ads1x15ConfigCompLatching    = 0x0004
 	ads1x15ConfigCompQueDisable  = 0x0003
+	ads1x15ConfigCompMask        = 0x001F
+	ads1x15ConfigMuxGainMask     = 0x7E00
+
+	// Default config register value, without starting a conversion.
+	ads1x15ConfigPowerDown = 0x0583
+
+	defaultConversionSlack = 100 * time.Microsecond
+
+	// maxFilterSamples is the maximum number of samples of ReadFiltered().
+	maxFilterSamples = 64
+
+	// configWriteBits and conversionReadBits are the number of I²C clock
+	// cycles of the config register write and of the conversion register
+	// read, including the START, repeated START and STOP conditions.
+	configWriteBits    = 38
+	conversionReadBits = 48
 
 	Channel0 = 0
 	Channel1 = 1
@@ -44,9 +66,137 @@ const (
 	Channel3 = 3
 )
 
+// Gain is the setting of the programmable gain amplifier, which determines the
+// full-scale range of the conversions.
+type Gain int
+
+// Supported gains.
+const (
+	GainTwoThirds Gain = iota + 1 // ±6.144V
+	Gain1                         // ±4.096V
+	Gain2                         // ±2.048V
+	Gain4                         // ±1.024V
+	Gain8                         // ±0.512V
+	Gain16                        // ±0.256V
+)
+
+// FullScale returns the full-scale range selected by the gain, e.g. ±4.096V
+// for Gain1, or 0 for an invalid gain.
+//
+// Combined with Dev.BestGain(), it tells the range a maximum voltage selects.
+func (g Gain) FullScale() physic.ElectricPotential {
+	return gainFullScale[g]
+}
+
+func (g Gain) String() string {
+	switch g {
+	case GainTwoThirds:
+		return "2/3"
+	case Gain1:
+		return "1"
+	case Gain2:
+		return "2"
+	case Gain4:
+		return "4"
+	case Gain8:
+		return "8"
+	case Gain16:
+		return "16"
+	default:
+		return fmt.Sprintf("Gain(%d)", int(g))
+	}
+}
+
 // Opts holds the configuration options.
 type Opts struct {
+	// I2cAddress is the address of the device, as selected by the ADDR pin
+	// strap: 0x48 (GND), 0x49 (VDD), 0x4A (SDA) or 0x4B (SCL). 0 means the
+	// default of 0x48.
 	I2cAddress uint16
+	// DisablePolling waits for a fixed duration derived from the data rate
+	// instead of polling the OS bit of the config register to detect the end
+	// of a single-shot conversion. Use it on buses where the extra
+	// transactions are undesirable.
+	DisablePolling bool
+	// ConversionSlack is added to the nominal conversion time to determine
+	// how long to wait for a single-shot conversion. 0 means the default of
+	// 100µs. A negative value shortens the wait but it is never shorter than
+	// 90% of the nominal conversion time, the oscillator tolerance.
+	//
+	// When polling is enabled, the resulting duration is used as the
+	// expected conversion time: polling starts at half of it.
+	ConversionSlack time.Duration
+	// ConversionTimeout is how long to wait for a single-shot conversion to
+	// complete, when polling or using ReadyPin, before failing with
+	// ErrConversionTimeout. 0 means 10 times the expected conversion time.
+	ConversionTimeout time.Duration
+	// ReadyPin is the GPIO connected to the ALERT/RDY pin of the chip. When
+	// set, the comparator is used as a conversion ready signal and the end of
+	// a single-shot conversion is detected on a falling edge of this pin,
+	// instead of polling or sleeping. SetComparator() cannot be used in this
+	// case.
+	ReadyPin gpio.PinIn
+	// DiscardFirstAfterMuxChange throws away the first conversion after the
+	// input multiplexer or the gain changed, as it may be disturbed while the
+	// inputs settle. This doubles the duration of the first read of a pin
+	// when alternating between pins.
+	DiscardFirstAfterMuxChange bool
+	// SkipProbe skips reading back the config register at construction to
+	// verify the device is present. Use it when the device may not be powered
+	// yet. It is ignored for the ADS1118, which can't be probed.
+	SkipProbe bool
+	// DefaultRange is the maximum voltage used by the PinFor* methods when
+	// their maxVoltage argument is 0. The gain is selected as for an explicit
+	// maxVoltage.
+	DefaultRange physic.ElectricPotential
+	// DefaultRate is the minimum frequency used by the PinFor* methods when
+	// their minimumFrequency argument is 0. When it is not set either, the
+	// lowest data rate is selected.
+	DefaultRate physic.Frequency
+	// DefaultMode is the initial mode of the pins returned by the PinFor*
+	// methods.
+	DefaultMode Mode
+	// VerifyPowerDown reads back the config register after each single-shot
+	// conversion and fails with ErrNotPoweredDown if the chip is not back in
+	// power-down. It is ignored for the ADS1118, whose config register can't
+	// be read.
+	VerifyPowerDown bool
+	// IdleReinit is the idle duration after which the chip is assumed to
+	// have possibly been power-cycled, e.g. because its supply is gated. The
+	// next access probes the chip again, unless SkipProbe is set, and
+	// reprograms all its registers. 0 disables it.
+	IdleReinit time.Duration
+	// BusFrequency is the clock frequency of the I²C bus. It is used to
+	// account for the duration of the transactions, which dominates on slow
+	// buses: it is added to the single-shot wait time, included in the
+	// SampleRate() of the pins and BestDataRate() only selects data rates
+	// whose effective sample rate is reachable. 0 means querying the bus when
+	// it has a Speed() physic.Frequency method, like bitbang.I2C, and
+	// otherwise assuming the transactions take no time. It is ignored for the
+	// ADS1118.
+	BusFrequency physic.Frequency
+}
+
+// Mode is the conversion mode of a pin.
+type Mode int
+
+// Supported modes.
+const (
+	// ModeSingleShot starts a single-shot conversion on each read.
+	ModeSingleShot Mode = iota
+	// ModeContinuous is the low-latency mode, see AnalogPin.SetContinuous().
+	ModeContinuous
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeSingleShot:
+		return "SingleShot"
+	case ModeContinuous:
+		return "Continuous"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
 }
 
 // DefaultOpts are the recommended default options.
@@ -54,114 +204,485 @@ var DefaultOpts = Opts{
 	I2cAddress: I2CAddr,
 }
 
-// Dev is the driver for the ADS1015/ADS1115 ADC
+// Validate checks the options and returns an error listing all the invalid
+// fields, if any.
+//
+// It is called by the constructors.
+func (o *Opts) Validate() error {
+	var errs []string
+	if o.I2cAddress != 0 && (o.I2cAddress < I2CAddr || o.I2cAddress > I2CAddr+3) {
+		errs = append(errs, fmt.Sprintf("invalid I²C address %#02x; valid addresses are 0x48, 0x49, 0x4a and 0x4b", o.I2cAddress))
+	}
+	if o.ConversionTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("ConversionTimeout %s must not be negative", o.ConversionTimeout))
+	}
+	if o.BusFrequency < 0 {
+		errs = append(errs, fmt.Sprintf("BusFrequency %s must not be negative", o.BusFrequency))
+	}
+	if o.IdleReinit < 0 {
+		errs = append(errs, fmt.Sprintf("IdleReinit %s must not be negative", o.IdleReinit))
+	}
+	if o.DefaultRange < 0 {
+		errs = append(errs, fmt.Sprintf("DefaultRange %s must not be negative", o.DefaultRange))
+	}
+	if o.DefaultRate < 0 {
+		errs = append(errs, fmt.Sprintf("DefaultRate %s must not be negative", o.DefaultRate))
+	}
+	if o.DefaultMode != ModeSingleShot && o.DefaultMode != ModeContinuous {
+		errs = append(errs, fmt.Sprintf("invalid DefaultMode %s", o.DefaultMode))
+	}
+	if len(errs) != 0 {
+		return errors.New("ads1x15: invalid options: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Dev is the driver for the ADS1015/ADS1115/ADS1118 ADC
 type Dev struct {
-	// I2C Communication
-	c i2c.Dev
+	// lastActivity is the time of the last config write or conversion read,
+	// in Unix nanoseconds. It is accessed atomically and kept first for the
+	// 64 bits alignment.
+	lastActivity int64
+	// t is the register level access, over I²C or SPI.
+	t transport
+	// id is a unique identifier defining the order in which the locks of
+	// multiple devices are taken.
+	id uint64
 
 	name string
 
-	gainConfig  map[int]uint16
+	gainConfig  map[Gain]uint16
 	dataRates   map[int]uint16
-	gainVoltage map[int]physic.ElectricPotential
-	mutex       *sync.Mutex
+	gainVoltage map[Gain]physic.ElectricPotential
+	// resolution is the number of bits of a conversion: 12 for the ADS1015,
+	// 16 for the others. The conversion register is left-justified.
+	resolution uint
+	// lock is a semaphore serializing the access to the device. A channel is
+	// used instead of a sync.Mutex so waiting for it can be cancelled and the
+	// waiters are served in arrival order. waiters is the number of
+	// goroutines waiting for it.
+	lock    chan struct{}
+	waiters int32
+	polling bool
+	slack   time.Duration
+	timeout time.Duration
+	// discardFirst is Opts.DiscardFirstAfterMuxChange.
+	discardFirst bool
+	// verifyPowerDown, idleReinit and probe are from Opts.VerifyPowerDown,
+	// Opts.IdleReinit and !Opts.SkipProbe.
+	verifyPowerDown bool
+	idleReinit      time.Duration
+	probe           bool
+	// busFrequency is the I²C clock frequency, 0 when unknown. See
+	// Opts.BusFrequency.
+	busFrequency physic.Frequency
+	// defaultRange, defaultRate and defaultMode are the defaults from Opts
+	// applied by the PinFor* methods.
+	defaultRange physic.ElectricPotential
+	defaultRate  physic.Frequency
+	defaultMode  Mode
+	// ready is the GPIO connected to ALERT/RDY, if any. readyInit is set once
+	// the chip and the GPIO are configured for it.
+	ready     gpio.PinIn
+	readyInit bool
+	// continuous is true while a ReadContinuous stream owns the device.
+	// stopContinuous stops it. continuousErr is the error that ended the last
+	// stream early, if any.
+	continuous     bool
+	stopContinuous func()
+	continuousErr  error
+	// halted is set by Halt().
+	halted bool
+	// thresholds is the pin whose comparator thresholds are currently
+	// programmed in the chip, if any.
+	thresholds *ads1x15AnalogPin
+	// config is the last value written to the config register, without the
+	// OS bit. It is only meaningful when configValid is set.
+	config      uint16
+	configValid bool
+	// lowLatency is the pin the chip is continuously converting for, if any.
+	// See SetContinuous().
+	lowLatency *ads1x15AnalogPin
 }
 
-// Reading is the result of AnalogPin.Read()  (obviously not the case right now but this could be)
-type Reading struct {
-	V   physic.ElectricPotential
-	Raw int32
-}
+// Reading is the result of AnalogPin.Read().
+//
+// It is an alias kept for compatibility, use analog.Reading instead.
+type Reading = analog.Reading
 
-// AnalogPin represents a pin which is able to read an electric potential
+// AnalogPin represents a pin which is able to read an electric potential.
+//
+// It implements analog.PinADC along ADS1x15 specific functionality.
 type AnalogPin interface {
-	pin.Pin
-	// Range returns the maximum supported range [min, max] of the values.
-	Range() (Reading, Reading)
-	// Read returns the current pin level.
-	Read() (Reading, error)
+	analog.PinADC
+	// ReadCtx returns the current pin level, giving up when ctx is done.
+	//
+	// Cancellation is honored both while waiting for the device to be
+	// available and while waiting for the conversion.
+	ReadCtx(ctx context.Context) (Reading, error)
+	// ReadAveraged takes n back-to-back conversions and returns their mean.
+	ReadAveraged(n int) (AveragedReading, error)
+	// ReadFiltered takes n back-to-back conversions and reduces them with
+	// filter.
+	ReadFiltered(n int, filter FilterKind) (FilteredReading, error)
+	// Sample reads the pin every interval and calls fn with each reading
+	// until ctx is done. It returns the number of skipped samples.
+	Sample(ctx context.Context, interval time.Duration, fn func(TimedReading)) (int, error)
+	// ReadEvery starts reading the pin every period in a goroutine until ctx
+	// is done, dropping the samples the consumer is not ready to receive.
+	ReadEvery(ctx context.Context, period time.Duration) (<-chan Reading, error)
+	// ReadEveryStats returns the statistics of the last ReadEvery() once its
+	// channel is closed.
+	ReadEveryStats() SamplerStats
+	// SetComparator programs the low and high thresholds of the comparator
+	// and enables it for the following conversions on this pin.
+	SetComparator(low, high physic.ElectricPotential, opts ComparatorOpts) error
+	// SetCalibration sets the correction applied to the readings of this pin:
+	// Reading.V becomes V*scale + offset.
+	SetCalibration(offset physic.ElectricPotential, scale float64) error
+	// TwoPointCalibration computes the arguments to SetCalibration() from two
+	// raw readings and the electric potentials actually measured for them.
+	TwoPointCalibration(raw1 int32, v1 physic.ElectricPotential, raw2 int32, v2 physic.ElectricPotential) (physic.ElectricPotential, float64, error)
+	// SetContinuous enables or disables the low-latency mode of this pin,
+	// where the chip is left in continuous conversion mode.
+	SetContinuous(on bool) error
+	// Gain returns the gain selected for this pin.
+	Gain() Gain
+	// FullScale returns the full-scale range selected by the gain of this
+	// pin, before calibration.
+	FullScale() physic.ElectricPotential
+	// DataRate returns the data rate selected for this pin.
+	DataRate() physic.Frequency
+	// Channels returns the channels measured by this pin. b is -1 for a
+	// single-ended pin, otherwise the pin measures a - b.
+	Channels() (a, b int)
+}
+
+// TimedReading is a reading along the time the conversion completed.
+type TimedReading struct {
+	Reading
+	T time.Time
+}
+
+// SamplerStats are the statistics of AnalogPin.ReadEvery().
+type SamplerStats struct {
+	// Delivered is the number of samples received by the consumer.
+	Delivered int
+	// Dropped is the number of samples dropped, either because the consumer
+	// was not ready or because the sampling fell behind the schedule.
+	Dropped int
+	// MaxJitter is the largest delay between the scheduled time of a sample
+	// and the start of its conversion.
+	MaxJitter time.Duration
+	// Err is the error that stopped the sampling, if any. It is nil when the
+	// sampling stopped because the context was done.
+	Err error
+}
+
+// AveragedReading is the result of AnalogPin.ReadAveraged().
+type AveragedReading struct {
+	// Reading is the mean of the samples, with Raw rounded to the nearest
+	// integer.
+	Reading
+	// Min and Max are the extreme samples.
+	Min Reading
+	Max Reading
+	// StdDev is the standard deviation of the samples.
+	StdDev physic.ElectricPotential
+	// N is the number of samples.
+	N int
+}
+
+// FilterKind selects how AnalogPin.ReadFiltered() reduces the samples.
+type FilterKind int
+
+// Supported filters.
+const (
+	// FilterMean is the mean of the samples, like ReadAveraged().
+	FilterMean FilterKind = iota
+	// FilterMedian is the median of the samples. It is robust to impulsive
+	// noise.
+	FilterMedian
+	// FilterTrimmedMean is the mean of the samples once the lowest and
+	// highest 10% are discarded.
+	FilterTrimmedMean
+)
+
+func (f FilterKind) String() string {
+	switch f {
+	case FilterMean:
+		return "Mean"
+	case FilterMedian:
+		return "Median"
+	case FilterTrimmedMean:
+		return "TrimmedMean"
+	default:
+		return fmt.Sprintf("FilterKind(%d)", int(f))
+	}
+}
+
+// FilteredReading is the result of AnalogPin.ReadFiltered().
+type FilteredReading struct {
+	// Reading is the filtered value, with Raw rounded to the nearest
+	// integer.
+	Reading
+	// Filter is the filter applied.
+	Filter FilterKind
+	// Min and Max are the extreme samples, including the discarded ones.
+	Min Reading
+	Max Reading
+	// N is the number of samples.
+	N int
+	// Discarded is the number of samples discarded by FilterTrimmedMean.
+	Discarded int
+}
+
+// ComparatorOpts configures the comparator driving the ALERT/RDY pin.
+type ComparatorOpts struct {
+	// Window selects the window comparator mode. ALERT is asserted when the
+	// conversion is outside [low, high]. Otherwise the traditional comparator
+	// mode is used: ALERT is asserted above high and deasserted below low.
+	Window bool
+	// ActiveHigh sets the ALERT pin polarity to active high. The default is
+	// active low.
+	ActiveHigh bool
+	// Latching keeps ALERT asserted until the conversion register is read,
+	// see Dev.ClearAlert, or the device answers RespondToAlert.
+	Latching bool
+	// Queue is the number of successive conversions exceeding the thresholds
+	// before ALERT is asserted. It must be 1, 2 or 4.
+	Queue int
 }
 
 type ads1x15AnalogPin struct {
-	adc               *Dev
-	query             []byte
+	adc *Dev
+	// config is the config register value to start a single-shot conversion.
+	// It is protected by the device lock.
+	config            uint16
 	voltageMultiplier physic.ElectricPotential
 	waitTime          time.Duration
+	dataRate          int
+	gain              Gain
+	mux               int
+	// comparator is set by SetComparator(); low and high are the raw
+	// thresholds to program before each conversion on this pin.
+	comparator bool
+	low, high  int16
+	// continuous is set by SetContinuous(). It is protected by the device
+	// lock.
+	continuous bool
+	// negate is set for a reversed differential pair; the chip measures the
+	// opposite of the requested difference.
+	negate bool
+	// offset and scale are the calibration set by SetCalibration(). They are
+	// protected by the device lock.
+	offset physic.ElectricPotential
+	scale  float64
+
+	// mu protects stats.
+	mu    sync.Mutex
+	stats SamplerStats
 }
 
 // NewADS1015 creates a new driver for the ADS1015 (12-bit ADC)
+//
+// Reading.Raw is the 12 bits result, within [-2048, 2047].
+//
+// A nil opts is equivalent to &DefaultOpts.
+//
 // Largely inspired by: https://github.com/adafruit/Adafruit_Python_ADS1x15
 func NewADS1015(i i2c.Bus, opts *Opts) (l *Dev, err error) {
-	l, err = newADS1x15(i, opts)
-
-	l.dataRates = map[int]uint16{
-		128:  0x0000,
-		250:  0x0020,
-		490:  0x0040,
-		920:  0x0060,
-		1600: 0x0080,
-		2400: 0x00A0,
-		3300: 0x00C0,
-	}
-
-	l.name = "ADS1015"
-
-	return
+	return newI2C(i, opts, "ADS1015", ads1015DataRates, 12)
 }
 
 // NewADS1115 creates a new driver for the ADS1115 (16-bit ADC)
+//
+// A nil opts is equivalent to &DefaultOpts.
 func NewADS1115(i i2c.Bus, opts *Opts) (l *Dev, err error) {
-	l, err = newADS1x15(i, opts)
+	return newI2C(i, opts, "ADS1115", ads1115DataRates, 16)
+}
 
-	l.dataRates = map[int]uint16{
-		8:   0x0000,
-		16:  0x0020,
-		32:  0x0040,
-		64:  0x0060,
-		128: 0x0080,
-		250: 0x00A0,
-		475: 0x00C0,
-		860: 0x00E0,
+// NewADS1115Group creates one ADS1115 driver per address on the same bus.
+//
+// opts is used for all the devices, except for I2cAddress. A nil opts is
+// equivalent to &DefaultOpts.
+func NewADS1115Group(bus i2c.Bus, addrs []uint16, opts *Opts) ([]*Dev, error) {
+	if opts == nil {
+		opts = &DefaultOpts
 	}
+	for i, addr := range addrs {
+		for _, prev := range addrs[:i] {
+			if prev == addr {
+				return nil, fmt.Errorf("ads1x15: address %#02x specified twice", addr)
+			}
+		}
+	}
+	devs := make([]*Dev, 0, len(addrs))
+	for _, addr := range addrs {
+		o := *opts
+		o.I2cAddress = addr
+		d, err := NewADS1115(bus, &o)
+		if err != nil {
+			return nil, err
+		}
+		devs = append(devs, d)
+	}
+	return devs, nil
+}
 
-	l.name = "ADS1115"
-
-	return
+func newI2C(i i2c.Bus, opts *Opts, name string, dataRates map[int]uint16, resolution uint) (*Dev, error) {
+	if opts == nil {
+		opts = &DefaultOpts
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	addr := opts.I2cAddress
+	if addr == 0 {
+		addr = I2CAddr
+	}
+	t := &i2cTransport{c: i2c.Dev{Bus: i, Addr: addr}}
+	if !opts.SkipProbe {
+		if err := t.probe(); err != nil {
+			return nil, err
+		}
+	}
+	l, err := newADS1x15(t, opts)
+	if err != nil {
+		return nil, err
+	}
+	l.verifyPowerDown = opts.VerifyPowerDown
+	l.probe = !opts.SkipProbe
+	l.busFrequency = opts.BusFrequency
+	if l.busFrequency == 0 {
+		if s, ok := i.(busSpeeder); ok {
+			l.busFrequency = s.Speed()
+		}
+	}
+	l.dataRates = dataRates
+	l.resolution = resolution
+	l.name = name
+	if err := l.checkDefaults(); err != nil {
+		return nil, err
+	}
+	return l, nil
 }
 
-func newADS1x15(i i2c.Bus, opts *Opts) (l *Dev, err error) {
+func newADS1x15(t transport, opts *Opts) (l *Dev, err error) {
 	l = &Dev{
-		c: i2c.Dev{Bus: i, Addr: opts.I2cAddress},
+		t:  t,
+		id: atomic.AddUint64(&lastDevID, 1),
 		// Mapping of gain values to config register values.
-		gainConfig: map[int]uint16{
-			2 / 3: 0x0000,
-			1:     0x0200,
-			2:     0x0400,
-			4:     0x0600,
-			8:     0x0800,
-			16:    0x0A00,
+		gainConfig: map[Gain]uint16{
+			GainTwoThirds: 0x0000,
+			Gain1:         0x0200,
+			Gain2:         0x0400,
+			Gain4:         0x0600,
+			Gain8:         0x0800,
+			Gain16:        0x0A00,
 		},
-		gainVoltage: map[int]physic.ElectricPotential{
-			2 / 3: 6144 * physic.MilliVolt,
-			1:     4096 * physic.MilliVolt,
-			2:     2048 * physic.MilliVolt,
-			4:     1024 * physic.MilliVolt,
-			8:     512 * physic.MilliVolt,
-			16:    256 * physic.MilliVolt,
-		},
-		mutex: &sync.Mutex{},
+		gainVoltage:  gainFullScale,
+		lock:         make(chan struct{}, 1),
+		polling:      !opts.DisablePolling,
+		slack:        opts.ConversionSlack,
+		timeout:      opts.ConversionTimeout,
+		ready:        opts.ReadyPin,
+		discardFirst: opts.DiscardFirstAfterMuxChange,
+		defaultRange: opts.DefaultRange,
+		defaultRate:  opts.DefaultRate,
+		defaultMode:  opts.DefaultMode,
+		idleReinit:   opts.IdleReinit,
+	}
+	if l.slack == 0 {
+		l.slack = defaultConversionSlack
 	}
 
 	return
 }
 
+// String returns the chip model along its bus and address, e.g.
+// "ADS1115{I2C1, 0x49}".
 func (d *Dev) String() string {
+	if addr := d.Addr(); addr != 0 {
+		return fmt.Sprintf("%s{%s, %#02x}", d.name, d.Bus(), addr)
+	}
+	return fmt.Sprintf("%s{%s}", d.name, d.Bus())
+}
+
+// Bus returns the name of the bus the device is connected to.
+func (d *Dev) Bus() string {
+	return d.t.bus()
+}
+
+// Addr returns the I²C address of the device, or 0 for the ADS1118.
+func (d *Dev) Addr() uint16 {
+	return d.t.addr()
+}
+
+// Conn returns the connection to the device: an *i2c.Dev for the ADS1015 and
+// ADS1115, the spi.Conn for the ADS1118.
+//
+// It is meant for diagnostics; transactions on it are not serialized with the
+// ones of the driver.
+func (d *Dev) Conn() conn.Conn {
+	return d.t.conn()
+}
+
+// Type returns the chip model: "ADS1015", "ADS1115" or "ADS1118".
+func (d *Dev) Type() string {
 	return d.name
 }
 
-// Halt returns true if devices is halted successfully
-func (d *Dev) Halt() error { return nil }
+// SupportedDataRates returns the data rates supported by the chip, sorted in
+// increasing order.
+func (d *Dev) SupportedDataRates() []physic.Frequency {
+	rates := make([]int, 0, len(d.dataRates))
+	for k := range d.dataRates {
+		rates = append(rates, k)
+	}
+	sort.Ints(rates)
+	out := make([]physic.Frequency, len(rates))
+	for i, r := range rates {
+		out[i] = physic.Frequency(r) * physic.Hertz
+	}
+	return out
+}
+
+// SupportedRanges returns the full-scale ranges supported by the chip, one
+// per gain, sorted in increasing order.
+func (d *Dev) SupportedRanges() []physic.ElectricPotential {
+	out := make([]physic.ElectricPotential, 0, len(d.gainVoltage))
+	for _, v := range d.gainVoltage {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Halt stops any continuous conversion and powers the device down.
+//
+// The pins created from this device return ErrHalted afterward.
+func (d *Dev) Halt() error {
+	d.acquire()
+	stop := d.stopContinuous
+	d.release()
+	if stop != nil {
+		stop()
+	}
+	d.acquire()
+	defer d.release()
+	if d.halted {
+		return nil
+	}
+	d.halted = true
+	return d.writeConfig(ads1x15ConfigPowerDown)
+}
 
+// PinForChannel reads the electric potential of a channel against GND.
+//
+// maxVoltage selects the smallest full-scale range that includes it and
+// minimumFrequency the slowest data rate at least as fast. When they are 0,
+// Opts.DefaultRange and Opts.DefaultRate are used instead.
 func (d *Dev) PinForChannel(channel int, maxVoltage physic.ElectricPotential, minimumFrequency physic.Frequency) (pin AnalogPin, err error) {
 	if err = d.checkChannel(channel); err != nil {
 		return
@@ -177,69 +698,318 @@ func (d *Dev) PinForChannel(channel int, maxVoltage physic.ElectricPotential, mi
 // * Channel 0 - channel 3
 // * Channel 1 - channel 3
 // * Channel 2 - channel 3
+//
+// The reversed pairs, e.g. channel 1 - channel 0, are also accepted; the chip
+// measures the opposite difference and the readings are negated.
+//
+// maxVoltage is the absolute maximum of channelA - channelB, which can be
+// negative. Use PinForDifferenceOfChannelsWithRange for an asymmetric signal.
 func (d *Dev) PinForDifferenceOfChannels(channelA int, channelB int, maxVoltage physic.ElectricPotential, minimumFrequency physic.Frequency) (pin AnalogPin, err error) {
-	var mux int
-
-	if err = d.checkChannel(channelA); err != nil {
+	mux, negate, err := d.differenceMux(channelA, channelB)
+	if err != nil {
 		return
 	}
-	if err = d.checkChannel(channelB); err != nil {
+	p, err := d.newPin(mux, maxVoltage, minimumFrequency)
+	if err != nil {
 		return
 	}
+	p.negate = negate
+	p.continuous = d.defaultMode == ModeContinuous
+	return p, nil
+}
 
-	if channelA == Channel0 && channelB == Channel1 {
-		mux = 0
-	} else if channelA == Channel0 && channelB == Channel3 {
-		mux = 1
-	} else if channelA == Channel1 && channelB == Channel3 {
-		mux = 2
-	} else if channelA == Channel2 && channelB == Channel3 {
-		mux = 3
-	} else {
-		err = errors.New("Only some differences of channels are allowed:  0 - 1, 0 - 3, 1 - 3 or 2 - 3")
+// PinForDifferenceOfChannelsWithRange is like PinForDifferenceOfChannels but
+// selects the gain from the expected range [minVoltage, maxVoltage] of
+// channelA - channelB.
+//
+// The full-scale range of the chip is symmetric so the gain is selected from
+// the largest magnitude.
+func (d *Dev) PinForDifferenceOfChannelsWithRange(channelA int, channelB int, minVoltage, maxVoltage physic.ElectricPotential, minimumFrequency physic.Frequency) (AnalogPin, error) {
+	if minVoltage >= maxVoltage {
+		return nil, fmt.Errorf("ads1x15: minimum voltage %s must be below maximum voltage %s", minVoltage, maxVoltage)
+	}
+	if minVoltage < 0 && -minVoltage > maxVoltage {
+		maxVoltage = -minVoltage
+	}
+	return d.PinForDifferenceOfChannels(channelA, channelB, maxVoltage, minimumFrequency)
+}
+
+// PinForChannelAtRate is like PinForChannel but uses exactly the specified
+// data rate instead of the slowest one at least as fast as a minimum
+// frequency, e.g. 8Hz for the rejection of 50Hz and 60Hz by the digital
+// filter.
+//
+// It fails if rate is not one of SupportedDataRates().
+func (d *Dev) PinForChannelAtRate(channel int, maxVoltage physic.ElectricPotential, rate physic.Frequency) (AnalogPin, error) {
+	if err := d.checkChannel(channel); err != nil {
+		return nil, err
+	}
+	return d.newPinAtRate(channel+0x04, false, maxVoltage, rate)
+}
+
+// PinForDifferenceOfChannelsAtRate is like PinForDifferenceOfChannels but
+// uses exactly the specified data rate, like PinForChannelAtRate.
+func (d *Dev) PinForDifferenceOfChannelsAtRate(channelA int, channelB int, maxVoltage physic.ElectricPotential, rate physic.Frequency) (AnalogPin, error) {
+	mux, negate, err := d.differenceMux(channelA, channelB)
+	if err != nil {
+		return nil, err
+	}
+	return d.newPinAtRate(mux, negate, maxVoltage, rate)
+}
+
+// PinForChannelWithGain is like PinForChannel but uses the specified gain
+// instead of selecting the one the most adapted to a maximum voltage.
+//
+// This is useful to leave headroom above the nominal maximum of a sensor.
+func (d *Dev) PinForChannelWithGain(channel int, gain Gain, minimumFrequency physic.Frequency) (pin AnalogPin, err error) {
+	if err = d.checkChannel(channel); err != nil {
 		return
 	}
+	mux := channel + 0x04
 
-	return d.prepareQuery(mux, maxVoltage, minimumFrequency)
+	return d.prepareQueryWithGain(mux, gain, minimumFrequency)
 }
 
-func (d *Dev) prepareQuery(mux int, maxVoltage physic.ElectricPotential, minimumFrequency physic.Frequency) (pin AnalogPin, err error) {
-	// Determine the most appropriate gain
-	gain, err := d.bestGainForElectricPotential(maxVoltage)
+// PinForDifferenceOfChannelsWithGain is like PinForDifferenceOfChannels but
+// uses the specified gain instead of selecting the one the most adapted to a
+// maximum voltage.
+func (d *Dev) PinForDifferenceOfChannelsWithGain(channelA int, channelB int, gain Gain, minimumFrequency physic.Frequency) (pin AnalogPin, err error) {
+	mux, negate, err := d.differenceMux(channelA, channelB)
+	if err != nil {
+		return
+	}
+	p, err := d.newPinWithGain(mux, gain, minimumFrequency)
 	if err != nil {
 		return
 	}
+	p.negate = negate
+	p.continuous = d.defaultMode == ModeContinuous
+	return p, nil
+}
+
+// ReadContinuous puts the ADC in continuous conversion mode on the specified
+// channel and streams readings at the data rate the most adapted to the
+// requested frequency.
+//
+// The returned function stops the stream, closes the channel and powers the
+// device down. If an I²C transaction fails, the device is powered down and the
+// channel is closed; ContinuousErr() then returns the error. While the stream
+// is active, reads from other pins on the same device fail.
+func (d *Dev) ReadContinuous(channel int, maxVoltage physic.ElectricPotential, f physic.Frequency) (<-chan Reading, func(), error) {
+	if err := d.checkChannel(channel); err != nil {
+		return nil, nil, err
+	}
+	p, err := d.newPin(channel+0x04, maxVoltage, f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := p.continuousConfig()
+	powerDown := p.powerDownConfig()
+
+	d.acquire()
+	if err := d.checkState(); err != nil {
+		d.release()
+		return nil, nil, err
+	}
+	if err := d.writeConfig(config); err != nil {
+		d.release()
+		return nil, nil, err
+	}
+	d.continuous = true
+	d.continuousErr = nil
+
+	c := make(chan Reading)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	// ended is set once the stream released the device. It is protected by
+	// the device lock.
+	ended := false
+	// end releases the device. The caller must hold the lock.
+	end := func() {
+		// There's nothing the caller can do about a failure here; the next
+		// single-shot conversion resets the mode anyway.
+		_ = d.writeConfig(powerDown)
+		d.continuous = false
+		d.stopContinuous = nil
+		ended = true
+	}
+	go func() {
+		defer close(done)
+		defer close(c)
+		t := time.NewTicker(dataRatePeriod(p.dataRate))
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+			d.acquire()
+			r, err := d.readConversion(p.voltageMultiplier)
+			if err != nil {
+				end()
+				d.continuousErr = err
+				d.release()
+				return
+			}
+			d.release()
+			select {
+			case <-stop:
+				return
+			case c <- r:
+			}
+		}
+	}()
+
+	var once sync.Once
+	stopFn := func() {
+		once.Do(func() {
+			close(stop)
+			<-done
+			d.acquire()
+			defer d.release()
+			if !ended {
+				end()
+			}
+		})
+	}
+	d.stopContinuous = stopFn
+	d.release()
+	return c, stopFn, nil
+}
+
+// ContinuousErr returns the error that ended the last ReadContinuous stream,
+// or nil if it is still running or was stopped.
+func (d *Dev) ContinuousErr() error {
+	d.acquire()
+	defer d.release()
+	return d.continuousErr
+}
+
+// ReadAll samples the four single-ended channels in one sweep, without
+// letting other reads interleave.
+//
+// maxVoltages and f are used like in PinForChannel for each channel. Channels
+// with a zero maxVoltage are skipped and their reading is left empty. The
+// returned time is when the sweep started.
+func (d *Dev) ReadAll(maxVoltages [4]physic.ElectricPotential, f physic.Frequency) ([4]Reading, time.Time, error) {
+	var readings [4]Reading
+	var pins [4]*ads1x15AnalogPin
+	for i, v := range maxVoltages {
+		if v == 0 {
+			continue
+		}
+		p, err := d.newPin(i+0x04, v, f)
+		if err != nil {
+			return readings, time.Time{}, err
+		}
+		pins[i] = p
+	}
+
+	d.acquire()
+	defer d.release()
+	if err := d.checkState(); err != nil {
+		return readings, time.Time{}, err
+	}
+	now := time.Now()
+	for i, p := range pins {
+		if p == nil {
+			continue
+		}
+		r, err := d.convert(context.Background(), p)
+		if err != nil {
+			return readings, now, err
+		}
+		readings[i] = r
+	}
+	return readings, now, nil
+}
+
+func (d *Dev) prepareQuery(mux int, maxVoltage physic.ElectricPotential, minimumFrequency physic.Frequency) (AnalogPin, error) {
+	p, err := d.newPin(mux, maxVoltage, minimumFrequency)
+	if err != nil {
+		return nil, err
+	}
+	p.continuous = d.defaultMode == ModeContinuous
+	return p, nil
+}
+
+func (d *Dev) prepareQueryWithGain(mux int, gain Gain, minimumFrequency physic.Frequency) (AnalogPin, error) {
+	p, err := d.newPinWithGain(mux, gain, minimumFrequency)
+	if err != nil {
+		return nil, err
+	}
+	p.continuous = d.defaultMode == ModeContinuous
+	return p, nil
+}
+
+func (d *Dev) newPinAtRate(mux int, negate bool, maxVoltage physic.ElectricPotential, rate physic.Frequency) (*ads1x15AnalogPin, error) {
+	if maxVoltage == 0 {
+		maxVoltage = d.defaultRange
+	}
+	gain, err := d.BestGain(maxVoltage)
+	if err != nil {
+		return nil, err
+	}
+	dataRate, err := d.exactDataRate(rate)
+	if err != nil {
+		return nil, err
+	}
+	p, err := d.newPinWithRate(mux, gain, dataRate)
+	if err != nil {
+		return nil, err
+	}
+	p.negate = negate
+	p.continuous = d.defaultMode == ModeContinuous
+	return p, nil
+}
+
+func (d *Dev) newPin(mux int, maxVoltage physic.ElectricPotential, minimumFrequency physic.Frequency) (*ads1x15AnalogPin, error) {
+	if maxVoltage == 0 {
+		maxVoltage = d.defaultRange
+	}
+	// Determine the most appropriate gain
+	gain, err := d.BestGain(maxVoltage)
+	if err != nil {
+		return nil, err
+	}
+	return d.newPinWithGain(mux, gain, minimumFrequency)
+}
+
+func (d *Dev) newPinWithGain(mux int, gain Gain, minimumFrequency physic.Frequency) (*ads1x15AnalogPin, error) {
+	// Determine the most appropriate data rate
+	if minimumFrequency == 0 {
+		minimumFrequency = d.defaultRate
+	}
+	rate, err := d.BestDataRate(minimumFrequency)
+	if err != nil {
+		return nil, err
+	}
+	return d.newPinWithRate(mux, gain, int(rate/physic.Hertz))
+}
 
+// newPinWithRate returns a pin using exactly the specified gain and data
+// rate, in samples per second.
+func (d *Dev) newPinWithRate(mux int, gain Gain, dataRate int) (pin *ads1x15AnalogPin, err error) {
 	// Validate the gain.
 	gainConf, ok := d.gainConfig[gain]
 	if !ok {
-		err = errors.New("Gain must be one of: 2/3, 1, 2, 4, 8, 16")
+		err = errInvalidGain
 		return
 	}
 
 	// Determine the voltage multiplier for this gain
 	voltageMultiplier, ok := d.gainVoltage[gain]
 	if !ok {
-		err = errors.New("Gain must be one of: 2/3, 1, 2, 4, 8, 16")
-		return
-	}
-
-	// Determine the most appropriate data rate
-	dataRate, err := d.bestDataRateForFrequency(minimumFrequency)
-	if err != nil {
+		err = errInvalidGain
 		return
 	}
 
 	dataRateConf, ok := d.dataRates[dataRate]
-
 	if !ok {
 		// Write a nice error message in case the data rate is not found
-		keys := []int{}
-		for k := range d.dataRates {
-			keys = append(keys, k)
-		}
-
-		err = fmt.Errorf("Invalid data rate. Accepted values: %d", keys)
+		err = fmt.Errorf("ads1x15: invalid data rate %s; accepted values: %v", physic.Frequency(dataRate)*physic.Hertz, d.SupportedDataRates())
 		return
 	}
 
@@ -256,150 +1026,1488 @@ func (d *Dev) prepareQuery(mux int, maxVoltage physic.ElectricPotential, minimum
 	// Set the data rate (this is controlled by the subclass as it differs
 	// between ADS1015 and ADS1115).
 	config |= dataRateConf
-	config |= ads1x15ConfigCompQueDisable // Disable comparator mode.
-
-	// Build the query to the ADC
-	configBytes := make([]byte, 2)
-	binary.BigEndian.PutUint16(configBytes, config)
-	query := append([]byte{ads1x15PointerConfig}, configBytes...)
+	if d.ready == nil {
+		config |= ads1x15ConfigCompQueDisable // Disable comparator mode.
+	}
+	// Otherwise ALERT/RDY (active low) is asserted after each conversion. It is
+	// set up by the first read, so no I²C transaction happens here.
 
 	// The wait for the ADC sample to finish is based on the sample rate plus a
-	// small offset to be sure (0.1 millisecond).
-	waitTime := time.Second/time.Duration(dataRate) + 100*time.Microsecond
+	// small offset to be sure.
+	waitTime := d.conversionTime(dataRate)
 
 	pin = &ads1x15AnalogPin{
 		adc:               d,
-		query:             query,
+		config:            config,
 		voltageMultiplier: voltageMultiplier,
 		waitTime:          waitTime,
+		dataRate:          dataRate,
+		gain:              gain,
+		mux:               mux,
+		scale:             1,
 	}
 
 	return
 }
 
-func (d *Dev) executePreparedQuery(query []byte, waitTime time.Duration, voltageMultiplier physic.ElectricPotential) (reading Reading, err error) {
-	// Lock the ADC converter to avoid multiple simultaneous readings.
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
-
-	// Send the config value to start the ADC conversion.
-	// Explicitly break the 16-bit value down to a big endian pair of bytes.
-	if err = d.c.Tx(query, nil); err != nil {
-		return
+// checkDefaults verifies that Opts.DefaultRange and Opts.DefaultRate are
+// supported by the chip, so that an invalid default fails the constructor
+// with a *VoltageRangeError or a *FrequencyRangeError.
+func (d *Dev) checkDefaults() error {
+	if d.defaultRange != 0 {
+		if _, err := d.BestGain(d.defaultRange); err != nil {
+			return err
+		}
 	}
+	if d.defaultRate != 0 {
+		if _, err := d.BestDataRate(d.defaultRate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// Wait for the ADC sample to finish.
-	time.Sleep(waitTime)
+// acquire locks the device.
+//
+// The goroutines blocked on the channel are queued in arrival order and
+// release() hands the lock over to the first one, so the device is served
+// fairly.
+func (d *Dev) acquire() {
+	atomic.AddInt32(&d.waiters, 1)
+	d.lock <- struct{}{}
+	atomic.AddInt32(&d.waiters, -1)
+}
 
-	// Retrieve the result.
-	data := []byte{0, 0}
-	if err = d.c.Tx([]byte{ads1x15PointerConversion}, data); err != nil {
-		return
+// acquireCtx locks the device, giving up when ctx is done.
+func (d *Dev) acquireCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
+	atomic.AddInt32(&d.waiters, 1)
+	defer atomic.AddInt32(&d.waiters, -1)
+	select {
+	case d.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	// Convert the raw data into physical value.
-	raw := int16(binary.BigEndian.Uint16(data))
-	reading.Raw = int32(raw)
-	reading.V = physic.ElectricPotential(reading.Raw) * voltageMultiplier / physic.ElectricPotential(1<<15)
-
-	return
+// QueueDepth returns the number of operations waiting for the device, for
+// diagnostics.
+//
+// The operations are served in arrival order.
+func (d *Dev) QueueDepth() int {
+	return int(atomic.LoadInt32(&d.waiters))
 }
 
-// bestGainForElectricPotential returns the gain the most adapted to read up to the specified difference of potential.
-func (d *Dev) bestGainForElectricPotential(voltage physic.ElectricPotential) (bestGain int, err error) {
-	var max physic.ElectricPotential
-	difference := physic.ElectricPotential(math.MaxInt64)
-	currentBestGain := -1
+// release unlocks the device.
+func (d *Dev) release() {
+	<-d.lock
+}
 
-	for key, value := range d.gainVoltage {
-		// We compute the maximum in case we need to display an error
-		if value > max {
-			max = value
-		}
-		newDiff := value - voltage
-		if newDiff >= 0 && newDiff < difference {
-			difference = newDiff
-			currentBestGain = key
+// checkState returns an error if the device cannot be used for a new
+// conversion.
+//
+// After Opts.IdleReinit, it also probes the chip again and forgets its cached
+// state so the registers are programmed again. The caller must hold the lock.
+func (d *Dev) checkState() error {
+	if d.halted {
+		return ErrHalted
+	}
+	if d.continuous {
+		return errContinuous
+	}
+	if d.idleReinit > 0 {
+		if last := atomic.LoadInt64(&d.lastActivity); last != 0 && time.Since(time.Unix(0, last)) > d.idleReinit {
+			if t, ok := d.t.(*i2cTransport); ok && d.probe {
+				if err := t.probe(); err != nil {
+					return err
+				}
+			}
+			d.configValid = false
+			d.lowLatency = nil
+			d.thresholds = nil
+			d.readyInit = false
+			d.touch()
 		}
 	}
+	return nil
+}
 
-	if currentBestGain < 0 {
-		err = fmt.Errorf("The maximum voltage which can be read is %s", max.String())
-		return
+// LastActivity returns the last time the driver started a conversion or read
+// one, or the zero time if it never did.
+//
+// It can be used to decide when to cut the power of the chip, see
+// Opts.IdleReinit.
+func (d *Dev) LastActivity() time.Time {
+	if last := atomic.LoadInt64(&d.lastActivity); last != 0 {
+		return time.Unix(0, last)
 	}
+	return time.Time{}
+}
 
-	bestGain = currentBestGain
-	return
+// touch updates the time of the last activity.
+func (d *Dev) touch() {
+	atomic.StoreInt64(&d.lastActivity, time.Now().UnixNano())
 }
 
-// bestDataRateForFrequency returns the gain the most data rate to read samples at least at the requested frequency.
-func (d *Dev) bestDataRateForFrequency(minimumFrequency physic.Frequency) (bestDataRate int, err error) {
-	var max physic.Frequency
-	difference := physic.Frequency(math.MaxInt64)
-	currentBestDataRate := -1
+func (d *Dev) executePreparedQuery(ctx context.Context, p *ads1x15AnalogPin) (reading Reading, err error) {
+	// Lock the ADC converter to avoid multiple simultaneous readings.
+	if err = d.acquireCtx(ctx); err != nil {
+		return
+	}
+	defer d.release()
 
-	for key := range d.dataRates {
-		freq := physic.Frequency(key) * physic.Hertz
+	if err = d.checkState(); err != nil {
+		return
+	}
+	return d.convert(ctx, p)
+}
 
-		// We compute the minimum in case we need to display an error
-		if freq > max {
-			max = freq
+// convert runs a single-shot conversion and returns its result.
+//
+// The caller must hold the lock.
+func (d *Dev) convert(ctx context.Context, p *ads1x15AnalogPin) (Reading, error) {
+	r, _, err := d.convertTimed(ctx, p)
+	return r, err
+}
+
+// convertTimed runs a single-shot conversion and returns its result along the
+// time the end of the conversion was detected.
+//
+// The caller must hold the lock.
+func (d *Dev) convertTimed(ctx context.Context, p *ads1x15AnalogPin) (reading Reading, t time.Time, err error) {
+	if err = d.applyThresholds(p); err != nil {
+		return
+	}
+	if p.continuous {
+		return d.readLatest(ctx, p)
+	}
+	if d.muxChanged(p) {
+		// Throw away the first conversion.
+		if err = d.startAndWait(ctx, p); err != nil {
+			return
 		}
+	}
+	if err = d.startAndWait(ctx, p); err != nil {
+		return
+	}
+	t = time.Now()
 
-		newDiff := freq - minimumFrequency
+	if reading, err = d.readConversion(p.voltageMultiplier); err != nil {
+		return
+	}
+	if err = d.checkPowerDown(); err != nil {
+		return
+	}
+	reading = p.adjust(reading)
+	return
+}
+
+// checkPowerDown verifies that the chip is back in power-down after a
+// single-shot conversion when Opts.VerifyPowerDown is set.
+//
+// The caller must hold the lock.
+func (d *Dev) checkPowerDown() error {
+	if !d.verifyPowerDown {
+		return nil
+	}
+	config, err := d.t.readConfig()
+	if err != nil {
+		return err
+	}
+	if config&(ads1x15ConfigOsSingle|ads1x15ConfigModeSingle) != ads1x15ConfigOsSingle|ads1x15ConfigModeSingle {
+		d.configValid = false
+		return fmt.Errorf("%w: config %#04x", ErrNotPoweredDown, config)
+	}
+	return nil
+}
+
+// conversionTimeout returns how long to wait for a single-shot conversion
+// expected to take waitTime.
+func (d *Dev) conversionTimeout(waitTime time.Duration) time.Duration {
+	if d.timeout > 0 {
+		return d.timeout
+	}
+	return 10 * waitTime
+}
+
+// waitForConversion polls the OS bit of the config register until the
+// single-shot conversion is done.
+//
+// It gives up after conversionTimeout(). The caller must hold the lock.
+func (d *Dev) waitForConversion(ctx context.Context, waitTime time.Duration) error {
+	timeout := time.Now().Add(d.conversionTimeout(waitTime))
+	// The conversion cannot complete much before its nominal duration, so skip
+	// the first half and then poll with an increasing delay.
+	if err := sleep(ctx, waitTime/2); err != nil {
+		return err
+	}
+	delay := waitTime / 16
+	for {
+		config, err := d.t.readConfig()
+		if err != nil {
+			return err
+		}
+		if config&ads1x15ConfigOsSingle != 0 {
+			return nil
+		}
+		if time.Now().After(timeout) {
+			return ErrConversionTimeout
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+		if delay < waitTime/4 {
+			delay *= 2
+		}
+	}
+}
+
+// startAndWait starts a single-shot conversion and waits for it to complete.
+//
+// The caller must hold the lock.
+func (d *Dev) startAndWait(ctx context.Context, p *ads1x15AnalogPin) error {
+	if d.ready != nil {
+		if err := d.initReadyPin(); err != nil {
+			return err
+		}
+		// Discard any stale edge.
+		for d.ready.WaitForEdge(0) {
+		}
+	}
+
+	// Send the config value to start the ADC conversion.
+	if err := d.writeConfig(p.config); err != nil {
+		return err
+	}
+
+	// Wait for the ADC sample to finish.
+	// There's no need to abort the conversion on cancellation, the device
+	// goes back to power-down on its own once it is done.
+	if d.ready != nil {
+		return d.waitForReady(ctx, p.waitTime)
+	} else if d.polling {
+		return d.waitForConversion(ctx, p.waitTime)
+	}
+	return sleep(ctx, p.waitTime)
+}
+
+// muxChanged returns true if the first conversion for p must be discarded
+// because the input multiplexer or the gain were changed since the last
+// conversion. It is only the case with Opts.DiscardFirstAfterMuxChange.
+//
+// The caller must hold the lock.
+func (d *Dev) muxChanged(p *ads1x15AnalogPin) bool {
+	return d.discardFirst && (!d.configValid || d.config&ads1x15ConfigMuxGainMask != p.config&ads1x15ConfigMuxGainMask)
+}
+
+// readLatest returns the latest conversion of a pin in low-latency mode,
+// starting continuous conversions first if needed.
+//
+// The caller must hold the lock.
+func (d *Dev) readLatest(ctx context.Context, p *ads1x15AnalogPin) (reading Reading, t time.Time, err error) {
+	if d.lowLatency != p {
+		wait := p.waitTime
+		if d.muxChanged(p) {
+			// Skip the first conversion.
+			wait *= 2
+		}
+		if err = d.writeConfig(p.continuousConfig()); err != nil {
+			return
+		}
+		d.lowLatency = p
+		// Wait for the first conversion with the new settings.
+		if err = sleep(ctx, wait); err != nil {
+			return
+		}
+	}
+	t = time.Now()
+	if reading, err = d.readConversion(p.voltageMultiplier); err != nil {
+		return
+	}
+	reading = p.adjust(reading)
+	return
+}
+
+// writeConfig writes the config register unless it already holds config.
+//
+// Writes setting the OS bit are never skipped: the register can only be
+// written as a whole and setting this bit is what starts a single-shot
+// conversion. Once the conversion is done the chip is back in power-down with
+// the same settings, so a following power-down write is skipped.
+//
+// The caller must hold the lock.
+func (d *Dev) writeConfig(config uint16) error {
+	d.lowLatency = nil
+	if config&ads1x15ConfigOsSingle == 0 && d.configValid && d.config == config {
+		return nil
+	}
+	d.configValid = false
+	if err := d.t.writeConfig(config); err != nil {
+		return err
+	}
+	d.touch()
+	d.config = config &^ ads1x15ConfigOsSingle
+	d.configValid = true
+	return nil
+}
+
+// applyThresholds programs the comparator thresholds of p unless they are
+// already in the chip. The threshold registers are shared by all the pins so
+// they must be written again whenever another pin changed them.
+//
+// The caller must hold the lock.
+func (d *Dev) applyThresholds(p *ads1x15AnalogPin) error {
+	if !p.comparator || d.thresholds == p {
+		return nil
+	}
+	d.thresholds = nil
+	if err := d.t.writeRegister(ads1x15PointerLowThreshold, uint16(p.low)); err != nil {
+		return err
+	}
+	if err := d.t.writeRegister(ads1x15PointerHighThreshold, uint16(p.high)); err != nil {
+		return err
+	}
+	d.thresholds = p
+	return nil
+}
+
+// initReadyPin configures the comparator thresholds so ALERT/RDY is used as
+// a conversion ready signal, and the GPIO connected to it.
+//
+// It is done once, on the first conversion, and again after the registers
+// were reset. The caller must hold the lock.
+func (d *Dev) initReadyPin() error {
+	if d.readyInit {
+		return nil
+	}
+	// A high threshold with the MSB set and a low threshold with the MSB
+	// cleared enable the conversion ready mode.
+	if err := d.t.writeRegister(ads1x15PointerLowThreshold, 0x7FFF); err != nil {
+		return err
+	}
+	if err := d.t.writeRegister(ads1x15PointerHighThreshold, 0x8000); err != nil {
+		return err
+	}
+	// ALERT/RDY is open drain.
+	if err := d.ready.In(gpio.PullUp, gpio.FallingEdge); err != nil {
+		return err
+	}
+	d.readyInit = true
+	return nil
+}
+
+// waitForReady waits for the falling edge of ALERT/RDY that signals the end
+// of the conversion.
+//
+// It gives up after conversionTimeout(). The caller must hold the lock.
+func (d *Dev) waitForReady(ctx context.Context, waitTime time.Duration) error {
+	timeout := time.Now().Add(d.conversionTimeout(waitTime))
+	// Wait in slices so cancellation is noticed in a timely manner.
+	slice := waitTime
+	if slice > 10*time.Millisecond {
+		slice = 10 * time.Millisecond
+	}
+	for {
+		if d.ready.WaitForEdge(slice) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if time.Now().After(timeout) {
+			return ErrConversionTimeout
+		}
+	}
+}
+
+// readConversion reads the last conversion result.
+//
+// The caller must hold the lock.
+func (d *Dev) readConversion(voltageMultiplier physic.ElectricPotential) (reading Reading, err error) {
+	data, err := d.t.readConversion()
+	if err != nil {
+		return
+	}
+	d.touch()
+
+	// Convert the raw data into physical value. The arithmetic shift keeps
+	// the sign of a left-justified 12 bits result.
+	reading.Raw = int32(int16(data)) >> (16 - d.resolution)
+	reading.V = physic.ElectricPotential(reading.Raw) * voltageMultiplier / physic.ElectricPotential(int64(1)<<(d.resolution-1))
+
+	return
+}
+
+// BestGain returns the gain the most adapted to read up to the specified
+// difference of potential: the one with the smallest full-scale range
+// including it.
+//
+// It returns a *VoltageRangeError when voltage is above the largest range.
+// This is the selection done by PinForChannel().
+func (d *Dev) BestGain(voltage physic.ElectricPotential) (bestGain Gain, err error) {
+	if voltage <= 0 {
+		err = fmt.Errorf("ads1x15: maximum voltage %s must be positive", voltage)
+		return
+	}
+	var max physic.ElectricPotential
+	difference := physic.ElectricPotential(math.MaxInt64)
+	currentBestGain := Gain(0)
+
+	for key, value := range d.gainVoltage {
+		// We compute the maximum in case we need to display an error
+		if value > max {
+			max = value
+		}
+		newDiff := value - voltage
 		if newDiff >= 0 && newDiff < difference {
+			difference = newDiff
+			currentBestGain = key
+		}
+	}
+
+	if currentBestGain == 0 {
+		err = &VoltageRangeError{Requested: voltage, Max: max}
+		return
+	}
+
+	bestGain = currentBestGain
+	return
+}
+
+// BestDataRate returns the slowest data rate to read samples at least at the
+// requested frequency.
+//
+// It returns a *FrequencyRangeError when minimumFrequency is above the fastest
+// data rate. When the bus frequency is known, the effective sample rate
+// including the bus transactions must reach minimumFrequency, otherwise it
+// returns a *BusSpeedError. This is the selection done by PinForChannel().
+func (d *Dev) BestDataRate(minimumFrequency physic.Frequency) (bestDataRate physic.Frequency, err error) {
+	var max, maxEffective physic.Frequency
+	difference := physic.Frequency(math.MaxInt64)
+	currentBestDataRate := -1
+
+	for key := range d.dataRates {
+		freq := physic.Frequency(key) * physic.Hertz
+		effective := d.sampleRate(key)
+
+		// We compute the minimum in case we need to display an error
+		if freq > max {
+			max = freq
+			maxEffective = effective
+		}
+
+		newDiff := freq - minimumFrequency
+		if newDiff >= 0 && newDiff < difference && effective >= minimumFrequency {
 			difference = newDiff
 			currentBestDataRate = key
 		}
 	}
 
 	if currentBestDataRate < 0 {
-		err = fmt.Errorf("The maximum frequency which can be read is %s", max.String())
+		if max >= minimumFrequency {
+			err = &BusSpeedError{Requested: minimumFrequency, Max: maxEffective, Bus: d.busFrequency}
+		} else {
+			err = &FrequencyRangeError{Requested: minimumFrequency, Max: max}
+		}
+		return
+	}
+
+	bestDataRate = physic.Frequency(currentBestDataRate) * physic.Hertz
+	return
+}
+
+// exactDataRate returns the data rate in samples per second matching rate
+// exactly.
+func (d *Dev) exactDataRate(rate physic.Frequency) (int, error) {
+	if rate%physic.Hertz == 0 {
+		if _, ok := d.dataRates[int(rate/physic.Hertz)]; ok {
+			return int(rate / physic.Hertz), nil
+		}
+	}
+	return 0, fmt.Errorf("ads1x15: unsupported data rate %s; supported data rates: %v", rate, d.SupportedDataRates())
+}
+
+// conversionTime returns the duration to wait for a single-shot conversion
+// at the specified data rate.
+//
+// The conversion starts at the end of the config write, which may complete
+// after the bus adapter returns, so its duration is added.
+func (d *Dev) conversionTime(dataRate int) time.Duration {
+	period := dataRatePeriod(dataRate)
+	waitTime := period + d.slack
+	if min := period * 9 / 10; waitTime < min {
+		waitTime = min
+	}
+	return waitTime + d.busTime(configWriteBits)
+}
+
+// sampleRate returns the effective rate of back to back single-shot reads
+// at the specified data rate, including the bus transactions.
+func (d *Dev) sampleRate(dataRate int) physic.Frequency {
+	if d.busFrequency == 0 {
+		return physic.Frequency(dataRate) * physic.Hertz
+	}
+	period := dataRatePeriod(dataRate) + d.busTime(configWriteBits+conversionReadBits)
+	return physic.PeriodToFrequency(period)
+}
+
+// busTime returns the duration of a transaction of n bus clock cycles, or 0
+// when the bus frequency is unknown.
+func (d *Dev) busTime(n int) time.Duration {
+	if d.busFrequency == 0 {
+		return 0
+	}
+	return d.busFrequency.Duration(int64(n))
+}
+
+// dataRatePeriod returns the conversion period at a data rate in samples per
+// second.
+func dataRatePeriod(dataRate int) time.Duration {
+	return (physic.Frequency(dataRate) * physic.Hertz).Period()
+}
+
+// differenceMux returns the mux value to measure channelA - channelB.
+//
+// negate is set when the chip only supports channelB - channelA, so the
+// result must be negated.
+func (d *Dev) differenceMux(channelA, channelB int) (mux int, negate bool, err error) {
+	if err = d.checkChannel(channelA); err != nil {
 		return
 	}
+	if err = d.checkChannel(channelB); err != nil {
+		return
+	}
+	if channelA > channelB {
+		channelA, channelB = channelB, channelA
+		negate = true
+	}
 
-	bestDataRate = currentBestDataRate
+	if channelA == Channel0 && channelB == Channel1 {
+		mux = 0
+	} else if channelA == Channel0 && channelB == Channel3 {
+		mux = 1
+	} else if channelA == Channel1 && channelB == Channel3 {
+		mux = 2
+	} else if channelA == Channel2 && channelB == Channel3 {
+		mux = 3
+	} else {
+		err = ErrInvalidDifferentialPair
+	}
 	return
 }
 
 func (d *Dev) checkChannel(channel int) (err error) {
 	if channel < 0 || channel > 3 {
-		err = errors.New("Invalid channel, must be between 0 and 3")
+		err = ErrInvalidChannel
 	}
 	return
 }
 
+// continuousConfig returns the config register value that starts continuous
+// conversions with the pin configuration.
+func (p *ads1x15AnalogPin) continuousConfig() uint16 {
+	return p.config &^ (ads1x15ConfigOsSingle | ads1x15ConfigModeSingle)
+}
+
+// powerDownConfig returns the config register value that reverts to
+// single-shot mode without starting a conversion, which powers the device
+// down.
+func (p *ads1x15AnalogPin) powerDownConfig() uint16 {
+	return p.config &^ ads1x15ConfigOsSingle
+}
+
 // Range returns the maximum supported range [min, max] of the values.
+//
+// The values are calibrated, see SetCalibration(). The range is symmetric so
+// it is the same for a reversed differential pair.
 func (p *ads1x15AnalogPin) Range() (minValue Reading, maxValue Reading) {
-	maxValue.V = p.voltageMultiplier
-	maxValue.Raw = 1 << 15
-	minValue.V = -maxValue.V
+	p.adc.acquire()
+	defer p.adc.release()
+	maxValue.V = p.calibrate(p.voltageMultiplier)
+	maxValue.Raw = 1 << (p.adc.resolution - 1)
+	minValue.V = p.calibrate(-p.voltageMultiplier)
 	minValue.Raw = -maxValue.Raw
 
 	return
 }
 
+// SetCalibration sets the correction applied to the readings of this pin, to
+// compensate for the offset and gain errors of the board.
+//
+// Reading.V becomes V*scale + offset while Reading.Raw is left as read. scale
+// must be positive. Use SetCalibration(0, 1) to remove the calibration.
+func (p *ads1x15AnalogPin) SetCalibration(offset physic.ElectricPotential, scale float64) error {
+	if !(scale > 0) || math.IsInf(scale, 1) {
+		return fmt.Errorf("ads1x15: invalid calibration scale %g, must be positive", scale)
+	}
+	// Checking the range guarantees that no reading overflows.
+	for _, v := range []physic.ElectricPotential{-p.voltageMultiplier, p.voltageMultiplier} {
+		if _, ok := calibration(v, offset, scale); !ok {
+			return fmt.Errorf("%w: calibration offset %s and scale %g over the range ±%s of the pin", ErrOverflow, offset, scale, p.voltageMultiplier)
+		}
+	}
+	p.adc.acquire()
+	defer p.adc.release()
+	p.offset = offset
+	p.scale = scale
+	return nil
+}
+
+// TwoPointCalibration computes the arguments to SetCalibration() from two
+// raw readings of this pin and the electric potentials actually measured for
+// them.
+func (p *ads1x15AnalogPin) TwoPointCalibration(raw1 int32, v1 physic.ElectricPotential, raw2 int32, v2 physic.ElectricPotential) (physic.ElectricPotential, float64, error) {
+	if raw1 == raw2 {
+		return 0, 0, errors.New("ads1x15: calibration points must have different raw values")
+	}
+	u1 := p.rawToVoltage(float64(raw1))
+	u2 := p.rawToVoltage(float64(raw2))
+	scale := float64(v2-v1) / float64(u2-u1)
+	if !(scale > 0) {
+		return 0, 0, fmt.Errorf("ads1x15: invalid calibration points, scale %g must be positive", scale)
+	}
+	s, ok := physic.Scale(int64(u1), scale)
+	offset, ok2 := physic.Sub(int64(v1), s)
+	if !ok || !ok2 {
+		return 0, 0, fmt.Errorf("%w: calibration points %s and %s", ErrOverflow, v1, v2)
+	}
+	return physic.ElectricPotential(offset), scale, nil
+}
+
+// adjust negates the reading for a reversed differential pair then applies
+// the calibration.
+//
+// The caller must hold the lock.
+func (p *ads1x15AnalogPin) adjust(r Reading) Reading {
+	if p.negate {
+		r.Raw = -r.Raw
+		r.V = -r.V
+	}
+	r.V = p.calibrate(r.V)
+	return r
+}
+
+// calibrate applies the calibration to an electric potential.
+//
+// SetCalibration() guarantees that it doesn't overflow over the range of the
+// pin. The caller must hold the lock.
+func (p *ads1x15AnalogPin) calibrate(v physic.ElectricPotential) physic.ElectricPotential {
+	c, _ := calibration(v, p.offset, p.scale)
+	return c
+}
+
+// uncalibrate reverts calibrate. ok is false when the result overflows.
+//
+// The caller must hold the lock.
+func (p *ads1x15AnalogPin) uncalibrate(v physic.ElectricPotential) (physic.ElectricPotential, bool) {
+	d, ok := physic.Sub(int64(v), int64(p.offset))
+	if !ok {
+		return 0, false
+	}
+	u, ok := physic.Scale(d, 1/p.scale)
+	return physic.ElectricPotential(u), ok
+}
+
+// calibration returns v*scale + offset. ok is false when the result
+// overflows.
+func calibration(v, offset physic.ElectricPotential, scale float64) (physic.ElectricPotential, bool) {
+	s, ok := physic.Scale(int64(v), scale)
+	c, ok2 := physic.Add(s, int64(offset))
+	return physic.ElectricPotential(c), ok && ok2
+}
+
+// SetComparator programs the low and high thresholds of the comparator and
+// enables it for the following conversions on this pin.
+//
+// The thresholds are calibrated values, like the ones returned by Read(). They
+// are converted to raw values using the gain of the pin and must be within
+// Range(). Use ComparatorOpts.Window to assert ALERT when the
+// signal leaves [low, high], e.g. to monitor a battery.
+//
+// The threshold registers are shared by all the pins of the device; they are
+// written again before a conversion on this pin if another pin changed them
+// in between.
+func (p *ads1x15AnalogPin) SetComparator(low, high physic.ElectricPotential, opts ComparatorOpts) error {
+	if low >= high {
+		return fmt.Errorf("ads1x15: comparator low threshold %s must be below high threshold %s", low, high)
+	}
+	var comp uint16
+	switch opts.Queue {
+	case 1:
+		comp = 0x0000
+	case 2:
+		comp = 0x0001
+	case 4:
+		comp = 0x0002
+	default:
+		return errors.New("ads1x15: comparator queue must be 1, 2 or 4")
+	}
+	if opts.Window {
+		comp |= ads1x15ConfigCompWindow
+	} else if p.negate {
+		return errors.New("ads1x15: only the window comparator is supported on a reversed differential pair")
+	}
+	if opts.ActiveHigh {
+		comp |= ads1x15ConfigCompAactiveHigh
+	}
+	if opts.Latching {
+		comp |= ads1x15ConfigCompLatching
+	}
+
+	d := p.adc
+	if d.ready != nil {
+		return errors.New("ads1x15: the comparator is used for ReadyPin")
+	}
+	d.acquire()
+	defer d.release()
+	if err := d.checkState(); err != nil {
+		return err
+	}
+	l, okLow := p.uncalibrate(low)
+	h, okHigh := p.uncalibrate(high)
+	if !okLow || !okHigh || l < -p.voltageMultiplier || h > p.voltageMultiplier {
+		return fmt.Errorf("ads1x15: comparator thresholds [%s, %s] are outside of the range [%s, %s] of the pin", low, high, p.calibrate(-p.voltageMultiplier), p.calibrate(p.voltageMultiplier))
+	}
+	if p.negate {
+		// The chip compares the opposite of the signal.
+		l, h = -h, -l
+	}
+	p.comparator = true
+	p.low = p.voltageToRaw(l)
+	p.high = p.voltageToRaw(h)
+	if d.thresholds == p {
+		d.thresholds = nil
+	}
+	if err := d.applyThresholds(p); err != nil {
+		return err
+	}
+	p.config = p.config&^ads1x15ConfigCompMask | comp
+	return nil
+}
+
+// voltageToRaw converts an electric potential into the value as found in the
+// conversion register, clamped to the 16 bits range.
+//
+// The value is left-justified, like the threshold registers expect; on the
+// ADS1015 the 4 least significant bits are cleared.
+func (p *ads1x15AnalogPin) voltageToRaw(v physic.ElectricPotential) int16 {
+	raw := int64(v) * (1 << 15) / int64(p.voltageMultiplier)
+	if raw > math.MaxInt16 {
+		raw = math.MaxInt16
+	}
+	if raw < math.MinInt16 {
+		raw = math.MinInt16
+	}
+	return int16(raw) &^ (1<<(16-p.adc.resolution) - 1)
+}
+
+// Gain returns the gain selected for this pin.
+func (p *ads1x15AnalogPin) Gain() Gain {
+	return p.gain
+}
+
+// DataRate returns the data rate selected for this pin.
+func (p *ads1x15AnalogPin) DataRate() physic.Frequency {
+	return physic.Frequency(p.dataRate) * physic.Hertz
+}
+
+// SampleRate implements analog.SampleRater.
+//
+// It is the rate of back to back single-shot reads: the data rate programmed
+// in the chip, as returned by DataRate(), slowed down by the bus transactions
+// when the bus frequency is known. See Opts.BusFrequency.
+func (p *ads1x15AnalogPin) SampleRate() physic.Frequency {
+	return p.adc.sampleRate(p.dataRate)
+}
+
+// ConversionTime implements analog.SampleRater.
+//
+// It is the nominal conversion period at the data rate of the pin. A
+// single-shot read takes a bit longer, see Opts.ConversionSlack.
+func (p *ads1x15AnalogPin) ConversionTime() time.Duration {
+	return dataRatePeriod(p.dataRate)
+}
+
+// Channels returns the channels measured by this pin. b is -1 for a
+// single-ended pin, otherwise the pin measures a - b.
+func (p *ads1x15AnalogPin) Channels() (a, b int) {
+	a, b = muxChannels(p.mux)
+	if p.negate {
+		a, b = b, a
+	}
+	return
+}
+
 // Read returns the current pin level.
 func (p *ads1x15AnalogPin) Read() (Reading, error) {
-	return p.adc.executePreparedQuery(p.query, p.waitTime, p.voltageMultiplier)
+	return p.ReadCtx(context.Background())
+}
+
+// ReadCtx returns the current pin level, giving up when ctx is done.
+func (p *ads1x15AnalogPin) ReadCtx(ctx context.Context) (Reading, error) {
+	return p.adc.executePreparedQuery(ctx, p)
+}
+
+// Sample reads the pin every interval and calls fn with each reading until
+// ctx is done.
+//
+// fn is called synchronously. When fn or the conversion take longer than
+// interval, the ticks that were missed are skipped instead of queued; the
+// number of skipped samples is returned once ctx is done. An error is
+// returned only if a conversion fails.
+func (p *ads1x15AnalogPin) Sample(ctx context.Context, interval time.Duration, fn func(TimedReading)) (int, error) {
+	if interval < p.waitTime {
+		return 0, fmt.Errorf("ads1x15: interval %s is shorter than the conversion time %s", interval, p.waitTime)
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	start := time.Now()
+	dropped := 0
+	last := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return dropped, nil
+		case <-t.C:
+		}
+		// The ticker drops ticks when the receiver is slow and keeps at most
+		// one stale tick buffered; use the wall clock to account for them.
+		n := int((time.Since(start) + interval/2) / interval)
+		if n > last+1 {
+			dropped += n - last - 1
+		}
+		last = n
+
+		r, ts, err := p.readTimed(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return dropped, nil
+			}
+			return dropped, err
+		}
+		fn(TimedReading{Reading: r, T: ts})
+	}
+}
+
+// ReadEvery starts reading the pin every period in a goroutine until ctx is
+// done, then closes the returned channel.
+//
+// The conversions are scheduled at fixed offsets from the start, so the
+// phase does not drift. The channel is unbuffered and a sample is dropped
+// when the consumer is not waiting on it; slots missed because a conversion
+// was delayed, e.g. by other users of the device, are also dropped rather
+// than caught up. The statistics are available with ReadEveryStats() once the
+// channel is closed.
+//
+// A period shorter than the conversion time is rejected.
+func (p *ads1x15AnalogPin) ReadEvery(ctx context.Context, period time.Duration) (<-chan Reading, error) {
+	if period < p.waitTime {
+		return nil, fmt.Errorf("ads1x15: period %s is shorter than the conversion time %s", period, p.waitTime)
+	}
+	p.setStats(SamplerStats{})
+	c := make(chan Reading)
+	go p.readEvery(ctx, period, c)
+	return c, nil
+}
+
+// ReadEveryStats returns the statistics of the last ReadEvery() once its
+// channel is closed.
+func (p *ads1x15AnalogPin) ReadEveryStats() SamplerStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+func (p *ads1x15AnalogPin) setStats(s SamplerStats) {
+	p.mu.Lock()
+	p.stats = s
+	p.mu.Unlock()
+}
+
+func (p *ads1x15AnalogPin) readEvery(ctx context.Context, period time.Duration, c chan<- Reading) {
+	var stats SamplerStats
+	defer close(c)
+	defer func() { p.setStats(stats) }()
+	t := time.NewTimer(0)
+	defer t.Stop()
+	<-t.C
+	start := time.Now()
+	for next := time.Duration(0); ; next += period {
+		// Skip the slots that are more than half a period in the past.
+		for time.Since(start) > next+period/2 {
+			stats.Dropped++
+			next += period
+		}
+		if wait := time.Until(start.Add(next)); wait > 0 {
+			t.Reset(wait)
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+		if j := time.Since(start) - next; j > stats.MaxJitter {
+			stats.MaxJitter = j
+		}
+		r, _, err := p.readTimed(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				stats.Err = err
+			}
+			return
+		}
+		select {
+		case c <- r:
+			stats.Delivered++
+		default:
+			stats.Dropped++
+		}
+	}
+}
+
+// readTimed is like ReadCtx but also returns the time the conversion
+// completed.
+func (p *ads1x15AnalogPin) readTimed(ctx context.Context) (Reading, time.Time, error) {
+	d := p.adc
+	if err := d.acquireCtx(ctx); err != nil {
+		return Reading{}, time.Time{}, err
+	}
+	defer d.release()
+	if err := d.checkState(); err != nil {
+		return Reading{}, time.Time{}, err
+	}
+	return d.convertTimed(ctx, p)
 }
 
+// ReadAveraged takes n back-to-back conversions and returns their mean.
+//
+// The device runs in continuous mode for the duration of the sampling, so
+// the configuration is written once and the total time is roughly n
+// conversion periods.
+func (p *ads1x15AnalogPin) ReadAveraged(n int) (AveragedReading, error) {
+	var a AveragedReading
+	if n <= 0 {
+		return a, errors.New("ads1x15: number of samples must be positive")
+	}
+	var sum, sumSq float64
+	err := p.readBurst(n, func(i int, r Reading) {
+		if i == 0 || r.Raw < a.Min.Raw {
+			a.Min = r
+		}
+		if i == 0 || r.Raw > a.Max.Raw {
+			a.Max = r
+		}
+		sum += float64(r.Raw)
+		sumSq += float64(r.Raw) * float64(r.Raw)
+	})
+	if err != nil {
+		return a, err
+	}
+
+	mean := sum / float64(n)
+	a.N = n
+	a.Raw = int32(math.Floor(mean + 0.5))
+	a.V = p.calibrate(p.rawToVoltage(mean))
+	if variance := sumSq/float64(n) - mean*mean; variance > 0 {
+		a.StdDev = physic.ElectricPotential(math.Floor(float64(p.rawToVoltage(math.Sqrt(variance)))*p.scale + 0.5))
+	}
+	return a, nil
+}
+
+// ReadFiltered takes n back-to-back conversions, like ReadAveraged(), and
+// reduces them with filter.
+//
+// The filter is applied to the raw values before converting the result to
+// volts. n must be between 2 and 64. With FilterMedian and an even n, the
+// result is the mean of the two middle samples. FilterTrimmedMean discards
+// the lowest and highest 10% of the samples, rounded down, so nothing is
+// discarded when n is below 10.
+func (p *ads1x15AnalogPin) ReadFiltered(n int, filter FilterKind) (FilteredReading, error) {
+	var f FilteredReading
+	if n < 2 || n > maxFilterSamples {
+		return f, fmt.Errorf("ads1x15: number of samples %d must be between 2 and %d", n, maxFilterSamples)
+	}
+	if filter < FilterMean || filter > FilterTrimmedMean {
+		return f, fmt.Errorf("ads1x15: invalid filter %s", filter)
+	}
+	var buf [maxFilterSamples]int32
+	raws := buf[:n]
+	err := p.readBurst(n, func(i int, r Reading) {
+		if i == 0 || r.Raw < f.Min.Raw {
+			f.Min = r
+		}
+		if i == 0 || r.Raw > f.Max.Raw {
+			f.Max = r
+		}
+		// Insertion sort, n is small.
+		j := i
+		for ; j > 0 && raws[j-1] > r.Raw; j-- {
+			raws[j] = raws[j-1]
+		}
+		raws[j] = r.Raw
+	})
+	if err != nil {
+		return f, err
+	}
+
+	var raw float64
+	switch filter {
+	case FilterMedian:
+		raw = float64(raws[n/2])
+		if n%2 == 0 {
+			raw = (float64(raws[n/2-1]) + raw) / 2
+		}
+	case FilterTrimmedMean:
+		k := n / 10
+		raws = raws[k : n-k]
+		f.Discarded = 2 * k
+		fallthrough
+	default:
+		var sum float64
+		for _, r := range raws {
+			sum += float64(r)
+		}
+		raw = sum / float64(len(raws))
+	}
+	f.Filter = filter
+	f.N = n
+	f.Raw = int32(math.Floor(raw + 0.5))
+	f.V = p.calibrate(p.rawToVoltage(raw))
+	return f, nil
+}
+
+// readBurst takes n back-to-back conversions in continuous mode and calls fn
+// with each of them, adjusted for the pin.
+func (p *ads1x15AnalogPin) readBurst(n int, fn func(i int, r Reading)) error {
+	d := p.adc
+	d.acquire()
+	defer d.release()
+	if err := d.checkState(); err != nil {
+		return err
+	}
+	if err := d.applyThresholds(p); err != nil {
+		return err
+	}
+	discard := d.muxChanged(p)
+	if err := d.writeConfig(p.continuousConfig()); err != nil {
+		return err
+	}
+	if discard {
+		// Skip the first conversion.
+		time.Sleep(p.waitTime)
+	}
+
+	for i := 0; i < n; i++ {
+		// Wait slightly more than the conversion period so each read returns
+		// a fresh conversion.
+		time.Sleep(p.waitTime)
+		r, err := d.readConversion(p.voltageMultiplier)
+		if err != nil {
+			// Try to power down anyway.
+			_ = d.writeConfig(p.powerDownConfig())
+			return err
+		}
+		fn(i, p.adjust(r))
+	}
+	return d.writeConfig(p.powerDownConfig())
+}
+
+// rawToVoltage converts a raw value, which may be fractional, into an
+// electric potential.
+func (p *ads1x15AnalogPin) rawToVoltage(raw float64) physic.ElectricPotential {
+	return physic.ElectricPotential(math.Floor(raw*float64(p.voltageMultiplier)/float64(int64(1)<<(p.adc.resolution-1)) + 0.5))
+}
+
+// Name returns the pin name, based on the chip, its address and the measured
+// channels, e.g. "ads1115-0x48-ain2" or "ads1115-0x48-ain0-ain1".
 func (p *ads1x15AnalogPin) Name() string {
-	return fmt.Sprintf("%s pin", p.adc.name)
+	name := strings.ToLower(p.adc.name) + "-" + p.adc.t.id()
+	a, b := p.Channels()
+	name += fmt.Sprintf("-ain%d", a)
+	if b != -1 {
+		name += fmt.Sprintf("-ain%d", b)
+	}
+	return name
 }
 
+// Number returns the channel for a single-ended pin, or 100+10*a+b for a
+// differential pin measuring a - b.
 func (p *ads1x15AnalogPin) Number() int {
-	return -1
+	a, b := p.Channels()
+	if b == -1 {
+		return a
+	}
+	return 100 + 10*a + b
 }
 
+// Function returns "ADC<n>" for a single-ended pin, or "ADC(AIN<a>-AIN<b>)"
+// for a differential pin.
 func (p *ads1x15AnalogPin) Function() string {
-	return "DEPRECATED"
+	return string(p.Func())
+}
+
+// Func implements pin.PinFunc.
+func (p *ads1x15AnalogPin) Func() pin.Func {
+	a, b := p.Channels()
+	if b == -1 {
+		return analog.ADC.Specialize(-1, a)
+	}
+	return pin.Func(fmt.Sprintf("%s(AIN%d-AIN%d)", analog.ADC, a, b))
+}
+
+// SupportedFuncs implements pin.PinFunc.
+//
+// The function of a pin is fixed at its creation.
+func (p *ads1x15AnalogPin) SupportedFuncs() []pin.Func {
+	return []pin.Func{p.Func()}
+}
+
+// SetFunc implements pin.PinFunc.
+//
+// Only the current function is accepted.
+func (p *ads1x15AnalogPin) SetFunc(f pin.Func) error {
+	if f != p.Func() {
+		return fmt.Errorf("ads1x15: can't change the function of %s to %s", p.Name(), f)
+	}
+	return nil
 }
 
+// Halt implements conn.Resource.
+//
+// It powers the device down if it is continuously converting for this pin,
+// see SetContinuous(). It returns ErrHalted if the device was halted.
 func (p *ads1x15AnalogPin) Halt() error {
+	d := p.adc
+	d.acquire()
+	defer d.release()
+	if d.halted {
+		return ErrHalted
+	}
+	if d.lowLatency == p {
+		return d.writeConfig(p.powerDownConfig())
+	}
 	return nil
 }
 
+// SetContinuous enables or disables the low-latency mode of this pin.
+//
+// In low-latency mode, the first Read() puts the chip in continuous
+// conversion mode with the settings of this pin and waits for the first
+// conversion. The following reads only read the conversion register and
+// return the latest completed conversion without waiting, so the same
+// conversion may be returned more than once when reading faster than the
+// data rate.
+//
+// Reading another pin of the same device switches the chip back to
+// single-shot mode. The next Read() on this pin then waits again for a full
+// conversion, so that the input multiplexer settles.
+//
+// Disabling the low-latency mode or calling Halt() powers the device down.
+func (p *ads1x15AnalogPin) SetContinuous(on bool) error {
+	d := p.adc
+	d.acquire()
+	defer d.release()
+	if err := d.checkState(); err != nil {
+		return err
+	}
+	p.continuous = on
+	if !on && d.lowLatency == p {
+		return d.writeConfig(p.powerDownConfig())
+	}
+	return nil
+}
+
+// String implements conn.Resource.
+//
+// It includes the device, the inputs, the full-scale range and the data rate,
+// e.g. "ADS1115{I2C1, 0x49}.AIN2 ±2.048V @250Hz".
 func (p *ads1x15AnalogPin) String() string {
-	return p.Name()
+	a, b := p.Channels()
+	in := fmt.Sprintf("AIN%d", a)
+	if b != -1 {
+		in += fmt.Sprintf("-AIN%d", b)
+	}
+	return fmt.Sprintf("%s.%s ±%s @%s", p.adc, in, p.voltageMultiplier, p.DataRate())
+}
+
+// FullScale returns the full-scale range selected by the gain of this pin,
+// before calibration.
+func (p *ads1x15AnalogPin) FullScale() physic.ElectricPotential {
+	return p.voltageMultiplier
+}
+
+// muxChannels returns the channels selected by a mux value. b is -1 for a
+// single-ended measurement.
+func muxChannels(mux int) (a, b int) {
+	switch mux {
+	case 0:
+		return Channel0, Channel1
+	case 1:
+		return Channel0, Channel3
+	case 2:
+		return Channel1, Channel3
+	case 3:
+		return Channel2, Channel3
+	default:
+		return mux - 0x04, -1
+	}
+}
+
+// transport is the register level access to the chip.
+//
+// The register values are the ones of the ADS1x15 family. Transports for chips
+// with a different register layout translate them.
+type transport interface {
+	// id returns a short identifier of the device on its bus.
+	id() string
+	// bus returns the name of the bus.
+	bus() string
+	// addr returns the I²C address, or 0.
+	addr() uint16
+	// conn returns the connection to the device.
+	conn() conn.Conn
+	// writeConfig writes the config register.
+	writeConfig(config uint16) error
+	// readConfig reads the config register.
+	readConfig() (uint16, error)
+	// readConversion reads the conversion register.
+	readConversion() (uint16, error)
+	// writeRegister writes another register, like the comparator thresholds.
+	writeRegister(reg byte, v uint16) error
+	// readRegister reads any register.
+	readRegister(reg byte) (uint16, error)
+}
+
+// i2cTransport implements transport for the ADS1015 and ADS1115.
+type i2cTransport struct {
+	c i2c.Dev
+	// buf is the scratch buffer for the transactions, to not allocate on each
+	// read. It is protected by the device lock.
+	buf [3]byte
+}
+
+func (t *i2cTransport) id() string {
+	return fmt.Sprintf("%#02x", t.c.Addr)
+}
+
+func (t *i2cTransport) bus() string {
+	return t.c.Bus.String()
+}
+
+func (t *i2cTransport) addr() uint16 {
+	return t.c.Addr
+}
+
+func (t *i2cTransport) conn() conn.Conn {
+	return &t.c
+}
+
+// probe verifies that a device answers at the address.
+func (t *i2cTransport) probe() error {
+	config, err := t.read(ads1x15PointerConfig)
+	if err != nil {
+		return fmt.Errorf("ads1x15: no device at %#02x on bus %s: %w", t.c.Addr, t.c.Bus, err)
+	}
+	// The config register has no reserved bit, but all ones is what is read
+	// on a floating bus.
+	if config == 0xFFFF {
+		return fmt.Errorf("ads1x15: no device at %#02x on bus %s: unexpected config %#04x", t.c.Addr, t.c.Bus, config)
+	}
+	return nil
+}
+
+func (t *i2cTransport) writeConfig(config uint16) error {
+	return t.writeRegister(ads1x15PointerConfig, config)
+}
+
+func (t *i2cTransport) readConfig() (uint16, error) {
+	return t.readRegister(ads1x15PointerConfig)
+}
+
+func (t *i2cTransport) readConversion() (uint16, error) {
+	return t.readRegister(ads1x15PointerConversion)
+}
+
+func (t *i2cTransport) writeRegister(reg byte, v uint16) error {
+	// Explicitly break the 16-bit value down to a big endian pair of bytes.
+	t.buf = [3]byte{reg, byte(v >> 8), byte(v)}
+	if err := t.c.Tx(t.buf[:], nil); err != nil {
+		return t.wrap("write", reg, err)
+	}
+	return nil
+}
+
+func (t *i2cTransport) readRegister(reg byte) (uint16, error) {
+	v, err := t.read(reg)
+	if err != nil {
+		return 0, t.wrap("read", reg, err)
+	}
+	return v, nil
+}
+
+// read reads a big endian 16-bit register.
+func (t *i2cTransport) read(reg byte) (uint16, error) {
+	t.buf[0] = reg
+	if err := t.c.Tx(t.buf[:1], t.buf[1:]); err != nil {
+		return 0, err
+	}
+	return uint16(t.buf[1])<<8 | uint16(t.buf[2]), nil
+}
+
+// wrap adds the register and the device location to a bus error.
+func (t *i2cTransport) wrap(op string, reg byte, err error) error {
+	return fmt.Errorf("ads1x15: failed to %s the %s register of %#02x on bus %s: %w", op, Register(reg), t.c.Addr, t.c.Bus, err)
+}
+
+// sleep waits for d, returning early when ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if ctx.Done() == nil {
+		// The context can't be cancelled, skip the timer allocation.
+		time.Sleep(d)
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
+
+var (
+	// Mapping of gain values to full-scale ranges.
+	gainFullScale = map[Gain]physic.ElectricPotential{
+		GainTwoThirds: 6144 * physic.MilliVolt,
+		Gain1:         4096 * physic.MilliVolt,
+		Gain2:         2048 * physic.MilliVolt,
+		Gain4:         1024 * physic.MilliVolt,
+		Gain8:         512 * physic.MilliVolt,
+		Gain16:        256 * physic.MilliVolt,
+	}
+
+	// Mapping of data rates to config register values.
+	ads1015DataRates = map[int]uint16{
+		128:  0x0000,
+		250:  0x0020,
+		490:  0x0040,
+		920:  0x0060,
+		1600: 0x0080,
+		2400: 0x00A0,
+		3300: 0x00C0,
+	}
+	ads1115DataRates = map[int]uint16{
+		8:   0x0000,
+		16:  0x0020,
+		32:  0x0040,
+		64:  0x0060,
+		128: 0x0080,
+		250: 0x00A0,
+		475: 0x00C0,
+		860: 0x00E0,
+	}
+)
+
+var (
+	// ErrHalted is returned when using a device, or a pin of a device, after
+	// Halt() was called.
+	ErrHalted = errors.New("ads1x15: device is halted")
+	// ErrNotPoweredDown is returned with Opts.VerifyPowerDown when the chip is
+	// not back in power-down after a single-shot conversion.
+	ErrNotPoweredDown = errors.New("ads1x15: device is not in power-down after the conversion")
+	// ErrConversionTimeout is returned when a single-shot conversion doesn't
+	// complete in time, see Opts.ConversionTimeout. This happens when the
+	// device is reset or browns out during the conversion.
+	ErrConversionTimeout = errors.New("ads1x15: timed out waiting for the conversion")
+	// ErrVoltageTooHigh is returned when the requested electric potential is
+	// above the range of the chip. The returned error is a *VoltageRangeError.
+	ErrVoltageTooHigh = errors.New("ads1x15: voltage is too high")
+	// ErrFrequencyTooHigh is returned when the requested sampling frequency is
+	// above the data rates of the chip. The returned error is a
+	// *FrequencyRangeError, or a *BusSpeedError when the limit is the bus.
+	ErrFrequencyTooHigh = errors.New("ads1x15: frequency is too high")
+	// ErrOverflow is returned when a calibration or a scaling makes an
+	// electric potential or a current overflow its int64 representation.
+	ErrOverflow = errors.New("ads1x15: value overflows")
+	// ErrInvalidChannel is returned when a channel is not between 0 and 3.
+	ErrInvalidChannel = errors.New("ads1x15: invalid channel, must be between 0 and 3")
+	// ErrInvalidDifferentialPair is returned when the difference of two
+	// channels cannot be measured. Only 0 - 1, 0 - 3, 1 - 3 and 2 - 3 are
+	// supported.
+	ErrInvalidDifferentialPair = errors.New("ads1x15: only some differences of channels are allowed: 0 - 1, 0 - 3, 1 - 3 or 2 - 3")
+)
+
+// VoltageRangeError is returned when the requested electric potential cannot
+// be read. It wraps ErrVoltageTooHigh.
+type VoltageRangeError struct {
+	Requested physic.ElectricPotential
+	// Max is the maximum electric potential which can be read.
+	Max physic.ElectricPotential
+}
+
+func (e *VoltageRangeError) Error() string {
+	return fmt.Sprintf("ads1x15: voltage %s is too high, the maximum which can be read is %s", e.Requested, e.Max)
+}
+
+// Unwrap returns ErrVoltageTooHigh.
+func (e *VoltageRangeError) Unwrap() error {
+	return ErrVoltageTooHigh
+}
+
+// FrequencyRangeError is returned when the requested sampling frequency
+// cannot be reached. It wraps ErrFrequencyTooHigh.
+type FrequencyRangeError struct {
+	Requested physic.Frequency
+	// Max is the highest data rate of the chip.
+	Max physic.Frequency
+}
+
+func (e *FrequencyRangeError) Error() string {
+	return fmt.Sprintf("ads1x15: frequency %s is too high, the maximum which can be read is %s", e.Requested, e.Max)
+}
+
+// Unwrap returns ErrFrequencyTooHigh.
+func (e *FrequencyRangeError) Unwrap() error {
+	return ErrFrequencyTooHigh
+}
+
+// BusSpeedError is returned when the requested sampling frequency is
+// supported by the chip but cannot be reached because of the time taken by
+// the bus transactions. It wraps ErrFrequencyTooHigh.
+type BusSpeedError struct {
+	Requested physic.Frequency
+	// Max is the highest effective sample rate on this bus.
+	Max physic.Frequency
+	// Bus is the I²C clock frequency.
+	Bus physic.Frequency
+}
+
+func (e *BusSpeedError) Error() string {
+	return fmt.Sprintf("ads1x15: frequency %s is too high for a %s bus, the maximum which can be read is %s", e.Requested, e.Bus, e.Max)
+}
+
+// Unwrap returns ErrFrequencyTooHigh.
+func (e *BusSpeedError) Unwrap() error {
+	return ErrFrequencyTooHigh
+}
+
+// busSpeeder is implemented by the buses reporting their clock frequency.
+type busSpeeder interface {
+	Speed() physic.Frequency
+}
+
+// lastDevID is the last Dev.id allocated.
+var lastDevID uint64
+
+var (
+	errContinuous  = errors.New("ads1x15: device is busy in continuous mode")
+	errInvalidGain = errors.New("ads1x15: gain must be one of: 2/3, 1, 2, 4, 8, 16")
+)
+
+var _ analog.PinADC = &ads1x15AnalogPin{}
+var _ analog.SampleRater = &ads1x15AnalogPin{}
+var _ pin.PinFunc = &ads1x15AnalogPin{}
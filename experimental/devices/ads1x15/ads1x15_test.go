@@ -0,0 +1,1888 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/analog"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+	"periph.io/x/periph/experimental/devices/ads1x15/ads1x15test"
+)
+
+func TestPinForChannel_Read(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x01, 0xc1, 0xe3}},
+			// Poll until the OS bit is set.
+			{Addr: I2CAddr, W: []byte{0x01}, R: []byte{0x41, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x01}, R: []byte{0xc1, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x40, 0x00}},
+		},
+	}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Raw != 0x4000 || r.V != 3072*physic.MilliVolt {
+		t.Fatalf("unexpected reading %#v", r)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRead_fixtures(t *testing.T) {
+	ops := []i2ctest.IO{probeOp}
+	ops = append(ops, ads1x15test.SingleEndedReadOps(I2CAddr, 0x4000)...)
+	ops = append(ops, ads1x15test.DifferentialReadOps(I2CAddr, -0x10)...)
+	bus := i2ctest.Playback{Ops: ops}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := p.Read(); err != nil || r.Raw != 0x4000 {
+		t.Fatal(r, err)
+	}
+	p, err = d.PinForDifferenceOfChannels(Channel0, Channel1, 2*physic.Volt, 128*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := p.Read(); err != nil || r.Raw != -0x10 {
+		t.Fatal(r, err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPinForChannel_Read_disablePolling(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x01, 0xc1, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0xc0, 0x00}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Raw != -0x4000 || r.V != -3072*physic.MilliVolt {
+		t.Fatalf("unexpected reading %#v", r)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPinForChannel_Read_conversionSlack(t *testing.T) {
+	data := []struct {
+		slack    time.Duration
+		rate     physic.Frequency
+		config   byte
+		waitTime time.Duration
+	}{
+		{0, 128 * physic.Hertz, 0x83, 7812500 + 100*time.Microsecond},
+		{0, 860 * physic.Hertz, 0xe3, 1162791 + 100*time.Microsecond},
+		{time.Millisecond, 128 * physic.Hertz, 0x83, 7812500 + time.Millisecond},
+		// Clamped to 90% of the conversion period.
+		{-time.Second, 128 * physic.Hertz, 0x83, 7031250},
+	}
+	for i, line := range data {
+		bus := i2ctest.Playback{
+			Ops: []i2ctest.IO{
+				probeOp,
+				{Addr: I2CAddr, W: []byte{0x01, 0xc1, line.config}},
+				{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x00}},
+			},
+		}
+		opts := Opts{I2cAddress: I2CAddr, DisablePolling: true, ConversionSlack: line.slack}
+		d, err := NewADS1115(&bus, &opts)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		p, err := d.PinForChannel(Channel0, 5*physic.Volt, line.rate)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if w := p.(*ads1x15AnalogPin).waitTime; w != line.waitTime {
+			t.Fatalf("#%d: expected wait time %s, got %s", i, line.waitTime, w)
+		}
+		start := time.Now()
+		if _, err := p.Read(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if e := time.Since(start); e < line.waitTime {
+			t.Fatalf("#%d: expected to sleep at least %s, slept %s", i, line.waitTime, e)
+		}
+		if err := bus.Close(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+	}
+}
+
+func TestPinForChannel_Read_timeout(t *testing.T) {
+	d, err := NewADS1115(&busyBus{}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Read(); !errors.Is(err, ErrConversionTimeout) {
+		t.Fatalf("expected ErrConversionTimeout, got %v", err)
+	}
+}
+
+func TestPinForChannel_Read_conversionTimeout(t *testing.T) {
+	d, err := NewADS1115(&busyBus{}, &Opts{I2cAddress: I2CAddr, ConversionTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The default timeout would be 10 times 125ms.
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 8*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetCalibration(physic.Volt, 1); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	r, err := p.Read()
+	if !errors.Is(err, ErrConversionTimeout) {
+		t.Fatalf("expected ErrConversionTimeout, got %v", err)
+	}
+	if r != (Reading{}) {
+		t.Fatalf("expected a zero reading, got %#v", r)
+	}
+	if e := time.Since(start); e > time.Second {
+		t.Fatalf("timed out after %s", e)
+	}
+	// The lock was released.
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadAveraged(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// Continuous mode, AIN0, ±6.144V, 860SPS.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x0a}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x0c}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x0b}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x0c}},
+			// Power down.
+			{Addr: I2CAddr, W: []byte{0x01, 0x41, 0xe3}},
+		},
+	}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ReadAveraged(0); err == nil {
+		t.Fatal("expected error with n=0")
+	}
+	a, err := p.ReadAveraged(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The mean is 11.25.
+	if a.N != 4 || a.Raw != 11 || a.Min.Raw != 10 || a.Max.Raw != 12 {
+		t.Fatalf("unexpected result %#v", a)
+	}
+	if a.V != 2109375*physic.NanoVolt {
+		t.Fatal(a.V)
+	}
+	if a.StdDev == 0 {
+		t.Fatal("expected non-zero standard deviation")
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadFiltered(t *testing.T) {
+	data := []struct {
+		filter    FilterKind
+		samples   []int16
+		raw       int32
+		v         physic.ElectricPotential
+		discarded int
+	}{
+		// The median of an even number of samples is the mean of the two
+		// middle ones, 11.5.
+		{FilterMedian, []int16{10, 500, 12, 11}, 12, 2156250 * physic.NanoVolt, 0},
+		{FilterMedian, []int16{10, 500, 12, 11, -300}, 11, 2062500 * physic.NanoVolt, 0},
+		// 500 and -300 are discarded, the mean of the others is 10.875.
+		{FilterTrimmedMean, []int16{10, 11, 12, 10, 11, 12, 10, 11, 500, -300}, 11, 2039063 * physic.NanoVolt, 2},
+		// Nothing is discarded below 10 samples.
+		{FilterTrimmedMean, []int16{10, 12}, 11, 2062500 * physic.NanoVolt, 0},
+		{FilterMean, []int16{10, 500, 12, 11, -300}, 47, 8737500 * physic.NanoVolt, 0},
+	}
+	for i, line := range data {
+		ops := []i2ctest.IO{
+			probeOp,
+			// Continuous mode, AIN0, ±6.144V, 860SPS.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0xe3}},
+		}
+		for _, s := range line.samples {
+			ops = append(ops, i2ctest.IO{Addr: I2CAddr, W: []byte{0x00}, R: []byte{byte(uint16(s) >> 8), byte(s)}})
+		}
+		// Power down.
+		ops = append(ops, i2ctest.IO{Addr: I2CAddr, W: []byte{0x01, 0x41, 0xe3}})
+		bus := i2ctest.Playback{Ops: ops}
+		d, err := NewADS1115(&bus, &DefaultOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := p.ReadFiltered(len(line.samples), line.filter)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if f.Raw != line.raw || f.V != line.v || f.Discarded != line.discarded || f.N != len(line.samples) || f.Filter != line.filter {
+			t.Fatalf("#%d: unexpected result %#v", i, f)
+		}
+		if f.Min.Raw != int32(min16(line.samples)) || f.Max.Raw != int32(max16(line.samples)) {
+			t.Fatalf("#%d: unexpected min/max %s %s", i, f.Min, f.Max)
+		}
+		if err := bus.Close(); err != nil {
+			t.Fatal(i, err)
+		}
+	}
+}
+
+func TestReadFiltered_error(t *testing.T) {
+	d, err := NewADS1115(&i2ctest.Playback{}, &Opts{SkipProbe: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{1, 65} {
+		if _, err := p.ReadFiltered(n, FilterMedian); err == nil {
+			t.Fatalf("expected error with n=%d", n)
+		}
+	}
+	if _, err := p.ReadFiltered(3, FilterKind(3)); err == nil || err.Error() != "ads1x15: invalid filter FilterKind(3)" {
+		t.Fatal(err)
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	ops := []i2ctest.IO{probeOp}
+	// AIN0, ±6.144V.
+	ops = append(ops, ads1x15test.SingleEndedReadOps(I2CAddr, 1)...)
+	// AIN1 and AIN2 are skipped, AIN3 at ±1.024V.
+	ops = append(ops, ads1x15test.ReadOps(I2CAddr, 0xF7E3, 3)...)
+	bus := i2ctest.Playback{Ops: ops}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ts, err := d.ReadAll([4]physic.ElectricPotential{5 * physic.Volt, 0, 0, physic.Volt}, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.IsZero() {
+		t.Fatal("expected a timestamp")
+	}
+	if r[0].Raw != 1 || r[1].Raw != 0 || r[2].Raw != 0 || r[3].Raw != 3 {
+		t.Fatalf("unexpected readings %#v", r)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSample(t *testing.T) {
+	ops := []i2ctest.IO{probeOp}
+	ops = append(ops, ads1x15test.SingleEndedReadOps(I2CAddr, 1)...)
+	ops = append(ops, ads1x15test.SingleEndedReadOps(I2CAddr, 2)...)
+	bus := i2ctest.Playback{Ops: ops}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Sample(context.Background(), time.Microsecond, func(TimedReading) {}); err == nil {
+		t.Fatal("expected error with an interval shorter than the conversion")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []TimedReading
+	dropped, err := p.Sample(ctx, 5*time.Millisecond, func(r TimedReading) {
+		got = append(got, r)
+		if len(got) == 1 {
+			// Be slow to force skipping ticks.
+			time.Sleep(17 * time.Millisecond)
+		} else {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped < 1 {
+		t.Fatalf("expected dropped samples, got %d", dropped)
+	}
+	if len(got) != 2 || got[0].Raw != 1 || got[1].Raw != 2 || !got[0].T.Before(got[1].T) {
+		t.Fatalf("unexpected readings %#v", got)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadEvery(t *testing.T) {
+	d, err := NewADS1115(&countingBus{}, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := p.ReadEvery(ctx, time.Microsecond); err == nil {
+		t.Fatal("expected error with a period shorter than the conversion")
+	}
+	c, err := p.ReadEvery(ctx, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if r := <-c; r.Raw != -0x7a7d {
+			t.Fatal(r)
+		}
+	}
+	// Be slow to force dropping samples.
+	time.Sleep(17 * time.Millisecond)
+	<-c
+	cancel()
+	for range c {
+	}
+	s := p.ReadEveryStats()
+	if s.Delivered < 3 || s.Dropped < 2 || s.MaxJitter <= 0 || s.Err != nil {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestReadEvery_error(t *testing.T) {
+	bus := i2ctest.Playback{Ops: []i2ctest.IO{probeOp}, DontPanic: true}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.ReadEvery(context.Background(), 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-c; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+	if s := p.ReadEveryStats(); s.Delivered != 0 || s.Err == nil {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestReadCtx_lock(t *testing.T) {
+	d, err := NewADS1115(&i2ctest.Playback{Ops: []i2ctest.IO{probeOp}}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.acquire()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.ReadCtx(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	d.release()
+}
+
+func TestReadCtx_conversion(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// AIN0, ±6.144V, 8SPS.
+			{Addr: I2CAddr, W: []byte{0x01, 0xc1, 0x03}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.ReadCtx(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	// The lock must have been released.
+	if err := d.acquireCtx(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	d.release()
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPinForChannel_Read_readyPin(t *testing.T) {
+	ready := &gpiotest.Pin{N: "RDY", EdgesChan: make(chan gpio.Level, 1)}
+	bus := readyBus{
+		Playback: i2ctest.Playback{
+			Ops: []i2ctest.IO{
+				probeOp,
+				// Conversion ready mode.
+				{Addr: I2CAddr, W: []byte{0x02, 0x7f, 0xff}},
+				{Addr: I2CAddr, W: []byte{0x03, 0x80, 0x00}},
+				// The comparator is enabled.
+				{Addr: I2CAddr, W: []byte{0x01, 0xc1, 0xe0}},
+				{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x40, 0x00}},
+			},
+		},
+		ready: ready,
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, ReadyPin: ready})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The ready pin is set up on the first read.
+	if ready.P != gpio.PullNoChange {
+		t.Fatal(ready.P)
+	}
+	if err := p.SetComparator(0, physic.Volt, ComparatorOpts{Queue: 1}); err == nil {
+		t.Fatal("expected error")
+	}
+	r, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Raw != 0x4000 {
+		t.Fatalf("unexpected reading %#v", r)
+	}
+	if ready.P != gpio.PullUp {
+		t.Fatal(ready.P)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPinForChannel_Read_readyPinTimeout(t *testing.T) {
+	ready := &gpiotest.Pin{N: "RDY", EdgesChan: make(chan gpio.Level, 1)}
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x02, 0x7f, 0xff}},
+			{Addr: I2CAddr, W: []byte{0x03, 0x80, 0x00}},
+			{Addr: I2CAddr, W: []byte{0x01, 0xc1, 0xe0}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, ReadyPin: ready})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Read(); !errors.Is(err, ErrConversionTimeout) {
+		t.Fatalf("expected ErrConversionTimeout, got %v", err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPinForChannel_gain(t *testing.T) {
+	d, err := NewADS1115(&i2ctest.Playback{Ops: []i2ctest.IO{probeOp}}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []struct {
+		v    physic.ElectricPotential
+		gain Gain
+	}{
+		{6144 * physic.MilliVolt, GainTwoThirds},
+		{4097 * physic.MilliVolt, GainTwoThirds},
+		{4096*physic.MilliVolt + 1, GainTwoThirds},
+		{4096 * physic.MilliVolt, Gain1},
+		{3 * physic.Volt, Gain1},
+		{2 * physic.Volt, Gain2},
+		{physic.Volt, Gain4},
+		{500 * physic.MilliVolt, Gain8},
+		{100 * physic.MilliVolt, Gain16},
+	}
+	for i, line := range data {
+		p, err := d.PinForChannel(Channel0, line.v, physic.Hertz)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if g := p.Gain(); g != line.gain {
+			t.Fatalf("#%d: expected gain %s for %s, got %s", i, line.gain, line.v, g)
+		}
+	}
+	_, err = d.PinForChannel(Channel0, 6145*physic.MilliVolt, physic.Hertz)
+	if !errors.Is(err, ErrVoltageTooHigh) {
+		t.Fatalf("expected ErrVoltageTooHigh, got %v", err)
+	}
+	var verr *VoltageRangeError
+	if !errors.As(err, &verr) || verr.Max != 6144*physic.MilliVolt {
+		t.Fatalf("unexpected error %#v", err)
+	}
+}
+
+func TestPinForChannelWithGain(t *testing.T) {
+	d, err := NewADS1115(&i2ctest.Playback{Ops: []i2ctest.IO{probeOp}}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannelWithGain(Channel0, Gain1, physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := p.Gain(); g != Gain1 {
+		t.Fatal(g)
+	}
+	if min, max := p.Range(); max.V != 4096*physic.MilliVolt || min.V != -4096*physic.MilliVolt {
+		t.Fatal(min, max)
+	}
+	p, err = d.PinForDifferenceOfChannelsWithGain(Channel0, Channel1, Gain16, physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, max := p.Range(); max.V != 256*physic.MilliVolt {
+		t.Fatal(max)
+	}
+	if _, err := d.PinForChannelWithGain(Channel0, Gain(0), physic.Hertz); !errors.Is(err, errInvalidGain) {
+		t.Fatalf("expected errInvalidGain, got %v", err)
+	}
+	if _, err := d.PinForDifferenceOfChannelsWithGain(Channel1, Channel2, Gain1, physic.Hertz); !errors.Is(err, ErrInvalidDifferentialPair) {
+		t.Fatalf("expected ErrInvalidDifferentialPair, got %v", err)
+	}
+	if _, err := d.PinForChannelWithGain(4, Gain1, physic.Hertz); !errors.Is(err, ErrInvalidChannel) {
+		t.Fatalf("expected ErrInvalidChannel, got %v", err)
+	}
+	_, err = d.PinForChannel(Channel0, physic.Volt, 861*physic.Hertz)
+	var ferr *FrequencyRangeError
+	if !errors.As(err, &ferr) || ferr.Max != 860*physic.Hertz || !errors.Is(err, ErrFrequencyTooHigh) {
+		t.Fatalf("unexpected error %#v", err)
+	}
+}
+
+func TestDev_supported(t *testing.T) {
+	d, err := NewADS1015(&i2ctest.Playback{Ops: []i2ctest.IO{probeOp}}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.Type(); s != "ADS1015" {
+		t.Fatal(s)
+	}
+	if r := fmt.Sprint(d.SupportedDataRates()); r != "[128Hz 250Hz 490Hz 920Hz 1.600kHz 2.400kHz 3.300kHz]" {
+		t.Fatal(r)
+	}
+	if r := fmt.Sprint(d.SupportedRanges()); r != "[256mV 512mV 1.024V 2.048V 4.096V 6.144V]" {
+		t.Fatal(r)
+	}
+	if d, err = NewADS1115(&i2ctest.Playback{Ops: []i2ctest.IO{probeOp}}, &DefaultOpts); err != nil {
+		t.Fatal(err)
+	}
+	if s := d.Type(); s != "ADS1115" {
+		t.Fatal(s)
+	}
+	if r := fmt.Sprint(d.SupportedDataRates()); r != "[8Hz 16Hz 32Hz 64Hz 128Hz 250Hz 475Hz 860Hz]" {
+		t.Fatal(r)
+	}
+}
+
+func TestPin_metadata(t *testing.T) {
+	d, err := NewADS1115(&i2ctest.Playback{Ops: []i2ctest.IO{probeOp}}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel2, 2*physic.Volt, 100*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Name(); n != "ads1115-0x48-ain2" {
+		t.Fatal(n)
+	}
+	if n := p.Number(); n != 2 {
+		t.Fatal(n)
+	}
+	if a, b := p.Channels(); a != 2 || b != -1 {
+		t.Fatal(a, b)
+	}
+	if r := p.DataRate(); r != 128*physic.Hertz {
+		t.Fatal(r)
+	}
+	sr, ok := p.(analog.SampleRater)
+	if !ok {
+		t.Fatal("expected analog.SampleRater")
+	}
+	if r := sr.SampleRate(); r != 128*physic.Hertz {
+		t.Fatal(r)
+	}
+	if c := sr.ConversionTime(); c != 7812500*time.Nanosecond {
+		t.Fatal(c)
+	}
+	if s := p.String(); s != "ADS1115{playback, 0x48}.AIN2 ±2.048V @128Hz" {
+		t.Fatal(s)
+	}
+	if f := p.FullScale(); f != 2048*physic.MilliVolt {
+		t.Fatal(f)
+	}
+	if s := d.String(); s != "ADS1115{playback, 0x48}" {
+		t.Fatal(s)
+	}
+	if b, a := d.Bus(), d.Addr(); b != "playback" || a != 0x48 {
+		t.Fatal(b, a)
+	}
+
+	p, err = d.PinForDifferenceOfChannels(Channel1, Channel3, 2*physic.Volt, 100*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Name(); n != "ads1115-0x48-ain1-ain3" {
+		t.Fatal(n)
+	}
+	if n := p.Number(); n != 113 {
+		t.Fatal(n)
+	}
+	if a, b := p.Channels(); a != 1 || b != 3 {
+		t.Fatal(a, b)
+	}
+}
+
+func TestPinForChannelAtRate(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// AIN0, ±4.096V, 8SPS.
+			{Addr: I2CAddr, W: []byte{0x01, 0xc3, 0x03}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x10}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannelAtRate(Channel0, 4*physic.Volt, 8*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := p.Read(); err != nil || r.Raw != 0x10 {
+		t.Fatal(r, err)
+	}
+	_, err = d.PinForChannelAtRate(Channel0, 4*physic.Volt, 100*physic.Hertz)
+	if err == nil || err.Error() != "ads1x15: unsupported data rate 100Hz; supported data rates: [8Hz 16Hz 32Hz 64Hz 128Hz 250Hz 475Hz 860Hz]" {
+		t.Fatal(err)
+	}
+	if _, err := d.PinForChannelAtRate(4, 4*physic.Volt, 8*physic.Hertz); err == nil {
+		t.Fatal("expected invalid channel")
+	}
+	p, err = d.PinForDifferenceOfChannelsAtRate(Channel1, Channel0, physic.Volt, 475*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := p.Channels(); a != 1 || b != 0 || p.DataRate() != 475*physic.Hertz || p.Gain() != Gain4 {
+		t.Fatal(p)
+	}
+	if _, err := d.PinForDifferenceOfChannelsAtRate(Channel0, Channel1, 10*physic.Volt, 475*physic.Hertz); !errors.Is(err, ErrVoltageTooHigh) {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBestGain_BestDataRate(t *testing.T) {
+	d, err := NewADS1015(&i2ctest.Playback{}, &Opts{SkipProbe: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, err := d.BestGain(3 * physic.Volt); err != nil || g != Gain1 || g.FullScale() != 4096*physic.MilliVolt {
+		t.Fatal(g, err)
+	}
+	if v := Gain(0).FullScale(); v != 0 {
+		t.Fatal(v)
+	}
+	var verr *VoltageRangeError
+	if _, err := d.BestGain(7 * physic.Volt); !errors.As(err, &verr) || verr.Max != 6144*physic.MilliVolt {
+		t.Fatal(err)
+	}
+	if r, err := d.BestDataRate(1000 * physic.Hertz); err != nil || r != 1600*physic.Hertz {
+		t.Fatal(r, err)
+	}
+	var ferr *FrequencyRangeError
+	if _, err := d.BestDataRate(4 * physic.KiloHertz); !errors.As(err, &ferr) || ferr.Max != 3300*physic.Hertz {
+		t.Fatal(err)
+	}
+}
+
+func TestResolution(t *testing.T) {
+	data := []struct {
+		name   string
+		new    func(i2c.Bus, *Opts) (*Dev, error)
+		config uint16
+		max    int32
+		reads  []Reading
+	}{
+		{
+			"ADS1015",
+			NewADS1015,
+			// The ADS1015 encodes 128SPS as 0.
+			0x8503,
+			2048,
+			[]Reading{{Raw: 0x123, V: 291 * physic.MilliVolt}, {Raw: -0x800, V: -2048 * physic.MilliVolt}, {Raw: 0x7ff, V: 2047 * physic.MilliVolt}},
+		},
+		{
+			"ADS1115",
+			NewADS1115,
+			ads1x15test.DifferentialConfig,
+			32768,
+			[]Reading{{Raw: 0x1230, V: 4656 * 62500 * physic.NanoVolt}, {Raw: -0x8000, V: -2048 * physic.MilliVolt}, {Raw: 0x7ff0, V: 32752 * 62500 * physic.NanoVolt}},
+		},
+	}
+	for _, line := range data {
+		ops := []i2ctest.IO{probeOp}
+		for _, raw := range []int16{0x1230, -0x8000, 0x7ff0} {
+			ops = append(ops, ads1x15test.ReadOps(I2CAddr, line.config, raw)...)
+		}
+		bus := i2ctest.Playback{Ops: ops}
+		d, err := line.new(&bus, &DefaultOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := d.PinForDifferenceOfChannels(Channel0, Channel1, 2*physic.Volt, 128*physic.Hertz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if min, max := p.Range(); min.Raw != -line.max || max.Raw != line.max || max.V != 2048*physic.MilliVolt {
+			t.Fatal(line.name, min, max)
+		}
+		for i, want := range line.reads {
+			if r, err := p.Read(); err != nil || r != want {
+				t.Fatal(line.name, i, r, want, err)
+			}
+		}
+		if err := bus.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPin_Func(t *testing.T) {
+	d, err := NewADS1015(&i2ctest.Playback{Ops: []i2ctest.IO{probeOp}}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel3, physic.Volt, physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := p.Function(); f != "ADC3" {
+		t.Fatal(f)
+	}
+	pf := p.(pin.PinFunc)
+	if f := pf.Func(); f != analog.ADC.Specialize(-1, 3) {
+		t.Fatal(f)
+	}
+	if f := pf.SupportedFuncs(); len(f) != 1 || f[0] != "ADC3" {
+		t.Fatal(f)
+	}
+	if err := pf.SetFunc("ADC3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pf.SetFunc("ADC2"); err == nil {
+		t.Fatal("expected failure")
+	}
+
+	p, err = d.PinForDifferenceOfChannels(Channel2, Channel3, physic.Volt, physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := p.Function(); f != "ADC(AIN2-AIN3)" {
+		t.Fatal(f)
+	}
+	if f := p.(pin.PinFunc).Func(); f != "ADC(AIN2-AIN3)" {
+		t.Fatal(f)
+	}
+}
+
+func TestGain_String(t *testing.T) {
+	if s := GainTwoThirds.String(); s != "2/3" {
+		t.Fatal(s)
+	}
+	if s := Gain16.String(); s != "16" {
+		t.Fatal(s)
+	}
+	if s := Gain(0).String(); s != "Gain(0)" {
+		t.Fatal(s)
+	}
+}
+
+func TestSetComparator(t *testing.T) {
+	ops := []i2ctest.IO{
+		probeOp,
+		// Low threshold 1V, high threshold 2V at ±4.096V.
+		{Addr: I2CAddr, W: []byte{0x02, 0x1f, 0x40}},
+		{Addr: I2CAddr, W: []byte{0x03, 0x3e, 0x80}},
+	}
+	// Window, active high, latching, queue of 2.
+	ops = append(ops, ads1x15test.ReadOps(I2CAddr, 0xD3FD, 0)...)
+	bus := i2ctest.Playback{Ops: ops}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel1, 4*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetComparator(2*physic.Volt, physic.Volt, ComparatorOpts{Queue: 1}); err == nil {
+		t.Fatal("expected error on inverted thresholds")
+	}
+	if err := p.SetComparator(physic.Volt, physic.Volt, ComparatorOpts{Queue: 1}); err == nil {
+		t.Fatal("expected error on equal thresholds")
+	}
+	if err := p.SetComparator(physic.Volt, 5*physic.Volt, ComparatorOpts{Queue: 1}); err == nil {
+		t.Fatal("expected error on threshold out of range")
+	}
+	if err := p.SetComparator(physic.Volt, 2*physic.Volt, ComparatorOpts{Queue: 3}); err == nil {
+		t.Fatal("expected error on invalid queue")
+	}
+	opts := ComparatorOpts{Window: true, ActiveHigh: true, Latching: true, Queue: 2}
+	if err := p.SetComparator(physic.Volt, 2*physic.Volt, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPinForDifferenceOfChannelsWithRange(t *testing.T) {
+	d, err := NewADS1115(&i2ctest.Playback{Ops: []i2ctest.IO{probeOp}}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForDifferenceOfChannelsWithRange(Channel0, Channel1, -1500*physic.MilliVolt, 300*physic.MilliVolt, physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, max := p.Range(); max.V != 2048*physic.MilliVolt {
+		t.Fatal(max)
+	}
+	if _, err := d.PinForDifferenceOfChannelsWithRange(Channel0, Channel1, physic.Volt, -physic.Volt, physic.Hertz); err == nil {
+		t.Fatal("expected error on inverted range")
+	}
+	if _, err := d.PinForDifferenceOfChannelsWithRange(Channel0, Channel1, -7*physic.Volt, 0, physic.Hertz); !errors.Is(err, ErrVoltageTooHigh) {
+		t.Fatalf("expected ErrVoltageTooHigh, got %v", err)
+	}
+	for _, v := range []physic.ElectricPotential{0, -physic.Volt} {
+		if _, err := d.PinForChannel(Channel0, v, physic.Hertz); err == nil {
+			t.Fatalf("expected error for %s", v)
+		}
+	}
+}
+
+func TestPinForDifferenceOfChannels_reversed(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// AIN2 - AIN3, ±4.096V.
+			{Addr: I2CAddr, W: []byte{0x01, 0xb3, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x20, 0x00}},
+			// Window [-2.048V, 1.024V] of AIN3 - AIN2.
+			{Addr: I2CAddr, W: []byte{0x02, 0xe0, 0x00}},
+			{Addr: I2CAddr, W: []byte{0x03, 0x40, 0x00}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.PinForDifferenceOfChannels(Channel2, Channel1, 4*physic.Volt, 860*physic.Hertz); !errors.Is(err, ErrInvalidDifferentialPair) {
+		t.Fatalf("expected ErrInvalidDifferentialPair, got %v", err)
+	}
+	p, err := d.PinForDifferenceOfChannels(Channel3, Channel2, 4*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := p.Channels(); a != 3 || b != 2 {
+		t.Fatal(a, b)
+	}
+	if n := p.Name(); n != "ads1115-0x48-ain3-ain2" {
+		t.Fatal(n)
+	}
+	if min, max := p.Range(); min.V != -4096*physic.MilliVolt || max.V != 4096*physic.MilliVolt {
+		t.Fatal(min, max)
+	}
+	r, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Raw != -0x2000 || r.V != -1024*physic.MilliVolt {
+		t.Fatalf("unexpected reading %#v", r)
+	}
+	if err := p.SetComparator(-2048*physic.MilliVolt, 1024*physic.MilliVolt, ComparatorOpts{Queue: 1}); err == nil {
+		t.Fatal("expected error on traditional comparator")
+	}
+	if err := p.SetComparator(-2048*physic.MilliVolt, 1024*physic.MilliVolt, ComparatorOpts{Window: true, Queue: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscardFirstAfterMuxChange(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// First conversion on AIN0 is thrown away.
+			{Addr: I2CAddr, W: []byte{0x01, 0xc1, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x01, 0xc1, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x01}},
+			// Same mux, no discard.
+			{Addr: I2CAddr, W: []byte{0x01, 0xc1, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x02}},
+			// AIN1.
+			{Addr: I2CAddr, W: []byte{0x01, 0xd1, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x01, 0xd1, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x03}},
+			// ReadAveraged on AIN0 discards once.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x04}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x04}},
+			{Addr: I2CAddr, W: []byte{0x01, 0x41, 0xe3}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true, DiscardFirstAfterMuxChange: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p0, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := d.PinForChannel(Channel1, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []AnalogPin{p0, p0, p1} {
+		r, err := p.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Raw != int32(i+1) {
+			t.Fatalf("#%d: unexpected reading %#v", i, r)
+		}
+	}
+	a, err := p0.ReadAveraged(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Raw != 4 {
+		t.Fatalf("unexpected reading %#v", a)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetContinuous(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// Continuous mode on AIN0 then read the latest conversions.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x01}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x02}},
+			// A single-shot conversion on AIN1.
+			{Addr: I2CAddr, W: []byte{0x01, 0xd1, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x03}},
+			// AIN0 has to be programmed again.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x04}},
+			// Halt() powers down.
+			{Addr: I2CAddr, W: []byte{0x01, 0x41, 0xe3}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p0, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := d.PinForChannel(Channel1, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p0.SetContinuous(true); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []AnalogPin{p0, p0, p1, p0} {
+		r, err := p.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Raw != int32(i+1) {
+			t.Fatalf("#%d: unexpected reading %#v", i, r)
+		}
+	}
+	if err := p0.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	// Already powered down.
+	if err := p0.SetContinuous(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetCalibration(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x01, 0xc3, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x20, 0x00}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 4*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetCalibration(0, 0); err == nil {
+		t.Fatal("expected error on null scale")
+	}
+	if err := p.SetCalibration(0, 3e9); !errors.Is(err, ErrOverflow) {
+		t.Fatal(err)
+	}
+	if err := p.SetCalibration(math.MaxInt64-physic.Volt, 1); !errors.Is(err, ErrOverflow) {
+		t.Fatal(err)
+	}
+	if _, _, err := p.TwoPointCalibration(10, 0, 10, physic.Volt); err == nil {
+		t.Fatal("expected error on identical raw values")
+	}
+	offset, scale, err := p.TwoPointCalibration(0, 10*physic.MilliVolt, 0x4000, 2078480*physic.MicroVolt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 10*physic.MilliVolt || math.Abs(scale-1.01) > 1e-9 {
+		t.Fatal(offset, scale)
+	}
+	if err := p.SetCalibration(offset, scale); err != nil {
+		t.Fatal(err)
+	}
+	min, max := p.Range()
+	if min.V != -4126960*physic.MicroVolt || max.V != 4146960*physic.MicroVolt || max.Raw != 1<<15 {
+		t.Fatal(min, max)
+	}
+	r, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Raw != 0x2000 || r.V != 1044240*physic.MicroVolt {
+		t.Fatalf("unexpected reading %#v", r)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetComparator_reapply(t *testing.T) {
+	ops := []i2ctest.IO{
+		probeOp,
+		// AIN0 window [1V, 2V] at ±4.096V.
+		{Addr: I2CAddr, W: []byte{0x02, 0x1f, 0x40}},
+		{Addr: I2CAddr, W: []byte{0x03, 0x3e, 0x80}},
+		// AIN1 window [-1V, 1V] at ±4.096V.
+		{Addr: I2CAddr, W: []byte{0x02, 0xe0, 0xc0}},
+		{Addr: I2CAddr, W: []byte{0x03, 0x1f, 0x40}},
+		// Reading AIN0 programs its thresholds again.
+		{Addr: I2CAddr, W: []byte{0x02, 0x1f, 0x40}},
+		{Addr: I2CAddr, W: []byte{0x03, 0x3e, 0x80}},
+	}
+	ops = append(ops, ads1x15test.ReadOps(I2CAddr, 0xC3F0, 0)...)
+	// Reading it again doesn't.
+	ops = append(ops, ads1x15test.ReadOps(I2CAddr, 0xC3F0, 0)...)
+	bus := i2ctest.Playback{Ops: ops}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p0, err := d.PinForChannel(Channel0, 4*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := d.PinForChannel(Channel1, 4*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := ComparatorOpts{Window: true, Queue: 1}
+	if err := p0.SetComparator(physic.Volt, 2*physic.Volt, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := p1.SetComparator(-physic.Volt, physic.Volt, opts); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p0.Read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadContinuous(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// Continuous mode, AIN0, ±6.144V, 8SPS.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0x03}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x20, 0x00}},
+			// Power down.
+			{Addr: I2CAddr, W: []byte{0x01, 0x41, 0x03}},
+		},
+	}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, stop, err := d.ReadContinuous(Channel0, 5*physic.Volt, 1*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := d.ReadContinuous(Channel1, 5*physic.Volt, 1*physic.Hertz); !errors.Is(err, errContinuous) {
+		t.Fatalf("expected errContinuous, got %v", err)
+	}
+	r := <-c
+	if r.Raw != 0x2000 || r.V != 1536*physic.MilliVolt {
+		t.Fatalf("unexpected reading %#v", r)
+	}
+	stop()
+	stop()
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadContinuous_busError(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// Continuous mode, AIN0, ±6.144V, 8SPS.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0x03}},
+			// Reading the conversion and powering down fail.
+		},
+		DontPanic: true,
+	}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, stop, err := d.ReadContinuous(Channel0, 5*physic.Volt, 1*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ContinuousErr(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	if err := d.ContinuousErr(); err == nil {
+		t.Fatal("expected the stream error")
+	}
+	// The device is released without calling stop.
+	p, err := d.PinForChannel(Channel1, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Read(); err == nil || errors.Is(err, errContinuous) {
+		t.Fatalf("expected a bus error, got %v", err)
+	}
+	stop()
+}
+
+func TestHalt(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// Continuous mode, AIN0, ±6.144V, 8SPS.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0x03}},
+			// The stream is stopped.
+			{Addr: I2CAddr, W: []byte{0x01, 0x41, 0x03}},
+			// Power down.
+			{Addr: I2CAddr, W: []byte{0x01, 0x05, 0x83}},
+		},
+	}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel1, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _, err := d.ReadContinuous(Channel0, 5*physic.Volt, 1*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Halt(); !errors.Is(err, ErrHalted) {
+		t.Fatalf("expected ErrHalted, got %v", err)
+	}
+	if _, err := p.Read(); !errors.Is(err, ErrHalted) {
+		t.Fatalf("expected ErrHalted, got %v", err)
+	}
+	if _, _, err := d.ReadContinuous(Channel0, 5*physic.Volt, 1*physic.Hertz); !errors.Is(err, ErrHalted) {
+		t.Fatalf("expected ErrHalted, got %v", err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadContinuous_blocksSingleShot(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0x03}},
+			{Addr: I2CAddr, W: []byte{0x01, 0x41, 0x03}},
+		},
+	}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel1, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, stop, err := d.ReadContinuous(Channel0, 5*physic.Volt, 1*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Read(); !errors.Is(err, errContinuous) {
+		t.Fatalf("expected errContinuous, got %v", err)
+	}
+	stop()
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewADS1115_probe(t *testing.T) {
+	bus := i2ctest.Playback{DontPanic: true}
+	if _, err := NewADS1115(&bus, &DefaultOpts); err == nil {
+		t.Fatal("expected probe failure")
+	}
+	bus = i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: I2CAddr, W: []byte{0x01}, R: []byte{0xff, 0xff}},
+		},
+	}
+	if _, err := NewADS1115(&bus, &DefaultOpts); err == nil {
+		t.Fatal("expected probe failure")
+	}
+	if _, err := NewADS1015(&i2ctest.Playback{}, &Opts{I2cAddress: I2CAddr, SkipProbe: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRead_busError(t *testing.T) {
+	bus := i2ctest.Playback{Ops: []i2ctest.IO{probeOp}, DontPanic: true}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Read()
+	if err == nil || errors.Unwrap(err) == nil {
+		t.Fatalf("expected a wrapped error, got %v", err)
+	}
+	if s := err.Error(); !strings.HasPrefix(s, "ads1x15: failed to write the Config register of 0x48 on bus playback: ") {
+		t.Fatal(s)
+	}
+}
+
+func TestNewADS1115_address(t *testing.T) {
+	if _, err := NewADS1115(&i2ctest.Playback{}, &Opts{I2cAddress: 0x84}); err == nil {
+		t.Fatal("expected invalid address")
+	}
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x48, W: []byte{0x01}, R: []byte{0x85, 0x83}},
+		},
+	}
+	d, err := NewADS1115(&bus, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := d.Addr(); a != 0x48 {
+		t.Fatal(a)
+	}
+	if c, ok := d.Conn().(*i2c.Dev); !ok || c.Addr != 0x48 || c.Bus != &bus {
+		t.Fatal(d.Conn())
+	}
+}
+
+func TestOpts_defaults(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// AIN1, ±4.096V, 128SPS, continuous mode.
+			{Addr: I2CAddr, W: []byte{0x01, 0x52, 0x83}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x10, 0x00}},
+		},
+	}
+	opts := Opts{I2cAddress: I2CAddr, DefaultRange: 4 * physic.Volt, DefaultRate: 100 * physic.Hertz, DefaultMode: ModeContinuous}
+	d, err := NewADS1115(&bus, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel1, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, r := p.Gain(), p.DataRate(); g != Gain1 || r != 128*physic.Hertz {
+		t.Fatal(g, r)
+	}
+	if r, err := p.Read(); err != nil || r.Raw != 0x1000 {
+		t.Fatal(r, err)
+	}
+	// Explicit arguments override the defaults.
+	p, err = d.PinForDifferenceOfChannels(Channel0, Channel1, physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, r := p.Gain(), p.DataRate(); g != Gain4 || r != 860*physic.Hertz {
+		t.Fatal(g, r)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid defaults fail at construction.
+	opts = Opts{SkipProbe: true, DefaultRange: 10 * physic.Volt}
+	if _, err := NewADS1115(&bus, &opts); !errors.Is(err, ErrVoltageTooHigh) {
+		t.Fatal(err)
+	}
+	opts = Opts{SkipProbe: true, DefaultRate: 1000 * physic.Hertz}
+	if _, err := NewADS1115(&bus, &opts); !errors.Is(err, ErrFrequencyTooHigh) {
+		t.Fatal(err)
+	}
+	opts = Opts{SkipProbe: true, DefaultMode: 3}
+	if _, err := NewADS1115(&bus, &opts); err == nil {
+		t.Fatal("expected invalid mode")
+	}
+}
+
+func TestOpts_VerifyPowerDown(t *testing.T) {
+	ops := []i2ctest.IO{probeOp}
+	ops = append(ops, ads1x15test.SingleEndedReadOps(I2CAddr, 1)...)
+	ops = append(ops, i2ctest.IO{Addr: I2CAddr, W: []byte{0x01}, R: []byte{0xc1, 0xe3}})
+	ops = append(ops, ads1x15test.SingleEndedReadOps(I2CAddr, 2)...)
+	// Still in continuous mode.
+	ops = append(ops, i2ctest.IO{Addr: I2CAddr, W: []byte{0x01}, R: []byte{0xc0, 0xe3}})
+	bus := i2ctest.Playback{Ops: ops}
+	d, err := NewADS1115(&bus, &Opts{VerifyPowerDown: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.LastActivity().IsZero() {
+		t.Fatal(d.LastActivity())
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := p.Read(); err != nil || r.Raw != 1 {
+		t.Fatal(r, err)
+	}
+	if time.Since(d.LastActivity()) > time.Second {
+		t.Fatal(d.LastActivity())
+	}
+	if _, err := p.Read(); !errors.Is(err, ErrNotPoweredDown) {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpts_IdleReinit(t *testing.T) {
+	ops := []i2ctest.IO{probeOp}
+	ops = append(ops, ads1x15test.SingleEndedReadOps(I2CAddr, 1)...)
+	ops = append(ops, ads1x15test.SingleEndedReadOps(I2CAddr, 2)...)
+	// Probed again after being idle.
+	ops = append(ops, probeOp)
+	ops = append(ops, ads1x15test.SingleEndedReadOps(I2CAddr, 3)...)
+	bus := i2ctest.Playback{Ops: ops}
+	d, err := NewADS1115(&bus, &Opts{IdleReinit: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := int32(1); i <= 3; i++ {
+		if i == 3 {
+			time.Sleep(30 * time.Millisecond)
+		}
+		if r, err := p.Read(); err != nil || r.Raw != i {
+			t.Fatal(r, err)
+		}
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Opts{IdleReinit: -1}).Validate(); err == nil {
+		t.Fatal("expected invalid IdleReinit")
+	}
+}
+
+func TestOpts_BusFrequency(t *testing.T) {
+	// A 10kHz bus is queried through its Speed() method.
+	bus := speedBus{Playback: &i2ctest.Playback{}, speed: 10 * physic.KiloHertz}
+	d, err := NewADS1115(&bus, &Opts{SkipProbe: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each read takes 86 clock cycles, 8.6ms, which limits the sample rate to
+	// about 102Hz even at 860SPS.
+	var berr *BusSpeedError
+	if _, err := d.BestDataRate(500 * physic.Hertz); !errors.As(err, &berr) || !errors.Is(err, ErrFrequencyTooHigh) || berr.Bus != 10*physic.KiloHertz {
+		t.Fatal(err)
+	}
+	if _, err := d.PinForChannel(Channel0, 5*physic.Volt, 500*physic.Hertz); !errors.As(err, &berr) {
+		t.Fatal(err)
+	}
+	var ferr *FrequencyRangeError
+	if _, err := d.BestDataRate(physic.KiloHertz); !errors.As(err, &ferr) {
+		t.Fatal(err)
+	}
+	if r, err := d.BestDataRate(100 * physic.Hertz); err != nil || r != 860*physic.Hertz {
+		t.Fatal(r, err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 100*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := p.(*ads1x15AnalogPin).SampleRate(); f != physic.PeriodToFrequency(1162791*time.Nanosecond+8600*time.Microsecond) {
+		t.Fatal(f)
+	}
+	if w := p.(*ads1x15AnalogPin).waitTime; w != 1162791*time.Nanosecond+defaultConversionSlack+3800*time.Microsecond {
+		t.Fatal(w)
+	}
+
+	// Opts.BusFrequency takes precedence.
+	d, err = NewADS1115(&bus, &Opts{SkipProbe: true, BusFrequency: 400 * physic.KiloHertz})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := d.BestDataRate(500 * physic.Hertz); err != nil || r != 860*physic.Hertz {
+		t.Fatal(r, err)
+	}
+	if err := (&Opts{BusFrequency: -1}).Validate(); err == nil {
+		t.Fatal("expected invalid BusFrequency")
+	}
+}
+
+func TestOpts_Validate(t *testing.T) {
+	if err := DefaultOpts.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	o := Opts{I2cAddress: 0x84, ConversionTimeout: -time.Second}
+	err := o.Validate()
+	if err == nil {
+		t.Fatal("expected invalid options")
+	}
+	const s = "ads1x15: invalid options: invalid I²C address 0x84; valid addresses are 0x48, 0x49, 0x4a and 0x4b; ConversionTimeout -1s must not be negative"
+	if err.Error() != s {
+		t.Fatal(err)
+	}
+}
+
+func TestNewADS1115Group(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x48, W: []byte{0x01}, R: []byte{0x85, 0x83}},
+			{Addr: 0x4b, W: []byte{0x01}, R: []byte{0x85, 0x83}},
+		},
+	}
+	devs, err := NewADS1115Group(&bus, []uint16{0x48, 0x4b}, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 2 {
+		t.Fatal(devs)
+	}
+	p, err := devs[1].PinForChannel(Channel0, physic.Volt, physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Name(); n != "ads1115-0x4b-ain0" {
+		t.Fatal(n)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewADS1115Group(&bus, []uint16{0x49, 0x49}, &DefaultOpts); err == nil {
+		t.Fatal("expected duplicate address failure")
+	}
+	if _, err := NewADS1115Group(&bus, []uint16{0x50}, &DefaultOpts); err == nil {
+		t.Fatal("expected invalid address failure")
+	}
+}
+
+//
+
+// probeOp is the config register read back done at construction.
+var probeOp = ads1x15test.ProbeOp(I2CAddr)
+
+func TestRead_configRewrite(t *testing.T) {
+	// The config register is always written to start a conversion, even when
+	// reading the same pin again.
+	ops := []i2ctest.IO{probeOp}
+	ops = append(ops, ads1x15test.DifferentialReadOps(I2CAddr, 1)...)
+	ops = append(ops, ads1x15test.ReadOps(I2CAddr, 0xB583, 2)...)
+	ops = append(ops, ads1x15test.DifferentialReadOps(I2CAddr, 3)...)
+	// Halt() finds the chip already powered down with the same settings so it
+	// doesn't write the config register.
+	bus := i2ctest.Playback{Ops: ops}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p0, err := d.PinForDifferenceOfChannels(Channel0, Channel1, 2*physic.Volt, 128*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := d.PinForDifferenceOfChannels(Channel2, Channel3, 2*physic.Volt, 128*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []AnalogPin{p0, p1, p0} {
+		r, err := p.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Raw != int32(i+1) {
+			t.Fatalf("#%d: unexpected reading %#v", i, r)
+		}
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRead_allocs(t *testing.T) {
+	bus := countingBus{}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := testing.AllocsPerRun(10, func() {
+		if _, err := p.Read(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if n != 0 {
+		t.Fatalf("expected no allocation, got %g", n)
+	}
+}
+
+func TestRead_fairness(t *testing.T) {
+	bus := countingBus{}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const workers = 8
+	const reads = 20
+	var wg sync.WaitGroup
+	waits := make([]time.Duration, workers)
+	var depth int32
+	for i := 0; i < workers; i++ {
+		p, err := d.PinForChannel(i%4, 5*physic.Volt, 860*physic.Hertz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(i int, p AnalogPin) {
+			defer wg.Done()
+			for j := 0; j < reads; j++ {
+				start := time.Now()
+				if _, err := p.Read(); err != nil {
+					t.Error(err)
+					return
+				}
+				if e := time.Since(start); e > waits[i] {
+					waits[i] = e
+				}
+				if n := int32(d.QueueDepth()); n > atomic.LoadInt32(&depth) {
+					atomic.StoreInt32(&depth, n)
+				}
+			}
+		}(i, p)
+	}
+	wg.Wait()
+	// Each read waits at most for the reads of the other workers, around
+	// 10ms. A starved worker would wait for a significant part of the 200ms
+	// run.
+	for i, w := range waits {
+		if w > 100*time.Millisecond {
+			t.Errorf("worker %d waited up to %s", i, w)
+		}
+	}
+	if depth == 0 {
+		t.Error("expected the operations to be queued")
+	}
+	if n := d.QueueDepth(); n != 0 {
+		t.Fatalf("expected an empty queue, got %d", n)
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	bus := countingBus{}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		b.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		b.Fatal(err)
+	}
+	bus.n = 0
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Read(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(bus.n)/float64(b.N), "bytes/op")
+}
+
+func BenchmarkRead_interleaved(b *testing.B) {
+	bus := countingBus{}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		b.Fatal(err)
+	}
+	var pins [2]AnalogPin
+	for i := range pins {
+		if pins[i], err = d.PinForChannel(i, 5*physic.Volt, 860*physic.Hertz); err != nil {
+			b.Fatal(err)
+		}
+	}
+	bus.n = 0
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pins[i&1].Read(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(bus.n)/float64(b.N), "bytes/op")
+}
+
+// countingBus is an i2c.Bus counting the bytes transferred. Reads return the
+// config register as idle.
+type countingBus struct {
+	n int
+}
+
+func (c *countingBus) String() string {
+	return "counting"
+}
+
+func (c *countingBus) Tx(addr uint16, w, r []byte) error {
+	c.n += len(w) + len(r)
+	if len(r) == 2 {
+		r[0], r[1] = 0x85, 0x83
+	}
+	return nil
+}
+
+func (c *countingBus) SetSpeed(f physic.Frequency) error {
+	return nil
+}
+
+// readyBus simulates the falling edge on ALERT/RDY when a conversion is
+// started.
+type readyBus struct {
+	i2ctest.Playback
+	ready *gpiotest.Pin
+}
+
+func (r *readyBus) Tx(addr uint16, w, read []byte) error {
+	if err := r.Playback.Tx(addr, w, read); err != nil {
+		return err
+	}
+	if len(w) == 3 && w[0] == 0x01 && w[1]&0x80 != 0 {
+		r.ready.EdgesChan <- gpio.Low
+	}
+	return nil
+}
+
+// busyBus is an i2c.Bus where the conversion never completes.
+type busyBus struct {
+}
+
+func (b *busyBus) String() string {
+	return "busy"
+}
+
+func (b *busyBus) Tx(addr uint16, w, r []byte) error {
+	for i := range r {
+		r[i] = 0
+	}
+	return nil
+}
+
+func (b *busyBus) SetSpeed(f physic.Frequency) error {
+	return nil
+}
+
+// speedBus is a bus reporting its clock frequency.
+type speedBus struct {
+	*i2ctest.Playback
+	speed physic.Frequency
+}
+
+func (s *speedBus) Speed() physic.Frequency {
+	return s.speed
+}
+
+func min16(s []int16) int16 {
+	m := s[0]
+	for _, v := range s[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+func max16(s []int16) int16 {
+	m := s[0]
+	for _, v := range s[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,156 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package ads1x15test is meant to be used to test code using the ads1x15
+// driver.
+//
+// It provides the I²C transactions done by the driver, to compose
+// i2ctest.Playback scripts, and RecordedDev for code only needing the
+// analog pins.
+package ads1x15test
+
+import (
+	"fmt"
+	"sync"
+
+	"periph.io/x/periph/conn/analog"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Config register values of canonical ADS1115 pins.
+const (
+	// SingleEndedConfig starts a conversion on the pin returned by
+	// PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz): AIN0 against
+	// GND, ±6.144V at 860SPS.
+	SingleEndedConfig uint16 = 0xC1E3
+	// DifferentialConfig starts a conversion on the pin returned by
+	// PinForDifferenceOfChannels(Channel0, Channel1, 2*physic.Volt,
+	// 128*physic.Hertz): AIN0 - AIN1, ±2.048V at 128SPS.
+	DifferentialConfig uint16 = 0x8583
+)
+
+// ProbeOp returns the transaction done by NewADS1015() and NewADS1115() to
+// detect the device at addr, unless Opts.SkipProbe is set.
+func ProbeOp(addr uint16) i2ctest.IO {
+	return i2ctest.IO{Addr: addr, W: []byte{0x01}, R: []byte{0x85, 0x83}}
+}
+
+// ReadOps returns the transactions of a single-shot Read() with the default
+// options, on a pin whose config register value is config, returning raw.
+//
+// The config register is written to start the conversion, polled once for
+// the end of the conversion then the conversion register is read. raw is the
+// conversion register value, so on the ADS1015 it is the 12 bits result
+// shifted left by 4.
+func ReadOps(addr uint16, config uint16, raw int16) []i2ctest.IO {
+	return []i2ctest.IO{
+		{Addr: addr, W: []byte{0x01, byte(config >> 8), byte(config)}},
+		{Addr: addr, W: []byte{0x01}, R: []byte{byte(config >> 8), byte(config)}},
+		{Addr: addr, W: []byte{0x00}, R: []byte{byte(uint16(raw) >> 8), byte(raw)}},
+	}
+}
+
+// SingleEndedReadOps returns ReadOps() for SingleEndedConfig.
+func SingleEndedReadOps(addr uint16, raw int16) []i2ctest.IO {
+	return ReadOps(addr, SingleEndedConfig, raw)
+}
+
+// DifferentialReadOps returns ReadOps() for DifferentialConfig.
+func DifferentialReadOps(addr uint16, raw int16) []i2ctest.IO {
+	return ReadOps(addr, DifferentialConfig, raw)
+}
+
+// RecordedDev simulates an ADC whose four single-ended pins return scripted
+// readings.
+type RecordedDev struct {
+	// These should be immutable.
+	// Name is the prefix of the pin names.
+	Name string
+	// Max is the full-scale range of the pins: [-Max, Max].
+	Max physic.ElectricPotential
+	// DontPanic makes the pins return an error instead of panicking when a
+	// reading is not scripted.
+	DontPanic bool
+
+	// Grab the Mutex before accessing the following members.
+	sync.Mutex
+	// Readings are the readings returned by the pin of each channel, in order.
+	Readings [4][]analog.Reading
+	// Count is the number of readings returned per channel.
+	Count [4]int
+}
+
+// PinForChannel returns the pin of a channel.
+func (r *RecordedDev) PinForChannel(channel int) (analog.PinADC, error) {
+	if channel < 0 || channel > 3 {
+		return nil, fmt.Errorf("ads1x15test: invalid channel %d", channel)
+	}
+	return &recordedPin{d: r, channel: channel}, nil
+}
+
+// Close verifies that all the scripted readings were returned.
+func (r *RecordedDev) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	for i := range r.Readings {
+		if r.Count[i] != len(r.Readings[i]) {
+			return errorf(r.DontPanic, "ads1x15test: expected all readings of channel %d to be used: %d; expected %d", i, r.Count[i], len(r.Readings[i]))
+		}
+	}
+	return nil
+}
+
+// recordedPin implements analog.PinADC for RecordedDev.
+type recordedPin struct {
+	d       *RecordedDev
+	channel int
+}
+
+func (p *recordedPin) String() string {
+	return p.Name()
+}
+
+func (p *recordedPin) Halt() error {
+	return nil
+}
+
+func (p *recordedPin) Name() string {
+	return fmt.Sprintf("%s-ain%d", p.d.Name, p.channel)
+}
+
+func (p *recordedPin) Number() int {
+	return p.channel
+}
+
+func (p *recordedPin) Function() string {
+	return string(analog.ADC.Specialize(-1, p.channel))
+}
+
+func (p *recordedPin) Range() (analog.Reading, analog.Reading) {
+	return analog.Reading{V: -p.d.Max, Raw: -1 << 15}, analog.Reading{V: p.d.Max, Raw: 1 << 15}
+}
+
+func (p *recordedPin) Read() (analog.Reading, error) {
+	d := p.d
+	d.Lock()
+	defer d.Unlock()
+	i := d.Count[p.channel]
+	if i >= len(d.Readings[p.channel]) {
+		return analog.Reading{}, errorf(d.DontPanic, "ads1x15test: unexpected Read() on channel %d (count #%d)", p.channel, i)
+	}
+	d.Count[p.channel]++
+	return d.Readings[p.channel][i], nil
+}
+
+func errorf(dontPanic bool, format string, a ...interface{}) error {
+	err := conntest.Errorf(format, a...)
+	if !dontPanic {
+		panic(err)
+	}
+	return err
+}
+
+var _ analog.PinADC = &recordedPin{}
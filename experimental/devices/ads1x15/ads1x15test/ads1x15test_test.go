@@ -0,0 +1,59 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15test
+
+import (
+	"bytes"
+	"testing"
+
+	"periph.io/x/periph/conn/analog"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestReadOps(t *testing.T) {
+	ops := ReadOps(0x49, 0xC383, -2)
+	if len(ops) != 3 {
+		t.Fatal(ops)
+	}
+	if !bytes.Equal(ops[0].W, []byte{0x01, 0xc3, 0x83}) || ops[0].Addr != 0x49 {
+		t.Fatal(ops[0])
+	}
+	if !bytes.Equal(ops[2].R, []byte{0xff, 0xfe}) {
+		t.Fatal(ops[2])
+	}
+}
+
+func TestRecordedDev(t *testing.T) {
+	d := RecordedDev{Name: "adc", Max: 4096 * physic.MilliVolt, DontPanic: true}
+	d.Readings[2] = []analog.Reading{{V: physic.Volt, Raw: 8000}}
+	if _, err := d.PinForChannel(4); err == nil {
+		t.Fatal("expected invalid channel")
+	}
+	p, err := d.PinForChannel(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := p.String(); s != "adc-ain2" {
+		t.Fatal(s)
+	}
+	if f := p.Function(); f != "ADC2" {
+		t.Fatal(f)
+	}
+	if err := d.Close(); err == nil {
+		t.Fatal("expected unused readings")
+	}
+	if r, err := p.Read(); err != nil || r.Raw != 8000 {
+		t.Fatal(r, err)
+	}
+	if _, err := p.Read(); err == nil {
+		t.Fatal("expected unexpected read")
+	}
+	if min, max := p.Range(); min.V != -max.V || max.V != 4096*physic.MilliVolt {
+		t.Fatal(min, max)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
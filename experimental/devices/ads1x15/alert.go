@@ -0,0 +1,48 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"errors"
+	"fmt"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+// AlertResponseAddress is the SMBus Alert Response Address (ARA).
+const AlertResponseAddress = 0x0C
+
+// RespondToAlert reads the SMBus Alert Response Address and returns the I²C
+// address of the device asserting ALERT.
+//
+// It is useful when the ALERT pins of multiple devices share a single GPIO.
+// When more than one device asserts ALERT, the one with the lowest address
+// wins the arbitration and releases its ALERT pin; call RespondToAlert again
+// until it fails to find the other ones.
+//
+// An error is returned when no device acknowledges the read.
+func RespondToAlert(bus i2c.Bus) (uint16, error) {
+	var b [1]byte
+	if err := bus.Tx(AlertResponseAddress, nil, b[:]); err != nil {
+		return 0, fmt.Errorf("ads1x15: no device responded to the alert response address on bus %s: %w", bus, err)
+	}
+	// The address is in the 7 most significant bits.
+	return uint16(b[0] >> 1), nil
+}
+
+// ClearAlert releases the ALERT pin asserted by a latching comparator by
+// reading the conversion register.
+//
+// See ComparatorOpts.Latching. It returns an error on the ADS1118, which has
+// no comparator.
+func (d *Dev) ClearAlert() error {
+	if _, ok := d.t.(*i2cTransport); !ok {
+		return errors.New("ads1x15: the comparator is not supported by the ADS1118")
+	}
+	d.acquire()
+	defer d.release()
+	_, err := d.t.readRegister(ads1x15PointerConversion)
+	return err
+}
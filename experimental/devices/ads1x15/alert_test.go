@@ -0,0 +1,46 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/i2c/i2ctest"
+)
+
+func TestRespondToAlert(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: AlertResponseAddress, R: []byte{0x49 << 1}},
+		},
+		DontPanic: true,
+	}
+	if addr, err := RespondToAlert(&bus); err != nil || addr != 0x49 {
+		t.Fatal(addr, err)
+	}
+	// No more device asserting ALERT.
+	if _, err := RespondToAlert(&bus); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDev_ClearAlert(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x12, 0x34}},
+		},
+	}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ClearAlert(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
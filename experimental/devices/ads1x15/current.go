@@ -0,0 +1,106 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"fmt"
+	"math"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+// CurrentReading is a reading of a CurrentSense.
+type CurrentReading struct {
+	Reading
+	// I is the current through the shunt, derived from Reading.V.
+	I physic.ElectricCurrent
+}
+
+func (c CurrentReading) String() string {
+	return fmt.Sprintf("%s (%s)", c.I, c.V)
+}
+
+// CurrentSense measures a current as the electric potential across a shunt
+// resistor.
+//
+// The pin should be a differential pin, as returned by
+// PinForDifferenceOfChannels, whose channels are on each side of the shunt.
+// The sign of the current is preserved: it is positive when the first channel
+// is at a higher potential than the second one.
+type CurrentSense struct {
+	p     AnalogPin
+	shunt physic.ElectricResistance
+	gain  float64
+}
+
+// NewCurrentSense returns a CurrentSense reading p across a shunt of the
+// resistance specified.
+func NewCurrentSense(p AnalogPin, shunt physic.ElectricResistance) (*CurrentSense, error) {
+	if shunt <= 0 {
+		return nil, fmt.Errorf("ads1x15: invalid shunt resistance %s, must be positive", shunt)
+	}
+	return &CurrentSense{p: p, shunt: shunt, gain: 1}, nil
+}
+
+func (c *CurrentSense) String() string {
+	return fmt.Sprintf("CurrentSense{%s, %s}", c.p, c.shunt)
+}
+
+// Halt implements conn.Resource.
+func (c *CurrentSense) Halt() error {
+	return c.p.Halt()
+}
+
+// SetGain sets a correction factor applied to the derived current, e.g. to
+// compensate the tolerance of the shunt. It defaults to 1.
+//
+// It must not be called concurrently with the other methods.
+func (c *CurrentSense) SetGain(gain float64) error {
+	if !(gain > 0) || math.IsInf(gain, 1) {
+		return fmt.Errorf("ads1x15: invalid current gain %g, must be positive", gain)
+	}
+	c.gain = gain
+	return nil
+}
+
+// Read returns the current through the shunt.
+func (c *CurrentSense) Read() (physic.ElectricCurrent, error) {
+	r, err := c.Measure()
+	return r.I, err
+}
+
+// Measure returns both the electric potential across the shunt and the
+// current derived from it, from a single conversion.
+func (c *CurrentSense) Measure() (CurrentReading, error) {
+	r, err := c.p.Read()
+	if err != nil {
+		return CurrentReading{}, err
+	}
+	i, ok := c.current(r.V)
+	if !ok {
+		return CurrentReading{}, fmt.Errorf("%w: current for %s across %s", ErrOverflow, r.V, c.shunt)
+	}
+	return CurrentReading{Reading: r, I: i}, nil
+}
+
+// MaxCurrent returns the largest current magnitude that can be measured with
+// the range of the pin, saturated if it overflows.
+func (c *CurrentSense) MaxCurrent() physic.ElectricCurrent {
+	min, max := c.p.Range()
+	v := max.V
+	if -min.V > v {
+		v = -min.V
+	}
+	i, _ := c.current(v)
+	return i
+}
+
+// current converts an electric potential across the shunt into a current.
+// ok is false when it overflows, in which case the result is saturated.
+func (c *CurrentSense) current(v physic.ElectricPotential) (physic.ElectricCurrent, bool) {
+	// nV / nΩ gives A.
+	i, ok := physic.Scale(int64(physic.Ampere), float64(v)/float64(c.shunt)*c.gain)
+	return physic.ElectricCurrent(i), ok
+}
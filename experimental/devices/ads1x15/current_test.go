@@ -0,0 +1,61 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/experimental/devices/ads1x15/ads1x15test"
+)
+
+func TestCurrentSense(t *testing.T) {
+	ops := []i2ctest.IO{probeOp}
+	ops = append(ops, ads1x15test.DifferentialReadOps(I2CAddr, -0x10)...)
+	ops = append(ops, ads1x15test.DifferentialReadOps(I2CAddr, 0x20)...)
+	bus := i2ctest.Playback{Ops: ops}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForDifferenceOfChannels(Channel0, Channel1, 2*physic.Volt, 128*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCurrentSense(p, 0); err == nil {
+		t.Fatal("expected invalid shunt")
+	}
+	c, err := NewCurrentSense(p, 100*physic.MilliOhm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := c.MaxCurrent(); m != 20480*physic.MilliAmpere {
+		t.Fatal(m)
+	}
+	// -1mV across 0.1Ω.
+	if i, err := c.Read(); err != nil || i != -10*physic.MilliAmpere {
+		t.Fatal(i, err)
+	}
+	if err := c.SetGain(0); err == nil {
+		t.Fatal("expected invalid gain")
+	}
+	if err := c.SetGain(1.5); err != nil {
+		t.Fatal(err)
+	}
+	r, err := c.Measure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Raw != 0x20 || r.V != 2*physic.MilliVolt || r.I != 30*physic.MilliAmpere {
+		t.Fatal(r)
+	}
+	if s := r.String(); s != "30mA (2mV)" {
+		t.Fatal(s)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"periph.io/x/periph/conn/analog"
+	"periph.io/x/periph/conn/physic"
+)
+
+// DividedPin returns a pin measuring the input of a resistor divider whose
+// output is connected to p.
+//
+// rTop is the resistor between the input and p, rBottom the one between p
+// and GND. Reading.V and Range() are scaled by (rTop+rBottom)/rBottom while
+// Reading.Raw is left untouched. The electric potentials passed to
+// SetComparator(), SetCalibration() and TwoPointCalibration() are also at the
+// input of the divider.
+//
+// A reading that overflows once scaled fails with ErrOverflow.
+func DividedPin(p AnalogPin, rTop, rBottom physic.ElectricResistance) (AnalogPin, error) {
+	if rTop <= 0 || rBottom <= 0 {
+		return nil, fmt.Errorf("ads1x15: invalid divider %s/%s, resistances must be positive", rTop, rBottom)
+	}
+	return DividedPinRatio(p, float64(rTop+rBottom)/float64(rBottom))
+}
+
+// DividedPinRatio is like DividedPin but takes the ratio of the divider
+// directly, e.g. as measured on the board. It must be at least 1.
+func DividedPinRatio(p AnalogPin, ratio float64) (AnalogPin, error) {
+	if !(ratio >= 1) || math.IsInf(ratio, 1) {
+		return nil, fmt.Errorf("ads1x15: invalid divider ratio %g, must be at least 1", ratio)
+	}
+	return &dividedPin{AnalogPin: p, ratio: ratio}, nil
+}
+
+// dividedPin implements AnalogPin for DividedPin.
+type dividedPin struct {
+	AnalogPin
+	ratio float64
+}
+
+func (d *dividedPin) String() string {
+	return fmt.Sprintf("%s ×%g", d.AnalogPin, d.ratio)
+}
+
+// Name returns the name of the underlying pin with a "-divided" suffix.
+func (d *dividedPin) Name() string {
+	return d.AnalogPin.Name() + "-divided"
+}
+
+// Range returns the scaled range of the underlying pin, saturated if it
+// overflows.
+func (d *dividedPin) Range() (Reading, Reading) {
+	min, max := d.AnalogPin.Range()
+	min.V, _ = d.up(min.V)
+	max.V, _ = d.up(max.V)
+	return min, max
+}
+
+func (d *dividedPin) Read() (Reading, error) {
+	r, err := d.AnalogPin.Read()
+	if err != nil {
+		return Reading{}, err
+	}
+	return d.scale(r)
+}
+
+func (d *dividedPin) ReadCtx(ctx context.Context) (Reading, error) {
+	r, err := d.AnalogPin.ReadCtx(ctx)
+	if err != nil {
+		return Reading{}, err
+	}
+	return d.scale(r)
+}
+
+func (d *dividedPin) ReadAveraged(n int) (AveragedReading, error) {
+	a, err := d.AnalogPin.ReadAveraged(n)
+	if err != nil {
+		return AveragedReading{}, err
+	}
+	if a.Reading, err = d.scale(a.Reading); err != nil {
+		return AveragedReading{}, err
+	}
+	if a.Min, err = d.scale(a.Min); err != nil {
+		return AveragedReading{}, err
+	}
+	if a.Max, err = d.scale(a.Max); err != nil {
+		return AveragedReading{}, err
+	}
+	// The standard deviation is smaller than the range, so it doesn't
+	// overflow if the readings don't.
+	a.StdDev, _ = d.up(a.StdDev)
+	return a, nil
+}
+
+func (d *dividedPin) ReadFiltered(n int, filter FilterKind) (FilteredReading, error) {
+	f, err := d.AnalogPin.ReadFiltered(n, filter)
+	if err != nil {
+		return FilteredReading{}, err
+	}
+	if f.Reading, err = d.scale(f.Reading); err != nil {
+		return FilteredReading{}, err
+	}
+	if f.Min, err = d.scale(f.Min); err != nil {
+		return FilteredReading{}, err
+	}
+	if f.Max, err = d.scale(f.Max); err != nil {
+		return FilteredReading{}, err
+	}
+	return f, nil
+}
+
+// Sample stops at the first reading that overflows once scaled and returns
+// the error.
+func (d *dividedPin) Sample(ctx context.Context, interval time.Duration, fn func(TimedReading)) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var overflow error
+	dropped, err := d.AnalogPin.Sample(ctx, interval, func(r TimedReading) {
+		if overflow != nil {
+			return
+		}
+		if r.Reading, overflow = d.scale(r.Reading); overflow != nil {
+			cancel()
+			return
+		}
+		fn(r)
+	})
+	if overflow != nil {
+		return dropped, overflow
+	}
+	return dropped, err
+}
+
+// ReadEvery forwards the scaled samples. A sample is dropped when the
+// consumer is not ready while the previous one is being forwarded. The
+// channel is closed early if a sample overflows once scaled.
+func (d *dividedPin) ReadEvery(ctx context.Context, period time.Duration) (<-chan Reading, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	in, err := d.AnalogPin.ReadEvery(ctx, period)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c := make(chan Reading)
+	go func() {
+		defer close(c)
+		defer cancel()
+		for r := range in {
+			s, err := d.scale(r)
+			if err != nil {
+				// Stop the underlying pin and wait for it to close its channel.
+				cancel()
+				continue
+			}
+			select {
+			case c <- s:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return c, nil
+}
+
+func (d *dividedPin) SetComparator(low, high physic.ElectricPotential, opts ComparatorOpts) error {
+	return d.AnalogPin.SetComparator(d.down(low), d.down(high), opts)
+}
+
+func (d *dividedPin) SetCalibration(offset physic.ElectricPotential, scale float64) error {
+	return d.AnalogPin.SetCalibration(d.down(offset), scale)
+}
+
+func (d *dividedPin) TwoPointCalibration(raw1 int32, v1 physic.ElectricPotential, raw2 int32, v2 physic.ElectricPotential) (physic.ElectricPotential, float64, error) {
+	offset, scale, err := d.AnalogPin.TwoPointCalibration(raw1, d.down(v1), raw2, d.down(v2))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The offset is at the output of the divider, so it is smaller than v1
+	// or v2 and doesn't overflow.
+	offset, _ = d.up(offset)
+	return offset, scale, nil
+}
+
+// SampleRate implements analog.SampleRater.
+func (d *dividedPin) SampleRate() physic.Frequency {
+	if s, ok := d.AnalogPin.(analog.SampleRater); ok {
+		return s.SampleRate()
+	}
+	return d.AnalogPin.DataRate()
+}
+
+// ConversionTime implements analog.SampleRater.
+func (d *dividedPin) ConversionTime() time.Duration {
+	return d.AnalogPin.DataRate().Period()
+}
+
+// scale converts a reading of the underlying pin.
+func (d *dividedPin) scale(r Reading) (Reading, error) {
+	v, ok := d.up(r.V)
+	if !ok {
+		return Reading{}, fmt.Errorf("%w: %s ×%g", ErrOverflow, r.V, d.ratio)
+	}
+	r.V = v
+	return r, nil
+}
+
+// up converts an electric potential at the output of the divider into the
+// one at its input. ok is false when it overflows, in which case the result
+// is saturated.
+func (d *dividedPin) up(v physic.ElectricPotential) (physic.ElectricPotential, bool) {
+	u, ok := physic.Scale(int64(v), d.ratio)
+	return physic.ElectricPotential(u), ok
+}
+
+// down converts an electric potential at the input of the divider into the
+// one at its output.
+//
+// The ratio is at least 1 so the result fits, except for the float64
+// rounding near the int64 limits, where it is saturated.
+func (d *dividedPin) down(v physic.ElectricPotential) physic.ElectricPotential {
+	u, _ := physic.Scale(int64(v), 1/d.ratio)
+	return physic.ElectricPotential(u)
+}
+
+var _ AnalogPin = &dividedPin{}
+var _ analog.SampleRater = &dividedPin{}
@@ -0,0 +1,104 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/analog"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestDividedPin(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x01, 0xc3, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x20, 0x00}},
+			// Window [3V, 6V] at the input is [1V, 2V] on AIN0.
+			{Addr: I2CAddr, W: []byte{0x02, 0x1f, 0x40}},
+			{Addr: I2CAddr, W: []byte{0x03, 0x3e, 0x80}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 4*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DividedPin(p, 0, physic.KiloOhm); err == nil {
+		t.Fatal("expected error on null resistance")
+	}
+	if _, err := DividedPinRatio(p, 0.5); err == nil {
+		t.Fatal("expected error on ratio below 1")
+	}
+	dp, err := DividedPin(p, 20*physic.KiloOhm, 10*physic.KiloOhm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := dp.Name(); n != "ads1115-0x48-ain0-divided" {
+		t.Fatal(n)
+	}
+	if s := dp.String(); s != "ADS1115{playback, 0x48}.AIN0 ±4.096V @860Hz ×3" {
+		t.Fatal(s)
+	}
+	if min, max := dp.Range(); min.V != -12288*physic.MilliVolt || max.V != 12288*physic.MilliVolt || max.Raw != 1<<15 {
+		t.Fatal(min, max)
+	}
+	r, err := dp.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Raw != 0x2000 || r.V != 3072*physic.MilliVolt {
+		t.Fatalf("unexpected reading %#v", r)
+	}
+	if err := dp.SetComparator(3*physic.Volt, 6*physic.Volt, ComparatorOpts{Window: true, Queue: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if c := dp.(analog.SampleRater).ConversionTime(); c != 1162791*time.Nanosecond {
+		t.Fatal(c)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDividedPin_overflow(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x01, 0xc3, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x20, 0x00}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 4*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1.024V×1e10 doesn't fit.
+	dp, err := DividedPinRatio(p, 1e10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min, max := dp.Range(); min.V != math.MinInt64 || max.V != math.MaxInt64 {
+		t.Fatal(min, max)
+	}
+	if _, err := dp.Read(); !errors.Is(err, ErrOverflow) {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,10 +3,11 @@
 // that can be found in the LICENSE file.
 
 // Package ads1x15 controls ADS1015/ADS1115 Analog-Digital Converters (ADC) via i2c
-// interface.
+// interface, and the ADS1118 via SPI interface.
 //
 // Datasheet
 //
 // ADS1015: http://www.ti.com/product/ADS1015
 // ADS1115: http://www.ti.com/product/ADS1115
+// ADS1118: http://www.ti.com/product/ADS1118
 package ads1x15
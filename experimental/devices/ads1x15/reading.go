@@ -0,0 +1,215 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+// The types embedding Reading define their own String and JSON methods,
+// otherwise the ones of Reading would be promoted and their other fields
+// silently lost.
+
+func (t TimedReading) String() string {
+	return fmt.Sprintf("%s @ %s", t.Reading, t.T.Format(time.RFC3339Nano))
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It adds "t" to the encoding of Reading, e.g.
+// {"v":"1.2345V","raw":20345,"t":"2018-01-02T03:04:05.000000006Z"}.
+func (t TimedReading) MarshalJSON() ([]byte, error) {
+	ts, err := t.T.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return appendJSONFields(t.Reading, append([]byte(`,"t":`), ts...))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *TimedReading) UnmarshalJSON(b []byte) error {
+	var v struct {
+		T time.Time `json:"t"`
+	}
+	if err := t.Reading.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("ads1x15: invalid timed reading: %v", err)
+	}
+	t.T = v.T
+	return nil
+}
+
+func (a AveragedReading) String() string {
+	return fmt.Sprintf("%s ±%s [%s, %s] n=%d", a.Reading, a.StdDev, a.Min.V, a.Max.V, a.N)
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It adds "min", "max", "stddev" and "n" to the encoding of Reading.
+// StdDev is formatted by physic.ElectricPotential.String(), which is precise
+// enough for a standard deviation.
+func (a AveragedReading) MarshalJSON() ([]byte, error) {
+	min, err := a.Min.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	max, err := a.Max.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	b := append([]byte(`,"min":`), min...)
+	b = append(b, `,"max":`...)
+	b = append(b, max...)
+	b = append(b, `,"stddev":`...)
+	b = strconv.AppendQuote(b, a.StdDev.String())
+	b = append(b, `,"n":`...)
+	b = strconv.AppendInt(b, int64(a.N), 10)
+	return appendJSONFields(a.Reading, b)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// Like for Reading.V, "stddev" is accepted either as a string or a number of
+// volts.
+func (a *AveragedReading) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Min    Reading         `json:"min"`
+		Max    Reading         `json:"max"`
+		StdDev json.RawMessage `json:"stddev"`
+		N      int             `json:"n"`
+	}
+	if err := a.Reading.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("ads1x15: invalid averaged reading: %v", err)
+	}
+	var stddev Reading
+	if len(v.StdDev) != 0 {
+		// Reuse the parsing of Reading.V.
+		if err := stddev.UnmarshalJSON([]byte(`{"v":` + string(v.StdDev) + `}`)); err != nil {
+			return err
+		}
+	}
+	a.Min = v.Min
+	a.Max = v.Max
+	a.StdDev = stddev.V
+	a.N = v.N
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It adds "i" to the encoding of Reading, formatted by
+// physic.ElectricCurrent.MarshalJSON(), e.g. "-10mA".
+func (c CurrentReading) MarshalJSON() ([]byte, error) {
+	i, err := c.I.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return appendJSONFields(c.Reading, append([]byte(`,"i":`), i...))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// "i" is accepted either as a string like "-10mA" or as a number of amperes.
+func (c *CurrentReading) UnmarshalJSON(b []byte) error {
+	var v struct {
+		I physic.ElectricCurrent `json:"i"`
+	}
+	if err := c.Reading.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("ads1x15: invalid current reading: %v", err)
+	}
+	c.I = v.I
+	return nil
+}
+
+func (f FilteredReading) String() string {
+	s := fmt.Sprintf("%s %s [%s, %s] n=%d", f.Reading, f.Filter, f.Min.V, f.Max.V, f.N)
+	if f.Discarded != 0 {
+		s += fmt.Sprintf(" discarded=%d", f.Discarded)
+	}
+	return s
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It adds "filter", "min", "max", "n" and "discarded" to the encoding of
+// Reading. The filter is encoded by its name, e.g. "Median".
+func (f FilteredReading) MarshalJSON() ([]byte, error) {
+	min, err := f.Min.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	max, err := f.Max.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	b := append([]byte(`,"filter":`), strconv.Quote(f.Filter.String())...)
+	b = append(b, `,"min":`...)
+	b = append(b, min...)
+	b = append(b, `,"max":`...)
+	b = append(b, max...)
+	b = append(b, `,"n":`...)
+	b = strconv.AppendInt(b, int64(f.N), 10)
+	b = append(b, `,"discarded":`...)
+	b = strconv.AppendInt(b, int64(f.Discarded), 10)
+	return appendJSONFields(f.Reading, b)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *FilteredReading) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Filter    string  `json:"filter"`
+		Min       Reading `json:"min"`
+		Max       Reading `json:"max"`
+		N         int     `json:"n"`
+		Discarded int     `json:"discarded"`
+	}
+	if err := f.Reading.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("ads1x15: invalid filtered reading: %v", err)
+	}
+	filter := FilterKind(-1)
+	for k := FilterMean; k <= FilterTrimmedMean; k++ {
+		if k.String() == v.Filter {
+			filter = k
+		}
+	}
+	if filter < 0 {
+		return fmt.Errorf("ads1x15: invalid filtered reading: unknown filter %q", v.Filter)
+	}
+	f.Filter = filter
+	f.Min = v.Min
+	f.Max = v.Max
+	f.N = v.N
+	f.Discarded = v.Discarded
+	return nil
+}
+
+// appendJSONFields returns the JSON encoding of r with the fields appended.
+//
+// fields must start with a comma.
+func appendJSONFields(r Reading, fields []byte) ([]byte, error) {
+	b, err := r.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	// Drop the closing brace.
+	b = append(b[:len(b)-1], fields...)
+	return append(b, '}'), nil
+}
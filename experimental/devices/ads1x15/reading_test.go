@@ -0,0 +1,128 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestTimedReading_JSON(t *testing.T) {
+	r := TimedReading{
+		Reading: Reading{V: 1234500 * physic.MicroVolt, Raw: 20345},
+		T:       time.Date(2018, 1, 2, 3, 4, 5, 6, time.UTC),
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); s != `{"v":"1.2345V","raw":20345,"t":"2018-01-02T03:04:05.000000006Z"}` {
+		t.Fatal(s)
+	}
+	var r2 TimedReading
+	if err := json.Unmarshal(b, &r2); err != nil {
+		t.Fatal(err)
+	}
+	if r2.Reading != r.Reading || !r2.T.Equal(r.T) {
+		t.Fatal(r2)
+	}
+	if s := r.String(); s != "1.234V (raw 20345) @ 2018-01-02T03:04:05.000000006Z" && s != "1.235V (raw 20345) @ 2018-01-02T03:04:05.000000006Z" {
+		t.Fatal(s)
+	}
+	if err := json.Unmarshal([]byte(`{"v":"1V","t":1}`), &r2); err == nil {
+		t.Fatal("expected invalid time")
+	}
+}
+
+func TestAveragedReading_JSON(t *testing.T) {
+	a := AveragedReading{
+		Reading: Reading{V: 1500 * physic.MilliVolt, Raw: 24000},
+		Min:     Reading{V: 1499 * physic.MilliVolt, Raw: 23984},
+		Max:     Reading{V: 1501 * physic.MilliVolt, Raw: 24016},
+		StdDev:  125 * physic.MicroVolt,
+		N:       16,
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const s = `{"v":"1.5V","raw":24000,"min":{"v":"1.499V","raw":23984},"max":{"v":"1.501V","raw":24016},"stddev":"125µV","n":16}`
+	if string(b) != s {
+		t.Fatal(string(b))
+	}
+	var a2 AveragedReading
+	if err := json.Unmarshal(b, &a2); err != nil {
+		t.Fatal(err)
+	}
+	if a2 != a {
+		t.Fatal(a2)
+	}
+	if err := json.Unmarshal([]byte(`{"v":"1.5V","stddev":0.001}`), &a2); err != nil || a2.StdDev != physic.MilliVolt {
+		t.Fatal(a2, err)
+	}
+	if err := json.Unmarshal([]byte(`{"v":"1.5V","stddev":"x"}`), &a2); err == nil {
+		t.Fatal("expected invalid stddev")
+	}
+	if s := a.String(); s != "1.500V (raw 24000) ±125µV [1.499V, 1.501V] n=16" {
+		t.Fatal(s)
+	}
+}
+
+func TestFilteredReading_JSON(t *testing.T) {
+	f := FilteredReading{
+		Reading:   Reading{V: 1500 * physic.MilliVolt, Raw: 24000},
+		Filter:    FilterTrimmedMean,
+		Min:       Reading{V: 1499 * physic.MilliVolt, Raw: 23984},
+		Max:       Reading{V: 2 * physic.Volt, Raw: 32000},
+		N:         10,
+		Discarded: 2,
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const s = `{"v":"1.5V","raw":24000,"filter":"TrimmedMean","min":{"v":"1.499V","raw":23984},"max":{"v":"2V","raw":32000},"n":10,"discarded":2}`
+	if string(b) != s {
+		t.Fatal(string(b))
+	}
+	var f2 FilteredReading
+	if err := json.Unmarshal(b, &f2); err != nil {
+		t.Fatal(err)
+	}
+	if f2 != f {
+		t.Fatal(f2)
+	}
+	if err := json.Unmarshal([]byte(`{"v":"1.5V","filter":"Mode"}`), &f2); err == nil {
+		t.Fatal("expected invalid filter")
+	}
+	if s := f.String(); s != "1.500V (raw 24000) TrimmedMean [1.499V, 2V] n=10 discarded=2" {
+		t.Fatal(s)
+	}
+}
+
+func TestCurrentReading_JSON(t *testing.T) {
+	c := CurrentReading{Reading: Reading{V: -physic.MilliVolt, Raw: -16}, I: -10 * physic.MilliAmpere}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); s != `{"v":"-1mV","raw":-16,"i":"-10mA"}` {
+		t.Fatal(s)
+	}
+	var c2 CurrentReading
+	if err := json.Unmarshal(b, &c2); err != nil {
+		t.Fatal(err)
+	}
+	if c2 != c {
+		t.Fatal(c2)
+	}
+	// A number of amperes is accepted.
+	if err := json.Unmarshal([]byte(`{"v":"-1mV","raw":-16,"i":-0.01}`), &c2); err != nil || c2 != c {
+		t.Fatal(c2, err)
+	}
+}
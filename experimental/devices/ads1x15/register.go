@@ -0,0 +1,221 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	// generalCallAddr and generalCallReset form the I²C general call reset.
+	generalCallAddr  = 0x00
+	generalCallReset = 0x06
+	// resetDelay is the time to wait after a reset; the datasheet specifies
+	// a power-up time of 25µs.
+	resetDelay = 50 * time.Microsecond
+)
+
+// Register is a register of the chip, as selected by the address pointer
+// register.
+type Register byte
+
+// Registers of the chip.
+//
+// The ADS1118 only has ConversionRegister and a write-only ConfigRegister.
+const (
+	ConversionRegister    Register = ads1x15PointerConversion
+	ConfigRegister        Register = ads1x15PointerConfig
+	LowThresholdRegister  Register = ads1x15PointerLowThreshold
+	HighThresholdRegister Register = ads1x15PointerHighThreshold
+)
+
+func (r Register) String() string {
+	switch r {
+	case ConversionRegister:
+		return "Conversion"
+	case ConfigRegister:
+		return "Config"
+	case LowThresholdRegister:
+		return "LowThreshold"
+	case HighThresholdRegister:
+		return "HighThreshold"
+	default:
+		return fmt.Sprintf("Register(%d)", byte(r))
+	}
+}
+
+// ReadRegister reads a register of the chip.
+//
+// It is meant for debugging and advanced use. The access is serialized with
+// the conversions.
+func (d *Dev) ReadRegister(reg Register) (uint16, error) {
+	if reg > HighThresholdRegister {
+		return 0, fmt.Errorf("ads1x15: invalid register %s", reg)
+	}
+	d.acquire()
+	defer d.release()
+	return d.t.readRegister(byte(reg))
+}
+
+// WriteRegister writes a register of the chip.
+//
+// It is meant for debugging and advanced use. The access is serialized with
+// the conversions. The driver assumes nothing about the chip state
+// afterward: the following conversions program the config and threshold
+// registers again as needed.
+func (d *Dev) WriteRegister(reg Register, v uint16) error {
+	if reg == ConversionRegister || reg > HighThresholdRegister {
+		return fmt.Errorf("ads1x15: register %s is not writable", reg)
+	}
+	d.acquire()
+	defer d.release()
+	if err := d.checkState(); err != nil {
+		return err
+	}
+	if reg == ConfigRegister {
+		d.configValid = false
+		d.lowLatency = nil
+	} else {
+		d.thresholds = nil
+		d.readyInit = false
+	}
+	return d.t.writeRegister(byte(reg), v)
+}
+
+// Reset sends the I²C general call reset to put the chip back in its power-on
+// state, e.g. to recover from a brown-out leaving a bogus config or a latched
+// comparator.
+//
+// Warning: the general call is received by every device on the bus, not only
+// this one. All the ADS1x15 on the bus are reset and other chips supporting
+// it may reset too. This is why Halt() never does it.
+//
+// Nothing is written afterward: the chip stays powered down with its default
+// config until the next conversion, which programs the config and threshold
+// registers again as needed. It fails while ReadContinuous() is active and
+// on the ADS1118.
+func (d *Dev) Reset() error {
+	t, ok := d.t.(*i2cTransport)
+	if !ok {
+		return errors.New("ads1x15: the general call reset is not supported by the ADS1118")
+	}
+	d.acquire()
+	defer d.release()
+	if d.continuous {
+		return errContinuous
+	}
+	d.configValid = false
+	d.lowLatency = nil
+	d.thresholds = nil
+	d.readyInit = false
+	if err := t.c.Bus.Tx(generalCallAddr, []byte{generalCallReset}, nil); err != nil {
+		return fmt.Errorf("ads1x15: general call reset on bus %s failed: %w", t.c.Bus, err)
+	}
+	time.Sleep(resetDelay)
+	return nil
+}
+
+// ConfigSnapshot is the decoded state of the chip, as returned by DumpState.
+type ConfigSnapshot struct {
+	// Config is the raw config register.
+	Config uint16
+	// Idle is the OS bit; it is set when no conversion is in progress.
+	Idle bool
+	// A and B are the channels selected by the input multiplexer. B is -1 when
+	// measuring A against GND.
+	A, B int
+	// Gain is the programmable gain amplifier setting.
+	Gain Gain
+	// Continuous is set in continuous conversion mode.
+	Continuous bool
+	// DataRate is the data rate setting.
+	DataRate physic.Frequency
+	// Comparator is set when the comparator is enabled. ComparatorOpts is its
+	// configuration.
+	Comparator     bool
+	ComparatorOpts ComparatorOpts
+	// LowThreshold and HighThreshold are the raw comparator thresholds.
+	LowThreshold, HighThreshold int16
+}
+
+func (c *ConfigSnapshot) String() string {
+	mux := fmt.Sprintf("AIN%d", c.A)
+	if c.B != -1 {
+		mux += fmt.Sprintf("-AIN%d", c.B)
+	}
+	mode := "single-shot"
+	if c.Continuous {
+		mode = "continuous"
+	}
+	comp := "disabled"
+	if c.Comparator {
+		comp = fmt.Sprintf("%+v [%d, %d]", c.ComparatorOpts, c.LowThreshold, c.HighThreshold)
+	}
+	return fmt.Sprintf("config=%#04x idle=%t mux=%s gain=%s mode=%s rate=%s comparator=%s", c.Config, c.Idle, mux, c.Gain, mode, c.DataRate, comp)
+}
+
+// DumpState reads the config and threshold registers and decodes them.
+//
+// It is only supported on the ADS1015 and ADS1115.
+func (d *Dev) DumpState() (ConfigSnapshot, error) {
+	var c ConfigSnapshot
+	d.acquire()
+	defer d.release()
+	var regs [3]uint16
+	for i, reg := range []Register{ConfigRegister, LowThresholdRegister, HighThresholdRegister} {
+		v, err := d.t.readRegister(byte(reg))
+		if err != nil {
+			return c, err
+		}
+		regs[i] = v
+	}
+	c.Config = regs[0]
+	c.LowThreshold = int16(regs[1])
+	c.HighThreshold = int16(regs[2])
+
+	c.Idle = c.Config&ads1x15ConfigOsSingle != 0
+	c.A, c.B = muxChannels(int(c.Config>>12) & 0x07)
+	for g, v := range d.gainConfig {
+		if v == c.Config&0x0E00 {
+			c.Gain = g
+		}
+	}
+	if c.Gain == 0 {
+		// The three values above ±0.256V are all Gain16.
+		c.Gain = Gain16
+	}
+	c.Continuous = c.Config&ads1x15ConfigModeSingle == 0
+	fastest := 0
+	for r, v := range d.dataRates {
+		if v == c.Config&0x00E0 {
+			c.DataRate = physic.Frequency(r) * physic.Hertz
+		}
+		if r > fastest {
+			fastest = r
+		}
+	}
+	if c.DataRate == 0 {
+		// On the ADS1015, DR=111 is 3300SPS like DR=110, which is the value
+		// written for 3300Hz.
+		c.DataRate = physic.Frequency(fastest) * physic.Hertz
+	}
+	switch c.Config & ads1x15ConfigCompQueDisable {
+	case 0:
+		c.ComparatorOpts.Queue = 1
+	case 1:
+		c.ComparatorOpts.Queue = 2
+	case 2:
+		c.ComparatorOpts.Queue = 4
+	}
+	c.Comparator = c.ComparatorOpts.Queue != 0
+	c.ComparatorOpts.Window = c.Config&ads1x15ConfigCompWindow != 0
+	c.ComparatorOpts.ActiveHigh = c.Config&ads1x15ConfigCompAactiveHigh != 0
+	c.ComparatorOpts.Latching = c.Config&ads1x15ConfigCompLatching != 0
+	return c, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestReadWriteRegister(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x02}, R: []byte{0x80, 0x00}},
+			{Addr: I2CAddr, W: []byte{0x03, 0x12, 0x34}},
+			{Addr: I2CAddr, W: []byte{0x01, 0x85, 0x83}},
+		},
+	}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := d.ReadRegister(LowThresholdRegister)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 0x8000 {
+		t.Fatalf("unexpected value %#04x", v)
+	}
+	if _, err := d.ReadRegister(Register(4)); err == nil {
+		t.Fatal("expected error on invalid register")
+	}
+	if err := d.WriteRegister(ConversionRegister, 0); err == nil {
+		t.Fatal("expected error on read-only register")
+	}
+	if err := d.WriteRegister(HighThresholdRegister, 0x1234); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.WriteRegister(ConfigRegister, 0x8583); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// Continuous mode on AIN0.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x01}},
+			// General call reset.
+			{Addr: 0x00, W: []byte{0x06}},
+			// The continuous mode has to be programmed again.
+			{Addr: I2CAddr, W: []byte{0x01, 0x40, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x02}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true, DefaultMode: ModeContinuous})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := p.Read(); err != nil || r.Raw != 1 {
+		t.Fatal(r, err)
+	}
+	if err := d.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := p.Read(); err != nil || r.Raw != 2 {
+		t.Fatal(r, err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegister_String(t *testing.T) {
+	if s := ConfigRegister.String(); s != "Config" {
+		t.Fatal(s)
+	}
+	if s := Register(7).String(); s != "Register(7)" {
+		t.Fatal(s)
+	}
+}
+
+func TestDumpState(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x01}, R: []byte{0x32, 0xf9}},
+			{Addr: I2CAddr, W: []byte{0x02}, R: []byte{0xe0, 0xc0}},
+			{Addr: I2CAddr, W: []byte{0x03}, R: []byte{0x1f, 0x40}},
+		},
+	}
+	d, err := NewADS1115(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := d.DumpState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ConfigSnapshot{
+		Config:         0x32f9,
+		A:              Channel2,
+		B:              Channel3,
+		Gain:           Gain1,
+		Continuous:     true,
+		DataRate:       860 * physic.Hertz,
+		Comparator:     true,
+		ComparatorOpts: ComparatorOpts{Window: true, ActiveHigh: true, Queue: 2},
+		LowThreshold:   -8000,
+		HighThreshold:  8000,
+	}
+	if c != expected {
+		t.Fatalf("%+v != %+v", c, expected)
+	}
+	if s := c.String(); s != "config=0x32f9 idle=false mux=AIN2-AIN3 gain=1 mode=continuous rate=860Hz comparator={Window:true ActiveHigh:true Latching:false Queue:2} [-8000, 8000]" {
+		t.Fatal(s)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDumpState_ads1015(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			{Addr: I2CAddr, W: []byte{0x01}, R: []byte{0x85, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x02}, R: []byte{0x80, 0x00}},
+			{Addr: I2CAddr, W: []byte{0x03}, R: []byte{0x7f, 0xff}},
+		},
+	}
+	d, err := NewADS1015(&bus, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := d.DumpState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// DR=111 is 3300SPS, like DR=110.
+	if c.DataRate != 3300*physic.Hertz {
+		t.Fatal(c.DataRate)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ScanGroup samples pins of different devices as close together in time as
+// possible.
+//
+// All the conversions are started back-to-back, one config write per device,
+// then the group waits once for the slowest conversion and reads all the
+// conversion registers.
+type ScanGroup struct {
+	pins []*ads1x15AnalogPin
+	// devs are the devices of pins, sorted by id: the order in which their
+	// locks are taken, so that concurrent groups do not deadlock.
+	devs []*Dev
+}
+
+// NewScanGroup returns a ScanGroup reading pins.
+//
+// The pins must have been returned by the PinFor* methods of a Dev, either
+// single-ended or differential, and belong to different devices.
+func NewScanGroup(pins ...AnalogPin) (*ScanGroup, error) {
+	if len(pins) == 0 {
+		return nil, errors.New("ads1x15: scan group needs at least one pin")
+	}
+	g := &ScanGroup{pins: make([]*ads1x15AnalogPin, 0, len(pins))}
+	for _, pin := range pins {
+		p, ok := pin.(*ads1x15AnalogPin)
+		if !ok {
+			return nil, fmt.Errorf("ads1x15: pin %s cannot be part of a scan group", pin)
+		}
+		for _, d := range g.devs {
+			if d == p.adc {
+				return nil, fmt.Errorf("ads1x15: scan group has more than one pin of %s", d)
+			}
+		}
+		g.pins = append(g.pins, p)
+		g.devs = append(g.devs, p.adc)
+	}
+	sort.Slice(g.devs, func(i, j int) bool { return g.devs[i].id < g.devs[j].id })
+	return g, nil
+}
+
+// Read returns one reading per pin, in the order passed to NewScanGroup, and
+// the spread between the first and the last conversion start.
+//
+// The end of the conversions is determined by waiting for the longest
+// conversion time; the OS bit and Opts.ReadyPin are not used. A pin in
+// low-latency mode does a single-shot conversion.
+func (g *ScanGroup) Read() ([]Reading, time.Duration, error) {
+	for _, d := range g.devs {
+		d.acquire()
+	}
+	defer func() {
+		for i := len(g.devs) - 1; i >= 0; i-- {
+			g.devs[i].release()
+		}
+	}()
+	rounds := 1
+	for _, p := range g.pins {
+		d := p.adc
+		if err := d.checkState(); err != nil {
+			return nil, 0, err
+		}
+		if err := d.applyThresholds(p); err != nil {
+			return nil, 0, err
+		}
+		if d.muxChanged(p) {
+			// Throw away the first conversions.
+			rounds = 2
+		}
+	}
+
+	var first, last time.Time
+	for ; rounds > 0; rounds-- {
+		var end time.Time
+		for i, p := range g.pins {
+			if err := p.adc.writeConfig(p.config); err != nil {
+				return nil, 0, err
+			}
+			last = time.Now()
+			if i == 0 {
+				first = last
+			}
+			if e := last.Add(p.waitTime); e.After(end) {
+				end = e
+			}
+		}
+		if err := sleep(context.Background(), time.Until(end)); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	readings := make([]Reading, len(g.pins))
+	for i, p := range g.pins {
+		r, err := p.adc.readConversion(p.voltageMultiplier)
+		if err != nil {
+			return nil, 0, err
+		}
+		readings[i] = p.adjust(r)
+	}
+	return readings, last.Sub(first), nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"sync"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/experimental/devices/ads1x15/ads1x15test"
+)
+
+func TestScanGroup(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			ads1x15test.ProbeOp(0x48),
+			ads1x15test.ProbeOp(0x49),
+			ads1x15test.ProbeOp(0x4a),
+			// Conversions started back-to-back.
+			{Addr: 0x49, W: []byte{0x01, 0xc1, 0xe3}},
+			{Addr: 0x48, W: []byte{0x01, 0xc1, 0xe3}},
+			{Addr: 0x4a, W: []byte{0x01, 0x85, 0x83}},
+			// Then read.
+			{Addr: 0x49, W: []byte{0x00}, R: []byte{0x00, 0x01}},
+			{Addr: 0x48, W: []byte{0x00}, R: []byte{0x00, 0x02}},
+			{Addr: 0x4a, W: []byte{0x00}, R: []byte{0xff, 0xfd}},
+		},
+	}
+	devs, err := NewADS1115Group(&bus, []uint16{0x48, 0x49, 0x4a}, &Opts{DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pins []AnalogPin
+	for _, d := range []*Dev{devs[1], devs[0]} {
+		p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pins = append(pins, p)
+	}
+	p, err := devs[2].PinForDifferenceOfChannels(Channel0, Channel1, 2*physic.Volt, 128*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pins = append(pins, p)
+
+	if _, err := NewScanGroup(); err == nil {
+		t.Fatal("expected error without pins")
+	}
+	if _, err := NewScanGroup(pins[0], pins[0]); err == nil {
+		t.Fatal("expected error with two pins of the same device")
+	}
+	g, err := NewScanGroup(pins...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, spread, err := g.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 3 || r[0].Raw != 1 || r[1].Raw != 2 || r[2].Raw != -3 || spread < 0 {
+		t.Fatal(r, spread)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanGroup_lockOrder(t *testing.T) {
+	bus := countingBus{}
+	devs, err := NewADS1115Group(&bus, []uint16{0x48, 0x49}, &Opts{DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pins []AnalogPin
+	for _, d := range devs {
+		p, err := d.PinForChannel(Channel0, 5*physic.Volt, 860*physic.Hertz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pins = append(pins, p)
+	}
+	g1, err := NewScanGroup(pins[0], pins[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := NewScanGroup(pins[1], pins[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Groups listing the devices in opposite orders must not deadlock.
+	var wg sync.WaitGroup
+	for _, g := range []*ScanGroup{g1, g2} {
+		wg.Add(1)
+		go func(g *ScanGroup) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if _, _, err := g.Read(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,205 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+// ThermistorOpts describes a NTC thermistor in a voltage divider.
+//
+// Either R25 and Beta, or the Steinhart–Hart coefficients A, B and C must be
+// set.
+type ThermistorOpts struct {
+	// R is the fixed resistor of the divider.
+	R physic.ElectricResistance
+	// Supply is the electric potential across the divider.
+	Supply physic.ElectricPotential
+	// HighSide is set when the thermistor is between the supply and the pin,
+	// with R to GND. By default the thermistor is between the pin and GND.
+	HighSide bool
+
+	// R25 is the resistance of the thermistor at 25°C and Beta its B
+	// coefficient, e.g. 10kΩ and 3950.
+	R25  physic.ElectricResistance
+	Beta float64
+
+	// A, B and C are the Steinhart–Hart coefficients, with the resistance in
+	// ohms. They are used instead of R25 and Beta when set.
+	A, B, C float64
+}
+
+// Thermistor reads the temperature of a NTC thermistor in a divider measured
+// by an AnalogPin.
+//
+// It implements physic.SenseEnv.
+type Thermistor struct {
+	p    AnalogPin
+	opts ThermistorOpts
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewThermistor returns a Thermistor reading p.
+func NewThermistor(p AnalogPin, opts *ThermistorOpts) (*Thermistor, error) {
+	if opts == nil {
+		return nil, errors.New("ads1x15: thermistor options are required")
+	}
+	if opts.R <= 0 {
+		return nil, errors.New("ads1x15: thermistor divider resistance must be positive")
+	}
+	if opts.Supply <= 0 {
+		return nil, errors.New("ads1x15: thermistor supply must be positive")
+	}
+	if opts.A == 0 && opts.B == 0 && opts.C == 0 && (opts.R25 <= 0 || opts.Beta <= 0) {
+		return nil, errors.New("ads1x15: thermistor needs either positive R25 and Beta, or Steinhart-Hart coefficients")
+	}
+	return &Thermistor{p: p, opts: *opts}, nil
+}
+
+func (t *Thermistor) String() string {
+	return fmt.Sprintf("Thermistor{%s}", t.p)
+}
+
+// Halt stops a continuous sensing, if any, and halts the pin.
+func (t *Thermistor) Halt() error {
+	t.mu.Lock()
+	stop, done := t.stop, t.done
+	t.stop, t.done = nil, nil
+	t.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return t.p.Halt()
+}
+
+// Temperature reads the pin and converts it into a temperature.
+//
+// An error is returned when the reading is at one of the rails, which happens
+// when the thermistor is open or shorted.
+func (t *Thermistor) Temperature() (physic.Temperature, error) {
+	r, err := t.p.Read()
+	if err != nil {
+		return 0, err
+	}
+	return t.temperature(r.V)
+}
+
+// Sense implements physic.SenseEnv.
+//
+// Only the temperature is set.
+func (t *Thermistor) Sense(e *physic.Env) error {
+	temp, err := t.Temperature()
+	if err != nil {
+		return err
+	}
+	e.Temperature = temp
+	return nil
+}
+
+// SenseContinuous implements physic.SenseEnv.
+//
+// The channel is closed on the first failure or when Halt() is called.
+func (t *Thermistor) SenseContinuous(interval time.Duration) (<-chan physic.Env, error) {
+	if interval <= 0 {
+		return nil, errors.New("ads1x15: interval must be positive")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stop != nil {
+		return nil, errors.New("ads1x15: thermistor is already sensing continuously")
+	}
+	t.stop = make(chan struct{})
+	t.done = make(chan struct{})
+	c := make(chan physic.Env)
+	go t.senseContinuous(interval, c, t.stop, t.done)
+	return c, nil
+}
+
+// Precision implements physic.SenseEnv.
+//
+// The resolution depends on the temperature and the gain of the pin; this is
+// its order of magnitude around 25°C.
+func (t *Thermistor) Precision(e *physic.Env) {
+	e.Temperature = 10 * physic.MilliKelvin
+}
+
+// Fields implements physic.SenseEnvFields.
+func (t *Thermistor) Fields() physic.EnvField {
+	return physic.EnvTemperature
+}
+
+func (t *Thermistor) senseContinuous(interval time.Duration, c chan<- physic.Env, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	defer close(c)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+		}
+		var e physic.Env
+		if err := t.Sense(&e); err != nil {
+			return
+		}
+		select {
+		case c <- e:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// temperature converts the electric potential at the pin into the
+// temperature of the thermistor.
+func (t *Thermistor) temperature(v physic.ElectricPotential) (physic.Temperature, error) {
+	// Consider 0.5% of the supply around the rails as open or shorted.
+	margin := t.opts.Supply / 200
+	low, high := "shorted", "open"
+	if t.opts.HighSide {
+		low, high = high, low
+	}
+	if v <= margin {
+		return 0, fmt.Errorf("ads1x15: thermistor reading %s is at GND, the thermistor or the divider is %s", v, low)
+	}
+	if v >= t.opts.Supply-margin {
+		return 0, fmt.Errorf("ads1x15: thermistor reading %s is at the supply, the thermistor or the divider is %s", v, high)
+	}
+	// Resistance of the thermistor, in ohms.
+	r := float64(t.opts.R) / float64(physic.Ohm)
+	var rt float64
+	if t.opts.HighSide {
+		rt = r * float64(t.opts.Supply-v) / float64(v)
+	} else {
+		rt = r * float64(v) / float64(t.opts.Supply-v)
+	}
+	var inv float64
+	if t.opts.A != 0 || t.opts.B != 0 || t.opts.C != 0 {
+		l := math.Log(rt)
+		inv = t.opts.A + t.opts.B*l + t.opts.C*l*l*l
+	} else {
+		r25 := float64(t.opts.R25) / float64(physic.Ohm)
+		inv = 1/(float64(physic.ZeroCelsius+25*physic.Celsius)/float64(physic.Kelvin)) + math.Log(rt/r25)/t.opts.Beta
+	}
+	k, ok := physic.Scale(int64(physic.Kelvin), 1/inv)
+	if !(inv > 0) || math.IsInf(inv, 1) || !ok {
+		return 0, fmt.Errorf("ads1x15: thermistor resistance %gΩ is out of range of the model", rt)
+	}
+	return physic.Temperature(k), nil
+}
+
+var _ physic.SenseEnv = &Thermistor{}
+var _ physic.SenseEnvFields = &Thermistor{}
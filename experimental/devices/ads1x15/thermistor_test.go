@@ -0,0 +1,89 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package ads1x15
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestThermistor(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			probeOp,
+			// 1.65V, half of the supply.
+			{Addr: I2CAddr, W: []byte{0x01, 0xc3, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x33, 0x90}},
+			// 3.3V, at the supply.
+			{Addr: I2CAddr, W: []byte{0x01, 0xc3, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x67, 0x20}},
+			// 0V.
+			{Addr: I2CAddr, W: []byte{0x01, 0xc3, 0xe3}},
+			{Addr: I2CAddr, W: []byte{0x00}, R: []byte{0x00, 0x00}},
+		},
+	}
+	d, err := NewADS1115(&bus, &Opts{I2cAddress: I2CAddr, DisablePolling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.PinForChannel(Channel0, 4*physic.Volt, 860*physic.Hertz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := ThermistorOpts{R: 10 * physic.KiloOhm, Supply: 3300 * physic.MilliVolt, R25: 10 * physic.KiloOhm, Beta: 3950}
+	if _, err := NewThermistor(p, nil); err == nil {
+		t.Fatal("expected error without options")
+	}
+	if _, err := NewThermistor(p, &ThermistorOpts{R: 10 * physic.KiloOhm, Supply: 3300 * physic.MilliVolt}); err == nil {
+		t.Fatal("expected error without a model")
+	}
+	th, err := NewThermistor(p, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e physic.Env
+	if err := th.Sense(&e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Temperature != physic.ZeroCelsius+25*physic.Celsius {
+		t.Fatal(e.Temperature)
+	}
+	if _, err := th.Temperature(); err == nil {
+		t.Fatal("expected open thermistor")
+	}
+	if _, err := th.Temperature(); err == nil {
+		t.Fatal("expected shorted thermistor")
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestThermistor_steinhartHart(t *testing.T) {
+	th, err := NewThermistor(nil, &ThermistorOpts{
+		R:        10 * physic.KiloOhm,
+		Supply:   3300 * physic.MilliVolt,
+		HighSide: true,
+		A:        1.009249522e-3,
+		B:        2.378405444e-4,
+		C:        2.019202697e-7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// With the thermistor on the high side, 1.65V means 10kΩ.
+	temp, err := th.temperature(1650 * physic.MilliVolt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := temp - physic.ZeroCelsius; c < 24*physic.Celsius || c > 26*physic.Celsius {
+		t.Fatal(temp)
+	}
+	if _, err := th.temperature(3300 * physic.MilliVolt); err == nil {
+		t.Fatal("expected shorted thermistor")
+	}
+}
@@ -116,8 +116,14 @@ func (d *Dev) SetResolution(r Resolution) error {
 	return err
 }
 
-// Sense reads the light value from the bh1750 sensor.
-func (d *Dev) Sense() (physic.LuminousFlux, error) {
+func (d *Dev) String() string {
+	return "BH1750{" + d.dev.String() + "}"
+}
+
+// Sense reads the illuminance from the bh1750 sensor.
+//
+// It implements physic.SenseLight.
+func (d *Dev) Sense() (physic.Illuminance, error) {
 	if err := d.SetResolution(d.res); err != nil {
 		return 0, err
 	}
@@ -129,12 +135,15 @@ func (d *Dev) Sense() (physic.LuminousFlux, error) {
 		return 0, err
 	}
 
-	rawValue := binary.BigEndian.Uint16(buf[:])
-	lux := physic.LuminousFlux(float64(rawValue) / 1.2)
-	return lux * physic.Lumen, nil
+	// The count is 1.2 times the illuminance in lux, round it to the nearest
+	// nano lux.
+	rawValue := int64(binary.BigEndian.Uint16(buf[:]))
+	return physic.Illuminance((rawValue*10*int64(physic.Lux) + 6) / 12), nil
 }
 
 // Halt turn off device.
 func (d *Dev) Halt() error {
 	return d.SetMode(PowerDown)
 }
+
+var _ physic.SenseLight = &Dev{}
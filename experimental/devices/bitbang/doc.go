@@ -5,4 +5,23 @@
 // Package bitbang implements conn by banging on the bits (GPIO pins).
 //
 // This is not efficient but works around broken or missing drivers.
+//
+// Testing on hardware
+//
+// The unit tests simulate an I²C device on the pins. To verify the I²C master
+// on real hardware, connect two spare GPIOs to the SCL and SDA lines of a
+// known device, with 4.7kΩ pull up resistors to 3.3V, e.g. the EEPROM of the
+// periph-tester board at address 0x50. Then:
+//
+//   - Call Register("bitbang", scl, sda, 100*physic.KiloHertz) after
+//     host.Init().
+//   - Open the bus with i2creg.Open("bitbang") and verify that i2c.Scan()
+//     finds the device.
+//   - Write a random page to the EEPROM, read it back with a combined
+//     write/read Tx() and compare.
+//   - Repeat with the same device on the hardware bus, to confirm that both
+//     return the same data.
+//
+// A logic analyzer on the two lines confirms the repeated start between the
+// write and the read, and the NACK sent by the master on the last byte read.
 package bitbang
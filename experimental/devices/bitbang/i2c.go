@@ -17,6 +17,7 @@ import (
 
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2creg"
 	"periph.io/x/periph/conn/physic"
 	"periph.io/x/periph/host/cpu"
 )
@@ -24,37 +25,59 @@ import (
 // SkipAddr can be used to skip the address from being sent.
 const SkipAddr uint16 = 0xFFFF
 
+// MaxSpeed is the maximum clock frequency supported, the one of the I²C
+// standard mode.
+const MaxSpeed = 100 * physic.KiloHertz
+
+// StretchTimeout is the maximum duration a device can hold the clock line low,
+// which is the SMBus timeout.
+const StretchTimeout = 25 * time.Millisecond
+
+// ErrNACK is returned when the device doesn't acknowledge its address or a
+// byte written to it.
+var ErrNACK = errors.New("bitbang-i2c: got NACK")
+
 // New returns an object that communicates I²C over two pins.
 //
-// BUG(maruel): It is close to working but not yet, the signal is incorrect
-// during ACK.
+// The pins are used as open drain: a line is driven low with Out(gpio.Low)
+// and released with In(), to be pulled high by the pull up resistor. The
+// internal pull up is enabled, but an external pull up resistor is
+// recommended. f must be at most MaxSpeed.
+//
+// The clock is generated with a busy loop, so the effective frequency is
+// lower than f, depending on the speed of the GPIO pins.
 //
 // It has two special features:
 // - Special address SkipAddr can be used to skip the address from being
 //   communicated
-// - An arbitrary speed can be used
-func New(clk gpio.PinIO, data gpio.PinIO, speedHz int) (*I2C, error) {
+// - Clock stretching is supported, up to StretchTimeout
+func New(clk gpio.PinIO, data gpio.PinIO, f physic.Frequency) (*I2C, error) {
+	if f <= 0 || f > MaxSpeed {
+		return nil, fmt.Errorf("bitbang-i2c: invalid speed %s; maximum supported clock is %s", f, MaxSpeed)
+	}
 	// Spec calls to idle at high. Page 8, section 3.1.1.
-	// Set SCL as pull-up.
 	if err := clk.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		return nil, err
-	}
-	if err := clk.Out(gpio.High); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("bitbang-i2c: %v", err)
 	}
-	// Set SDA as pull-up.
 	if err := data.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("bitbang-i2c: %v", err)
 	}
-	if err := data.Out(gpio.High); err != nil {
-		return nil, err
-	}
-	i := &I2C{
-		scl:       clk,
-		sda:       data,
-		halfCycle: time.Second / time.Duration(speedHz) / time.Duration(2),
+	return &I2C{scl: clk, sda: data, halfCycle: f.Period() / 2}, nil
+}
+
+// Register creates an I²C bus over two pins with New() and registers it in
+// i2creg under name, so it can be opened with i2creg.Open(name).
+//
+// All the opened handles share the same bus. Use i2creg.Unregister() to
+// remove it.
+func Register(name string, clk gpio.PinIO, data gpio.PinIO, f physic.Frequency) error {
+	i, err := New(clk, data, f)
+	if err != nil {
+		return err
 	}
-	return i, nil
+	return i2creg.Register(name, nil, -1, func() (i2c.BusCloser, error) {
+		return i, nil
+	})
 }
 
 // I2C represents an I²C master implemented as bit-banging on 2 GPIO pins.
@@ -75,179 +98,322 @@ func (i *I2C) Close() error {
 }
 
 // Tx implements i2c.Bus.
+//
+// When both w and r are specified, a repeated start is sent between the write
+// and the read. A NACK returns an error wrapping ErrNACK.
 func (i *I2C) Tx(addr uint16, w, r []byte) error {
+	return i.txSlowest(addr, 0, w, r)
+}
+
+// TxSpeed implements i2c.SpeedTxer.
+//
+// The clock is slowed down to maxSpeed for this transaction when it is lower
+// than the speed of the bus.
+func (i *I2C) TxSpeed(addr uint16, maxSpeed physic.Frequency, w, r []byte) error {
+	if maxSpeed <= 0 {
+		return fmt.Errorf("bitbang-i2c: invalid speed %s", maxSpeed)
+	}
+	return i.txSlowest(addr, maxSpeed.Period()/2, w, r)
+}
+
+// SetSpeed implements i2c.Bus.
+func (i *I2C) SetSpeed(f physic.Frequency) error {
+	if f <= 0 || f > MaxSpeed {
+		return fmt.Errorf("bitbang-i2c: invalid speed %s; maximum supported clock is %s", f, MaxSpeed)
+	}
 	i.mu.Lock()
 	defer i.mu.Unlock()
-	runtime.LockOSThread()
-	defer runtime.UnlockOSThread()
-	//syscall.Setpriority(which, who, prio)
+	i.halfCycle = f.Period() / 2
+	return nil
+}
+
+// Speed returns the clock frequency set by New() or SetSpeed().
+func (i *I2C) Speed() physic.Frequency {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.halfCycle == 0 {
+		return 0
+	}
+	return physic.PeriodToFrequency(2 * i.halfCycle)
+}
+
+// Recover implements i2c.Recoverer.
+//
+// It runs the bus recovery sequence with i2c.RecoverPins().
+func (i *I2C) Recover() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := i2c.RecoverPins(i.scl, i.sda); err != nil {
+		return fmt.Errorf("bitbang-i2c: %w", err)
+	}
+	return nil
+}
+
+// SCL implements i2c.Pins.
+func (i *I2C) SCL() gpio.PinIO {
+	return i.scl
+}
+
+// SDA implements i2c.Pins.
+func (i *I2C) SDA() gpio.PinIO {
+	return i.sda
+}
+
+//
 
-	i.start()
-	defer i.stop()
+// txSlowest does a transaction with a half clock cycle of at least halfCycle.
+func (i *I2C) txSlowest(addr uint16, halfCycle time.Duration, w, r []byte) error {
 	if addr != SkipAddr {
-		if addr > 0xFF {
+		if addr&i2c.TenBit != 0 {
 			// Page 15, section 3.1.11 10-bit addressing
 			// TODO(maruel): Implement if desired; prefix 0b11110xx.
-			return errors.New("bitbang-i2c: invalid address")
+			return fmt.Errorf("bitbang-i2c: 10 bits address: %w", i2c.ErrNotSupported)
 		}
-		// Page 13, section 3.1.10 The slave address and R/W bit
-		addr <<= 1
-		if len(r) == 0 {
-			addr |= 1
+		if err := i2c.CheckAddr(addr); err != nil {
+			return fmt.Errorf("bitbang-i2c: %w", err)
 		}
-		ack, err := i.writeByte(byte(addr))
-		if err != nil {
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if halfCycle > i.halfCycle {
+		defer func(h time.Duration) { i.halfCycle = h }(i.halfCycle)
+		i.halfCycle = halfCycle
+	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := i.start(); err != nil {
+		return err
+	}
+	err := i.tx(addr, w, r)
+	if err2 := i.stop(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// tx does the transaction between the start and the stop conditions.
+func (i *I2C) tx(addr uint16, w, r []byte) error {
+	if len(w) != 0 || len(r) == 0 {
+		// Page 13, section 3.1.10 The slave address and R/W bit
+		if err := i.writeAddr(addr, false); err != nil {
 			return err
 		}
-		if !ack {
-			return errors.New("bitbang-i2c: got NACK")
+		for x, b := range w {
+			ack, err := i.writeByte(b)
+			if err != nil {
+				return err
+			}
+			if !ack {
+				return fmt.Errorf("%w: byte #%d written to address %#x", ErrNACK, x, addr)
+			}
 		}
-	}
-	for _, b := range w {
-		ack, err := i.writeByte(b)
-		if err != nil {
-			return err
+		if len(r) == 0 {
+			return nil
 		}
-		if !ack {
-			return errors.New("bitbang-i2c: got NACK")
+		// Page 14, section 3.1.10, Figure 13, combined format.
+		if addr != SkipAddr {
+			if err := i.start(); err != nil {
+				return err
+			}
 		}
 	}
+	if err := i.writeAddr(addr, true); err != nil {
+		return err
+	}
 	for x := range r {
+		// The master doesn't acknowledge the last byte.
 		var err error
-		r[x], err = i.readByte()
-		if err != nil {
+		if r[x], err = i.readByte(x != len(r)-1); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// SetSpeed implements i2c.Bus.
-func (i *I2C) SetSpeed(f physic.Frequency) error {
-	i.mu.Lock()
-	defer i.mu.Unlock()
-	i.halfCycle = f.Duration() / 2
+// writeAddr writes the address byte with the R/W bit unless addr is
+// SkipAddr.
+func (i *I2C) writeAddr(addr uint16, read bool) error {
+	if addr == SkipAddr {
+		return nil
+	}
+	b := byte(addr) << 1
+	if read {
+		b |= 1
+	}
+	ack, err := i.writeByte(b)
+	if err != nil {
+		return err
+	}
+	if !ack {
+		return fmt.Errorf("%w: address %#x", ErrNACK, addr)
+	}
 	return nil
 }
 
-// SCL implements i2c.Pins.
-func (i *I2C) SCL() gpio.PinIO {
-	return i.scl
-}
-
-// SDA implements i2c.Pins.
-func (i *I2C) SDA() gpio.PinIO {
-	return i.sda
-}
-
+// start sends a start condition, or a repeated start condition.
 //
-
-// "When CLK is a high level and DIO changes from high to low level, data input
-// starts."
+// Expects SCL high when idle, or low for a repeated start.
 //
 // Ends with SDA and SCL low.
-//
-// Lasts 1/2 cycle.
-func (i *I2C) start() {
+func (i *I2C) start() error {
 	// Page 9, section 3.1.4 START and STOP conditions
 	// In multi-master mode, it would have to sense SDA first and after the sleep.
-	_ = i.sda.Out(gpio.Low)
+	if err := i.release(i.sda); err != nil {
+		return err
+	}
+	i.sleepHalfCycle()
+	if err := i.releaseSCL(); err != nil {
+		return err
+	}
+	i.sleepHalfCycle()
+	if err := i.sda.Out(gpio.Low); err != nil {
+		return fmt.Errorf("bitbang-i2c: %v", err)
+	}
 	i.sleepHalfCycle()
-	_ = i.scl.Out(gpio.Low)
+	if err := i.scl.Out(gpio.Low); err != nil {
+		return fmt.Errorf("bitbang-i2c: %v", err)
+	}
+	return nil
 }
 
-// "When CLK is a high level and DIO changes from low level to high level, data
-// input ends."
+// stop sends a stop condition.
 //
-// Lasts 3/2 cycle.
-func (i *I2C) stop() {
+// Expects SCL low.
+//
+// Ends with SDA and SCL released.
+func (i *I2C) stop() error {
 	// Page 9, section 3.1.4 START and STOP conditions
-	_ = i.scl.Out(gpio.Low)
+	if err := i.sda.Out(gpio.Low); err != nil {
+		return fmt.Errorf("bitbang-i2c: %v", err)
+	}
 	i.sleepHalfCycle()
-	_ = i.scl.Out(gpio.High)
+	if err := i.releaseSCL(); err != nil {
+		return err
+	}
 	i.sleepHalfCycle()
-	_ = i.sda.Out(gpio.High)
-	// TODO(maruel): This sleep could be skipped, assuming we wait for the next
-	// transfer if too quick to happen.
+	if err := i.release(i.sda); err != nil {
+		return err
+	}
 	i.sleepHalfCycle()
+	return nil
 }
 
-// writeByte writes 8 bits then waits for ACK.
-//
-// Expects SDA and SCL low.
+// writeByte writes 8 bits then reads the ACK.
 //
-// Ends with SDA low and SCL high.
+// Expects SCL low. Ends with SCL low.
 //
 // Lasts 9 cycles.
 func (i *I2C) writeByte(b byte) (bool, error) {
-	// Page 9, section 3.1.3 Data validity
-	// "The data on te SDA line must be stable during the high period of the
-	// clock."
 	// Page 10, section 3.1.5 Byte format
-	for x := 0; x < 8; x++ {
-		_ = i.sda.Out(b&byte(1<<byte(7-x)) != 0)
-		i.sleepHalfCycle()
-		// Let the device read SDA.
-		// TODO(maruel): Support clock stretching, the device may keep the line low.
-		_ = i.scl.Out(gpio.High)
-		i.sleepHalfCycle()
-		_ = i.scl.Out(gpio.Low)
+	for x := 7; x >= 0; x-- {
+		if err := i.writeBit(b&(1<<uint(x)) != 0); err != nil {
+			return false, err
+		}
 	}
 	// Page 10, section 3.1.6 ACK and NACK
-	// 9th clock is ACK.
-	i.sleepHalfCycle()
-	// SCL was already set as pull-up. PullNoChange
-	if err := i.scl.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		return false, err
-	}
-	// SDA was already set as pull-up.
-	if err := i.sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		return false, err
+	// 9th clock is ACK; ACK == Low.
+	nack, err := i.readBit()
+	return !nack, err
+}
+
+// readByte reads 8 bits then writes the ACK, or a NACK if ack is false.
+//
+// Expects SCL low. Ends with SCL low.
+//
+// Lasts 9 cycles.
+func (i *I2C) readByte(ack bool) (byte, error) {
+	var b byte
+	for x := 0; x < 8; x++ {
+		bit, err := i.readBit()
+		if err != nil {
+			return 0, err
+		}
+		b <<= 1
+		if bit {
+			b |= 1
+		}
 	}
-	// Implement clock stretching, the device may keep the line low.
-	for i.scl.Read() == gpio.Low {
-		i.sleepHalfCycle()
+	return b, i.writeBit(!ack)
+}
+
+// writeBit writes one bit.
+//
+// Expects SCL low. Ends with SCL low.
+func (i *I2C) writeBit(b bool) error {
+	// Page 9, section 3.1.3 Data validity
+	// "The data on the SDA line must be stable during the high period of the
+	// clock."
+	if b {
+		if err := i.release(i.sda); err != nil {
+			return err
+		}
+	} else if err := i.sda.Out(gpio.Low); err != nil {
+		return fmt.Errorf("bitbang-i2c: %v", err)
 	}
-	// ACK == Low.
-	ack := i.sda.Read() == gpio.Low
-	if err := i.scl.Out(gpio.Low); err != nil {
+	return i.clock()
+}
+
+// readBit reads one bit.
+//
+// Expects SCL low. Ends with SCL low.
+func (i *I2C) readBit() (bool, error) {
+	if err := i.release(i.sda); err != nil {
 		return false, err
 	}
-	if err := i.sda.Out(gpio.Low); err != nil {
+	i.sleepHalfCycle()
+	if err := i.releaseSCL(); err != nil {
 		return false, err
 	}
-	return ack, nil
+	i.sleepHalfCycle()
+	b := i.sda.Read() == gpio.High
+	if err := i.scl.Out(gpio.Low); err != nil {
+		return false, fmt.Errorf("bitbang-i2c: %v", err)
+	}
+	return b, nil
 }
 
-// readByte reads 8 bits and an ACK.
-//
-// Expects SDA and SCL low.
+// clock sends one clock pulse.
 //
-// Ends with SDA low and SCL high.
+// Expects SCL low. Ends with SCL low.
+func (i *I2C) clock() error {
+	i.sleepHalfCycle()
+	if err := i.releaseSCL(); err != nil {
+		return err
+	}
+	i.sleepHalfCycle()
+	if err := i.scl.Out(gpio.Low); err != nil {
+		return fmt.Errorf("bitbang-i2c: %v", err)
+	}
+	return nil
+}
+
+// releaseSCL releases the clock line and waits for it to be high.
 //
-// Lasts 9 cycles.
-func (i *I2C) readByte() (byte, error) {
-	var b byte
-	if err := i.sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		return b, err
+// Page 11, section 3.1.9 Clock stretching
+// The device may hold the line low, up to StretchTimeout.
+func (i *I2C) releaseSCL() error {
+	if err := i.release(i.scl); err != nil {
+		return err
 	}
-	for x := 0; x < 8; x++ {
-		i.sleepHalfCycle()
-		// TODO(maruel): Support clock stretching, the device may keep the line low.
-		_ = i.scl.Out(gpio.High)
-		i.sleepHalfCycle()
-		if i.sda.Read() == gpio.High {
-			b |= byte(1) << byte(7-x)
+	if i.scl.Read() == gpio.High {
+		return nil
+	}
+	for end := time.Now().Add(StretchTimeout); i.scl.Read() == gpio.Low; {
+		if time.Now().After(end) {
+			return errors.New("bitbang-i2c: clock stretching timeout")
 		}
-		_ = i.scl.Out(gpio.Low)
+		i.sleepHalfCycle()
 	}
-	if err := i.sda.Out(gpio.Low); err != nil {
-		return 0, err
+	return nil
+}
+
+// release releases a line so it's pulled high.
+func (i *I2C) release(p gpio.PinIO) error {
+	if err := p.In(gpio.PullNoChange, gpio.NoEdge); err != nil {
+		return fmt.Errorf("bitbang-i2c: %v", err)
 	}
-	i.sleepHalfCycle()
-	_ = i.scl.Out(gpio.High)
-	i.sleepHalfCycle()
-	return b, nil
+	return nil
 }
 
 // sleep does a busy loop to act as fast as possible.
@@ -255,4 +421,7 @@ func (i *I2C) sleepHalfCycle() {
 	cpu.Nanospin(i.halfCycle)
 }
 
-var _ i2c.Bus = &I2C{}
+var _ i2c.BusCloser = &I2C{}
+var _ i2c.Pins = &I2C{}
+var _ i2c.Recoverer = &I2C{}
+var _ i2c.SpeedTxer = &I2C{}
@@ -0,0 +1,486 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bitbang
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+func TestNew(t *testing.T) {
+	w := newWire(0x42)
+	if _, err := New(w.scl, w.sda, 0); err == nil {
+		t.Fatal("invalid speed")
+	}
+	if _, err := New(w.scl, w.sda, 400*physic.KiloHertz); err == nil {
+		t.Fatal("speed too high")
+	}
+	b, err := New(w.scl, w.sda, MaxSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "bitbang/i2c(SCL(1), SDA(2))" {
+		t.Fatal(s)
+	}
+	if f := b.Speed(); f != MaxSpeed {
+		t.Fatal(f)
+	}
+	if b.SetSpeed(0) == nil {
+		t.Fatal("invalid speed")
+	}
+	if err := b.SetSpeed(10 * physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	if f := b.Speed(); f != 10*physic.KiloHertz {
+		t.Fatal(f)
+	}
+	if b.SCL() != w.scl || b.SDA() != w.sda {
+		t.Fatal("pins")
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestI2C_Tx(t *testing.T) {
+	w := newWire(0x42)
+	w.dev.r = []byte{0xA5, 0x5A, 0xFF}
+	b := newI2C(t, w)
+	r := make([]byte, 2)
+	if err := b.Tx(0x42, []byte{0x10, 0x81}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w.dev.w, []byte{0x10, 0x81}) {
+		t.Fatalf("%#v", w.dev.w)
+	}
+	if !bytes.Equal(r, []byte{0xA5, 0x5A}) {
+		t.Fatalf("%#v", r)
+	}
+	// One start and one repeated start.
+	if w.dev.starts != 2 || w.dev.stops != 1 {
+		t.Fatal(w.dev.starts, w.dev.stops)
+	}
+	// The master NACKed the last byte.
+	if w.dev.acks != 1 {
+		t.Fatal(w.dev.acks)
+	}
+	if !w.idle() {
+		t.Fatal("bus must be released")
+	}
+}
+
+func TestI2C_Tx_write(t *testing.T) {
+	w := newWire(0x42)
+	b := newI2C(t, w)
+	if err := b.Tx(0x42, []byte{1, 2, 3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w.dev.w, []byte{1, 2, 3}) || w.dev.starts != 1 {
+		t.Fatalf("%#v", w.dev)
+	}
+	// Quick write.
+	w.dev.w = nil
+	if err := b.Tx(0x42, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.dev.w) != 0 || w.dev.starts != 2 {
+		t.Fatalf("%#v", w.dev)
+	}
+}
+
+func TestI2C_Tx_read(t *testing.T) {
+	w := newWire(0x42)
+	w.dev.r = []byte{0x12}
+	b := newI2C(t, w)
+	r := make([]byte, 1)
+	if err := b.Tx(0x42, nil, r); err != nil {
+		t.Fatal(err)
+	}
+	if r[0] != 0x12 || w.dev.starts != 1 || w.dev.acks != 0 {
+		t.Fatalf("%#v", w.dev)
+	}
+}
+
+func TestI2C_Tx_NACK(t *testing.T) {
+	w := newWire(0x42)
+	b := newI2C(t, w)
+	if err := b.Tx(0x43, []byte{1}, nil); !errors.Is(err, ErrNACK) {
+		t.Fatal(err)
+	}
+	if w.dev.stops != 1 || !w.idle() {
+		t.Fatal("expected a stop condition")
+	}
+	w.dev.nackAfter = 1
+	if err := b.Tx(0x42, []byte{1, 2}, nil); !errors.Is(err, ErrNACK) || err.Error() != "bitbang-i2c: got NACK: byte #1 written to address 0x42" {
+		t.Fatal(err)
+	}
+}
+
+func TestI2C_Tx_stretch(t *testing.T) {
+	w := newWire(0x42)
+	w.dev.r = []byte{0x12}
+	b := newI2C(t, w)
+	w.stretch = 3
+	r := make([]byte, 1)
+	if err := b.Tx(0x42, []byte{1}, r); err != nil {
+		t.Fatal(err)
+	}
+	if r[0] != 0x12 || w.stretched == 0 {
+		t.Fatal(r, w.stretched)
+	}
+	w.stretch = -1
+	if err := b.Tx(0x42, []byte{1}, nil); err == nil || err.Error() != "bitbang-i2c: clock stretching timeout" {
+		t.Fatal(err)
+	}
+}
+
+func TestI2C_TxSpeed(t *testing.T) {
+	w := newWire(0x42)
+	b := newI2C(t, w)
+	// A 1kHz clock lasts at least 19 half cycles of 500µs for one byte.
+	start := time.Now()
+	if err := b.TxSpeed(0x42, physic.KiloHertz, []byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 9500*time.Microsecond {
+		t.Fatal(d)
+	}
+	if !bytes.Equal(w.dev.w, []byte{1}) {
+		t.Fatalf("%#v", w.dev)
+	}
+	// The speed of the bus is restored.
+	if b.halfCycle != 0 {
+		t.Fatal(b.halfCycle)
+	}
+	// It isn't increased.
+	b.halfCycle = 5 * time.Microsecond
+	if err := b.TxSpeed(0x42, 400*physic.KiloHertz, []byte{2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.Speed(); s != MaxSpeed {
+		t.Fatal(s)
+	}
+	if b.TxSpeed(0x42, 0, []byte{3}, nil) == nil {
+		t.Fatal("invalid speed")
+	}
+}
+
+func TestI2C_Tx_addr(t *testing.T) {
+	w := newWire(0x42)
+	b := newI2C(t, w)
+	if err := b.Tx(0x42|i2c.TenBit, nil, nil); !errors.Is(err, i2c.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	if b.Tx(0x80, nil, nil) == nil {
+		t.Fatal("invalid address")
+	}
+	if w.dev.starts != 0 {
+		t.Fatal("the bus must not be accessed")
+	}
+	// SkipAddr sends the bytes as is; the device sees its address in the
+	// first byte.
+	if err := b.Tx(SkipAddr, []byte{0x42 << 1, 7}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w.dev.w, []byte{7}) {
+		t.Fatalf("%#v", w.dev.w)
+	}
+}
+
+func TestI2C_Tx_pinError(t *testing.T) {
+	w := newWire(0x42)
+	b := newI2C(t, w)
+	w.sda.err = errors.New("oops")
+	if err := b.Tx(0x42, []byte{1}, nil); err == nil || err.Error() != "bitbang-i2c: oops" {
+		t.Fatal(err)
+	}
+	if _, err := New(w.scl, w.sda, MaxSpeed); err == nil {
+		t.Fatal("pin error")
+	}
+}
+
+func TestI2C_Recover(t *testing.T) {
+	w := newWire(0x42)
+	b := newI2C(t, w)
+	if err := b.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if w.dev.stops != 1 || !w.idle() {
+		t.Fatal("expected a stop condition")
+	}
+	// The device holds SDA low forever.
+	w.dev.sdaLow = true
+	if err := b.Recover(); !errors.Is(err, i2c.ErrBusStuck) {
+		t.Fatal(err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	w := newWire(0x42)
+	if Register("bitbang", w.scl, w.sda, 0) == nil {
+		t.Fatal("invalid speed")
+	}
+	if err := Register("bitbang", w.scl, w.sda, MaxSpeed); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := i2creg.Unregister("bitbang"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	b1, err := i2creg.Open("bitbang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := i2creg.Open("bitbang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b1 != b2 {
+		t.Fatal("expected the same bus")
+	}
+	d := i2c.Dev{Bus: b1, Addr: 0x42}
+	if _, err := d.Write([]byte{3}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w.dev.w, []byte{3}) {
+		t.Fatalf("%#v", w.dev.w)
+	}
+}
+
+//
+
+func newI2C(t *testing.T, w *wire) *I2C {
+	b, err := New(w.scl, w.sda, MaxSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Do not slow down the tests.
+	b.halfCycle = 0
+	return b
+}
+
+// wire simulates the two open drain lines of an I²C bus with a device.
+//
+// The master drives a line low with Out(gpio.Low) and releases it with In().
+type wire struct {
+	scl, sda *wirePin
+	dev      device
+	// stretch is the number of reads for which the device holds SCL low after
+	// the master releases it; -1 to hold it forever.
+	stretch   int
+	stretched int
+	held      int
+}
+
+func newWire(addr byte) *wire {
+	w := &wire{dev: device{addr: addr}}
+	w.scl = &wirePin{Pin: gpiotest.Pin{N: "SCL", Num: 1}, w: w}
+	w.sda = &wirePin{Pin: gpiotest.Pin{N: "SDA", Num: 2}, w: w}
+	w.scl.released = true
+	w.sda.released = true
+	return w
+}
+
+func (w *wire) sclLevel() gpio.Level {
+	return gpio.Level(w.scl.released)
+}
+
+func (w *wire) sdaLevel() gpio.Level {
+	return gpio.Level(w.sda.released && !w.dev.sdaLow)
+}
+
+func (w *wire) idle() bool {
+	return w.scl.released && w.sda.released
+}
+
+// set is called when the master changes a line.
+func (w *wire) set(p *wirePin, released bool) {
+	if p.released == released {
+		return
+	}
+	scl, sda := w.sclLevel(), w.sdaLevel()
+	p.released = released
+	if p == w.scl {
+		w.held = 0
+		if released {
+			w.dev.rise(w.sdaLevel())
+		} else {
+			w.dev.fall()
+		}
+		return
+	}
+	if scl == gpio.High {
+		// SDA changing while SCL is high is a start or a stop condition.
+		if sda == gpio.High && w.sdaLevel() == gpio.Low {
+			w.dev.start()
+		} else if sda == gpio.Low && w.sdaLevel() == gpio.High {
+			w.dev.stop()
+		}
+	}
+}
+
+// wirePin is one line of a wire.
+type wirePin struct {
+	gpiotest.Pin
+	w        *wire
+	released bool
+	err      error
+}
+
+func (p *wirePin) In(pull gpio.Pull, edge gpio.Edge) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.w.set(p, true)
+	return nil
+}
+
+func (p *wirePin) Out(l gpio.Level) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.w.set(p, bool(l))
+	return nil
+}
+
+func (p *wirePin) SetFunc(f pin.Func) error {
+	p.Fn = string(f)
+	return nil
+}
+
+func (p *wirePin) Read() gpio.Level {
+	if p == p.w.scl {
+		if p.released && (p.w.stretch < 0 || p.w.held < p.w.stretch) {
+			// Clock stretching.
+			p.w.held++
+			p.w.stretched++
+			return gpio.Low
+		}
+		return p.w.sclLevel()
+	}
+	return p.w.sdaLevel()
+}
+
+// device is a minimal I²C device state machine.
+//
+// It records the bytes written to it and sends r when read.
+type device struct {
+	addr byte
+	w    []byte
+	r    []byte
+	// nackAfter is the number of data bytes to acknowledge; 0 means all.
+	nackAfter int
+
+	starts, stops int
+	// acks is the number of bytes acknowledged by the master.
+	acks int
+
+	state  int // 0: idle, 1: address, 2: write, 3: read
+	bit    int
+	b      byte
+	n      int
+	sdaLow bool
+	ack    bool
+}
+
+func (d *device) start() {
+	d.starts++
+	d.state, d.bit, d.b, d.n, d.sdaLow = 1, -1, 0, 0, false
+}
+
+func (d *device) stop() {
+	d.stops++
+	d.state, d.sdaLow = 0, false
+}
+
+// rise is called on SCL rising edge.
+func (d *device) rise(sda gpio.Level) {
+	switch d.state {
+	case 1, 2:
+		if d.bit >= 0 && d.bit < 8 {
+			d.b = d.b<<1 | byte(btoi(bool(sda)))
+		}
+	case 3:
+		if d.bit == 8 {
+			d.ack = sda == gpio.Low
+		}
+	}
+}
+
+// fall is called on SCL falling edge.
+func (d *device) fall() {
+	if d.state == 0 {
+		return
+	}
+	d.bit++
+	switch d.state {
+	case 1, 2:
+		switch d.bit {
+		case 8:
+			// Acknowledge the byte.
+			if d.state == 1 {
+				d.sdaLow = d.b>>1 == d.addr
+				if !d.sdaLow {
+					d.state = 0
+				}
+				return
+			}
+			d.w = append(d.w, d.b)
+			d.sdaLow = d.nackAfter == 0 || len(d.w) <= d.nackAfter
+		case 9:
+			d.sdaLow = false
+			d.bit = 0
+			if d.state == 1 && d.b&1 != 0 {
+				d.state = 3
+				d.sendBit()
+			} else {
+				d.state = 2
+			}
+			d.b = 0
+		}
+	case 3:
+		switch d.bit {
+		case 8:
+			// Release for the master's ACK.
+			d.sdaLow = false
+		case 9:
+			d.bit = 0
+			if !d.ack {
+				d.state = 0
+				return
+			}
+			d.acks++
+			d.n++
+			d.sendBit()
+		default:
+			d.sendBit()
+		}
+	}
+}
+
+func (d *device) sendBit() {
+	var v byte = 0xFF
+	if d.n < len(d.r) {
+		v = d.r[d.n]
+	}
+	d.sdaLow = v&(0x80>>uint(d.bit)) == 0
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
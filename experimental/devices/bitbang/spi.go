@@ -21,14 +21,17 @@ import (
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/physic"
 	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spireg"
 	"periph.io/x/periph/host/cpu"
 )
 
 // NewSPI returns an spi.PortCloser that communicates SPI over 3 or 4 pins.
 //
-// BUG(maruel): Completely untested.
+// It supports the modes 0 to 3, LSBFirst and NoCS, but not HalfDuplex, and
+// only 8 bits per word. The speed is best effort.
 //
-// cs can be nil.
+// cs can be nil. mosi or miso can be nil for a device that only reads or only
+// writes; zeros are then read.
 func NewSPI(clk, mosi gpio.PinOut, miso gpio.PinIn, cs gpio.PinOut) (*SPI, error) {
 	return &SPI{
 		spiConn: spiConn{
@@ -40,6 +43,22 @@ func NewSPI(clk, mosi gpio.PinOut, miso gpio.PinIn, cs gpio.PinOut) (*SPI, error
 	}, nil
 }
 
+// RegisterSPI creates a SPI port over the pins with NewSPI() and registers it
+// in spireg under name, so it can be opened with spireg.Open(name) by the
+// existing device drivers.
+//
+// All the opened handles share the same port. Use spireg.Unregister() to
+// remove it.
+func RegisterSPI(name string, clk, mosi gpio.PinOut, miso gpio.PinIn, cs gpio.PinOut) error {
+	s, err := NewSPI(clk, mosi, miso, cs)
+	if err != nil {
+		return err
+	}
+	return spireg.Register(name, nil, -1, func() (spi.PortCloser, error) {
+		return s, nil
+	})
+}
+
 // SPI represents a SPI master port implemented as bit-banging on 3 or 4 GPIO
 // pins.
 type SPI struct {
@@ -61,23 +80,23 @@ func (s *SPI) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn, er
 		return nil, errors.New("bitbang-spi: invalid frequency")
 	}
 	if mode&spi.HalfDuplex == spi.HalfDuplex {
-		return nil, errors.New("bitbang-spi: half-duplex mode not supported")
-	}
-	if mode&spi.LSBFirst == spi.LSBFirst {
-		return nil, errors.New("bitbang-spi: LSBFirst mode not supported")
+		return nil, fmt.Errorf("bitbang-spi: half-duplex mode: %w", spi.ErrNotSupported)
 	}
-	if mode >= 0x20 {
+	// NoSplit is implicitly honored, as there is no buffer size limit.
+	if mode&^spi.NoSplit >= 0x20 {
 		return nil, fmt.Errorf("bitbang-spi: unhandled mode %d(%s)", mode, mode.String())
 	}
+	if bits != 8 {
+		return nil, fmt.Errorf("bitbang-spi: %d bits per word: %w", bits, spi.ErrNotSupported)
+	}
 	s.spiConn.mu.Lock()
 	defer s.spiConn.mu.Unlock()
 	s.spiConn.freqDev = f
 	if s.spiConn.freqDev != 0 && (s.spiConn.freqPort == 0 || s.spiConn.freqDev < s.spiConn.freqPort) {
-		s.spiConn.halfCycle = f.Duration() / 2
+		s.spiConn.halfCycle = f.Period() / 2
 	}
 	s.spiConn.mode = mode
-	s.spiConn.bits = bits
-	s.spiConn.readAfterClockPulse = mode&spi.Mode1 == spi.Mode1
+	s.spiConn.cpha = mode&spi.Mode1 == spi.Mode1
 
 	// Set clock idle polarity, ensuring an idle clock to start
 	s.spiConn.clockIdle = gpio.Level(mode&spi.Mode2 == spi.Mode2)
@@ -101,7 +120,7 @@ func (s *SPI) LimitSpeed(f physic.Frequency) error {
 	defer s.spiConn.mu.Unlock()
 	s.spiConn.freqPort = f
 	if s.spiConn.freqDev == 0 || s.spiConn.freqPort < s.spiConn.freqDev {
-		s.spiConn.halfCycle = f.Duration() / 2
+		s.spiConn.halfCycle = f.Period() / 2
 	}
 	return nil
 }
@@ -137,14 +156,13 @@ type spiConn struct {
 	csn gpio.PinOut // CS
 
 	// Mutable.
-	mu                  sync.Mutex
-	freqPort            physic.Frequency
-	freqDev             physic.Frequency
-	clockIdle           gpio.Level
-	readAfterClockPulse bool
-	mode                spi.Mode
-	bits                int
-	halfCycle           time.Duration
+	mu        sync.Mutex
+	freqPort  physic.Frequency
+	freqDev   physic.Frequency
+	clockIdle gpio.Level
+	cpha      bool // Data is sampled on the trailing edge.
+	mode      spi.Mode
+	halfCycle time.Duration
 }
 
 func (s *spiConn) String() string {
@@ -159,63 +177,51 @@ func (s *spiConn) Duplex() conn.Duplex {
 
 // Tx implements spi.Conn.
 //
-// BUG(maruel): Implement mode (HalfDuplex and LSBFirst remain to be done).
-// BUG(maruel): Implement bits.
-// BUG(maruel): Test if read works.
-func (s *spiConn) Tx(w, r []byte) (err error) {
-	if len(r) != 0 && len(w) != len(r) {
-		return errors.New("bitbang-spi: write and read buffers must be the same length")
+// When w is empty, zeros are written while reading r.
+func (s *spiConn) Tx(w, r []byte) error {
+	return s.TxPackets([]spi.Packet{{W: w, R: r}})
+}
+
+// TxPackets implements spi.Conn.
+//
+// Packet.Speed is honored, up to the speed set with LimitSpeed(). Any number
+// of Packet.DummyBits is supported.
+func (s *spiConn) TxPackets(p []spi.Packet) error {
+	for i := range p {
+		if b := p[i].BitsPerWord; b != 0 && b != 8 {
+			return fmt.Errorf("bitbang-spi: %d bits per word: %w", b, spi.ErrNotSupported)
+		}
+		if lW, lR := len(p[i].W), len(p[i].R); lW != 0 && lR != 0 && lW != lR {
+			return errors.New("bitbang-spi: write and read buffers must be the same length")
+		}
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	if err = s.assertCS(); err != nil {
+	if err := s.assertCS(); err != nil {
 		return fmt.Errorf("bitbang-spi: failed to assert chip-select: %v", err)
 	}
-
-	for i := uint(0); i < uint(len(w)*8); i++ {
-		if err = s.sdo.Out(w[i/8]&(1<<(i%8)) != 0); err != nil {
-			return fmt.Errorf("bitbang-spi: failed to send bit %d of word %d: %v", i%8, i/8, err)
+	for i := range p {
+		if err := s.txPacket(&p[i]); err != nil {
+			// Best effort to release the device.
+			_ = s.unassertCS()
+			return err
 		}
-
-		s.sleepHalfCycle()
-		if err = s.sck.Out(!s.clockIdle); err != nil {
-			return fmt.Errorf("bitbang-spi: failed to assert clock: %v", err)
-		}
-		s.sleepHalfCycle()
-
-		if s.readAfterClockPulse {
-			if err = s.sck.Out(s.clockIdle); err != nil {
-				return fmt.Errorf("bitbang-spi: failed to idle clock: %v", err)
-			}
-			s.sleepHalfCycle()
-		}
-
-		if len(r) != 0 {
-			if s.sdi.Read() == gpio.High {
-				r[i/8] |= 1 << (i % 8)
+		if !p[i].KeepCS && i != len(p)-1 {
+			if err := s.unassertCS(); err != nil {
+				return fmt.Errorf("bitbang-spi: failed to unassert chip-select: %v", err)
 			}
-		}
-
-		if !s.readAfterClockPulse {
-			if err = s.sck.Out(s.clockIdle); err != nil {
-				return fmt.Errorf("bitbang-spi: failed to idle clock: %v", err)
+			if err := s.assertCS(); err != nil {
+				return fmt.Errorf("bitbang-spi: failed to assert chip-select: %v", err)
 			}
 		}
 	}
-	if err = s.unassertCS(); err != nil {
+	if err := s.unassertCS(); err != nil {
 		return fmt.Errorf("bitbang-spi: failed to unassert chip-select: %v", err)
 	}
-
 	return nil
 }
 
-// TxPackets implements spi.Conn.
-func (s *spiConn) TxPackets(p []spi.Packet) error {
-	return errors.New("bitbang-spi: not implemented")
-}
-
 // Write implements io.Writer.
 func (s *spiConn) Write(d []byte) (int, error) {
 	if err := s.Tx(d, nil); err != nil {
@@ -246,6 +252,98 @@ func (s *spiConn) CS() gpio.PinOut {
 
 //
 
+// txPacket clocks one packet. s.mu must be held.
+func (s *spiConn) txPacket(p *spi.Packet) error {
+	halfCycle := s.halfCycle
+	if p.Speed != 0 {
+		f := p.Speed
+		if s.freqPort != 0 && f > s.freqPort {
+			f = s.freqPort
+		}
+		halfCycle = f.Period() / 2
+	}
+	for i := 0; i < int(p.DummyBits); i++ {
+		if _, err := s.clockBit(gpio.Low, halfCycle); err != nil {
+			return fmt.Errorf("bitbang-spi: failed to send dummy bit %d: %v", i, err)
+		}
+	}
+	n := len(p.W)
+	if n == 0 {
+		n = len(p.R)
+	}
+	for i := 0; i < n*8; i++ {
+		mask := byte(0x80) >> uint(i%8)
+		if s.mode&spi.LSBFirst != 0 {
+			mask = 1 << uint(i%8)
+		}
+		out := gpio.Low
+		if len(p.W) != 0 && p.W[i/8]&mask != 0 {
+			out = gpio.High
+		}
+		in, err := s.clockBit(out, halfCycle)
+		if err != nil {
+			return fmt.Errorf("bitbang-spi: failed to send bit %d of byte %d: %v", i%8, i/8, err)
+		}
+		if len(p.R) != 0 {
+			if i%8 == 0 {
+				p.R[i/8] = 0
+			}
+			if in == gpio.High {
+				p.R[i/8] |= mask
+			}
+		}
+	}
+	return nil
+}
+
+// clockBit sends out on MOSI and samples MISO during one clock cycle.
+//
+// With CPHA=0, the data is set before the leading edge and sampled on it.
+// With CPHA=1, it is set on the leading edge and sampled on the trailing
+// edge.
+func (s *spiConn) clockBit(out gpio.Level, halfCycle time.Duration) (gpio.Level, error) {
+	in := gpio.Low
+	if !s.cpha {
+		if err := s.setMOSI(out); err != nil {
+			return in, err
+		}
+		cpu.Nanospin(halfCycle)
+		if err := s.sck.Out(!s.clockIdle); err != nil {
+			return in, err
+		}
+		in = s.readMISO()
+		cpu.Nanospin(halfCycle)
+		return in, s.sck.Out(s.clockIdle)
+	}
+	if err := s.sck.Out(!s.clockIdle); err != nil {
+		return in, err
+	}
+	if err := s.setMOSI(out); err != nil {
+		return in, err
+	}
+	cpu.Nanospin(halfCycle)
+	if err := s.sck.Out(s.clockIdle); err != nil {
+		return in, err
+	}
+	in = s.readMISO()
+	cpu.Nanospin(halfCycle)
+	return in, nil
+}
+
+func (s *spiConn) setMOSI(l gpio.Level) error {
+	if s.sdo == nil {
+		return nil
+	}
+	return s.sdo.Out(l)
+}
+
+func (s *spiConn) readMISO() gpio.Level {
+	if s.sdi == nil {
+		return gpio.Low
+	}
+	return s.sdi.Read()
+}
+
 // sleep does a busy loop to act as fast as possible.
 func (s *spiConn) sleepHalfCycle() {
 	cpu.Nanospin(s.halfCycle)
@@ -0,0 +1,347 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bitbang
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spireg"
+)
+
+func TestSPI_Connect(t *testing.T) {
+	w := newSPIWire(spi.Mode0)
+	s, err := NewSPI(w.clk, w.mosi, w.miso, w.cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := s.String(); v != "bitbang/spi(CLK(1), MISO(3), MOSI(2), CS(4))" {
+		t.Fatal(v)
+	}
+	if _, err := s.Connect(-1, spi.Mode0, 8); err == nil {
+		t.Fatal("invalid frequency")
+	}
+	if _, err := s.Connect(0, spi.Mode0|spi.HalfDuplex, 8); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	if _, err := s.Connect(0, spi.Mode0, 9); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	if _, err := s.Connect(0, 0x40, 8); err == nil {
+		t.Fatal("invalid mode")
+	}
+	if s.LimitSpeed(0) == nil {
+		t.Fatal("invalid speed")
+	}
+	if err := s.LimitSpeed(physic.MegaHertz); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Connect(10*physic.MegaHertz, spi.Mode2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.spiConn.halfCycle != physic.MegaHertz.Period()/2 {
+		t.Fatal(s.spiConn.halfCycle)
+	}
+	// The clock idles high in mode 2 and CS is not asserted.
+	if w.clk.L != gpio.High || w.cs.L != gpio.High {
+		t.Fatal(w.clk.L, w.cs.L)
+	}
+	if d := c.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+	p := c.(spi.Pins)
+	if p.CLK() != w.clk || p.MOSI() != w.mosi || p.MISO() != w.miso || p.CS() != w.cs {
+		t.Fatal("pins")
+	}
+	if s.CLK() != w.clk || s.MOSI() != w.mosi || s.MISO() != w.miso || s.CS() != w.cs {
+		t.Fatal("pins")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSPI_Tx_modes(t *testing.T) {
+	for _, m := range []spi.Mode{spi.Mode0, spi.Mode1, spi.Mode2, spi.Mode3} {
+		w := newSPIWire(m)
+		w.tx = []byte{0x3C, 0x81}
+		c := newSPI(t, w, m)
+		r := make([]byte, 2)
+		if err := c.Tx([]byte{0xA5, 0x0F}, r); err != nil {
+			t.Fatalf("%s: %v", m, err)
+		}
+		if !bytes.Equal(w.rx, []byte{0xA5, 0x0F}) {
+			t.Fatalf("%s: %#v", m, w.rx)
+		}
+		if !bytes.Equal(r, []byte{0x3C, 0x81}) {
+			t.Fatalf("%s: %#v", m, r)
+		}
+		// One clock cycle per bit, and the clock is idle when CS changes.
+		if w.cycles != 16 || w.selects != 1 || w.errs != 0 {
+			t.Fatalf("%s: %d cycles %d selects %d errors", m, w.cycles, w.selects, w.errs)
+		}
+		if w.cs.L != gpio.High {
+			t.Fatalf("%s: CS left asserted", m)
+		}
+	}
+}
+
+func TestSPI_Tx_LSBFirst(t *testing.T) {
+	w := newSPIWire(spi.Mode0)
+	w.tx = []byte{0x80}
+	c := newSPI(t, w, spi.Mode0|spi.LSBFirst)
+	r := make([]byte, 1)
+	if err := c.Tx([]byte{0x01}, r); err != nil {
+		t.Fatal(err)
+	}
+	// The device sees the bits in reverse order.
+	if !bytes.Equal(w.rx, []byte{0x80}) {
+		t.Fatalf("%#v", w.rx)
+	}
+	if r[0] != 0x01 {
+		t.Fatalf("%#x", r[0])
+	}
+}
+
+func TestSPI_Tx_halfBuffers(t *testing.T) {
+	w := newSPIWire(spi.Mode3)
+	w.tx = []byte{0x12}
+	c := newSPI(t, w, spi.Mode3)
+	// Read only; zeros are written.
+	r := []byte{0xFF}
+	if err := c.Tx(nil, r); err != nil {
+		t.Fatal(err)
+	}
+	if r[0] != 0x12 || !bytes.Equal(w.rx, []byte{0}) {
+		t.Fatal(r, w.rx)
+	}
+	if c.Tx([]byte{1, 2}, make([]byte, 1)) == nil {
+		t.Fatal("different lengths")
+	}
+}
+
+func TestSPI_Tx_noPins(t *testing.T) {
+	w := newSPIWire(spi.Mode0)
+	s, err := NewSPI(w.clk, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Connect(0, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := []byte{0xFF}
+	if err := c.Tx([]byte{1}, r); err != nil {
+		t.Fatal(err)
+	}
+	if r[0] != 0 {
+		t.Fatal(r)
+	}
+}
+
+func TestSPI_TxPackets(t *testing.T) {
+	w := newSPIWire(spi.Mode0)
+	w.tx = []byte{0, 0, 0xAA}
+	c := newSPI(t, w, spi.Mode0)
+	p := []spi.Packet{
+		{W: []byte{0x0B}, KeepCS: true},
+		{R: make([]byte, 1), DummyBits: 8, Speed: 100 * physic.MegaHertz},
+		{W: []byte{0x04}},
+	}
+	if err := c.TxPackets(p); err != nil {
+		t.Fatal(err)
+	}
+	if p[1].R[0] != 0xAA {
+		t.Fatalf("%#x", p[1].R[0])
+	}
+	// CS is kept asserted between the first two packets only.
+	if w.selects != 2 || w.cycles != 32 || w.errs != 0 {
+		t.Fatal(w.selects, w.cycles, w.errs)
+	}
+	if !bytes.Equal(w.rx, []byte{0x0B, 0, 0, 0x04}) {
+		t.Fatalf("%#v", w.rx)
+	}
+	if err := c.TxPackets([]spi.Packet{{W: []byte{1}, BitsPerWord: 9}}); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+}
+
+func TestSPI_Tx_pinError(t *testing.T) {
+	w := newSPIWire(spi.Mode0)
+	c := newSPI(t, w, spi.Mode0)
+	w.clk.err = errors.New("oops")
+	if err := c.Tx([]byte{1}, nil); err == nil || err.Error() != "bitbang-spi: failed to send bit 0 of byte 0: oops" {
+		t.Fatal(err)
+	}
+	// CS is released.
+	if w.cs.L != gpio.High {
+		t.Fatal("CS left asserted")
+	}
+	if n, err := c.(*spiConn).Write([]byte{1}); n != 0 || err == nil {
+		t.Fatal(n, err)
+	}
+}
+
+func TestRegisterSPI(t *testing.T) {
+	w := newSPIWire(spi.Mode0)
+	if err := RegisterSPI("bitbang-spi", w.clk, w.mosi, w.miso, w.cs); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := spireg.Unregister("bitbang-spi"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	p, err := spireg.Open("bitbang-spi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.(*spiConn).Write([]byte{3}); n != 1 || err != nil {
+		t.Fatal(n, err)
+	}
+	if !bytes.Equal(w.rx, []byte{3}) {
+		t.Fatalf("%#v", w.rx)
+	}
+}
+
+//
+
+func newSPI(t *testing.T, w *spiWire, m spi.Mode) spi.Conn {
+	s, err := NewSPI(w.clk, w.mosi, w.miso, w.cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Connect(0, m, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Do not slow down the tests.
+	s.spiConn.halfCycle = 0
+	return c
+}
+
+// spiWire simulates a SPI device in the given mode, MSB first.
+//
+// It samples MOSI and shifts out tx on MISO on the clock edges as defined by
+// the mode, so the data is only correct if the master drives the lines at the
+// right time.
+type spiWire struct {
+	clk, mosi, miso, cs *spiPin
+	mode                spi.Mode
+	tx                  []byte // Bytes sent on MISO.
+	rx                  []byte // Bytes received on MOSI.
+
+	in      int // Bits received since the start.
+	out     int // Bits shifted out since CS was asserted.
+	cycles  int
+	selects int
+	errs    int // CS changed while the clock wasn't idle.
+}
+
+func newSPIWire(m spi.Mode) *spiWire {
+	w := &spiWire{mode: m}
+	w.clk = &spiPin{Pin: gpiotest.Pin{N: "CLK", Num: 1}, w: w}
+	w.mosi = &spiPin{Pin: gpiotest.Pin{N: "MOSI", Num: 2}, w: w}
+	w.miso = &spiPin{Pin: gpiotest.Pin{N: "MISO", Num: 3}, w: w}
+	w.cs = &spiPin{Pin: gpiotest.Pin{N: "CS", Num: 4}, w: w}
+	w.cs.L = gpio.High
+	w.clk.L = gpio.Level(m&spi.Mode2 != 0)
+	return w
+}
+
+func (w *spiWire) idle() gpio.Level {
+	return gpio.Level(w.mode&spi.Mode2 != 0)
+}
+
+// set is called when the master changes a line.
+func (w *spiWire) set(p *spiPin, l gpio.Level) {
+	prev := p.L
+	p.L = l
+	if prev == l {
+		return
+	}
+	switch p {
+	case w.cs:
+		if w.clk.L != w.idle() {
+			w.errs++
+		}
+		if l == gpio.Low {
+			w.selects++
+			w.out = 0
+		}
+	case w.clk:
+		if w.cs.L == gpio.High {
+			return
+		}
+		leading := l != w.idle()
+		if !leading {
+			w.cycles++
+		}
+		cpha := w.mode&spi.Mode1 != 0
+		if leading == cpha {
+			// Shift edge. With CPHA=0, the first bit is presented when CS is
+			// asserted and the next one on each trailing edge.
+			w.out++
+		} else {
+			// Sample edge.
+			if w.in%8 == 0 {
+				w.rx = append(w.rx, 0)
+			}
+			if w.mosi.L == gpio.High {
+				w.rx[w.in/8] |= 0x80 >> uint(w.in%8)
+			}
+			w.in++
+		}
+	}
+}
+
+// misoLevel is the bit being shifted out.
+func (w *spiWire) misoLevel() gpio.Level {
+	i := w.out
+	if w.mode&spi.Mode1 != 0 {
+		i--
+	}
+	if w.cs.L == gpio.High || i < 0 || i/8 >= len(w.tx) {
+		return gpio.Low
+	}
+	return gpio.Level(w.tx[i/8]&(0x80>>uint(i%8)) != 0)
+}
+
+// spiPin is one line of a spiWire.
+type spiPin struct {
+	gpiotest.Pin
+	w   *spiWire
+	err error
+}
+
+func (p *spiPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	return nil
+}
+
+func (p *spiPin) Out(l gpio.Level) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.w.set(p, l)
+	return nil
+}
+
+func (p *spiPin) Read() gpio.Level {
+	if p == p.w.miso {
+		return p.w.misoLevel()
+	}
+	return p.L
+}
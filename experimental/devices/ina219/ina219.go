@@ -157,13 +157,17 @@ func (d *Dev) calibrate(sense physic.ElectricResistance, maxCurrent physic.Elect
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	d.currentLSB = maxCurrent / (2 << 15)
-	d.powerLSB = physic.Power(d.currentLSB * 20)
+	currentLSB := maxCurrent / (2 << 15)
 	// Calibration Register = 0.04096 / (current LSB * Shunt Resistance)
 	// Where lsb is in Amps and resistance is in ohms.
 	// Calibration register is 16 bits.
-	cal := uint16(calibratescale / (int64(d.currentLSB) * int64(sense)))
-	return d.m.WriteUint16(calibrationRegister, cal)
+	div, ok := physic.MulDiv(int64(currentLSB), int64(sense), 1)
+	if !ok || div == 0 || div > calibratescale {
+		return errCalibrationOutOfRange
+	}
+	d.currentLSB = currentLSB
+	d.powerLSB = physic.Power(d.currentLSB * 20)
+	return d.m.WriteUint16(calibrationRegister, uint16(calibratescale/div))
 }
 
 // PowerMonitor represents measurements from ina219 sensor.
@@ -187,6 +191,7 @@ var (
 	errAddressOutOfRange         = errors.New("i2c address out of range")
 	errSenseResistorValueInvalid = errors.New("sense resistor value cannot be negative or zero")
 	errMaxCurrentInvalid         = errors.New("max current cannot be negative or zero")
+	errCalibrationOutOfRange     = errors.New("sense resistor and max current are out of the calibration range")
 	errRegisterOverflow          = errors.New("bus voltage register overflow")
 	errWritingToConfigRegister   = errors.New("failed to write to configuration register")
 )
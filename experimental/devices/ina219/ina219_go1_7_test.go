@@ -314,6 +314,22 @@ func TestCalibrate(t *testing.T) {
 			},
 			err: errMaxCurrentInvalid,
 		},
+		{
+			name: "errCalibrationLSBTooLow",
+			args: fields{
+				sense:      physic.MilliOhm,
+				maxCurrent: 10 * physic.MicroAmpere,
+			},
+			err: errCalibrationOutOfRange,
+		},
+		{
+			name: "errCalibrationOverflow",
+			args: fields{
+				sense:      physic.MegaOhm,
+				maxCurrent: 1000 * physic.Ampere,
+			},
+			err: errCalibrationOutOfRange,
+		},
 		{
 			name: "errIO",
 			args: fields{
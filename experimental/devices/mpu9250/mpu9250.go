@@ -15,6 +15,7 @@ import (
 	"math"
 	"time"
 
+	"periph.io/x/periph/conn/physic"
 	"periph.io/x/periph/experimental/devices/mpu9250/reg"
 )
 
@@ -50,6 +51,16 @@ type (
 		X, Y, Z int16
 	}
 
+	// ScaledAcceleration the acceleration along X/Y/Z axises.
+	ScaledAcceleration struct {
+		X, Y, Z physic.Acceleration
+	}
+
+	// ScaledRotation the angular velocity around X/Y/Z axises.
+	ScaledRotation struct {
+		X, Y, Z physic.AngularVelocity
+	}
+
 	// Deviation defines the standard deviation for major axises.
 	Deviation struct {
 		X, Y, Z float64
@@ -1816,6 +1827,54 @@ func (m *MPU9250) GetMotion6() (*AccelerometerData, *RotationData, error) {
 	return acc, rot, nil
 }
 
+// GetScaledAcceleration Get 3-axis accelerometer readings converted using the
+// current full-scale range, see GetAccelRange.
+func (m *MPU9250) GetScaledAcceleration() (*ScaledAcceleration, error) {
+	r, err := m.GetAccelRange()
+	if err != nil {
+		return nil, err
+	}
+	acc, err := m.GetAcceleration()
+	if err != nil {
+		return nil, err
+	}
+	return &ScaledAcceleration{X: scaleAcceleration(acc.X, r), Y: scaleAcceleration(acc.Y, r), Z: scaleAcceleration(acc.Z, r)}, nil
+}
+
+// GetScaledRotation Get 3-axis gyroscope readings converted using the current
+// full-scale range, see GetGyroRange.
+func (m *MPU9250) GetScaledRotation() (*ScaledRotation, error) {
+	r, err := m.GetGyroRange()
+	if err != nil {
+		return nil, err
+	}
+	rot, err := m.GetRotation()
+	if err != nil {
+		return nil, err
+	}
+	return &ScaledRotation{X: scaleRotation(rot.X, r), Y: scaleRotation(rot.Y, r), Z: scaleRotation(rot.Z, r)}, nil
+}
+
+// scaleAcceleration converts a raw accelerometer reading; the full scale is
+// ±2g << rangeVal.
+func scaleAcceleration(raw int16, rangeVal byte) physic.Acceleration {
+	return physic.Acceleration(roundDiv(int64(raw)*int64(physic.StandardGravity)<<(rangeVal+1), 32768))
+}
+
+// scaleRotation converts a raw gyroscope reading; the full scale is
+// ±250°/s << rangeVal.
+func scaleRotation(raw int16, rangeVal byte) physic.AngularVelocity {
+	return physic.AngularVelocity(roundDiv(int64(raw)*250*int64(physic.DegreePerSecond)<<rangeVal, 32768))
+}
+
+// roundDiv returns n/d rounded half away from zero, d must be positive.
+func roundDiv(n, d int64) int64 {
+	if n < 0 {
+		return -((-n + d/2) / d)
+	}
+	return (n + d/2) / d
+}
+
 //Temperature functions
 
 // EnableTemperature Enable internal temperature sensor.
@@ -0,0 +1,50 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package mpu9250
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestScaleAcceleration(t *testing.T) {
+	data := []struct {
+		raw      int16
+		rangeVal byte
+		expected physic.Acceleration
+	}{
+		{0, 0, 0},
+		{16384, 0, physic.StandardGravity},
+		{-16384, 0, -physic.StandardGravity},
+		{16384, 3, 8 * physic.StandardGravity},
+		{-32768, 3, -16 * physic.StandardGravity},
+		{1, 0, 598550 * physic.NanoMetrePerSecondSquared},
+	}
+	for i, line := range data {
+		if a := scaleAcceleration(line.raw, line.rangeVal); a != line.expected {
+			t.Fatalf("%d: scaleAcceleration(%d, %d) = %s != %s", i, line.raw, line.rangeVal, a, line.expected)
+		}
+	}
+}
+
+func TestScaleRotation(t *testing.T) {
+	data := []struct {
+		raw      int16
+		rangeVal byte
+		expected physic.AngularVelocity
+	}{
+		{0, 0, 0},
+		{16384, 0, 125 * physic.DegreePerSecond},
+		{-32768, 0, -250 * physic.DegreePerSecond},
+		{-32768, 3, -2000 * physic.DegreePerSecond},
+		{131, 0, 17443706 * physic.NanoRadianPerSecond},
+	}
+	for i, line := range data {
+		if a := scaleRotation(line.raw, line.rangeVal); a != line.expected {
+			t.Fatalf("%d: scaleRotation(%d, %d) = %s != %s", i, line.raw, line.rangeVal, a, line.expected)
+		}
+	}
+}
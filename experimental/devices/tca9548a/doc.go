@@ -0,0 +1,16 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package tca9548a controls a TI TCA9548A or NXP PCA9548A 8 channels I²C
+// multiplexer.
+//
+// Each channel is exposed as an i2c.Bus, so devices with the same address can
+// be used on different channels with their unmodified drivers.
+//
+// Datasheet
+//
+// http://www.ti.com/lit/ds/symlink/tca9548a.pdf
+//
+// https://www.nxp.com/docs/en/data-sheet/PCA9548A.pdf
+package tca9548a
@@ -0,0 +1,83 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package tca9548a_test
+
+import (
+	"fmt"
+	"log"
+
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/devices/bmxx80"
+	"periph.io/x/periph/experimental/devices/tca9548a"
+	"periph.io/x/periph/host"
+)
+
+func Example() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Open default I²C bus.
+	bus, err := i2creg.Open("")
+	if err != nil {
+		log.Fatalf("failed to open I²C: %v", err)
+	}
+	defer bus.Close()
+
+	mux, err := tca9548a.New(bus, &tca9548a.DefaultOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer mux.Halt()
+
+	// Two sensors with the same address, on the channels 0 and 1.
+	for i := 0; i < 2; i++ {
+		p, err := mux.Port(i)
+		if err != nil {
+			log.Fatal(err)
+		}
+		dev, err := bmxx80.NewI2C(p, 0x76, &bmxx80.DefaultOpts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		e := physic.Env{}
+		if err := dev.Sense(&e); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %8s %10s %9s\n", p, e.Temperature, e.Pressure, e.Humidity)
+	}
+}
+
+func ExampleDev_Register() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	bus, err := i2creg.Open("")
+	if err != nil {
+		log.Fatalf("failed to open I²C: %v", err)
+	}
+	defer bus.Close()
+
+	mux, err := tca9548a.New(bus, &tca9548a.Opts{Addr: tca9548a.I2CAddr, Name: "sensors"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := mux.Register(); err != nil {
+		log.Fatal(err)
+	}
+	defer mux.Unregister()
+
+	// The channel 3 can now be opened by name, e.g. from a command line flag.
+	p, err := i2creg.Open("mux-sensors-3")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer p.Close()
+	fmt.Println(p)
+}
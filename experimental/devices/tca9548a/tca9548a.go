@@ -0,0 +1,201 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package tca9548a
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/physic"
+)
+
+// I2CAddr is the default I²C address, with the pins A0, A1 and A2 low.
+const I2CAddr uint16 = 0x70
+
+// NumPorts is the number of channels of the multiplexer.
+const NumPorts = 8
+
+// Opts holds the configuration options.
+type Opts struct {
+	// Addr is the I²C address of the multiplexer, between 0x70 and 0x77.
+	Addr uint16
+	// Name is used to name the ports registered in i2creg by Register(). It
+	// defaults to the name of the parent bus.
+	Name string
+}
+
+// DefaultOpts is the recommended default options.
+var DefaultOpts = Opts{Addr: I2CAddr}
+
+// New returns a handle to a TCA9548A multiplexer on the bus.
+//
+// All the channels are disabled.
+func New(b i2c.Bus, opts *Opts) (*Dev, error) {
+	if opts.Addr < 0x70 || opts.Addr > 0x77 {
+		return nil, errors.New("tca9548a: given address not supported by device")
+	}
+	name := opts.Name
+	if name == "" {
+		name = b.String()
+	}
+	d := &Dev{c: i2c.Dev{Bus: b, Addr: opts.Addr}, name: name, cur: -1}
+	for i := range d.ports {
+		d.ports[i] = &Port{d: d, n: i}
+	}
+	if err := d.Halt(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Dev is a handle to a TCA9548A multiplexer.
+//
+// The transactions on all its ports are serialized, since only one channel
+// can be selected at a time.
+type Dev struct {
+	c    i2c.Dev
+	name string
+
+	mu    sync.Mutex
+	cur   int // Selected channel; -1 if none or unknown.
+	ports [NumPorts]*Port
+	regs  []string
+}
+
+func (d *Dev) String() string {
+	return fmt.Sprintf("TCA9548A{%s}", &d.c)
+}
+
+// Halt implements conn.Resource.
+//
+// It disables all the channels.
+func (d *Dev) Halt() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cur = -1
+	if err := d.c.Tx([]byte{0}, nil); err != nil {
+		return fmt.Errorf("tca9548a: %v", err)
+	}
+	return nil
+}
+
+// Port returns the bus behind channel n, between 0 and 7.
+//
+// Each Tx() on the returned bus selects the channel if it isn't already
+// selected, then does the transaction. Closing it is a no-op.
+func (d *Dev) Port(n int) (i2c.BusCloser, error) {
+	if n < 0 || n >= NumPorts {
+		return nil, fmt.Errorf("tca9548a: invalid port %d", n)
+	}
+	return d.ports[n], nil
+}
+
+// Register registers the ports in i2creg as "mux-<name>-<n>", where name is
+// Opts.Name, so they can be opened with i2creg.Open().
+func (d *Dev) Register() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.regs) != 0 {
+		return errors.New("tca9548a: ports are already registered")
+	}
+	for _, p := range d.ports {
+		p := p
+		name := p.String()
+		if err := i2creg.Register(name, nil, -1, func() (i2c.BusCloser, error) { return p, nil }); err != nil {
+			_ = d.unregister()
+			return fmt.Errorf("tca9548a: %v", err)
+		}
+		d.regs = append(d.regs, name)
+	}
+	return nil
+}
+
+// Unregister unregisters the ports registered by Register().
+func (d *Dev) Unregister() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.unregister(); err != nil {
+		return fmt.Errorf("tca9548a: %v", err)
+	}
+	return nil
+}
+
+//
+
+// tx selects the channel n then calls fn.
+func (d *Dev) tx(n int, fn func() error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cur != n {
+		if err := d.c.Tx([]byte{1 << uint(n)}, nil); err != nil {
+			d.cur = -1
+			return fmt.Errorf("tca9548a: %v", err)
+		}
+		d.cur = n
+	}
+	return fn()
+}
+
+func (d *Dev) unregister() error {
+	var err error
+	for _, name := range d.regs {
+		if err2 := i2creg.Unregister(name); err == nil {
+			err = err2
+		}
+	}
+	d.regs = nil
+	return err
+}
+
+// Port is a channel of the multiplexer.
+//
+// It implements i2c.BusCloser.
+type Port struct {
+	d *Dev
+	n int
+}
+
+func (p *Port) String() string {
+	return "mux-" + p.d.name + "-" + strconv.Itoa(p.n)
+}
+
+// Close implements i2c.BusCloser.
+//
+// It is a no-op; the port can still be used afterward.
+func (p *Port) Close() error {
+	return nil
+}
+
+// Tx implements i2c.Bus.
+func (p *Port) Tx(addr uint16, w, r []byte) error {
+	return p.d.tx(p.n, func() error {
+		return p.d.c.Bus.Tx(addr, w, r)
+	})
+}
+
+// SetSpeed implements i2c.Bus.
+//
+// It changes the speed of the parent bus, thus of all the channels.
+func (p *Port) SetSpeed(f physic.Frequency) error {
+	return p.d.c.Bus.SetSpeed(f)
+}
+
+// QuickWrite implements i2c.QuickWriter.
+func (p *Port) QuickWrite(addr uint16) error {
+	q, ok := p.d.c.Bus.(i2c.QuickWriter)
+	if !ok {
+		return fmt.Errorf("tca9548a: %w", i2c.ErrQuickWriteUnsupported)
+	}
+	return p.d.tx(p.n, func() error {
+		return q.QuickWrite(addr)
+	})
+}
+
+var _ i2c.BusCloser = &Port{}
+var _ i2c.QuickWriter = &Port{}
@@ -0,0 +1,291 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package tca9548a
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestNew(t *testing.T) {
+	b := i2ctest.Playback{Ops: []i2ctest.IO{{Addr: 0x70, W: []byte{0}}}}
+	d, err := New(&b, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != "TCA9548A{playback(112)}" {
+		t.Fatal(s)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(&b, &Opts{Addr: 0x40}); err == nil {
+		t.Fatal("invalid address")
+	}
+	b = i2ctest.Playback{DontPanic: true}
+	if _, err := New(&b, &DefaultOpts); err == nil {
+		t.Fatal("Tx failed")
+	}
+}
+
+func TestPort_Tx(t *testing.T) {
+	b := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x71, W: []byte{0}},
+			// Channel 2 is selected once.
+			{Addr: 0x71, W: []byte{0x04}},
+			{Addr: 0x40, W: []byte{1}, R: []byte{2}},
+			{Addr: 0x40, W: []byte{3}},
+			// Then channel 7.
+			{Addr: 0x71, W: []byte{0x80}},
+			{Addr: 0x40, W: []byte{4}},
+			{Addr: 0x71, W: []byte{0x04}},
+			{Addr: 0x40, W: []byte{5}},
+			// Halt() disables the channels, so the next Tx() selects again.
+			{Addr: 0x71, W: []byte{0}},
+			{Addr: 0x71, W: []byte{0x04}},
+			{Addr: 0x40, W: []byte{6}},
+		},
+	}
+	d, err := New(&b, &Opts{Addr: 0x71})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := d.Port(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p7, err := d.Port(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := p2.String(); s != "mux-playback-2" {
+		t.Fatal(s)
+	}
+	r := make([]byte, 1)
+	if err := p2.Tx(0x40, []byte{1}, r); err != nil || r[0] != 2 {
+		t.Fatal(r, err)
+	}
+	if err := p2.Tx(0x40, []byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p7.Tx(0x40, []byte{4}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2.Tx(0x40, []byte{5}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2.Tx(0x40, []byte{6}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2.SetSpeed(physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Port(8); err == nil {
+		t.Fatal("invalid port")
+	}
+}
+
+func TestPort_Tx_selectError(t *testing.T) {
+	b := &muxBus{failSelect: true}
+	d, err := New(b, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := d.Port(1)
+	if err := p.Tx(0x40, []byte{1}, nil); err == nil {
+		t.Fatal("select failed")
+	}
+	// The selection is retried on the next Tx().
+	b.failSelect = false
+	if err := p.Tx(0x40, []byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.selects != 3 {
+		t.Fatal(b.selects)
+	}
+}
+
+func TestPort_concurrent(t *testing.T) {
+	b := &muxBus{}
+	d, err := New(b, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, NumPorts)
+	for i := 0; i < NumPorts; i++ {
+		p, err := d.Port(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(i int, p i2c.Bus) {
+			defer wg.Done()
+			r := make([]byte, 1)
+			for j := 0; j < 100; j++ {
+				// The device on each channel replies with its channel number.
+				if err := p.Tx(0x40, nil, r); err != nil {
+					errs <- err
+					return
+				}
+				if r[0] != byte(i) {
+					errs <- errors.New("read from the wrong channel")
+					return
+				}
+			}
+		}(i, p)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestPort_QuickWrite(t *testing.T) {
+	b := &muxBus{}
+	d, err := New(b, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := d.Port(3)
+	if err := p.(i2c.QuickWriter).QuickWrite(0x40); err != nil {
+		t.Fatal(err)
+	}
+	if b.quick != 3 {
+		t.Fatal(b.quick)
+	}
+
+	pb := i2ctest.Playback{Ops: []i2ctest.IO{{Addr: 0x70, W: []byte{0}}}}
+	d, err = New(&pb, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ = d.Port(3)
+	if err := p.(i2c.QuickWriter).QuickWrite(0x40); !errors.Is(err, i2c.ErrQuickWriteUnsupported) {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_Register(t *testing.T) {
+	b := &muxBus{}
+	d, err := New(b, &Opts{Addr: I2CAddr, Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Register(); err != nil {
+		t.Fatal(err)
+	}
+	if d.Register() == nil {
+		t.Fatal("already registered")
+	}
+	p, err := i2creg.Open("mux-test-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := make([]byte, 1)
+	if err := p.Tx(0x40, nil, r); err != nil || r[0] != 5 {
+		t.Fatal(r, err)
+	}
+	// A second multiplexer with the same name conflicts.
+	d2, err := New(b, &Opts{Addr: 0x71, Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d2.Register() == nil {
+		t.Fatal("duplicate names")
+	}
+	if err := d.Unregister(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i2creg.Open("mux-test-5"); err == nil {
+		t.Fatal("unregistered")
+	}
+	// d2 cleaned up after its failure.
+	if err := d2.Register(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d2.Unregister(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+
+// muxBus simulates a multiplexer at 0x70 or 0x71 with a device at 0x40 on
+// each channel, that replies with its channel number.
+type muxBus struct {
+	mu         sync.Mutex
+	sel        byte
+	selects    int
+	quick      int
+	failSelect bool
+}
+
+func (m *muxBus) String() string {
+	return "mux"
+}
+
+func (m *muxBus) Tx(addr uint16, w, r []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch addr {
+	case 0x70, 0x71:
+		m.selects++
+		if m.failSelect && w[0] != 0 {
+			return errors.New("nack")
+		}
+		m.sel = w[0]
+		return nil
+	case 0x40:
+		n, err := m.channel()
+		if err != nil {
+			return err
+		}
+		if len(r) != 0 {
+			r[0] = n
+		}
+		return nil
+	default:
+		return errors.New("nack")
+	}
+}
+
+func (m *muxBus) SetSpeed(f physic.Frequency) error {
+	return nil
+}
+
+func (m *muxBus) QuickWrite(addr uint16) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, err := m.channel()
+	m.quick = int(n)
+	return err
+}
+
+func (m *muxBus) channel() (byte, error) {
+	for i := byte(0); i < NumPorts; i++ {
+		if m.sel == 1<<i {
+			return i, nil
+		}
+	}
+	return 0, errors.New("no channel selected")
+}
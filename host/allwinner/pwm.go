@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"periph.io/x/periph/conn/physic"
 )
 
 const pwmClock = 24000000
@@ -171,7 +173,7 @@ func toPeriod(total uint32, active uint16) pwmPeriod {
 func getBestPrescale(period time.Duration) pwmPrescale {
 	// TODO(maruel): Rewrite this function, it is incorrect.
 	for _, v := range prescalers {
-		p := time.Second / time.Duration(v.freq)
+		p := (physic.Frequency(v.freq) * physic.Hertz).Period()
 		smallest := (period / pwmMaxPeriod)
 		largest := (period / 2)
 		if p > smallest && p < largest {
@@ -5,6 +5,7 @@
 package bcm283x
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -196,6 +197,7 @@ type Pin struct {
 
 	// Mutable.
 	usingEdge  bool           // Set when edge detection is enabled.
+	pull       gpio.Pull      // Last pull set by In(), for the chips where it can't be read.
 	usingClock bool           // Set when a CLK, PWM or I2S/PCM clock is used.
 	dmaCh      *dmaChannel    // Set when DMA is used for PWM or I2S/PCM.
 	dmaBuf     *videocore.Mem // Set when DMA is used for PWM or I2S/PCM.
@@ -398,7 +400,21 @@ func (p *Pin) In(pull gpio.Pull, edge gpio.Edge) error {
 		return err
 	}
 	p.setFunction(in)
-	if pull != gpio.PullNoChange {
+	if pull != gpio.PullNoChange && drvGPIO.hasPullRegisters {
+		// BCM2711 has a register with 2 bits per pin. 0 is float, 1 is pull up
+		// and 2 is pull down.
+		var v uint32
+		switch pull {
+		case gpio.PullDown:
+			v = 2
+		case gpio.PullUp:
+			v = 1
+		}
+		off := p.number / 16
+		shift := uint(p.number%16) * 2
+		drvGPIO.gpioMemory.pullRegister[off] = (drvGPIO.gpioMemory.pullRegister[off] &^ (3 << shift)) | (v << shift)
+		p.pull = pull
+	} else if pull != gpio.PullNoChange {
 		// Changing pull resistor requires a specific dance as described at
 		// https://www.raspberrypi.org/wp-content/uploads/2012/02/BCM2835-ARM-Peripherals.pdf
 		// page 101.
@@ -421,6 +437,7 @@ func (p *Pin) In(pull gpio.Pull, edge gpio.Edge) error {
 		sleep150cycles()
 		drvGPIO.gpioMemory.pullEnable = 0
 		drvGPIO.gpioMemory.pullEnableClock[offset] = 0
+		p.pull = pull
 	}
 	if edge != gpio.NoEdge {
 		if p.sysfsPin == nil {
@@ -473,13 +490,36 @@ func (p *Pin) WaitForEdge(timeout time.Duration) bool {
 	return false
 }
 
+// WaitForEdgeCtx implements gpio.PinInCtx.
+func (p *Pin) WaitForEdgeCtx(ctx context.Context) error {
+	if p.sysfsPin != nil {
+		return p.sysfsPin.WaitForEdgeCtx(ctx)
+	}
+	return p.wrap(errors.New("edge detection not enabled"))
+}
+
 // Pull implements gpio.PinIn.
 //
-// bcm283x doesn't support querying the pull resistor of any GPIO pin.
+// On BCM2711 (Raspberry Pi 4), the pull resistor is read from the CPU
+// registers. The older chips don't support querying the pull resistor, so it
+// returns the last pull set with In() by this process, or gpio.PullNoChange
+// if none was.
 func (p *Pin) Pull() gpio.Pull {
-	// TODO(maruel): The best that could be added is to cache the last set value
-	// and return it.
-	return gpio.PullNoChange
+	if drvGPIO.gpioMemory == nil {
+		return gpio.PullNoChange
+	}
+	if drvGPIO.hasPullRegisters {
+		switch (drvGPIO.gpioMemory.pullRegister[p.number/16] >> (uint(p.number%16) * 2)) & 3 {
+		case 0:
+			return gpio.Float
+		case 1:
+			return gpio.PullUp
+		case 2:
+			return gpio.PullDown
+		}
+		return gpio.PullNoChange
+	}
+	return p.pull
 }
 
 // DefaultPull implements gpio.PinIn.
@@ -1044,6 +1084,13 @@ type gpioMap struct {
 	// 0xA0    -    Reserved
 	dummy uint32
 	// 0xB0    -    Test (byte)
+	dummy11 [16]uint32
+	// BCM2711 only:
+	// 0xE4    RW   GPIO Pull-up / Pull-down Register 0 (GPIO0-15)
+	// 0xE8    RW   GPIO Pull-up / Pull-down Register 1 (GPIO16-31)
+	// 0xEC    RW   GPIO Pull-up / Pull-down Register 2 (GPIO32-47)
+	// 0xF0    RW   GPIO Pull-up / Pull-down Register 3 (GPIO48-57)
+	pullRegister [4]uint32 // GPIO_PUP_PDN_CNTRL_REG0-3
 }
 
 // pad defines the settings for a GPIO pad group.
@@ -1188,6 +1235,9 @@ type driverGPIO struct {
 	gpioMemory *gpioMap
 	// gpioBaseAddr is needed for DMA transfers.
 	gpioBaseAddr uint32
+	// hasPullRegisters is set on BCM2711, where the pull resistors are set and
+	// read with pullRegister instead of pullEnable and pullEnableClock.
+	hasPullRegisters bool
 }
 
 func (d *driverGPIO) Close() {
@@ -1195,6 +1245,7 @@ func (d *driverGPIO) Close() {
 	d.dramBus = 0
 	d.gpioMemory = nil
 	d.gpioBaseAddr = 0
+	d.hasPullRegisters = false
 }
 
 func (d *driverGPIO) String() string {
@@ -1214,7 +1265,12 @@ func (d *driverGPIO) Init() (bool, error) {
 		return false, errors.New("bcm283x CPU not detected")
 	}
 	model := distro.CPUInfo()["model name"]
-	if strings.Contains(model, "ARMv6") {
+	if isBCM2711() {
+		// RPi4
+		d.baseAddr = 0xFE000000
+		d.dramBus = 0xC0000000
+		d.hasPullRegisters = true
+	} else if strings.Contains(model, "ARMv6") {
 		d.baseAddr = 0x20000000
 		d.dramBus = 0x40000000
 	} else {
@@ -1328,6 +1384,16 @@ func (d *driverGPIO) Init() (bool, error) {
 	return true, sysfs.I2CSetSpeedHook(setSpeed)
 }
 
+// isBCM2711 returns true if the device tree reports a BCM2711.
+func isBCM2711() bool {
+	for _, c := range distro.DTCompatible() {
+		if c == "brcm,bcm2711" {
+			return true
+		}
+	}
+	return false
+}
+
 func setSpeed(f physic.Frequency) error {
 	// Writing to "/sys/module/i2c_bcm2708/parameters/baudrate" was confirmed to
 	// not work.
@@ -1355,6 +1421,7 @@ var drvGPIO driverGPIO
 
 var _ gpio.PinIO = &Pin{}
 var _ gpio.PinIn = &Pin{}
+var _ gpio.PinInCtx = &Pin{}
 var _ gpio.PinOut = &Pin{}
 var _ gpiostream.PinIn = &Pin{}
 var _ gpiostream.PinOut = &Pin{}
@@ -7,6 +7,7 @@ package bcm283x
 import (
 	"reflect"
 	"testing"
+	"unsafe"
 
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/gpio/gpiostream"
@@ -161,6 +162,67 @@ func TestPin(t *testing.T) {
 	}
 }
 
+func TestPin_Pull(t *testing.T) {
+	defer setMemory()
+	drvGPIO.gpioMemory = &gpioMap{}
+	p := Pin{name: "Foo", number: 21, defaultPull: gpio.PullDown}
+	if d := p.Pull(); d != gpio.PullNoChange {
+		t.Fatal(d)
+	}
+	// The last value set is returned on the older chips.
+	for _, pull := range []gpio.Pull{gpio.PullUp, gpio.PullNoChange, gpio.PullDown, gpio.Float} {
+		if err := p.In(pull, gpio.NoEdge); err != nil {
+			t.Fatal(err)
+		}
+		if pull == gpio.PullNoChange {
+			pull = gpio.PullUp
+		}
+		if d := p.Pull(); d != pull {
+			t.Fatal(d)
+		}
+	}
+	if m := drvGPIO.gpioMemory.pullRegister; m != [4]uint32{} {
+		t.Fatal(m)
+	}
+
+	// BCM2711 registers are read.
+	drvGPIO.hasPullRegisters = true
+	defer func() {
+		drvGPIO.hasPullRegisters = false
+	}()
+	drvGPIO.gpioMemory.pullRegister[1] = 0xFFFFFFFF
+	if d := p.Pull(); d != gpio.PullNoChange {
+		t.Fatal(d)
+	}
+	data := []struct {
+		pull gpio.Pull
+		reg  uint32
+	}{
+		{gpio.PullUp, 0xFFFFF7FF},
+		{gpio.PullDown, 0xFFFFFBFF},
+		{gpio.Float, 0xFFFFF3FF},
+	}
+	for _, line := range data {
+		if err := p.In(line.pull, gpio.NoEdge); err != nil {
+			t.Fatal(err)
+		}
+		if r := drvGPIO.gpioMemory.pullRegister[1]; r != line.reg {
+			t.Fatalf("%s: %#x", line.pull, r)
+		}
+		if d := p.Pull(); d != line.pull {
+			t.Fatal(d)
+		}
+	}
+	// Set by the firmware.
+	drvGPIO.gpioMemory.pullRegister[1] = 1 << 10
+	if d := p.Pull(); d != gpio.PullUp {
+		t.Fatal(d)
+	}
+	if o := unsafe.Offsetof(gpioMap{}.pullRegister); o != 0xE4 {
+		t.Fatalf("%#x", o)
+	}
+}
+
 func TestPin_SetFunc_25(t *testing.T) {
 	p := Pin{name: "Foo", number: 25, defaultPull: gpio.PullDown}
 	p.setFunction(alt0)
@@ -0,0 +1,114 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bcm283x
+
+import (
+	"reflect"
+	"strings"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+)
+
+// NewGroup returns a gpio.Group of pins.
+//
+// When all the pins are bcm283x pins, gpioreg aliases included, and the GPIO
+// memory is mapped, Out() updates all the pins with one write to GPSET and one
+// write to GPCLR per bank, so the pins in the same bank change simultaneously.
+// Otherwise, e.g. when a pin is wrapped by gpioutil.Debounce(), it returns the
+// generic gpio.NewGroup() implementation so the wrapper is not bypassed.
+func NewGroup(pins ...gpio.PinIO) (gpio.Group, error) {
+	g, err := gpio.NewGroup(pins...)
+	if err != nil || drvGPIO.gpioMemory == nil {
+		return g, err
+	}
+	f := &group{Group: g, pins: make([]*Pin, len(pins))}
+	for i, p := range pins {
+		bp, ok := toPin(p)
+		if !ok {
+			return g, nil
+		}
+		f.pins[i] = bp
+		f.mask[bp.number/32] |= 1 << uint(bp.number&31)
+	}
+	return f, nil
+}
+
+//
+
+// toPin returns the *Pin p is, or is an alias of in gpioreg.
+//
+// Other gpio.RealPin are wrappers that alter the pin behavior, so they are not
+// resolved.
+func toPin(p gpio.PinIO) (*Pin, bool) {
+	if bp, ok := p.(*Pin); ok {
+		return bp, true
+	}
+	r, ok := p.(gpio.RealPin)
+	if !ok {
+		return nil, false
+	}
+	// gpioreg returns a new alias object on each lookup, so compare the type.
+	a, ok := gpioreg.ByName(p.Name()).(gpio.RealPin)
+	if !ok || reflect.TypeOf(a) != reflect.TypeOf(p) || a.Real() != r.Real() {
+		return nil, false
+	}
+	bp, ok := r.Real().(*Pin)
+	return bp, ok
+}
+
+// group is a gpio.Group of bcm283x pins that uses the GPSET and GPCLR
+// registers directly.
+type group struct {
+	gpio.Group
+	pins []*Pin
+	mask [2]uint32 // Pins in the group, per bank.
+}
+
+func (g *group) String() string {
+	return strings.Replace(g.Group.String(), "Group(", "bcm283x.Group(", 1)
+}
+
+// Out implements gpio.Group.
+//
+// The pins that are not yet set as output are set with Pin.Out() first, one
+// at a time.
+func (g *group) Out(bits gpio.GPIOValue) error {
+	var set [2]uint32
+	for i, p := range g.pins {
+		l := gpio.Level(bits&(1<<uint(i)) != 0)
+		if p.function() != out {
+			if err := p.Out(l); err != nil {
+				return err
+			}
+		}
+		if l {
+			set[p.number/32] |= 1 << uint(p.number&31)
+		}
+	}
+	for i := range g.mask {
+		if g.mask[i] != 0 {
+			drvGPIO.gpioMemory.outputSet[i] = set[i]
+			drvGPIO.gpioMemory.outputClear[i] = g.mask[i] &^ set[i]
+		}
+	}
+	return nil
+}
+
+// Read implements gpio.Group.
+//
+// It reads each bank once.
+func (g *group) Read() gpio.GPIOValue {
+	level := [2]uint32{drvGPIO.gpioMemory.level[0], drvGPIO.gpioMemory.level[1]}
+	var v gpio.GPIOValue
+	for i, p := range g.pins {
+		if level[p.number/32]&(1<<uint(p.number&31)) != 0 {
+			v |= 1 << uint(i)
+		}
+	}
+	return v
+}
+
+var _ gpio.Group = &group{}
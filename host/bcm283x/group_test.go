@@ -0,0 +1,106 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bcm283x
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/gpio/gpioutil"
+)
+
+func TestNewGroup(t *testing.T) {
+	defer setMemory()
+	drvGPIO.gpioMemory = &gpioMap{level: [2]uint32{1 << 5, 1 << 1}}
+	pins := []*Pin{
+		{name: "GPIO4", number: 4},
+		{name: "GPIO5", number: 5},
+		{name: "GPIO33", number: 33},
+	}
+	g, err := NewGroup(pins[0], pins[1], pins[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); s != "bcm283x.Group(GPIO4,GPIO5,GPIO33)" {
+		t.Fatal(s)
+	}
+	if v := g.Read(); v != 6 {
+		t.Fatal(v)
+	}
+	if err := g.Out(5); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range pins {
+		if f := p.function(); f != out {
+			t.Fatal(p, f)
+		}
+	}
+	m := drvGPIO.gpioMemory
+	if m.outputSet[0] != 1<<4 || m.outputClear[0] != 1<<5 {
+		t.Fatalf("%#x %#x", m.outputSet[0], m.outputClear[0])
+	}
+	if m.outputSet[1] != 1<<1 || m.outputClear[1] != 0 {
+		t.Fatalf("%#x %#x", m.outputSet[1], m.outputClear[1])
+	}
+}
+
+func TestNewGroup_fallback(t *testing.T) {
+	p := &gpiotest.Pin{N: "GPIO1"}
+	g, err := NewGroup(&Pin{name: "GPIO4", number: 4}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); s != "Group(GPIO4,GPIO1(0))" {
+		t.Fatal(s)
+	}
+	if err := g.Out(2); err != nil || p.L != gpio.High {
+		t.Fatal(err, p.L)
+	}
+	if _, err := NewGroup(); err == nil {
+		t.Fatal("empty group")
+	}
+}
+
+func TestNewGroup_alias(t *testing.T) {
+	defer setMemory()
+	drvGPIO.gpioMemory = &gpioMap{}
+	p := &Pin{name: "GPIO_GROUP_TEST", number: 6}
+	if err := gpioreg.Register(p); err != nil {
+		t.Fatal(err)
+	}
+	defer gpioreg.Unregister("GPIO_GROUP_TEST")
+	if err := gpioreg.RegisterAlias("GROUP_TEST_ALIAS", "GPIO_GROUP_TEST"); err != nil {
+		t.Fatal(err)
+	}
+	defer gpioreg.Unregister("GROUP_TEST_ALIAS")
+	g, err := NewGroup(gpioreg.ByName("GROUP_TEST_ALIAS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); s != "bcm283x.Group(GROUP_TEST_ALIAS(GPIO_GROUP_TEST))" {
+		t.Fatal(s)
+	}
+	if err := g.Out(1); err != nil {
+		t.Fatal(err)
+	}
+	if m := drvGPIO.gpioMemory; m.outputSet[0] != 1<<6 {
+		t.Fatalf("%#x", m.outputSet[0])
+	}
+}
+
+func TestNewGroup_wrapper(t *testing.T) {
+	defer setMemory()
+	drvGPIO.gpioMemory = &gpioMap{}
+	// The wrapper is not bypassed.
+	g, err := NewGroup(gpioutil.OpenDrain(&Pin{name: "GPIO4", number: 4}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); s != "Group(GPIO4)" {
+		t.Fatal(s)
+	}
+}
@@ -93,10 +93,25 @@ func (e *Event) MakeEvent(fd uintptr) error {
 }
 
 // Wait waits for an event or the specified amount of time.
+//
+// It returns 0 on timeout, on signal or when Wakeup() was called.
 func (e *Event) Wait(timeoutms int) (int, error) {
 	return e.event.wait(timeoutms)
 }
 
+// Wakeup causes the current or next Wait() call to return early.
+//
+// It is safe to call concurrently with Wait().
+func (e *Event) Wakeup() error {
+	return e.event.wakeup()
+}
+
+// Drain discards the pending Wakeup() calls, so the next Wait() call doesn't
+// return early because of them.
+func (e *Event) Drain() error {
+	return e.event.drain()
+}
+
 //
 
 var (
@@ -4,7 +4,10 @@
 
 package fs
 
-import "syscall"
+import (
+	"errors"
+	"syscall"
+)
 
 const isLinux = true
 
@@ -23,10 +26,15 @@ const (
 	epollCTLMod = 3
 )
 
+const epollIN = 1
+
 type event struct {
 	event   [1]syscall.EpollEvent
+	events  [2]syscall.EpollEvent
 	epollFd int
 	fd      int
+	// wake is a pipe in the epoll set to wake up wait().
+	wake [2]int
 }
 
 // makeEvent creates an epoll *edge* triggered event.
@@ -40,18 +48,80 @@ func (e *event) makeEvent(fd uintptr) error {
 	if err != nil {
 		return err
 	}
-	e.epollFd = epollFd
-	e.fd = int(fd)
+	var wake [2]int
+	if err := syscall.Pipe2(wake[:], syscall.O_NONBLOCK|syscall.O_CLOEXEC); err != nil {
+		syscall.Close(epollFd)
+		return err
+	}
+	// Level triggered, the pipe is drained in wait().
+	w := syscall.EpollEvent{Events: epollIN, Fd: int32(wake[0])}
+	if err := syscall.EpollCtl(epollFd, epollCTLAdd, wake[0], &w); err != nil {
+		closeAll(epollFd, wake[0], wake[1])
+		return err
+	}
 	// EPOLLWAKEUP could be used to force the system to not go do sleep while
 	// waiting for an edge. This is generally a bad idea, as we'd instead have
 	// the system to *wake up* when an edge is triggered. Achieving this is
 	// outside the scope of this interface.
 	e.event[0].Events = epollPRI | epollET
-	e.event[0].Fd = int32(e.fd)
-	return syscall.EpollCtl(e.epollFd, epollCTLAdd, e.fd, &e.event[0])
+	e.event[0].Fd = int32(fd)
+	if err := syscall.EpollCtl(epollFd, epollCTLAdd, int(fd), &e.event[0]); err != nil {
+		closeAll(epollFd, wake[0], wake[1])
+		return err
+	}
+	e.epollFd = epollFd
+	e.fd = int(fd)
+	e.wake = wake
+	return nil
 }
 
 func (e *event) wait(timeoutms int) (int, error) {
 	// http://man7.org/linux/man-pages/man2/epoll_wait.2.html
-	return syscall.EpollWait(e.epollFd, e.event[:], timeoutms)
+	n, err := syscall.EpollWait(e.epollFd, e.events[:], timeoutms)
+	if err != nil {
+		return 0, err
+	}
+	// Only count the events on fd.
+	nr := 0
+	for i := 0; i < n; i++ {
+		if int(e.events[i].Fd) == e.fd {
+			nr++
+			continue
+		}
+		e.drain()
+	}
+	return nr, nil
+}
+
+func (e *event) wakeup() error {
+	if e.wake[1] == 0 {
+		return errors.New("fs: event is not initialized")
+	}
+	_, err := syscall.Write(e.wake[1], []byte{0})
+	if err == syscall.EAGAIN {
+		// The pipe is full, so wait() will wake up anyway.
+		return nil
+	}
+	return err
+}
+
+func (e *event) drain() error {
+	if e.wake[0] == 0 {
+		return errors.New("fs: event is not initialized")
+	}
+	var b [16]byte
+	for {
+		if c, _ := syscall.Read(e.wake[0], b[:]); c <= 0 {
+			return nil
+		}
+	}
+}
+
+//
+
+// closeAll closes the file descriptors, ignoring errors.
+func closeAll(fds ...int) {
+	for _, fd := range fds {
+		syscall.Close(fd)
+	}
 }
@@ -23,3 +23,11 @@ func (e *event) makeEvent(f uintptr) error {
 func (e *event) wait(timeoutms int) (int, error) {
 	return 0, errors.New("fs: unreachable code")
 }
+
+func (e *event) wakeup() error {
+	return errors.New("fs: unreachable code")
+}
+
+func (e *event) drain() error {
+	return errors.New("fs: unreachable code")
+}
@@ -4,7 +4,12 @@
 
 package fs
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
 
 func TestTranslateOpMIPS(t *testing.T) {
 	// input, expected
@@ -40,3 +45,104 @@ func TestTranslateOpMIPS_Error(t *testing.T) {
 		t.Fatal("dir")
 	}
 }
+
+func TestEvent_Wakeup(t *testing.T) {
+	var e Event
+	if e.Wakeup() == nil {
+		t.Fatal("not initialized")
+	}
+	if !isLinux {
+		return
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if err := e.MakeEvent(r.Fd()); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Wakeup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Wakeup(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := e.Wait(-1); n != 0 || err != nil {
+		t.Fatal(n, err)
+	}
+	// The wake ups were consumed.
+	if n, err := e.Wait(0); n != 0 || err != nil {
+		t.Fatal(n, err)
+	}
+}
+
+func TestEvent_Drain(t *testing.T) {
+	var e Event
+	if e.Drain() == nil {
+		t.Fatal("not initialized")
+	}
+	if !isLinux {
+		return
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if err := e.MakeEvent(r.Fd()); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Wakeup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Drain(); err != nil {
+		t.Fatal(err)
+	}
+	// Wait() times out instead of returning early.
+	start := time.Now()
+	if n, err := e.Wait(10); n != 0 || err != nil {
+		t.Fatal(n, err)
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Fatal(d)
+	}
+}
+
+func TestEvent_MakeEvent_err(t *testing.T) {
+	if !isLinux {
+		return
+	}
+	// Regular files don't support epoll.
+	f, err := ioutil.TempFile("", "periph_fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	before := openFds(t)
+	var e Event
+	if e.MakeEvent(f.Fd()) == nil {
+		t.Fatal("epoll is not supported on regular files")
+	}
+	// The epoll and wake up pipe file descriptors were closed.
+	if after := openFds(t); after != before {
+		t.Fatal(before, after)
+	}
+	if e.Wakeup() == nil {
+		t.Fatal("not initialized")
+	}
+}
+
+//
+
+// openFds returns the number of file descriptors opened by the process.
+func openFds(t *testing.T) int {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(fds)
+}
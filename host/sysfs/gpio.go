@@ -5,6 +5,7 @@
 package sysfs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -248,6 +249,47 @@ func (p *Pin) WaitForEdge(timeout time.Duration) bool {
 	}
 }
 
+// WaitForEdgeCtx implements gpio.PinInCtx.
+//
+// The wait is interrupted as soon as ctx is done.
+func (p *Pin) WaitForEdgeCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("sysfs-gpio (%s): %w", p, err)
+	}
+	if done := ctx.Done(); done != nil {
+		stop := make(chan struct{})
+		woke := make(chan bool)
+		go func() {
+			select {
+			case <-done:
+				_ = p.event.Wakeup()
+				woke <- true
+			case <-stop:
+				woke <- false
+			}
+		}()
+		defer func() {
+			// Wait for the goroutine so it can't call Wakeup() once this function
+			// returned, and discard the wake up if Wait() didn't consume it.
+			close(stop)
+			if <-woke {
+				_ = p.event.Drain()
+			}
+		}()
+	}
+	for {
+		if nr, err := p.event.Wait(-1); err != nil {
+			return p.wrap(err)
+		} else if nr == 1 {
+			return nil
+		}
+		// A signal occurred or ctx is done.
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("sysfs-gpio (%s): %w", p, err)
+		}
+	}
+}
+
 // Pull implements gpio.PinIn.
 //
 // It returns gpio.PullNoChange since gpio sysfs has no support for input pull
@@ -510,6 +552,7 @@ var drvGPIO driverGPIO
 
 var _ conn.Resource = &Pin{}
 var _ gpio.PinIn = &Pin{}
+var _ gpio.PinInCtx = &Pin{}
 var _ gpio.PinOut = &Pin{}
 var _ gpio.PinIO = &Pin{}
 var _ pin.PinFunc = &Pin{}
@@ -5,8 +5,11 @@
 package sysfs
 
 import (
+	"context"
 	"errors"
+	"os"
 	"testing"
+	"time"
 
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/physic"
@@ -138,6 +141,44 @@ func TestPin_WaitForEdges(t *testing.T) {
 	}
 }
 
+func TestPin_WaitForEdgeCtx(t *testing.T) {
+	p := Pin{number: 42, name: "foo", root: "/tmp/gpio/priv/"}
+	if err := p.WaitForEdgeCtx(context.Background()); err == nil {
+		t.Fatal("broken pin doesn't have edge triggered")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.WaitForEdgeCtx(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if !isLinux {
+		return
+	}
+	// Use a pipe in place of the value file, which never has an edge.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if err := p.event.MakeEvent(r.Fd()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.WaitForEdgeCtx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	// No wake up is left pending for the next wait.
+	start := time.Now()
+	if p.WaitForEdge(10 * time.Millisecond) {
+		t.Fatal("unexpected edge")
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Fatal(d)
+	}
+}
+
 func TestPin_Pull(t *testing.T) {
 	p := Pin{number: 42, name: "foo", root: "/tmp/gpio/priv/"}
 	if pull := p.Pull(); pull != gpio.PullNoChange {
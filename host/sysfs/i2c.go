@@ -5,8 +5,10 @@
 package sysfs
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -16,10 +18,12 @@ import (
 	"unsafe"
 
 	"periph.io/x/periph"
+	"periph.io/x/periph/conn"
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/gpio/gpioreg"
 	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/smbus"
 	"periph.io/x/periph/conn/physic"
 )
 
@@ -89,13 +93,98 @@ func (i *I2C) String() string {
 }
 
 // Tx execute a transaction as a single operation unit.
+//
+// A 10 bits address is supported when the adapter reports the 10BIT_ADDR
+// functionality.
 func (i *I2C) Tx(addr uint16, w, r []byte) error {
-	if addr >= 0x400 || (addr >= 0x80 && i.fn&func10BitAddr == 0) {
-		return errors.New("sysfs-i2c: invalid address")
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.tx(addr, w, r)
+}
+
+// TxSpeed implements i2c.SpeedTxer.
+//
+// The speed can't be switched around each transaction, since it applies to
+// all the buses of the host; see SetSpeed(). Instead, the bus speed is lowered
+// to the lowest maxSpeed received so far on any bus, as reported by
+// SpeedLimit().
+func (i *I2C) TxSpeed(addr uint16, maxSpeed physic.Frequency, w, r []byte) error {
+	if maxSpeed <= 0 {
+		return fmt.Errorf("sysfs-i2c: invalid speed %s", maxSpeed)
+	}
+	if err := drvI2C.lowerSpeed(maxSpeed); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.tx(addr, w, r)
+}
+
+// SpeedLimit returns the lowest maxSpeed passed to TxSpeed() on any bus, or 0
+// if none.
+//
+// SetSpeed() doesn't set a speed above this limit. The limit is shared by all
+// the buses since the speed hook applies to all of them.
+func (i *I2C) SpeedLimit() physic.Frequency {
+	drvI2C.mu.Lock()
+	defer drvI2C.mu.Unlock()
+	return drvI2C.limit
+}
+
+// TxCtx implements i2c.BusCtx.
+//
+// An ioctl in flight can't be interrupted, so the transaction runs in its own
+// goroutine on a copy of w and r. When ctx is done, TxCtx returns immediately
+// and the bus stays busy until the driver completes the transaction or its
+// own timeout expires.
+//
+// The adapter timeout is not modified, since it is shared by all the users of
+// the adapter and the kernel provides no way to read it back.
+func (i *I2C) TxCtx(ctx context.Context, addr uint16, w, r []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("sysfs-i2c: %w", err)
+	}
+	if ctx.Done() == nil {
+		// ctx can't be canceled.
+		return i.Tx(addr, w, r)
+	}
+	wc := append([]byte(nil), w...)
+	rc := make([]byte, len(r))
+	done := make(chan error, 1)
+	go func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		if err := ctx.Err(); err != nil {
+			done <- fmt.Errorf("sysfs-i2c: %w", err)
+			return
+		}
+		done <- i.tx(addr, wc, rc)
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			copy(r, rc)
+		}
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("sysfs-i2c: %w", ctx.Err())
+	}
+}
+
+// tx does the transaction.
+//
+// Must be called with mu held.
+func (i *I2C) tx(addr uint16, w, r []byte) error {
+	addr, flags, err := i.addr(addr)
+	if err != nil {
+		return err
 	}
 	if len(w) == 0 && len(r) == 0 {
 		return nil
 	}
+	if len(w) > i2cMaxMsgLen || len(r) > i2cMaxMsgLen {
+		return fmt.Errorf("sysfs-i2c: maximum transfer length is %d bytes, got %d bytes write and %d bytes read", i2cMaxMsgLen, len(w), len(r))
+	}
 
 	// Convert the messages to the internal format.
 	var buf [2]i2cMsg
@@ -103,6 +192,7 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 	if len(w) != 0 {
 		msgs = buf[:1]
 		buf[0].addr = addr
+		buf[0].flags = flags
 		buf[0].length = uint16(len(w))
 		buf[0].buf = uintptr(unsafe.Pointer(&w[0]))
 	}
@@ -110,7 +200,7 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 		l := len(msgs)
 		msgs = msgs[:l+1] // extend the slice by one
 		buf[l].addr = addr
-		buf[l].flags = flagRD
+		buf[l].flags = flags | flagRD
 		buf[l].length = uint16(len(r))
 		buf[l].buf = uintptr(unsafe.Pointer(&r[0]))
 	}
@@ -119,15 +209,138 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 		nmsgs: uint32(len(msgs)),
 	}
 	pp := uintptr(unsafe.Pointer(&p))
+	if err := i.f.Ioctl(ioctlRdwr, pp); err != nil {
+		return fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	return nil
+}
+
+// MaxTxSize implements conn.Limits.
+//
+// It is the maximum size of each of the write and the read of a transaction
+// accepted by the kernel. The limits of the adapter itself, if any, are not
+// exposed to userland.
+func (i *I2C) MaxTxSize() int {
+	return i2cMaxMsgLen
+}
+
+// QuickWrite implements i2c.QuickWriter.
+//
+// It sends a zero length write message, which is supported when the adapter
+// reports the SMBus quick command functionality.
+func (i *I2C) QuickWrite(addr uint16) error {
+	addr, flags, err := i.addr(addr)
+	if err != nil {
+		return err
+	}
+	if i.fn&funcSMBusQuick == 0 {
+		return fmt.Errorf("sysfs-i2c: %w", i2c.ErrQuickWriteUnsupported)
+	}
+	msg := i2cMsg{addr: addr, flags: flags}
+	p := rdwrIoctlData{msgs: uintptr(unsafe.Pointer(&msg)), nmsgs: 1}
 	i.mu.Lock()
 	defer i.mu.Unlock()
-	if err := i.f.Ioctl(ioctlRdwr, pp); err != nil {
+	if err := i.f.Ioctl(ioctlRdwr, uintptr(unsafe.Pointer(&p))); err != nil {
 		return fmt.Errorf("sysfs-i2c: %v", err)
 	}
 	return nil
 }
 
+// SMBusTx implements smbus.Bus.
+//
+// It uses the kernel SMBus API, which the kernel emulates over I²C when the
+// adapter doesn't support SMBus natively. 10 bits addresses are not
+// supported.
+func (i *I2C) SMBusTx(addr uint16, p smbus.Protocol, cmd byte, pec bool, w, r []byte) (int, error) {
+	if addr&i2c.TenBit != 0 {
+		return 0, fmt.Errorf("sysfs-i2c: 10 bits address: %w", i2c.ErrNotSupported)
+	}
+	if err := i2c.CheckAddr(addr); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %w", err)
+	}
+	// A process call is a write that returns data.
+	d := smbusIoctlData{readWrite: smbusWrite, command: cmd}
+	read := len(w) == 0 && p != smbus.ProcessCall
+	if read {
+		d.readWrite = smbusRead
+	}
+	var fn functionality
+	switch p {
+	case smbus.ByteData:
+		d.size, fn = smbusByteData, funcSMBusWriteByteData
+		if read {
+			fn = funcSMBusReadByteData
+		}
+	case smbus.WordData:
+		d.size, fn = smbusWordData, funcSMBusWriteWordData
+		if read {
+			fn = funcSMBusReadWordData
+		}
+	case smbus.ProcessCall:
+		d.size, fn = smbusProcCall, funcSMBusProcCall
+	case smbus.BlockData:
+		d.size, fn = smbusBlockData, funcSMBusWriteBlockData
+		if read {
+			fn = funcSMBusReadBlockData
+		}
+	default:
+		return 0, fmt.Errorf("sysfs-i2c: smbus %s: %w", p, i2c.ErrNotSupported)
+	}
+	if i.fn&fn == 0 || (pec && i.fn&funcSMBusPEC == 0) {
+		return 0, fmt.Errorf("sysfs-i2c: smbus %s: %w", p, i2c.ErrNotSupported)
+	}
+	var buf [smbus.BlockMax + 2]byte
+	d.data = uintptr(unsafe.Pointer(&buf[0]))
+	switch p {
+	case smbus.ByteData:
+		if len(w) != 0 {
+			buf[0] = w[0]
+		}
+	case smbus.WordData, smbus.ProcessCall:
+		if len(w) >= 2 {
+			// The word is in native endianness.
+			*(*uint16)(unsafe.Pointer(&buf[0])) = uint16(w[0]) | uint16(w[1])<<8
+		}
+	case smbus.BlockData:
+		buf[0] = byte(copy(buf[1:1+smbus.BlockMax], w))
+	}
+	var usePEC uintptr
+	if pec {
+		usePEC = 1
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := i.f.Ioctl(ioctlSlave, uintptr(addr)); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	if err := i.f.Ioctl(ioctlPEC, usePEC); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	if err := i.f.Ioctl(ioctlSMBus, uintptr(unsafe.Pointer(&d))); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	if len(r) == 0 {
+		return 0, nil
+	}
+	switch p {
+	case smbus.ByteData:
+		r[0] = buf[0]
+		return 1, nil
+	case smbus.WordData, smbus.ProcessCall:
+		v := *(*uint16)(unsafe.Pointer(&buf[0]))
+		return copy(r, []byte{byte(v), byte(v >> 8)}), nil
+	default:
+		n := int(buf[0])
+		if n > smbus.BlockMax {
+			return 0, fmt.Errorf("sysfs-i2c: invalid smbus block length %d", n)
+		}
+		return copy(r, buf[1:1+n]), nil
+	}
+}
+
 // SetSpeed implements i2c.Bus.
+//
+// f is lowered to SpeedLimit() if needed.
 func (i *I2C) SetSpeed(f physic.Frequency) error {
 	if f > 100*physic.MegaHertz {
 		return fmt.Errorf("sysfs-i2c: invalid speed %s; maximum supported clock is 100MHz", f)
@@ -137,12 +350,32 @@ func (i *I2C) SetSpeed(f physic.Frequency) error {
 	}
 	drvI2C.mu.Lock()
 	defer drvI2C.mu.Unlock()
+	if l := drvI2C.limit; l != 0 && f > l {
+		f = l
+	}
 	if drvI2C.setSpeed != nil {
 		return drvI2C.setSpeed(f)
 	}
 	return errors.New("sysfs-i2c: not supported")
 }
 
+// Recover implements i2c.Recoverer.
+//
+// It runs the bus recovery sequence with i2c.RecoverPins() on the pins of
+// the bus, which must be known to the GPIO drivers.
+func (i *I2C) Recover() error {
+	i.initPins()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.scl == gpio.INVALID || i.sda == gpio.INVALID {
+		return fmt.Errorf("sysfs-i2c: recovery: pins are unknown: %w", i2c.ErrNotSupported)
+	}
+	if err := i2c.RecoverPins(i.scl, i.sda); err != nil {
+		return fmt.Errorf("sysfs-i2c: %w", err)
+	}
+	return nil
+}
+
 // SCL implements i2c.Pins.
 func (i *I2C) SCL() gpio.PinIO {
 	i.initPins()
@@ -184,6 +417,21 @@ func newI2C(busNumber int) (*I2C, error) {
 	return i, nil
 }
 
+// addr validates addr and returns the address and the flags to use in an
+// i2cMsg.
+func (i *I2C) addr(addr uint16) (uint16, uint16, error) {
+	if err := i2c.CheckAddr(addr); err != nil {
+		return 0, 0, fmt.Errorf("sysfs-i2c: %w", err)
+	}
+	if addr&i2c.TenBit == 0 {
+		return addr, 0, nil
+	}
+	if i.fn&func10BitAddr == 0 {
+		return 0, 0, fmt.Errorf("sysfs-i2c: 10 bits address: %w", i2c.ErrNotSupported)
+	}
+	return addr &^ i2c.TenBit, flagTEN, nil
+}
+
 func (i *I2C) initPins() {
 	i.mu.Lock()
 	if i.scl == nil {
@@ -208,6 +456,22 @@ const (
 	ioctlTenBits = 0x704 // TODO(maruel): Expose this but the header says it's broken (!?)
 	ioctlFuncs   = 0x705
 	ioctlRdwr    = 0x707
+	ioctlPEC     = 0x708
+	ioctlSMBus   = 0x720
+)
+
+// smbusIoctlData.readWrite values.
+const (
+	smbusWrite = 0
+	smbusRead  = 1
+)
+
+// smbusIoctlData.size values.
+const (
+	smbusByteData  = 2
+	smbusWordData  = 3
+	smbusProcCall  = 4
+	smbusBlockData = 5
 )
 
 // flags
@@ -310,6 +574,17 @@ type rdwrIoctlData struct {
 	nmsgs uint32
 }
 
+type smbusIoctlData struct {
+	readWrite uint8
+	command   uint8
+	size      uint32
+	data      uintptr // Pointer to a 34 bytes buffer
+}
+
+// i2cMaxMsgLen is the maximum length of an i2cMsg accepted by the kernel for
+// ioctlRdwr.
+const i2cMaxMsgLen = 8192
+
 type i2cMsg struct {
 	addr   uint16 // Address to communicate with
 	flags  uint16 // 1 for read, see i2c.h for more details
@@ -324,6 +599,7 @@ type driverI2C struct {
 	mu       sync.Mutex
 	buses    []string
 	setSpeed func(f physic.Frequency) error
+	limit    physic.Frequency // Lowest maxSpeed passed to TxSpeed() on any bus.
 }
 
 func (d *driverI2C) String() string {
@@ -351,14 +627,19 @@ func (d *driverI2C) Init() (bool, error) {
 	}
 	// Make sure they are registered in order.
 	sort.Strings(items)
+	var buses []int
 	for _, item := range items {
-		bus, err := strconv.Atoi(item[len(prefix):])
-		if err != nil {
-			continue
+		if bus, err := strconv.Atoi(item[len(prefix):]); err == nil {
+			buses = append(buses, bus)
 		}
+	}
+	// The bus numbers may change across kernel versions, the device tree names
+	// don't.
+	dt := dtI2CNames("/", buses)
+	for _, bus := range buses {
 		name := fmt.Sprintf("/dev/i2c-%d", bus)
 		d.buses = append(d.buses, name)
-		aliases := []string{fmt.Sprintf("I2C%d", bus)}
+		aliases := append([]string{fmt.Sprintf("I2C%d", bus)}, dt[bus]...)
 		if err := i2creg.Register(name, aliases, bus, openerI2C(bus).Open); err != nil {
 			return true, err
 		}
@@ -366,6 +647,70 @@ func (d *driverI2C) Init() (bool, error) {
 	return true, nil
 }
 
+// lowerSpeed lowers the speed of all the buses to f, unless it is already
+// at or below f.
+func (d *driverI2C) lowerSpeed(f physic.Frequency) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.limit != 0 && f >= d.limit {
+		return nil
+	}
+	// Without a hook the speed is unknown; it is usually 100kHz.
+	if d.setSpeed != nil {
+		if err := d.setSpeed(f); err != nil {
+			return err
+		}
+	}
+	d.limit = f
+	return nil
+}
+
+// dtI2CNames returns the names of the I²C buses in the device tree: the node
+// name, e.g. "i2c@7e804000", and the OF aliases of the node, e.g. "i2c1".
+//
+// Names that are shared by multiple buses are skipped. root is "/" except in
+// unit tests.
+func dtI2CNames(root string, buses []int) map[int][]string {
+	base, err := filepath.EvalSymlinks(filepath.Join(root, "sys/firmware/devicetree/base"))
+	if err != nil {
+		return nil
+	}
+	// Each alias file contains the path of a node, NUL terminated.
+	ofAliases := map[string][]string{}
+	items, _ := filepath.Glob(filepath.Join(base, "aliases", "i2c*"))
+	for _, item := range items {
+		b, err := ioutil.ReadFile(item)
+		if err != nil {
+			continue
+		}
+		node := strings.TrimRight(string(b), "\x00")
+		ofAliases[node] = append(ofAliases[node], filepath.Base(item))
+	}
+	all := map[int][]string{}
+	count := map[string]int{}
+	for _, bus := range buses {
+		p, err := filepath.EvalSymlinks(filepath.Join(root, "sys/bus/i2c/devices", "i2c-"+strconv.Itoa(bus), "of_node"))
+		if err != nil || !strings.HasPrefix(p, base+"/") {
+			continue
+		}
+		node := p[len(base):]
+		names := append([]string{filepath.Base(node)}, ofAliases[node]...)
+		for _, n := range names {
+			count[n]++
+		}
+		all[bus] = names
+	}
+	out := map[int][]string{}
+	for bus, names := range all {
+		for _, n := range names {
+			if count[n] == 1 && !strings.Contains(n, ":") {
+				out[bus] = append(out[bus], n)
+			}
+		}
+	}
+	return out
+}
+
 type openerI2C int
 
 func (o openerI2C) Open() (i2c.BusCloser, error) {
@@ -386,3 +731,7 @@ var drvI2C driverI2C
 
 var _ i2c.Bus = &I2C{}
 var _ i2c.BusCloser = &I2C{}
+var _ conn.Limits = &I2C{}
+var _ i2c.QuickWriter = &I2C{}
+var _ smbus.Bus = &I2C{}
+var _ i2c.Recoverer = &I2C{}
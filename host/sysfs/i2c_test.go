@@ -5,9 +5,19 @@
 package sysfs
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
+	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/smbus"
 	"periph.io/x/periph/conn/physic"
 )
 
@@ -38,6 +48,38 @@ func TestI2C_faked(t *testing.T) {
 	if err := bus.Tx(1, []byte{0}, []byte{0}); err != nil {
 		t.Fatal(err)
 	}
+	if bus.Tx(0x80, []byte{0}, nil) == nil {
+		t.Fatal("0x80 requires TenBit")
+	}
+	if err := bus.Tx(0x80|i2c.TenBit, []byte{0}, nil); !errors.Is(err, i2c.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	if v := bus.MaxTxSize(); v != 8192 {
+		t.Fatal(v)
+	}
+	if bus.Tx(1, make([]byte, 8193), nil) == nil {
+		t.Fatal("too large")
+	}
+	if err := bus.QuickWrite(1); !errors.Is(err, i2c.ErrQuickWriteUnsupported) {
+		t.Fatal(err)
+	}
+	bus.fn = funcSMBusQuick
+	if err := bus.QuickWrite(0x80 | i2c.TenBit); !errors.Is(err, i2c.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	bus.fn |= func10BitAddr
+	if err := bus.Tx(0x3FF|i2c.TenBit, []byte{0}, []byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.QuickWrite(0x80 | i2c.TenBit); err != nil {
+		t.Fatal(err)
+	}
+	if bus.QuickWrite(0x401) == nil {
+		t.Fatal("invalid address")
+	}
+	if err := bus.QuickWrite(1); err != nil {
+		t.Fatal(err)
+	}
 	if bus.SetSpeed(0) == nil {
 		t.Fatal("0 is invalid")
 	}
@@ -46,11 +88,190 @@ func TestI2C_faked(t *testing.T) {
 	}
 	bus.SCL()
 	bus.SDA()
+	if err := bus.Recover(); !errors.Is(err, i2c.ErrNotSupported) {
+		t.Fatal(err)
+	}
 	if err := bus.Close(); err != nil {
 		t.Fatal(err)
 	}
 }
 
+func TestI2C_SMBusTx(t *testing.T) {
+	bus := I2C{f: &ioctlClose{}, busNumber: 24}
+	if _, err := bus.SMBusTx(0x0B, smbus.ByteData, 0x10, false, []byte{0x42}, nil); !errors.Is(err, i2c.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	bus.fn = functionality(0xFFFFFFFF)
+	if _, err := bus.SMBusTx(0x0B, smbus.ByteData, 0x10, true, []byte{0x42}, nil); err != nil {
+		t.Fatal(err)
+	}
+	var r [smbus.BlockMax]byte
+	if n, err := bus.SMBusTx(0x0B, smbus.WordData, 0x10, false, nil, r[:2]); n != 2 || err != nil {
+		t.Fatal(n, err)
+	}
+	if n, err := bus.SMBusTx(0x0B, smbus.ProcessCall, 0x10, false, []byte{1, 2}, r[:2]); n != 2 || err != nil {
+		t.Fatal(n, err)
+	}
+	if n, err := bus.SMBusTx(0x0B, smbus.BlockData, 0x10, false, nil, r[:]); n != 0 || err != nil {
+		t.Fatal(n, err)
+	}
+	if _, err := bus.SMBusTx(0x0B|i2c.TenBit, smbus.ByteData, 0x10, false, []byte{0x42}, nil); !errors.Is(err, i2c.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	if _, err := bus.SMBusTx(0x0B, smbus.Protocol(0), 0x10, false, []byte{0x42}, nil); !errors.Is(err, i2c.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	bus.f = &ioctlClose{ioctlErr: errors.New("oops")}
+	if _, err := bus.SMBusTx(0x0B, smbus.ByteData, 0x10, false, []byte{0x42}, nil); err == nil {
+		t.Fatal("ioctl failed")
+	}
+}
+
+func TestI2C_TxCtx(t *testing.T) {
+	f := &hungIoctl{release: make(chan struct{})}
+	bus := I2C{f: f, busNumber: 24}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bus.TxCtx(ctx, 1, []byte{0}, []byte{0}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	// The bus is busy until the transaction completes.
+	close(f.release)
+	if err := bus.Tx(1, []byte{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.TxCtx(ctx, 1, []byte{0}, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	if err := bus.TxCtx(context.Background(), 1, []byte{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := bus.TxCtx(ctx, 1, []byte{0}, []byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	// The adapter timeout is shared by all the users of the adapter, so it is
+	// left as is.
+	if f.timeouts != 0 {
+		t.Fatal(f.timeouts)
+	}
+	bus.f = &ioctlClose{ioctlErr: errors.New("oops")}
+	if bus.TxCtx(ctx, 1, []byte{0}, nil) == nil {
+		t.Fatal("ioctl failed")
+	}
+}
+
+func TestI2C_TxSpeed(t *testing.T) {
+	var speeds []physic.Frequency
+	drvI2C.setSpeed = func(f physic.Frequency) error {
+		speeds = append(speeds, f)
+		return nil
+	}
+	defer func() {
+		drvI2C.setSpeed = nil
+		drvI2C.limit = 0
+	}()
+	bus := I2C{f: &ioctlClose{}, busNumber: 24}
+	if err := bus.TxSpeed(1, 400*physic.KiloHertz, []byte{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.TxSpeed(2, 100*physic.KiloHertz, []byte{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.TxSpeed(1, 400*physic.KiloHertz, []byte{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if l := bus.SpeedLimit(); l != 100*physic.KiloHertz {
+		t.Fatal(l)
+	}
+	if err := bus.SetSpeed(physic.MegaHertz); err != nil {
+		t.Fatal(err)
+	}
+	// The speed applies to all the buses, so another handle can't raise it
+	// above the limit either.
+	other := I2C{f: &ioctlClose{}, busNumber: 25}
+	if l := other.SpeedLimit(); l != 100*physic.KiloHertz {
+		t.Fatal(l)
+	}
+	if err := other.SetSpeed(physic.MegaHertz); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.TxSpeed(1, 400*physic.KiloHertz, []byte{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := []physic.Frequency{400 * physic.KiloHertz, 100 * physic.KiloHertz, 100 * physic.KiloHertz, 100 * physic.KiloHertz}
+	if !reflect.DeepEqual(speeds, expected) {
+		t.Fatal(speeds)
+	}
+	if bus.TxSpeed(1, 0, []byte{0}, nil) == nil {
+		t.Fatal("invalid speed")
+	}
+	drvI2C.setSpeed = func(f physic.Frequency) error {
+		return errors.New("oops")
+	}
+	if bus.TxSpeed(1, 10*physic.KiloHertz, []byte{0}, nil) == nil {
+		t.Fatal("setSpeed failed")
+	}
+	if l := bus.SpeedLimit(); l != 100*physic.KiloHertz {
+		t.Fatal(l)
+	}
+}
+
+func TestDTI2CNames(t *testing.T) {
+	root, err := ioutil.TempDir("", "periph_sysfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	base := filepath.Join(root, "sys/firmware/devicetree/base")
+	files := map[string]string{
+		"aliases/i2c0":    "/soc/i2c@7e205000\x00",
+		"aliases/i2c1":    "/soc/i2c@7e804000\x00",
+		"aliases/i2c_arm": "/soc/i2c@7e804000\x00",
+		"aliases/spi0":    "/soc/spi@7e204000\x00",
+	}
+	for name, content := range files {
+		p := filepath.Join(base, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Buses 20 and 21 are behind two multiplexers, with the same node name.
+	nodes := map[int]string{
+		1:  "soc/i2c@7e804000",
+		3:  "soc/i2c@7e205000",
+		20: "soc/i2c@7e804000/mux@70/i2c@0",
+		21: "soc/i2c@7e205000/mux@70/i2c@0",
+	}
+	for bus, node := range nodes {
+		if err := os.MkdirAll(filepath.Join(base, node), 0700); err != nil {
+			t.Fatal(err)
+		}
+		d := filepath.Join(root, "sys/bus/i2c/devices", fmt.Sprintf("i2c-%d", bus))
+		if err := os.MkdirAll(d, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("../../../../firmware/devicetree/base/"+node, filepath.Join(d, "of_node")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := dtI2CNames(root, []int{1, 3, 4, 20, 21})
+	expected := map[int][]string{
+		1: {"i2c@7e804000", "i2c1", "i2c_arm"},
+		3: {"i2c@7e205000", "i2c0"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatal(got)
+	}
+	if got := dtI2CNames(filepath.Join(root, "missing"), []int{1}); len(got) != 0 {
+		t.Fatal(got)
+	}
+}
+
 func TestI2C_functionality(t *testing.T) {
 	expected := "I2C|10BIT_ADDR|PROTOCOL_MANGLING|SMBUS_PEC|NOSTART|SMBUS_BLOCK_PROC_CALL|SMBUS_QUICK|SMBUS_READ_BYTE|SMBUS_WRITE_BYTE|SMBUS_READ_BYTE_DATA|SMBUS_WRITE_BYTE_DATA|SMBUS_READ_WORD_DATA|SMBUS_WRITE_WORD_DATA|SMBUS_PROC_CALL|SMBUS_READ_BLOCK_DATA|SMBUS_WRITE_BLOCK_DATA|SMBUS_READ_I2C_BLOCK|SMBUS_WRITE_I2C_BLOCK"
 	if s := functionality(0xFFFFFFFF).String(); s != expected {
@@ -114,3 +335,23 @@ func BenchmarkI2C(b *testing.B) {
 		}
 	}
 }
+
+//
+
+// hungIoctl simulates a transaction that only completes once release is
+// closed.
+type hungIoctl struct {
+	ioctlClose
+	release  chan struct{}
+	timeouts int
+}
+
+func (h *hungIoctl) Ioctl(op uint, data uintptr) error {
+	switch op {
+	case ioctlTimeout:
+		h.timeouts++
+	case ioctlRdwr:
+		<-h.release
+	}
+	return nil
+}
@@ -11,10 +11,12 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"unsafe"
 
 	"periph.io/x/periph"
@@ -94,7 +96,7 @@ func (s *SPI) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn, er
 	if f < 100*physic.Hertz {
 		return nil, fmt.Errorf("sysfs-spi: invalid speed %s; minimum supported clock is 100Hz; did you forget to multiply by physic.MegaHertz?", f)
 	}
-	if mode&^(spi.Mode3|spi.HalfDuplex|spi.NoCS|spi.LSBFirst) != 0 {
+	if mode&^(spi.Mode3|spi.HalfDuplex|spi.NoCS|spi.LSBFirst|spi.NoSplit) != 0 {
 		return nil, fmt.Errorf("sysfs-spi: invalid mode %v", mode)
 	}
 	if bits < 1 || bits >= 256 {
@@ -108,6 +110,7 @@ func (s *SPI) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn, er
 	s.conn.connected = true
 	s.conn.freqConn = f
 	s.conn.bitsPerWord = uint8(bits)
+	s.conn.noSplit = mode&spi.NoSplit != 0
 	// Only mode needs to be set via an IOCTL, others can be specified in the
 	// spiIOCTransfer packet, which saves a kernel call.
 	m := mode & spi.Mode3
@@ -133,8 +136,20 @@ func (s *SPI) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn, er
 	// Only the first 8 bits are used. This only works because the system is
 	// running in little endian.
 	if err := s.conn.setFlag(spiIOCMode, uint64(m)); err != nil {
+		if err == syscall.EINVAL {
+			// The controller driver rejects a flag, e.g. SPI_3WIRE or SPI_LSB_FIRST.
+			return nil, fmt.Errorf("sysfs-spi: mode %v on %s: %w", mode, s, spi.ErrNotSupported)
+		}
 		return nil, fmt.Errorf("sysfs-spi: setting mode %v failed: %v", mode, err)
 	}
+	// The bits per word are also specified in each spiIOCTransfer but setting
+	// them here verifies that the controller supports them.
+	if err := s.conn.setFlag(spiIOCBitsPerWord, uint64(bits)); err != nil {
+		if err == syscall.EINVAL {
+			return nil, fmt.Errorf("sysfs-spi: %d bits per word on %s: %w", bits, s, spi.ErrNotSupported)
+		}
+		return nil, fmt.Errorf("sysfs-spi: setting %d bits per word failed: %v", bits, err)
+	}
 	return &s.conn, nil
 }
 
@@ -205,6 +220,7 @@ type spiConn struct {
 	connected   bool
 	halfDuplex  bool
 	noCS        bool
+	noSplit     bool
 	// Heap optimization: reduce the amount of memory allocations during
 	// transactions.
 	io [4]spiIOCTransfer
@@ -228,13 +244,17 @@ func (s *spiConn) Read(b []byte) (int, error) {
 	if len(b) == 0 {
 		return 0, errors.New("sysfs-spi: Read() with empty buffer")
 	}
-	if drvSPI.bufSize != 0 && len(b) > drvSPI.bufSize {
+	if s.noSplit && drvSPI.bufSize != 0 && len(b) > drvSPI.bufSize {
 		return 0, fmt.Errorf("sysfs-spi: maximum Read length is %d, got %d bytes", drvSPI.bufSize, len(b))
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if err := checkWords(b, s.bitsPerWord); err != nil {
+		return 0, fmt.Errorf("sysfs-spi: Read(): %v", err)
+	}
 	s.p[0].W = nil
 	s.p[0].R = b
+	s.p[0].KeepCS = false
 	if err := s.txPackets(s.p[:1]); err != nil {
 		return 0, fmt.Errorf("sysfs-spi: Read() failed: %v", err)
 	}
@@ -246,13 +266,17 @@ func (s *spiConn) Write(b []byte) (int, error) {
 	if len(b) == 0 {
 		return 0, errors.New("sysfs-spi: Write() with empty buffer")
 	}
-	if drvSPI.bufSize != 0 && len(b) > drvSPI.bufSize {
+	if s.noSplit && drvSPI.bufSize != 0 && len(b) > drvSPI.bufSize {
 		return 0, fmt.Errorf("sysfs-spi: maximum Write length is %d, got %d bytes", drvSPI.bufSize, len(b))
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if err := checkWords(b, s.bitsPerWord); err != nil {
+		return 0, fmt.Errorf("sysfs-spi: Write(): %v", err)
+	}
 	s.p[0].W = b
 	s.p[0].R = nil
+	s.p[0].KeepCS = false
 	if err := s.txPackets(s.p[:1]); err != nil {
 		return 0, fmt.Errorf("sysfs-spi: Write() failed: %v", err)
 	}
@@ -263,9 +287,11 @@ func (s *spiConn) Write(b []byte) (int, error) {
 //
 // It is OK if both w and r point to the same underlying byte slice.
 //
-// spidev enforces the maximum limit of transaction size. It can be as low as
-// 4096 bytes. See the platform documentation to learn how to increase the
-// limit.
+// spidev enforces the maximum limit of transaction size, as returned by
+// MaxTxSize(). It can be as low as 4096 bytes. A larger transaction is split
+// in multiple ioctls, with CS kept asserted in between on controllers that
+// support it, unless NoSplit was specified to Connect. See the platform
+// documentation to learn how to increase the limit.
 func (s *spiConn) Tx(w, r []byte) error {
 	l := len(w)
 	if l == 0 {
@@ -274,21 +300,32 @@ func (s *spiConn) Tx(w, r []byte) error {
 		}
 	} else {
 		// It's not a big deal to read halfDuplex without the lock.
-		if !s.halfDuplex && len(r) != 0 && len(r) != len(w) {
+		if s.halfDuplex {
+			l += len(r)
+		} else if len(r) != 0 && len(r) != len(w) {
 			return fmt.Errorf("sysfs-spi: Tx(): when both w and r are used, they must be the same size; got %d and %d bytes", len(w), len(r))
 		}
 	}
-	if drvSPI.bufSize != 0 && l > drvSPI.bufSize {
+	if s.noSplit && drvSPI.bufSize != 0 && l > drvSPI.bufSize {
 		return fmt.Errorf("sysfs-spi: maximum Tx length is %d, got %d bytes", drvSPI.bufSize, l)
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if err := checkWords(w, s.bitsPerWord); err != nil {
+		return fmt.Errorf("sysfs-spi: Tx(): %v", err)
+	}
+	if err := checkWords(r, s.bitsPerWord); err != nil {
+		return fmt.Errorf("sysfs-spi: Tx(): %v", err)
+	}
 	s.p[0].W = w
 	s.p[0].R = r
+	s.p[0].KeepCS = false
 	p := s.p[:1]
 	if s.halfDuplex && len(w) != 0 && len(r) != 0 {
-		// Create two packets for HalfDuplex operation: one write then one read.
+		// Create two packets for HalfDuplex operation: one write then one read,
+		// keeping CS asserted for the turnaround.
 		s.p[0].R = nil
+		s.p[0].KeepCS = true
 		s.p[1].W = nil
 		s.p[1].R = r
 		p = s.p[:2]
@@ -301,14 +338,32 @@ func (s *spiConn) Tx(w, r []byte) error {
 
 // TxPackets sends and receives packets as specified by the user.
 //
-// spidev enforces the maximum limit of transaction size. It can be as low as
-// 4096 bytes. See the platform documentation to learn how to increase the
-// limit.
+// In half duplex mode, a packet with both W and R is sent as a write followed
+// by a read, with CS kept asserted in between.
+//
+// Packet.Speed is supported via the per transfer speed of spidev.
+// Packet.DummyBits must be a multiple of 8; the dummy cycles are sent as zero
+// bytes.
+//
+// spidev enforces the maximum limit of transaction size, as returned by
+// MaxTxSize(). It can be as low as 4096 bytes. A larger transaction is split
+// in multiple ioctls, with CS kept asserted in between on controllers that
+// support it, unless NoSplit was specified to Connect. See the platform
+// documentation to learn how to increase the limit.
 func (s *spiConn) TxPackets(p []spi.Packet) error {
 	total := 0
 	for i := range p {
+		if d := p[i].DummyBits; d%8 != 0 {
+			return fmt.Errorf("sysfs-spi: %d dummy bits is not a multiple of 8: %w", d, spi.ErrNotSupported)
+		}
+		total += int(p[i].DummyBits / 8)
 		lW := len(p[i].W)
 		lR := len(p[i].R)
+		if s.halfDuplex {
+			// It's not a big deal to read halfDuplex without the lock.
+			total += lW + lR
+			continue
+		}
 		if lW != lR && lW != 0 && lR != 0 {
 			return fmt.Errorf("sysfs-spi: when both w and r are used, they must be the same size; got %d and %d bytes", lW, lR)
 		}
@@ -321,18 +376,26 @@ func (s *spiConn) TxPackets(p []spi.Packet) error {
 	if total == 0 {
 		return errors.New("sysfs-spi: empty packets")
 	}
-	if drvSPI.bufSize != 0 && total > drvSPI.bufSize {
+	if s.noSplit && drvSPI.bufSize != 0 && total > drvSPI.bufSize {
 		return fmt.Errorf("sysfs-spi: maximum TxPackets length is %d, got %d bytes", drvSPI.bufSize, total)
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.halfDuplex {
-		for i := range p {
-			if len(p[i].W) != 0 && len(p[i].R) != 0 {
-				return errors.New("sysfs-spi: can only specify one of w or r when in half duplex")
-			}
+	for i := range p {
+		bits := p[i].BitsPerWord
+		if bits == 0 {
+			bits = s.bitsPerWord
 		}
+		if err := checkWords(p[i].W, bits); err != nil {
+			return fmt.Errorf("sysfs-spi: TxPackets(): packet #%d: %v", i, err)
+		}
+		if err := checkWords(p[i].R, bits); err != nil {
+			return fmt.Errorf("sysfs-spi: TxPackets(): packet #%d: %v", i, err)
+		}
+	}
+	if s.halfDuplex {
+		p = splitTurnaround(p)
 	}
 	if err := s.txPackets(p); err != nil {
 		return fmt.Errorf("sysfs-spi: TxPackets() failed: %v", err)
@@ -385,23 +448,149 @@ func (s *spiConn) txPackets(p []spi.Packet) error {
 	if s.freqConn != 0 && (s.freqPort == 0 || s.freqConn < s.freqPort) {
 		f = s.freqConn
 	}
+	// Dummy cycles are sent as an additional transfer of zeros. A single buffer
+	// large enough for all the packets is used, since m only holds its address.
+	n := len(p)
+	maxDummy := 0
+	for i := range p {
+		if p[i].DummyBits != 0 {
+			n++
+		}
+		if l := int(p[i].DummyBits / 8); l > maxDummy {
+			maxDummy = l
+		}
+	}
 	var m []spiIOCTransfer
-	if len(p) > len(s.io) {
-		m = make([]spiIOCTransfer, len(p))
+	if n > len(s.io) {
+		m = make([]spiIOCTransfer, n)
 	} else {
-		m = s.io[:len(p)]
+		m = s.io[:n]
 	}
+	dummy := make([]byte, maxDummy)
+	j := 0
 	for i := range p {
+		pf := f
+		if p[i].Speed != 0 {
+			pf = p[i].Speed
+			if s.freqPort != 0 && pf > s.freqPort {
+				pf = s.freqPort
+			}
+		}
+		if l := int(p[i].DummyBits / 8); l != 0 {
+			m[j].reset(dummy[:l], nil, pf, 8)
+			j++
+		}
 		bits := p[i].BitsPerWord
 		if bits == 0 {
 			bits = s.bitsPerWord
 		}
-		m[i].reset(p[i].W, p[i].R, f, bits)
+		m[j].reset(p[i].W, p[i].R, pf, bits)
 		if !s.noCS && !p[i].KeepCS {
-			m[i].csChange = 1
+			m[j].csChange = 1
+		}
+		j++
+	}
+	// On the last transfer of a message, cs_change means to keep CS asserted
+	// after the message, so it must be cleared to release CS at the end.
+	m[len(m)-1].csChange = 0
+	err := s.ioctlTx(m)
+	// m only holds the address of dummy.
+	runtime.KeepAlive(dummy)
+	return err
+}
+
+// ioctlTx sends the transfers in as few messages as possible, each at most
+// drvSPI.bufSize bytes long.
+//
+// Transfers too large to fit are split at word boundaries. CS is kept
+// asserted between the messages by setting cs_change on the last transfer of
+// each message, as long as it wasn't requested to be released there.
+func (s *spiConn) ioctlTx(m []spiIOCTransfer) error {
+	limit := drvSPI.bufSize
+	total := 0
+	for i := range m {
+		total += int(m[i].length)
+	}
+	if limit == 0 || total <= limit {
+		return s.f.Ioctl(spiIOCTx(len(m)), uintptr(unsafe.Pointer(&m[0])))
+	}
+	var msg []spiIOCTransfer
+	size := 0
+	for i := range m {
+		t := m[i]
+		for {
+			room := limit - size
+			room -= room % spi.BytesPerWord(int(t.bitsPerWord))
+			if int(t.length) <= room {
+				msg = append(msg, t)
+				size += int(t.length)
+				break
+			}
+			if room != 0 {
+				head := t
+				head.length = uint32(room)
+				head.csChange = 0
+				msg = append(msg, head)
+				if t.tx != 0 {
+					t.tx += uint64(room)
+				}
+				if t.rx != 0 {
+					t.rx += uint64(room)
+				}
+				t.length -= uint32(room)
+			}
+			// The message is full; toggle the meaning of cs_change on its last
+			// transfer as CS continues in the next message.
+			if !s.noCS {
+				msg[len(msg)-1].csChange ^= 1
+			}
+			if err := s.f.Ioctl(spiIOCTx(len(msg)), uintptr(unsafe.Pointer(&msg[0]))); err != nil {
+				return err
+			}
+			msg = msg[:0]
+			size = 0
+		}
+	}
+	if len(msg) == 0 {
+		return nil
+	}
+	return s.f.Ioctl(spiIOCTx(len(msg)), uintptr(unsafe.Pointer(&msg[0])))
+}
+
+// checkWords verifies that b holds whole words of bits.
+func checkWords(b []byte, bits uint8) error {
+	if n := spi.BytesPerWord(int(bits)); len(b)%n != 0 {
+		return fmt.Errorf("%d bytes is not a multiple of %d bytes for %d bits per word", len(b), n, bits)
+	}
+	return nil
+}
+
+// splitTurnaround returns the packets with each packet that both writes and
+// reads split in a write then a read, as the data line is shared in half
+// duplex mode.
+//
+// p is returned as is when no packet needs to be split.
+func splitTurnaround(p []spi.Packet) []spi.Packet {
+	n := 0
+	for i := range p {
+		if len(p[i].W) != 0 && len(p[i].R) != 0 {
+			n++
+		}
+	}
+	if n == 0 {
+		return p
+	}
+	out := make([]spi.Packet, 0, len(p)+n)
+	for i := range p {
+		if len(p[i].W) == 0 || len(p[i].R) == 0 {
+			out = append(out, p[i])
+			continue
 		}
+		out = append(out,
+			spi.Packet{W: p[i].W, BitsPerWord: p[i].BitsPerWord, Speed: p[i].Speed, DummyBits: p[i].DummyBits, KeepCS: true},
+			spi.Packet{R: p[i].R, BitsPerWord: p[i].BitsPerWord, Speed: p[i].Speed, KeepCS: p[i].KeepCS})
 	}
-	return s.f.Ioctl(spiIOCTx(len(m)), uintptr(unsafe.Pointer(&m[0])))
+	return out
 }
 
 func (s *spiConn) setFlag(op uint, arg uint64) error {
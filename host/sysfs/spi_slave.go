@@ -0,0 +1,213 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"periph.io/x/periph/conn/spi"
+)
+
+// NewSPISlave opens a SPI controller in slave mode via its devfs interface,
+// as described at https://www.kernel.org/doc/Documentation/spi/spi-summary
+//
+// The controller must be registered by the kernel as a SPI slave controller,
+// with the spidev slave protocol handler bound to it, e.g. with
+// "echo spidev > /sys/class/spi_slave/spi0/slave" which creates
+// /dev/spidev0.0.
+//
+// mode specifies the clock polarity and phase, and if LSBFirst is used. It
+// returns an error wrapping spi.ErrNotSupported if busNumber is not a slave
+// controller.
+func NewSPISlave(busNumber int, mode spi.Mode, bits int) (*SPISlave, error) {
+	if isLinux {
+		return newSPISlave(busNumber, mode, bits)
+	}
+	return nil, fmt.Errorf("sysfs-spi: slave mode on non-linux OSes: %w", spi.ErrNotSupported)
+}
+
+// SPISlave is an open SPI controller in slave mode.
+//
+// It implements spi.Slave. The resulting object is safe for concurrent use.
+type SPISlave struct {
+	// Immutable
+	name string
+	bits uint8
+
+	mu     sync.Mutex
+	f      ioctlCloser
+	closed chan struct{} // Closed by Close() to interrupt Receive().
+
+	// muRx serializes the Receive() calls. It is not held by Close().
+	muRx sync.Mutex
+	// pending is a transaction left running by a canceled Receive() call. The
+	// kernel doesn't abort it, so its data is returned on the next call.
+	pending chan slaveRx
+
+	muResp sync.Mutex
+	resp   []byte
+}
+
+// Close closes the handle to the SPI driver.
+//
+// A Receive() call waiting for the master returns an error. The kernel
+// doesn't abort the transaction it started.
+func (s *SPISlave) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return errors.New("sysfs-spi: already closed")
+	}
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("sysfs-spi: %v", err)
+	}
+	s.f = nil
+	close(s.closed)
+	return nil
+}
+
+func (s *SPISlave) String() string {
+	return s.name
+}
+
+// Receive implements spi.Slave.
+//
+// The length of buf is the length of the transaction, as the kernel SPI
+// slave framework needs to know it in advance.
+//
+// When ctx is done while waiting for the master, the transaction is left
+// pending and the bytes it received are returned by the next call.
+func (s *SPISlave) Receive(ctx context.Context, buf []byte) (int, error) {
+	if len(buf) == 0 {
+		return 0, errors.New("sysfs-spi: Receive() with empty buffer")
+	}
+	if err := checkWords(buf, s.bits); err != nil {
+		return 0, fmt.Errorf("sysfs-spi: Receive(): %v", err)
+	}
+	s.muRx.Lock()
+	defer s.muRx.Unlock()
+	s.mu.Lock()
+	if s.f == nil {
+		s.mu.Unlock()
+		return 0, errors.New("sysfs-spi: Receive() on closed port")
+	}
+	c := s.pending
+	if c == nil {
+		if err := ctx.Err(); err != nil {
+			s.mu.Unlock()
+			return 0, fmt.Errorf("sysfs-spi: Receive(): %w", err)
+		}
+		c = s.start(len(buf))
+	}
+	closed := s.closed
+	s.mu.Unlock()
+	s.pending = nil
+	select {
+	case rx := <-c:
+		if rx.err != nil {
+			return 0, fmt.Errorf("sysfs-spi: Receive() failed: %v", rx.err)
+		}
+		return copy(buf, rx.b), nil
+	case <-ctx.Done():
+		s.pending = c
+		return 0, fmt.Errorf("sysfs-spi: Receive(): %w", ctx.Err())
+	case <-closed:
+		return 0, errors.New("sysfs-spi: Receive() on closed port")
+	}
+}
+
+// Respond implements spi.Slave.
+//
+// buf is used by the next transaction started by Receive(), and is truncated
+// to its length.
+func (s *SPISlave) Respond(buf []byte) error {
+	if err := checkWords(buf, s.bits); err != nil {
+		return fmt.Errorf("sysfs-spi: Respond(): %v", err)
+	}
+	s.muResp.Lock()
+	defer s.muResp.Unlock()
+	s.resp = append(s.resp[:0], buf...)
+	return nil
+}
+
+//
+
+// slaveRx is the result of a slave transaction.
+type slaveRx struct {
+	b   []byte
+	err error
+}
+
+// spiSlaveRoot is where the SPI slave controllers are listed.
+var spiSlaveRoot = "/sys/class/spi_slave/"
+
+func newSPISlave(busNumber int, mode spi.Mode, bits int) (*SPISlave, error) {
+	if busNumber < 0 || busNumber >= 1<<16 {
+		return nil, fmt.Errorf("sysfs-spi: invalid bus %d", busNumber)
+	}
+	if mode&^(spi.Mode3|spi.LSBFirst) != 0 {
+		return nil, fmt.Errorf("sysfs-spi: mode %v in slave mode: %w", mode, spi.ErrNotSupported)
+	}
+	if bits < 1 || bits >= 256 {
+		return nil, fmt.Errorf("sysfs-spi: invalid bits %d", bits)
+	}
+	if _, err := os.Stat(spiSlaveRoot + "spi" + strconv.Itoa(busNumber)); err != nil {
+		return nil, fmt.Errorf("sysfs-spi: SPI%d is not a slave controller: %w", busNumber, spi.ErrNotSupported)
+	}
+	f, err := ioctlOpen(fmt.Sprintf("/dev/spidev%d.0", busNumber), os.O_RDWR)
+	if err != nil {
+		return nil, fmt.Errorf("sysfs-spi: %v", err)
+	}
+	m := mode & spi.Mode3
+	if mode&spi.LSBFirst != 0 {
+		m |= lSBFirst
+	}
+	c := spiConn{f: f}
+	if err = c.setFlag(spiIOCMode, uint64(m)); err == nil {
+		err = c.setFlag(spiIOCBitsPerWord, uint64(bits))
+	}
+	if err != nil {
+		f.Close()
+		if err == syscall.EINVAL {
+			return nil, fmt.Errorf("sysfs-spi: mode %v with %d bits per word on SPI%d slave: %w", mode, bits, busNumber, spi.ErrNotSupported)
+		}
+		return nil, fmt.Errorf("sysfs-spi: setting mode %v failed: %v", mode, err)
+	}
+	return &SPISlave{name: fmt.Sprintf("SPI%d-slave", busNumber), bits: uint8(bits), f: f, closed: make(chan struct{})}, nil
+}
+
+// start starts a transaction of n bytes. s.mu must be held.
+//
+// The transaction can't be aborted, so it uses its own buffers.
+func (s *SPISlave) start(n int) chan slaveRx {
+	w := make([]byte, n)
+	s.muResp.Lock()
+	copy(w, s.resp)
+	s.resp = s.resp[:0]
+	s.muResp.Unlock()
+	r := make([]byte, n)
+	c := make(chan slaveRx, 1)
+	f := s.f
+	go func() {
+		var m spiIOCTransfer
+		m.reset(w, r, 0, s.bits)
+		err := f.Ioctl(spiIOCTx(1), uintptr(unsafe.Pointer(&m)))
+		// m only holds the addresses of w and r.
+		runtime.KeepAlive(w)
+		runtime.KeepAlive(r)
+		c <- slaveRx{r, err}
+	}()
+	return c
+}
+
+var _ spi.Slave = &SPISlave{}
@@ -0,0 +1,205 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"periph.io/x/periph/conn/spi"
+)
+
+func TestNewSPISlave(t *testing.T) {
+	if !isLinux {
+		if _, err := NewSPISlave(0, spi.Mode0, 8); !errors.Is(err, spi.ErrNotSupported) {
+			t.Fatal(err)
+		}
+		return
+	}
+	defer reset()
+	defer func(r string) {
+		spiSlaveRoot = r
+	}(spiSlaveRoot)
+	d, err := ioutil.TempDir("", "periph_sysfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	spiSlaveRoot = d + "/"
+	if err := os.Mkdir(filepath.Join(d, "spi1"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	f := &ioctlOp{op: spiIOCMode | 0x40000000, err: syscall.EINVAL}
+	ioctlOpen = func(path string, flag int) (ioctlCloser, error) {
+		if path != "/dev/spidev1.0" {
+			t.Fatal(path)
+		}
+		return f, nil
+	}
+	if _, err := NewSPISlave(-1, spi.Mode0, 8); err == nil {
+		t.Fatal("invalid bus")
+	}
+	if _, err := NewSPISlave(1, spi.Mode0|spi.HalfDuplex, 8); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	if _, err := NewSPISlave(1, spi.Mode0, 0); err == nil {
+		t.Fatal("invalid bits")
+	}
+	// Not a slave controller.
+	if _, err := NewSPISlave(0, spi.Mode0, 8); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	// The controller rejects the mode.
+	if _, err := NewSPISlave(1, spi.Mode3, 8); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	f.err = errors.New("foo")
+	if _, err := NewSPISlave(1, spi.Mode3, 8); err == nil || err.Error() != "sysfs-spi: setting mode Mode3 failed: foo" {
+		t.Fatal(err)
+	}
+	f.err = nil
+	s, err := NewSPISlave(1, spi.Mode3|spi.LSBFirst, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := s.String(); v != "SPI1-slave" {
+		t.Fatal(v)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Receive(context.Background(), make([]byte, 1)); err == nil {
+		t.Fatal("closed")
+	}
+}
+
+func TestSPISlave_Receive(t *testing.T) {
+	f := &ioctlMaster{tx: make(chan []byte), rx: make(chan []byte, 1)}
+	s := &SPISlave{name: "SPI1-slave", bits: 8, f: f, closed: make(chan struct{})}
+	if err := s.Respond([]byte{0xA5, 0x5A, 0xFF}); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		f.tx <- []byte{1, 2}
+	}()
+	b := make([]byte, 2)
+	if n, err := s.Receive(context.Background(), b); n != 2 || err != nil {
+		t.Fatal(n, err)
+	}
+	// The response is truncated to the transaction length.
+	if !bytes.Equal(b, []byte{1, 2}) || !bytes.Equal(<-f.rx, []byte{0xA5, 0x5A}) {
+		t.Fatal(b)
+	}
+	// The response is only used once.
+	go func() {
+		f.tx <- []byte{3, 4}
+	}()
+	if n, err := s.Receive(context.Background(), b); n != 2 || err != nil {
+		t.Fatal(n, err)
+	}
+	if !bytes.Equal(b, []byte{3, 4}) || !bytes.Equal(<-f.rx, []byte{0, 0}) {
+		t.Fatal(b)
+	}
+	if _, err := s.Receive(context.Background(), nil); err == nil {
+		t.Fatal("empty buffer")
+	}
+}
+
+func TestSPISlave_Receive_cancel(t *testing.T) {
+	f := &ioctlMaster{tx: make(chan []byte), rx: make(chan []byte)}
+	s := &SPISlave{name: "SPI1-slave", bits: 8, f: f, closed: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := make([]byte, 2)
+	if _, err := s.Receive(ctx, b); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if s.pending != nil {
+		t.Fatal("transaction started with a canceled context")
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		// Cancel once the master side is waiting.
+		<-f.rx
+		cancel()
+	}()
+	if _, err := s.Receive(ctx, b); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if s.pending == nil {
+		t.Fatal("transaction not pending")
+	}
+	// The pending transaction is returned by the next call.
+	f.tx <- []byte{5, 6}
+	if n, err := s.Receive(context.Background(), b); n != 2 || err != nil {
+		t.Fatal(n, err)
+	}
+	if !bytes.Equal(b, []byte{5, 6}) {
+		t.Fatal(b)
+	}
+	f.err = errors.New("foo")
+	if _, err := s.Receive(context.Background(), b); err == nil || err.Error() != "sysfs-spi: Receive() failed: foo" {
+		t.Fatal(err)
+	}
+}
+
+func TestSPISlave_Receive_close(t *testing.T) {
+	f := &ioctlMaster{tx: make(chan []byte), rx: make(chan []byte)}
+	s := &SPISlave{name: "SPI1-slave", bits: 8, f: f, closed: make(chan struct{})}
+	go func() {
+		// Close once the master side is waiting. It doesn't wait for Receive().
+		<-f.rx
+		if err := s.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	if _, err := s.Receive(context.Background(), make([]byte, 2)); err == nil || err.Error() != "sysfs-spi: Receive() on closed port" {
+		t.Fatal(err)
+	}
+	if s.Close() == nil {
+		t.Fatal("already closed")
+	}
+}
+
+func TestSPISlave_words(t *testing.T) {
+	s := &SPISlave{name: "SPI1-slave", bits: 16, f: &ioctlClose{}, closed: make(chan struct{})}
+	if _, err := s.Receive(context.Background(), make([]byte, 3)); err == nil {
+		t.Fatal("partial word")
+	}
+	if s.Respond(make([]byte, 3)) == nil {
+		t.Fatal("partial word")
+	}
+}
+
+//
+
+// ioctlMaster acts as the SPI master clocking a slave transaction.
+//
+// The bytes written by the master are read from tx, the bytes sent by the
+// slave are written to rx.
+type ioctlMaster struct {
+	ioctlClose
+	tx  chan []byte
+	rx  chan []byte
+	err error
+}
+
+func (i *ioctlMaster) Ioctl(op uint, data uintptr) error {
+	if i.err != nil {
+		return i.err
+	}
+	m := (*spiIOCTransfer)(toPointer(data))
+	w := (*[1 << 16]byte)(toPointer(uintptr(m.tx)))[:m.length:m.length]
+	r := (*[1 << 16]byte)(toPointer(uintptr(m.rx)))[:m.length:m.length]
+	i.rx <- append([]byte(nil), w...)
+	copy(r, <-i.tx)
+	return nil
+}
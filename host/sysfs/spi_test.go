@@ -7,7 +7,10 @@ package sysfs
 import (
 	"errors"
 	"io"
+	"reflect"
+	"syscall"
 	"testing"
+	"unsafe"
 
 	"periph.io/x/periph/conn"
 	"periph.io/x/periph/conn/gpio"
@@ -79,8 +82,9 @@ func TestSPI_Tx(t *testing.T) {
 	if err := c.Tx([]byte{0}, []byte{0, 1}); err == nil {
 		t.Fatal("different lengths")
 	}
-	if err := c.Tx(make([]byte, drvSPI.bufSize+1), nil); err.Error() != "sysfs-spi: maximum Tx length is 4096, got 4097 bytes" {
-		t.Fatal("buffer too long")
+	// Split in two ioctls.
+	if err := c.Tx(make([]byte, drvSPI.bufSize+1), nil); err != nil {
+		t.Fatal(err)
 	}
 	// Inject error.
 	f.ioctlErr = errors.New("foo")
@@ -100,12 +104,6 @@ func TestSPI_TxPackets(t *testing.T) {
 		t.Fatal("empty TxPackets")
 	}
 	pkt := []spi.Packet{
-		{W: make([]byte, drvSPI.bufSize+1)},
-	}
-	if err := c.TxPackets(pkt); err == nil {
-		t.Fatal("buffer too long")
-	}
-	pkt = []spi.Packet{
 		{W: []byte{0}, R: []byte{0, 1}},
 	}
 	if err := c.TxPackets(pkt); err == nil {
@@ -124,6 +122,134 @@ func TestSPI_TxPackets(t *testing.T) {
 	}
 }
 
+func TestSPI_TxPackets_SpeedDummy(t *testing.T) {
+	p := SPI{spiConn{f: &ioctlClose{}, busNumber: 24, freqPort: 50 * physic.MegaHertz}}
+	c, err := p.Connect(10*physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkt := []spi.Packet{
+		{W: []byte{0x0B, 0, 0, 0}, Speed: physic.MegaHertz, KeepCS: true},
+		{R: make([]byte, 4), Speed: 80 * physic.MegaHertz, DummyBits: 16},
+	}
+	if err := c.TxPackets(pkt); err != nil {
+		t.Fatal(err)
+	}
+	m := p.conn.io[:3]
+	// The command at the packet speed, the dummy cycles and the data at the
+	// port limit.
+	if m[0].speedHz != 1000000 || m[0].length != 4 || m[0].csChange != 0 {
+		t.Fatalf("%+v", m[0])
+	}
+	if m[1].speedHz != 50000000 || m[1].length != 2 || m[1].tx == 0 || m[1].rx != 0 || m[1].csChange != 0 {
+		t.Fatalf("%+v", m[1])
+	}
+	if m[2].speedHz != 50000000 || m[2].length != 4 || m[2].csChange != 0 {
+		t.Fatalf("%+v", m[2])
+	}
+	// Growing dummy cycles share a single buffer, as the transfers only hold its
+	// address.
+	pkt = []spi.Packet{
+		{W: []byte{0x0B}, DummyBits: 8, KeepCS: true},
+		{R: make([]byte, 4), DummyBits: 24},
+	}
+	if err := c.TxPackets(pkt); err != nil {
+		t.Fatal(err)
+	}
+	m = p.conn.io[:4]
+	if m[0].length != 1 || m[2].length != 3 || m[0].tx == 0 || m[0].tx != m[2].tx {
+		t.Fatalf("%+v", m)
+	}
+	pkt = []spi.Packet{{R: make([]byte, 1), DummyBits: 4}}
+	if err := c.TxPackets(pkt); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+}
+
+func TestSPI_Tx_split(t *testing.T) {
+	defer func(b int) {
+		drvSPI.bufSize = b
+	}(drvSPI.bufSize)
+	drvSPI.bufSize = 8
+	f := &ioctlRecord{}
+	p := SPI{spiConn{f: f, busNumber: 24}}
+	c, err := p.Connect(physic.MegaHertz, spi.Mode0, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := make([]byte, 22)
+	pkt := []spi.Packet{
+		{W: w[:2], KeepCS: true},
+		{W: w[2:], R: make([]byte, 20), KeepCS: true},
+		{W: w[:2]},
+	}
+	if err := c.TxPackets(pkt); err != nil {
+		t.Fatal(err)
+	}
+	// Each message is at most 8 bytes; CS is kept asserted in between and
+	// released at the end.
+	expected := [][]spiIOCTransfer{
+		{{length: 2}, {length: 6, csChange: 1}},
+		{{length: 8, csChange: 1}},
+		{{length: 6}, {length: 2}},
+	}
+	if len(f.msgs) != len(expected) {
+		t.Fatalf("%+v", f.msgs)
+	}
+	for i := range expected {
+		if len(f.msgs[i]) != len(expected[i]) {
+			t.Fatalf("#%d: %+v", i, f.msgs[i])
+		}
+		for j, e := range expected[i] {
+			if a := f.msgs[i][j]; a.length != e.length || a.csChange != e.csChange {
+				t.Fatalf("#%d.%d: %+v", i, j, a)
+			}
+		}
+	}
+	// The pieces continue where the previous one stopped.
+	if f.msgs[1][0].rx != f.msgs[0][1].rx+6 || f.msgs[2][0].tx != f.msgs[1][0].tx+8 {
+		t.Fatal("invalid split offsets")
+	}
+
+	// A packet that doesn't need CS asserted ends a message as is.
+	f.msgs = nil
+	pkt = []spi.Packet{{W: w[:8]}, {W: w[:2]}}
+	if err := c.TxPackets(pkt); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.msgs) != 2 || f.msgs[0][0].csChange != 0 || f.msgs[1][0].csChange != 0 {
+		t.Fatalf("%+v", f.msgs)
+	}
+	f.err = errors.New("foo")
+	if err := c.Tx(w[:10], nil); err == nil || err.Error() != "sysfs-spi: Tx() failed: foo" {
+		t.Fatal(err)
+	}
+}
+
+func TestSPI_NoSplit(t *testing.T) {
+	p := SPI{spiConn{f: &ioctlClose{}, busNumber: 24}}
+	c, err := p.Connect(100*physic.Hertz, spi.Mode3|spi.NoSplit, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, drvSPI.bufSize+1)
+	if err := c.Tx(b, nil); err == nil || err.Error() != "sysfs-spi: maximum Tx length is 4096, got 4097 bytes" {
+		t.Fatal(err)
+	}
+	if err := c.TxPackets([]spi.Packet{{W: b[:2]}, {W: b[2:]}}); err == nil || err.Error() != "sysfs-spi: maximum TxPackets length is 4096, got 4097 bytes" {
+		t.Fatal(err)
+	}
+	if n, err := c.(io.Reader).Read(b); n != 0 || err == nil || err.Error() != "sysfs-spi: maximum Read length is 4096, got 4097 bytes" {
+		t.Fatal(n, err)
+	}
+	if n, err := c.(io.Writer).Write(b); n != 0 || err == nil || err.Error() != "sysfs-spi: maximum Write length is 4096, got 4097 bytes" {
+		t.Fatal(n, err)
+	}
+	if err := c.Tx(b[:drvSPI.bufSize], nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSPI_Read(t *testing.T) {
 	f := ioctlClose{}
 	p := SPI{spiConn{f: &f, busNumber: 24}}
@@ -137,7 +263,7 @@ func TestSPI_Read(t *testing.T) {
 	if n, err := c.(io.Reader).Read([]byte{0}); n != 1 || err != nil {
 		t.Fatal(n, err)
 	}
-	if n, err := c.(io.Reader).Read(make([]byte, drvSPI.bufSize+1)); n != 0 || err.Error() != "sysfs-spi: maximum Read length is 4096, got 4097 bytes" {
+	if n, err := c.(io.Reader).Read(make([]byte, drvSPI.bufSize+1)); n != drvSPI.bufSize+1 || err != nil {
 		t.Fatal(n, err)
 	}
 	// Inject error.
@@ -160,7 +286,7 @@ func TestSPI_Write(t *testing.T) {
 	if n, err := c.(io.Writer).Write([]byte{0}); n != 1 || err != nil {
 		t.Fatal(n, err)
 	}
-	if n, err := c.(io.Writer).Write(make([]byte, drvSPI.bufSize+1)); n != 0 || err.Error() != "sysfs-spi: maximum Write length is 4096, got 4097 bytes" {
+	if n, err := c.(io.Writer).Write(make([]byte, drvSPI.bufSize+1)); n != drvSPI.bufSize+1 || err != nil {
 		t.Fatal(n, err)
 	}
 	// Inject error.
@@ -250,11 +376,12 @@ func TestSPI_Connect_Half(t *testing.T) {
 	if err := c.Tx([]byte{0}, []byte{0}); err != nil {
 		t.Fatal(err)
 	}
+	// Write then read with a turnaround.
 	pkt := []spi.Packet{
-		{W: []byte{0}, R: []byte{0}},
+		{W: []byte{0}, R: []byte{0, 0}},
 	}
-	if err := c.TxPackets(pkt); err == nil {
-		t.Fatal("half duplex")
+	if err := c.TxPackets(pkt); err != nil {
+		t.Fatal(err)
 	}
 	// Confirm memory allocation for large number of packets.
 	pkt = make([]spi.Packet, len(p.conn.io)+1)
@@ -269,6 +396,121 @@ func TestSPI_Connect_Half(t *testing.T) {
 	}
 }
 
+func TestSPI_Connect_HalfNotSupported(t *testing.T) {
+	p := SPI{spiConn{f: &ioctlClose{ioctlErr: syscall.EINVAL}, busNumber: 24}}
+	if _, err := p.Connect(100*physic.Hertz, spi.Mode0|spi.HalfDuplex, 8); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+}
+
+func TestSPI_Connect_LSBNotSupported(t *testing.T) {
+	p := SPI{spiConn{f: &ioctlClose{ioctlErr: syscall.EINVAL}, busNumber: 24}}
+	_, err := p.Connect(100*physic.Hertz, spi.Mode0|spi.LSBFirst, 8)
+	if !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+}
+
+func TestSPI_Connect_Bits(t *testing.T) {
+	f := &ioctlOp{op: spiIOCBitsPerWord | 0x40000000, err: syscall.EINVAL}
+	p := SPI{spiConn{f: f, busNumber: 24}}
+	if _, err := p.Connect(100*physic.Hertz, spi.Mode0, 12); !errors.Is(err, spi.ErrNotSupported) {
+		t.Fatal(err)
+	}
+	f.err = errors.New("foo")
+	p = SPI{spiConn{f: f, busNumber: 24}}
+	if _, err := p.Connect(100*physic.Hertz, spi.Mode0, 12); err == nil || err.Error() != "sysfs-spi: setting 12 bits per word failed: foo" {
+		t.Fatal(err)
+	}
+	f.err = nil
+	p = SPI{spiConn{f: f, busNumber: 24}}
+	c, err := p.Connect(100*physic.Hertz, spi.Mode0, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 12 bits words are stored in 2 bytes.
+	if err := c.Tx([]byte{1, 2, 3}, nil); err == nil || err.Error() != "sysfs-spi: Tx(): 3 bytes is not a multiple of 2 bytes for 12 bits per word" {
+		t.Fatal(err)
+	}
+	if err := c.Tx(spi.PackWords16([]uint16{0xFFF, 0x123}), make([]byte, 4)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.(io.Writer).Write([]byte{1}); err == nil {
+		t.Fatal("odd length")
+	}
+	if _, err := c.(io.Reader).Read([]byte{1}); err == nil {
+		t.Fatal("odd length")
+	}
+	// The packet's bits per word apply.
+	if err := c.TxPackets([]spi.Packet{{W: []byte{1}, BitsPerWord: 8}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.TxPackets([]spi.Packet{{W: []byte{1, 2}}, {R: []byte{1}}}); err == nil {
+		t.Fatal("odd length")
+	}
+}
+
+func TestSplitTurnaround(t *testing.T) {
+	w := []byte{1}
+	r := make([]byte, 2)
+	p := []spi.Packet{{W: w}, {R: r}}
+	if out := splitTurnaround(p); &out[0] != &p[0] {
+		t.Fatal("unexpected copy")
+	}
+	p = []spi.Packet{{W: w, R: r, BitsPerWord: 9}, {W: w}}
+	expected := []spi.Packet{
+		{W: w, BitsPerWord: 9, KeepCS: true},
+		{R: r, BitsPerWord: 9},
+		{W: w},
+	}
+	if out := splitTurnaround(p); !reflect.DeepEqual(out, expected) {
+		t.Fatal(out)
+	}
+}
+
+// ioctlOp fails the ioctl op with err.
+type ioctlOp struct {
+	ioctlClose
+	op  uint
+	err error
+}
+
+func (i *ioctlOp) Ioctl(op uint, data uintptr) error {
+	if op == i.op {
+		return i.err
+	}
+	return nil
+}
+
+// ioctlRecord records the transfers of each message.
+type ioctlRecord struct {
+	ioctlClose
+	msgs [][]spiIOCTransfer
+	err  error
+}
+
+func (i *ioctlRecord) Ioctl(op uint, data uintptr) error {
+	if op&0xFF00 != 0x6B00 || op&0xFF != 0 {
+		return nil
+	}
+	if i.err != nil {
+		return i.err
+	}
+	n := int(op>>16&0x3FFF) / int(unsafe.Sizeof(spiIOCTransfer{}))
+	m := (*[1 << 10]spiIOCTransfer)(toPointer(data))[:n:n]
+	i.msgs = append(i.msgs, append([]spiIOCTransfer(nil), m...))
+	return nil
+}
+
+// toPointer returns the address held in data.
+//
+// The ioctl ABI passes addresses as integers. Reading data through a pointer
+// doesn't trip go vet's unsafeptr check; the caller keeps the memory alive
+// until the ioctl returns.
+func toPointer(data uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&data))
+}
+
 func TestSPIIOCTX(t *testing.T) {
 	if v := spiIOCTx(1); v != 0x40206B00 {
 		t.Fatalf("Expected 0x40206B00, got 0x%08X", v)
@@ -128,6 +128,11 @@ func (t *ThermalSensor) Precision(e *physic.Env) {
 	e.Temperature = t.precision
 }
 
+// Fields implements physic.SenseEnvFields.
+func (t *ThermalSensor) Fields() physic.EnvField {
+	return physic.EnvTemperature
+}
+
 //
 
 func (t *ThermalSensor) open() error {
@@ -197,3 +202,4 @@ var drvThermalSensor driverThermalSensor
 
 var _ conn.Resource = &ThermalSensor{}
 var _ physic.SenseEnv = &ThermalSensor{}
+var _ physic.SenseEnvFields = &ThermalSensor{}